@@ -0,0 +1,129 @@
+package component
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"graduation/entity"
+	"graduation/mapper"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ApiKeyPrefix 个人 API 密钥的固定前缀，用于和 JWT 区分
+const ApiKeyPrefix = "epg_"
+
+// apiKeyTouchInterval 最后使用时间的最小更新间隔，避免每次请求都写库
+const apiKeyTouchInterval = time.Minute
+
+// ErrInvalidApiKey API 密钥不存在、已注销或所属用户不可用
+var ErrInvalidApiKey = errors.New("invalid api key")
+
+// ApiKeyManager 负责创建、校验和注销个人 API 密钥
+type ApiKeyManager struct {
+	keys  mapper.ApiKeyRepository
+	users mapper.UserRepository
+	now   func() time.Time
+}
+
+// NewApiKeyManager 创建 API 密钥管理器
+func NewApiKeyManager(keys mapper.ApiKeyRepository, users mapper.UserRepository) *ApiKeyManager {
+	return &ApiKeyManager{keys: keys, users: users, now: time.Now}
+}
+
+// IsApiKey 判断凭据是否为个人 API 密钥
+func IsApiKey(credential string) bool {
+	return strings.HasPrefix(credential, ApiKeyPrefix)
+}
+
+// ParseScopes 解析逗号分隔的权限列表
+func ParseScopes(scopes string) []Permission {
+	var perms []Permission
+	for _, s := range strings.Split(scopes, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			perms = append(perms, Permission(s))
+		}
+	}
+	return perms
+}
+
+// Create 为用户创建 API 密钥，scopes 必须是用户角色已拥有的权限，明文密钥只在创建时返回一次
+func (m *ApiKeyManager) Create(user entity.User, name string, scopes []Permission) (string, entity.ApiKey, error) {
+	if strings.TrimSpace(name) == "" {
+		return "", entity.ApiKey{}, fmt.Errorf("api key name is empty")
+	}
+	if len(scopes) == 0 {
+		return "", entity.ApiKey{}, fmt.Errorf("api key scopes are empty")
+	}
+	names := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		if !RoleHasPermission(user.UserRole, scope) {
+			return "", entity.ApiKey{}, fmt.Errorf("scope %s is not allowed for role %s", scope, user.UserRole)
+		}
+		names = append(names, string(scope))
+	}
+
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", entity.ApiKey{}, err
+	}
+	secret := hex.EncodeToString(buf)
+	plain := ApiKeyPrefix + secret
+	key := entity.ApiKey{
+		Username: user.Username,
+		Name:     strings.TrimSpace(name),
+		Prefix:   plain[:len(ApiKeyPrefix)+8],
+		KeyHash:  hashApiKey(plain),
+		Scopes:   strings.Join(names, ","),
+	}
+	if err := m.keys.CreateApiKey(&key); err != nil {
+		return "", entity.ApiKey{}, err
+	}
+	return plain, key, nil
+}
+
+// Authenticate 校验 API 密钥，返回密钥记录和所属用户，并更新最后使用时间
+func (m *ApiKeyManager) Authenticate(plain string) (entity.ApiKey, entity.User, error) {
+	key, err := m.keys.GetApiKeyByHash(hashApiKey(plain))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return entity.ApiKey{}, entity.User{}, ErrInvalidApiKey
+	}
+	if err != nil {
+		return entity.ApiKey{}, entity.User{}, err
+	}
+	users, err := m.users.GetEnabledUserByUsername(key.Username)
+	if err != nil {
+		return entity.ApiKey{}, entity.User{}, err
+	}
+	if len(users) == 0 {
+		return entity.ApiKey{}, entity.User{}, ErrInvalidApiKey
+	}
+	now := m.now()
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= apiKeyTouchInterval {
+		if err := m.keys.UpdateApiKeyLastUsed(key.ID, now); err != nil {
+			return entity.ApiKey{}, entity.User{}, err
+		}
+		key.LastUsedAt = &now
+	}
+	return key, users[0], nil
+}
+
+// List 获取用户的全部 API 密钥
+func (m *ApiKeyManager) List(username string) ([]entity.ApiKey, error) {
+	return m.keys.GetApiKeysByUsername(username)
+}
+
+// Revoke 注销用户自己的 API 密钥，密钥不存在或已注销时返回 false
+func (m *ApiKeyManager) Revoke(username string, id int) (bool, error) {
+	n, err := m.keys.RevokeApiKey(username, id, m.now())
+	return n > 0, err
+}
+
+func hashApiKey(plain string) string {
+	sum := sha256.Sum256([]byte(plain))
+	return hex.EncodeToString(sum[:])
+}
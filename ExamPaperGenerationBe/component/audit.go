@@ -0,0 +1,132 @@
+package component
+
+import (
+	"encoding/json"
+	"graduation/entity"
+	"graduation/mapper"
+	"log"
+	"reflect"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 审计日志中的操作类型
+const (
+	AuditCreate         = "create"
+	AuditUpdate         = "update"
+	AuditDelete         = "delete"     // 删除题目时为移入回收站
+	AuditDeleteAll      = "delete_all" // 导入时清空题库
+	AuditImport         = "import"
+	AuditApprove        = "approve" // 通过注册申请或审核通过题目
+	AuditReject         = "reject"  // 拒绝注册申请或退回题目
+	AuditUnlock         = "unlock"
+	AuditResetPassword  = "reset_password"
+	AuditChangePassword = "change_password"
+	AuditRevoke         = "revoke"
+	AuditRollback       = "rollback" // 题目回滚到历史版本
+	AuditRestore        = "restore"  // 从回收站恢复题目
+	AuditPurge          = "purge"    // 彻底删除回收站中的题目
+	AuditLock           = "lock"     // 锁定试卷，解锁使用 AuditUnlock
+	AuditSubmit         = "submit"   // 提交题目审核
+	AuditRetire         = "retire"   // 停用题目
+	AuditRedraft        = "redraft"  // 题目回到草稿
+	AuditAssign         = "assign"   // 指定题目的审核员
+	AuditReopen         = "reopen"   // 已通过的题目修改或回滚后回到草稿
+)
+
+// 审计日志中的实体类型
+const (
+	AuditEntityQuestion   = "question"
+	AuditEntityTestPaper  = "test_paper"
+	AuditEntityUser       = "user"
+	AuditEntityLabel      = "label"
+	AuditEntityApiKey     = "api_key"
+	AuditEntityMaterial   = "material"
+	AuditEntityAttachment = "attachment" // 题目图片，实体 ID 为题目 ID
+	AuditEntityLoginIP    = "login_ip"   // 登录锁定的 IP
+)
+
+// auditRedactedFields 快照中不记录的敏感字段
+var auditRedactedFields = []string{"password", "key_hash", "temp_password"}
+
+// AuditChange 单个字段的变化
+type AuditChange struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// Auditor 记录修改数据的操作，操作者取自当前登录用户
+type Auditor struct {
+	logs mapper.AuditRepository
+	now  func() time.Time
+}
+
+// NewAuditor 创建审计记录器
+func NewAuditor(logs mapper.AuditRepository) *Auditor {
+	return &Auditor{logs: logs, now: time.Now}
+}
+
+// Record 记录一次操作，before/after 为操作前后的实体，新增时 before 为 nil，删除时 after 为 nil。
+// 写入失败只记录日志，不影响已经完成的操作
+func (a *Auditor) Record(c *gin.Context, action, entityType, entityID string, before, after interface{}) {
+	beforeJSON, beforeMap := auditSnapshot(before)
+	afterJSON, afterMap := auditSnapshot(after)
+	entry := entity.AuditLog{
+		Actor:      CurrentUsername(c),
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   entityID,
+		Before:     beforeJSON,
+		After:      afterJSON,
+		IP:         c.ClientIP(),
+		CreatedAt:  a.now(),
+	}
+	if changes := AuditDiff(beforeMap, afterMap); len(changes) > 0 {
+		if data, err := json.Marshal(changes); err == nil {
+			entry.Diff = string(data)
+		}
+	}
+	if err := a.logs.InsertAuditLog(&entry); err != nil {
+		log.Printf("Failed to write audit log %s %s/%s: %v", action, entityType, entityID, err)
+	}
+}
+
+// auditSnapshot 将实体序列化为去除敏感字段的 JSON，实体为对象时同时返回字段表用于比较
+func auditSnapshot(v interface{}) (string, map[string]interface{}) {
+	if v == nil {
+		return "", nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", nil
+	}
+	var fields map[string]interface{}
+	if json.Unmarshal(data, &fields) != nil {
+		return string(data), nil
+	}
+	for _, name := range auditRedactedFields {
+		delete(fields, name)
+	}
+	data, _ = json.Marshal(fields)
+	return string(data), fields
+}
+
+// AuditDiff 比较操作前后的字段，返回发生变化的字段，新增或删除时列出全部字段
+func AuditDiff(before, after map[string]interface{}) map[string]AuditChange {
+	if before == nil && after == nil {
+		return nil
+	}
+	changes := make(map[string]AuditChange)
+	for k, b := range before {
+		if a := after[k]; !reflect.DeepEqual(b, a) {
+			changes[k] = AuditChange{Before: b, After: a}
+		}
+	}
+	for k, a := range after {
+		if _, ok := before[k]; !ok {
+			changes[k] = AuditChange{After: a}
+		}
+	}
+	return changes
+}
@@ -1,6 +1,8 @@
 package component
 
 import (
+	"graduation/entity"
+	"graduation/utils"
 	"net/http"
 	"path/filepath"
 	"strings"
@@ -9,14 +11,32 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-// LoginHandlerInterceptor 登录拦截器
-func LoginHandlerInterceptor() gin.HandlerFunc {
+// 拦截器写入 gin.Context 的当前用户信息
+const (
+	ContextUsername    = "username"
+	ContextUserRole    = "user_role"
+	contextTokenClaims = "token_claims"
+	contextApiKey      = "api_key"
+)
+
+// 需要先修改密码时仍然允许访问的路径
+var passwordChangePaths = map[string]bool{
+	"/changePassword": true,
+	"/logout":         true,
+}
+
+// ApiKeyHeader 传递个人 API 密钥的请求头，也可以使用 Authorization: Bearer epg_xxx
+const ApiKeyHeader = "X-API-Key"
+
+// LoginHandlerInterceptor 登录拦截器，支持 cookie session、Bearer 访问令牌和个人 API 密钥
+func LoginHandlerInterceptor(tokens *TokenManager, apiKeys *ApiKeyManager) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// 排除的路径
 		excludePaths := []string{
 			"/hello",
 			"/getLoginStatus",
 			"/login",
+			"/refreshToken",
 			"/permission_denied",
 			"/registered",
 			"/",
@@ -43,17 +63,104 @@ func LoginHandlerInterceptor() gin.HandlerFunc {
 				return
 			}
 		}
+		// 携带 API 密钥或 Bearer 令牌时只校验它们，不再回退到 session
+		credential, hasCredential := BearerToken(c)
+		if header := c.GetHeader(ApiKeyHeader); header != "" {
+			credential, hasCredential = header, true
+		}
+		if hasCredential && IsApiKey(credential) {
+			key, user, err := apiKeys.Authenticate(credential)
+			if err != nil {
+				Forbidden(c)
+				return
+			}
+			c.Set(ContextUsername, user.Username)
+			c.Set(ContextUserRole, user.UserRole)
+			c.Set(contextApiKey, key)
+			requirePasswordChanged(c, user.MustChangePassword)
+			return
+		}
+		if hasCredential {
+			claims, err := tokens.Parse(credential, TokenTypeAccess)
+			if err != nil {
+				Forbidden(c)
+				return
+			}
+			c.Set(ContextUsername, claims.Subject)
+			c.Set(ContextUserRole, claims.Role)
+			c.Set(contextTokenClaims, claims)
+			requirePasswordChanged(c, claims.MustChangePassword)
+			return
+		}
 		// 检查登录状态
 		session := sessions.Default(c)
-		username := session.Get("username")
-		if username == nil {
-			c.JSON(http.StatusForbidden, gin.H{
-				"status":  403,
-				"message": "Permission denied",
-			})
-			c.Abort()
+		username, _ := session.Get("username").(string)
+		if username == "" {
+			Forbidden(c)
 			return
 		}
-		c.Next()
+		role, _ := session.Get("user_role").(string)
+		mustChange, _ := session.Get("must_change_password").(bool)
+		c.Set(ContextUsername, username)
+		c.Set(ContextUserRole, role)
+		requirePasswordChanged(c, mustChange)
+	}
+}
+
+// requirePasswordChanged 管理员重置密码后，修改密码前只能访问 passwordChangePaths 中的接口
+func requirePasswordChanged(c *gin.Context, mustChange bool) {
+	if mustChange && !passwordChangePaths[c.Request.URL.Path] {
+		c.String(http.StatusForbidden, utils.MakeResp(http.StatusForbidden, "请先修改密码", gin.H{"must_change_password": true}))
+		c.Abort()
+		return
+	}
+	c.Next()
+}
+
+// BearerToken 从 Authorization 请求头中取出 Bearer 令牌
+func BearerToken(c *gin.Context) (string, bool) {
+	header := c.GetHeader("Authorization")
+	const prefix = "Bearer "
+	if len(header) <= len(prefix) || !strings.EqualFold(header[:len(prefix)], prefix) {
+		return "", false
+	}
+	return strings.TrimSpace(header[len(prefix):]), true
+}
+
+// CurrentUsername 获取当前登录用户名，优先使用拦截器解析的结果，未登录时返回空字符串
+func CurrentUsername(c *gin.Context) string {
+	if username := c.GetString(ContextUsername); username != "" {
+		return username
+	}
+	username, _ := sessions.Default(c).Get("username").(string)
+	return username
+}
+
+// CurrentRole 获取当前登录用户的角色，未登录时返回空字符串
+func CurrentRole(c *gin.Context) string {
+	if role := c.GetString(ContextUserRole); role != "" {
+		return role
+	}
+	role, _ := sessions.Default(c).Get("user_role").(string)
+	return role
+}
+
+// CurrentApiKey 获取本次请求使用的个人 API 密钥，未使用 API 密钥时返回 false
+func CurrentApiKey(c *gin.Context) (entity.ApiKey, bool) {
+	v, ok := c.Get(contextApiKey)
+	if !ok {
+		return entity.ApiKey{}, false
+	}
+	key, ok := v.(entity.ApiKey)
+	return key, ok
+}
+
+// CurrentTokenClaims 获取本次请求使用的访问令牌，使用 session 登录时返回 false
+func CurrentTokenClaims(c *gin.Context) (*TokenClaims, bool) {
+	v, ok := c.Get(contextTokenClaims)
+	if !ok {
+		return nil, false
 	}
+	claims, ok := v.(*TokenClaims)
+	return claims, ok
 }
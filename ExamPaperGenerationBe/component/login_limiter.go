@@ -0,0 +1,199 @@
+package component
+
+import (
+	"graduation/entity"
+	"graduation/mapper"
+	"sync"
+	"time"
+)
+
+// LockoutConfig 登录失败锁定配置
+type LockoutConfig struct {
+	MaxUserFailures int           `yaml:"max_user_failures"` // 同一用户名连续失败多少次后锁定
+	MaxIPFailures   int           `yaml:"max_ip_failures"`   // 同一 IP 失败多少次后锁定
+	BaseDuration    time.Duration `yaml:"base_duration"`     // 首次锁定时长，之后每多失败一次翻倍
+	MaxDuration     time.Duration `yaml:"max_duration"`      // 锁定时长上限
+	IPWindow        time.Duration `yaml:"ip_window"`         // IP 超过该时长没有失败记录时清零
+}
+
+// DefaultLockoutConfig 默认登录失败锁定配置
+func DefaultLockoutConfig() LockoutConfig {
+	return LockoutConfig{
+		MaxUserFailures: 5,
+		MaxIPFailures:   20,
+		BaseDuration:    time.Minute,
+		MaxDuration:     time.Hour,
+		IPWindow:        15 * time.Minute,
+	}
+}
+
+// LockoutStatus 登录失败后的锁定状态，会返回给客户端
+type LockoutStatus struct {
+	Locked            bool       `json:"locked"`
+	LockedUntil       *time.Time `json:"locked_until,omitempty"`
+	RetryAfter        int64      `json:"retry_after,omitempty"` // 距离解锁的秒数
+	RemainingAttempts int        `json:"remaining_attempts"`    // 锁定前剩余的尝试次数
+}
+
+// maxTrackedIPs 内存中 IP 记录超过该数量时清理过期记录
+const maxTrackedIPs = 1024
+
+// ipAttempts 单个 IP 的失败记录
+type ipAttempts struct {
+	failures    int
+	lastFailure time.Time
+	lockedUntil time.Time
+}
+
+// LoginLimiter 登录失败计数与锁定，用户名维度保存在 User 表，IP 维度保存在内存中
+type LoginLimiter struct {
+	cfg   LockoutConfig
+	users mapper.UserRepository
+	now   func() time.Time
+
+	mu  sync.Mutex
+	ips map[string]*ipAttempts
+}
+
+// NewLoginLimiter 创建登录限流器，未设置的配置项使用默认值
+func NewLoginLimiter(cfg LockoutConfig, users mapper.UserRepository) *LoginLimiter {
+	def := DefaultLockoutConfig()
+	if cfg.MaxUserFailures <= 0 {
+		cfg.MaxUserFailures = def.MaxUserFailures
+	}
+	if cfg.MaxIPFailures <= 0 {
+		cfg.MaxIPFailures = def.MaxIPFailures
+	}
+	if cfg.BaseDuration <= 0 {
+		cfg.BaseDuration = def.BaseDuration
+	}
+	if cfg.MaxDuration < cfg.BaseDuration {
+		cfg.MaxDuration = cfg.BaseDuration
+	}
+	if cfg.IPWindow <= 0 {
+		cfg.IPWindow = def.IPWindow
+	}
+	return &LoginLimiter{cfg: cfg, users: users, now: time.Now, ips: make(map[string]*ipAttempts)}
+}
+
+// lockDuration 根据失败次数计算锁定时长，达到阈值时为 BaseDuration，之后每次翻倍直到 MaxDuration
+func (l *LoginLimiter) lockDuration(failures, threshold int) time.Duration {
+	if failures < threshold {
+		return 0
+	}
+	d := l.cfg.BaseDuration
+	for i := threshold; i < failures && d < l.cfg.MaxDuration; i++ {
+		d *= 2
+	}
+	if d > l.cfg.MaxDuration {
+		d = l.cfg.MaxDuration
+	}
+	return d
+}
+
+// lockedStatus 生成锁定状态
+func (l *LoginLimiter) lockedStatus(until time.Time) LockoutStatus {
+	retry := int64(until.Sub(l.now()).Round(time.Second) / time.Second)
+	if retry < 1 {
+		retry = 1
+	}
+	return LockoutStatus{Locked: true, LockedUntil: &until, RetryAfter: retry}
+}
+
+// CheckIP 检查 IP 是否处于锁定状态
+func (l *LoginLimiter) CheckIP(ip string) (LockoutStatus, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	a, ok := l.ips[ip]
+	if !ok || !a.lockedUntil.After(l.now()) {
+		return LockoutStatus{}, false
+	}
+	return l.lockedStatus(a.lockedUntil), true
+}
+
+// CheckUser 检查用户是否处于锁定状态
+func (l *LoginLimiter) CheckUser(user entity.User) (LockoutStatus, bool) {
+	if user.LockedUntil == nil || !user.LockedUntil.After(l.now()) {
+		return LockoutStatus{}, false
+	}
+	return l.lockedStatus(*user.LockedUntil), true
+}
+
+// RecordFailure 记录一次登录失败，user 为 nil 表示用户名不存在，此时只计入 IP
+func (l *LoginLimiter) RecordFailure(ip string, user *entity.User) (LockoutStatus, error) {
+	now := l.now()
+	ipStatus := l.recordIPFailure(ip, now)
+	status := ipStatus
+	if user != nil {
+		failures := user.FailedLoginCount + 1
+		var lockedUntil *time.Time
+		if d := l.lockDuration(failures, l.cfg.MaxUserFailures); d > 0 {
+			until := now.Add(d)
+			lockedUntil = &until
+		}
+		if err := l.users.UpdateLoginFailures(user.Username, failures, lockedUntil); err != nil {
+			return LockoutStatus{}, err
+		}
+		user.FailedLoginCount = failures
+		user.LockedUntil = lockedUntil
+
+		if lockedUntil != nil && (!status.Locked || lockedUntil.After(*status.LockedUntil)) {
+			status = l.lockedStatus(*lockedUntil)
+		} else if !status.Locked {
+			status.RemainingAttempts = min(status.RemainingAttempts, l.cfg.MaxUserFailures-failures)
+		}
+	}
+	if status.Locked {
+		status.RemainingAttempts = 0
+	}
+	return status, nil
+}
+
+func (l *LoginLimiter) recordIPFailure(ip string, now time.Time) LockoutStatus {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.ips) > maxTrackedIPs {
+		l.pruneLocked(now)
+	}
+	a, ok := l.ips[ip]
+	if !ok || now.Sub(a.lastFailure) > l.cfg.IPWindow {
+		a = &ipAttempts{}
+		l.ips[ip] = a
+	}
+	a.failures++
+	a.lastFailure = now
+	if d := l.lockDuration(a.failures, l.cfg.MaxIPFailures); d > 0 {
+		a.lockedUntil = now.Add(d)
+		return l.lockedStatus(a.lockedUntil)
+	}
+	return LockoutStatus{RemainingAttempts: l.cfg.MaxIPFailures - a.failures}
+}
+
+// pruneLocked 清理已过期的 IP 记录，调用方需持有锁
+func (l *LoginLimiter) pruneLocked(now time.Time) {
+	for ip, a := range l.ips {
+		if now.Sub(a.lastFailure) > l.cfg.IPWindow && !a.lockedUntil.After(now) {
+			delete(l.ips, ip)
+		}
+	}
+}
+
+// RecordSuccess 登录成功后清零用户的失败计数
+func (l *LoginLimiter) RecordSuccess(user entity.User) error {
+	if user.FailedLoginCount == 0 && user.LockedUntil == nil {
+		return nil
+	}
+	return l.users.UpdateLoginFailures(user.Username, 0, nil)
+}
+
+// UnlockUser 解除用户锁定并清零失败计数
+func (l *LoginLimiter) UnlockUser(username string) error {
+	return l.users.UpdateLoginFailures(username, 0, nil)
+}
+
+// UnlockIP 解除 IP 锁定并清零失败计数
+func (l *LoginLimiter) UnlockIP(ip string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.ips, ip)
+}
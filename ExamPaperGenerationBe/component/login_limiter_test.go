@@ -0,0 +1,78 @@
+package component
+
+import (
+	"graduation/entity"
+	"graduation/mapper/memory"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoginLimiterUserBackoff(t *testing.T) {
+	users := memory.NewUserRepository(entity.User{Username: "teacher", Enable: 1})
+	l := NewLoginLimiter(LockoutConfig{MaxUserFailures: 3, MaxIPFailures: 100, BaseDuration: time.Minute, MaxDuration: 4 * time.Minute}, users)
+	now := time.Date(2025, 6, 1, 8, 0, 0, 0, time.UTC)
+	l.now = func() time.Time { return now }
+
+	fail := func() LockoutStatus {
+		list, err := users.GetUserByUsername("teacher")
+		require.NoError(t, err)
+		status, err := l.RecordFailure("10.0.0.1", &list[0])
+		require.NoError(t, err)
+		return status
+	}
+
+	status := fail()
+	require.False(t, status.Locked)
+	require.Equal(t, 2, status.RemainingAttempts)
+	fail()
+	status = fail()
+	require.True(t, status.Locked)
+	require.Equal(t, now.Add(time.Minute), *status.LockedUntil)
+
+	// 锁定过期后再次失败，锁定时长翻倍，且不超过上限
+	wants := []time.Duration{2 * time.Minute, 4 * time.Minute, 4 * time.Minute}
+	for _, want := range wants {
+		now = status.LockedUntil.Add(time.Second)
+		status = fail()
+		require.True(t, status.Locked)
+		require.Equal(t, now.Add(want), *status.LockedUntil)
+	}
+
+	list, _ := users.GetUserByUsername("teacher")
+	_, locked := l.CheckUser(list[0])
+	require.True(t, locked)
+	require.NoError(t, l.UnlockUser("teacher"))
+	list, _ = users.GetUserByUsername("teacher")
+	_, locked = l.CheckUser(list[0])
+	require.False(t, locked)
+	require.Zero(t, list[0].FailedLoginCount)
+}
+
+func TestLoginLimiterIP(t *testing.T) {
+	l := NewLoginLimiter(LockoutConfig{MaxIPFailures: 2, IPWindow: time.Minute}, memory.NewUserRepository())
+	now := time.Date(2025, 6, 1, 8, 0, 0, 0, time.UTC)
+	l.now = func() time.Time { return now }
+
+	// 用户名不存在时只计入 IP
+	status, err := l.RecordFailure("10.0.0.2", nil)
+	require.NoError(t, err)
+	require.Equal(t, 1, status.RemainingAttempts)
+
+	// 超过统计窗口后重新计数
+	now = now.Add(2 * time.Minute)
+	status, _ = l.RecordFailure("10.0.0.2", nil)
+	require.False(t, status.Locked)
+	status, _ = l.RecordFailure("10.0.0.2", nil)
+	require.True(t, status.Locked)
+
+	_, locked := l.CheckIP("10.0.0.2")
+	require.True(t, locked)
+	_, locked = l.CheckIP("10.0.0.3")
+	require.False(t, locked)
+
+	l.UnlockIP("10.0.0.2")
+	_, locked = l.CheckIP("10.0.0.2")
+	require.False(t, locked)
+}
@@ -0,0 +1,110 @@
+package component
+
+import (
+	"graduation/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 用户角色
+const (
+	RoleAdmin    = "admin"    // 管理员
+	RoleTeacher  = "teacher"  // 教师，负责维护题库和组卷
+	RoleReviewer = "reviewer" // 审核员，负责审核题目
+	RoleUser     = "user"     // 注册用户的默认角色，权限与教师相同
+)
+
+// Permission 权限标识
+type Permission string
+
+// 系统中声明的权限
+const (
+	PermUserManage        Permission = "user:manage"         // 用户管理、注册审核
+	PermQuestionRead      Permission = "question:read"       // 查看题库、标签和历史
+	PermQuestionWrite     Permission = "question:write"      // 新增、修改、删除、导入题目
+	PermQuestionDeleteAll Permission = "question:delete_all" // 导入时清空题库
+	PermQuestionReview    Permission = "question:review"     // 审核题目
+	PermLabelWrite        Permission = "label:write"         // 新增、修改知识点标签
+	PermLabelDelete       Permission = "label:delete"        // 删除知识点标签
+	PermPaperGenerate     Permission = "paper:generate"      // 组卷、导出试卷和维护出题历史
+	PermAuditRead         Permission = "audit:read"          // 查看审计日志
+)
+
+// rolePermissions 每个角色拥有的权限
+var rolePermissions = map[string][]Permission{
+	RoleAdmin: {
+		PermUserManage, PermQuestionRead, PermQuestionWrite, PermQuestionDeleteAll,
+		PermQuestionReview, PermLabelWrite, PermLabelDelete, PermPaperGenerate,
+		PermAuditRead,
+	},
+	RoleTeacher: {
+		PermQuestionRead, PermQuestionWrite, PermLabelWrite, PermPaperGenerate,
+	},
+	RoleReviewer: {
+		PermQuestionRead, PermQuestionReview,
+	},
+}
+
+func init() {
+	rolePermissions[RoleUser] = rolePermissions[RoleTeacher]
+}
+
+// RoleHasPermission 判断角色是否拥有指定权限
+func RoleHasPermission(role string, perm Permission) bool {
+	return containsPermission(rolePermissions[role], perm)
+}
+
+// IsRegistrableRole 判断注册时是否可以申请该角色，管理员不能通过注册获得
+func IsRegistrableRole(role string) bool {
+	switch role {
+	case RoleUser, RoleTeacher, RoleReviewer:
+		return true
+	}
+	return false
+}
+
+// HasPermission 判断当前登录用户是否拥有全部指定权限，使用 API 密钥时还需在密钥的授权范围内
+func HasPermission(c *gin.Context, perms ...Permission) bool {
+	role := CurrentRole(c)
+	key, viaApiKey := CurrentApiKey(c)
+	var scopes []Permission
+	if viaApiKey {
+		scopes = ParseScopes(key.Scopes)
+	}
+	for _, perm := range perms {
+		if !RoleHasPermission(role, perm) {
+			return false
+		}
+		if viaApiKey && !containsPermission(scopes, perm) {
+			return false
+		}
+	}
+	return true
+}
+
+func containsPermission(perms []Permission, perm Permission) bool {
+	for _, p := range perms {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// Forbidden 返回统一的 403 响应并终止请求
+func Forbidden(c *gin.Context) {
+	c.String(http.StatusForbidden, utils.Make403Resp("Permission denied"))
+	c.Abort()
+}
+
+// RequirePermission 权限拦截器，当前用户缺少任一权限时返回 403
+func RequirePermission(perms ...Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !HasPermission(c, perms...) {
+			Forbidden(c)
+			return
+		}
+		c.Next()
+	}
+}
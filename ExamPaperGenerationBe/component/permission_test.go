@@ -0,0 +1,58 @@
+package component
+
+import (
+	"encoding/json"
+	"graduation/utils"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-contrib/sessions/cookie"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoleHasPermission(t *testing.T) {
+	require.True(t, RoleHasPermission(RoleAdmin, PermUserManage))
+	require.True(t, RoleHasPermission(RoleTeacher, PermQuestionWrite))
+	require.True(t, RoleHasPermission(RoleUser, PermPaperGenerate))
+	require.False(t, RoleHasPermission(RoleTeacher, PermLabelDelete))
+	require.False(t, RoleHasPermission(RoleReviewer, PermQuestionWrite))
+	require.True(t, RoleHasPermission(RoleReviewer, PermQuestionReview))
+	require.False(t, RoleHasPermission("", PermQuestionRead))
+}
+
+func TestRequirePermission(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(sessions.Sessions("mysession", cookie.NewStore([]byte("test"))))
+	// 测试用：通过查询参数设置当前角色
+	r.Use(func(c *gin.Context) {
+		if role := c.Query("role"); role != "" {
+			sessions.Default(c).Set("user_role", role)
+		}
+	})
+	r.GET("/getAllUser", RequirePermission(PermUserManage), func(c *gin.Context) {
+		c.String(http.StatusOK, utils.Make200Resp("Success", nil))
+	})
+
+	cases := []struct {
+		role string
+		code int
+	}{
+		{RoleAdmin, http.StatusOK},
+		{RoleTeacher, http.StatusForbidden},
+		{RoleReviewer, http.StatusForbidden},
+		{"", http.StatusForbidden},
+	}
+	for _, tc := range cases {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/getAllUser?role="+tc.role, nil))
+		require.Equal(t, tc.code, w.Code, tc.role)
+
+		var resp utils.Response
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		require.Equal(t, tc.code, resp.Code, tc.role)
+	}
+}
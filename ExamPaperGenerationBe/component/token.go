@@ -0,0 +1,143 @@
+package component
+
+import (
+	"errors"
+	"fmt"
+	"graduation/entity"
+	"graduation/mapper"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// 令牌类型
+const (
+	TokenTypeAccess  = "access"
+	TokenTypeRefresh = "refresh"
+)
+
+// 令牌默认有效期
+const (
+	DefaultAccessTokenTTL  = 15 * time.Minute
+	DefaultRefreshTokenTTL = 7 * 24 * time.Hour
+)
+
+// ErrInvalidToken 令牌无效、过期、类型不符或已注销
+var ErrInvalidToken = errors.New("invalid token")
+
+// TokenConfig 令牌签发配置
+type TokenConfig struct {
+	Secret     string        `yaml:"secret"`      // HMAC 签名密钥
+	Issuer     string        `yaml:"issuer"`      // 签发者
+	AccessTTL  time.Duration `yaml:"access_ttl"`  // 访问令牌有效期
+	RefreshTTL time.Duration `yaml:"refresh_ttl"` // 刷新令牌有效期
+}
+
+// TokenClaims 令牌中携带的用户信息，Subject 为用户名
+type TokenClaims struct {
+	Role               string `json:"role"`
+	Type               string `json:"typ"`
+	MustChangePassword bool   `json:"mcp,omitempty"` // 需要先修改密码
+	jwt.RegisteredClaims
+}
+
+// TokenPair 登录或刷新时返回给客户端的令牌
+type TokenPair struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"` // 访问令牌有效秒数
+}
+
+// TokenManager 负责签发、校验和注销 HS256 签名的 JWT
+type TokenManager struct {
+	cfg     TokenConfig
+	revoked mapper.TokenRepository
+	now     func() time.Time
+}
+
+// NewTokenManager 创建令牌管理器，密钥为空时返回错误
+func NewTokenManager(cfg TokenConfig, revoked mapper.TokenRepository) (*TokenManager, error) {
+	if cfg.Secret == "" {
+		return nil, fmt.Errorf("token secret is empty")
+	}
+	if cfg.AccessTTL <= 0 {
+		cfg.AccessTTL = DefaultAccessTokenTTL
+	}
+	if cfg.RefreshTTL <= 0 {
+		cfg.RefreshTTL = DefaultRefreshTokenTTL
+	}
+	return &TokenManager{cfg: cfg, revoked: revoked, now: time.Now}, nil
+}
+
+// Issue 为用户签发一对访问令牌和刷新令牌
+func (m *TokenManager) Issue(user entity.User) (TokenPair, error) {
+	access, err := m.sign(user, TokenTypeAccess, m.cfg.AccessTTL)
+	if err != nil {
+		return TokenPair{}, err
+	}
+	refresh, err := m.sign(user, TokenTypeRefresh, m.cfg.RefreshTTL)
+	if err != nil {
+		return TokenPair{}, err
+	}
+	return TokenPair{
+		AccessToken:  access,
+		RefreshToken: refresh,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(m.cfg.AccessTTL / time.Second),
+	}, nil
+}
+
+func (m *TokenManager) sign(user entity.User, tokenType string, ttl time.Duration) (string, error) {
+	now := m.now()
+	claims := TokenClaims{
+		Role:               user.UserRole,
+		Type:               tokenType,
+		MustChangePassword: user.MustChangePassword,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.New().String(),
+			Subject:   user.Username,
+			Issuer:    m.cfg.Issuer,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(m.cfg.Secret))
+}
+
+// Parse 校验令牌签名、有效期、类型和注销状态
+func (m *TokenManager) Parse(token, tokenType string) (*TokenClaims, error) {
+	claims := &TokenClaims{}
+	_, err := jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		return []byte(m.cfg.Secret), nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuer(m.cfg.Issuer),
+		jwt.WithTimeFunc(m.now),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	if claims.Type != tokenType || claims.ID == "" {
+		return nil, ErrInvalidToken
+	}
+	revoked, err := m.revoked.IsTokenRevoked(claims.ID)
+	if err != nil {
+		return nil, err
+	}
+	if revoked {
+		return nil, fmt.Errorf("%w: token revoked", ErrInvalidToken)
+	}
+	return claims, nil
+}
+
+// Revoke 注销令牌，并顺带清理已过期的注销记录
+func (m *TokenManager) Revoke(claims *TokenClaims) error {
+	if err := m.revoked.RevokeToken(claims.ID, claims.ExpiresAt.Time); err != nil {
+		return err
+	}
+	_, err := m.revoked.DeleteExpiredRevokedTokens(m.now())
+	return err
+}
@@ -0,0 +1,231 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"graduation/component"
+	"graduation/mapper"
+	"graduation/media"
+	"os"
+	"strconv"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// 默认配置文件路径，可通过 EPG_CONFIG 环境变量覆盖
+const DefaultConfigPath = "config/application.yaml"
+
+// 环境变量前缀
+const envPrefix = "EPG_"
+
+// ServerConfig HTTP 服务配置
+type ServerConfig struct {
+	Addr string `yaml:"addr"`
+}
+
+// AuthConfig 登录认证配置，密钥为空时启动时随机生成，重启后已签发的 session 和令牌失效
+type AuthConfig struct {
+	SessionSecret string                  `yaml:"session_secret"` // cookie session 签名密钥
+	Token         component.TokenConfig   `yaml:"token"`
+	Lockout       component.LockoutConfig `yaml:"lockout"` // 登录失败锁定
+}
+
+// AppConfig 应用整体配置
+type AppConfig struct {
+	Server   ServerConfig  `yaml:"server"`
+	Database mapper.Config `yaml:"database"`
+	Auth     AuthConfig    `yaml:"auth"`
+	Media    media.Config  `yaml:"media"`
+}
+
+// defaultConfig 返回默认配置，与原先硬编码的连接信息保持一致
+func defaultConfig() AppConfig {
+	return AppConfig{
+		Server: ServerConfig{Addr: ":8081"},
+		Database: mapper.Config{
+			Driver: mapper.DriverMysql,
+			Mysql: mapper.Mysql{
+				Username: "root",
+				Password: "123456",
+				Host:     "127.0.0.1",
+				Port:     "3306",
+				Database: "graduation",
+			},
+			Pool: mapper.Pool{
+				MaxIdleConns:    10,
+				MaxOpenConns:    100,
+				ConnMaxLifetime: time.Hour,
+			},
+		},
+		Auth: AuthConfig{
+			Token: component.TokenConfig{
+				Issuer:     "graduation",
+				AccessTTL:  component.DefaultAccessTokenTTL,
+				RefreshTTL: component.DefaultRefreshTokenTTL,
+			},
+			Lockout: component.DefaultLockoutConfig(),
+		},
+		Media: media.Config{
+			Driver: media.DriverLocal,
+			Local:  media.LocalConfig{Root: media.DefaultLocalRoot},
+			S3:     media.S3Config{Region: media.DefaultS3Region},
+			GC: media.GCConfig{
+				Interval: 24 * time.Hour,
+				Grace:    time.Hour,
+			},
+		},
+	}
+}
+
+// LoadConfig 加载配置：默认值 <- 配置文件 <- 环境变量
+// path 为空时依次使用 EPG_CONFIG 环境变量和 DefaultConfigPath，默认文件不存在时忽略
+func LoadConfig(path string) (AppConfig, error) {
+	cfg := defaultConfig()
+
+	explicit := path != ""
+	if !explicit {
+		path = os.Getenv(envPrefix + "CONFIG")
+		explicit = path != ""
+	}
+	if path == "" {
+		path = DefaultConfigPath
+	}
+
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if err := yaml.Unmarshal(data, &cfg); err != nil {
+			return cfg, fmt.Errorf("解析配置文件 %s 失败: %w", path, err)
+		}
+	case errors.Is(err, os.ErrNotExist) && !explicit:
+		// 默认配置文件不存在时仅使用默认值和环境变量
+	default:
+		return cfg, fmt.Errorf("读取配置文件 %s 失败: %w", path, err)
+	}
+
+	if err := applyEnv(&cfg); err != nil {
+		return cfg, err
+	}
+	return cfg, nil
+}
+
+// applyEnv 使用环境变量覆盖配置
+func applyEnv(cfg *AppConfig) error {
+	setString(&cfg.Server.Addr, "SERVER_ADDR")
+
+	setString(&cfg.Database.Driver, "DB_DRIVER")
+	setString(&cfg.Database.Sqlite.Path, "SQLITE_PATH")
+	if err := setBool(&cfg.Database.AutoMigrate, "DB_AUTO_MIGRATE"); err != nil {
+		return err
+	}
+
+	m := &cfg.Database.Mysql
+	setString(&m.Username, "MYSQL_USERNAME")
+	setString(&m.Password, "MYSQL_PASSWORD")
+	setString(&m.Host, "MYSQL_HOST")
+	setString(&m.Port, "MYSQL_PORT")
+	setString(&m.Database, "MYSQL_DATABASE")
+	setString(&m.Params, "MYSQL_PARAMS")
+
+	a := &cfg.Auth
+	setString(&a.SessionSecret, "SESSION_SECRET")
+	setString(&a.Token.Secret, "TOKEN_SECRET")
+	if err := setDuration(&a.Token.AccessTTL, "ACCESS_TOKEN_TTL"); err != nil {
+		return err
+	}
+	if err := setDuration(&a.Token.RefreshTTL, "REFRESH_TOKEN_TTL"); err != nil {
+		return err
+	}
+	if err := setInt(&a.Lockout.MaxUserFailures, "LOGIN_MAX_USER_FAILURES"); err != nil {
+		return err
+	}
+	if err := setInt(&a.Lockout.MaxIPFailures, "LOGIN_MAX_IP_FAILURES"); err != nil {
+		return err
+	}
+	if err := setDuration(&a.Lockout.BaseDuration, "LOGIN_LOCKOUT_BASE"); err != nil {
+		return err
+	}
+	if err := setDuration(&a.Lockout.MaxDuration, "LOGIN_LOCKOUT_MAX"); err != nil {
+		return err
+	}
+	if err := setDuration(&a.Lockout.IPWindow, "LOGIN_IP_WINDOW"); err != nil {
+		return err
+	}
+
+	md := &cfg.Media
+	setString(&md.Driver, "MEDIA_DRIVER")
+	setString(&md.Local.Root, "MEDIA_ROOT")
+	setString(&md.S3.Endpoint, "S3_ENDPOINT")
+	setString(&md.S3.Region, "S3_REGION")
+	setString(&md.S3.Bucket, "S3_BUCKET")
+	setString(&md.S3.Prefix, "S3_PREFIX")
+	setString(&md.S3.AccessKey, "S3_ACCESS_KEY")
+	setString(&md.S3.SecretKey, "S3_SECRET_KEY")
+	if err := setDuration(&md.GC.Interval, "MEDIA_GC_INTERVAL"); err != nil {
+		return err
+	}
+	if err := setDuration(&md.GC.Grace, "MEDIA_GC_GRACE"); err != nil {
+		return err
+	}
+
+	p := &cfg.Database.Pool
+	if err := setInt(&p.MaxIdleConns, "DB_MAX_IDLE_CONNS"); err != nil {
+		return err
+	}
+	if err := setInt(&p.MaxOpenConns, "DB_MAX_OPEN_CONNS"); err != nil {
+		return err
+	}
+	if err := setDuration(&p.ConnMaxLifetime, "DB_CONN_MAX_LIFETIME"); err != nil {
+		return err
+	}
+	if err := setDuration(&p.ConnMaxIdleTime, "DB_CONN_MAX_IDLE_TIME"); err != nil {
+		return err
+	}
+	return nil
+}
+
+func setString(dst *string, key string) {
+	if v, ok := os.LookupEnv(envPrefix + key); ok {
+		*dst = v
+	}
+}
+
+func setInt(dst *int, key string) error {
+	v, ok := os.LookupEnv(envPrefix + key)
+	if !ok {
+		return nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return fmt.Errorf("环境变量 %s%s 不是整数: %w", envPrefix, key, err)
+	}
+	*dst = n
+	return nil
+}
+
+func setBool(dst *bool, key string) error {
+	v, ok := os.LookupEnv(envPrefix + key)
+	if !ok {
+		return nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return fmt.Errorf("环境变量 %s%s 不是布尔值: %w", envPrefix, key, err)
+	}
+	*dst = b
+	return nil
+}
+
+func setDuration(dst *time.Duration, key string) error {
+	v, ok := os.LookupEnv(envPrefix + key)
+	if !ok {
+		return nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return fmt.Errorf("环境变量 %s%s 不是有效的时长: %w", envPrefix, key, err)
+	}
+	*dst = d
+	return nil
+}
@@ -0,0 +1,71 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadConfigFileAndEnv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.yaml")
+	content := `
+server:
+  addr: ":9000"
+database:
+  mysql:
+    host: db.internal
+    database: exam
+  pool:
+    max_open_conns: 20
+    conn_max_lifetime: 30m
+auth:
+  session_secret: file-session
+  token:
+    access_ttl: 5m
+media:
+  driver: s3
+  s3:
+    endpoint: http://minio:9000
+    bucket: exam
+`
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	t.Setenv("EPG_MYSQL_PASSWORD", "from-env")
+	t.Setenv("EPG_DB_MAX_IDLE_CONNS", "3")
+	t.Setenv("EPG_TOKEN_SECRET", "token-from-env")
+	t.Setenv("EPG_S3_SECRET_KEY", "s3-from-env")
+
+	cfg, err := LoadConfig(path)
+	require.NoError(t, err)
+	require.Equal(t, ":9000", cfg.Server.Addr)
+	require.Equal(t, "db.internal", cfg.Database.Mysql.Host)
+	require.Equal(t, "exam", cfg.Database.Mysql.Database)
+	require.Equal(t, "root", cfg.Database.Mysql.Username) // 未配置的项保留默认值
+	require.Equal(t, "from-env", cfg.Database.Mysql.Password)
+	require.Equal(t, 20, cfg.Database.Pool.MaxOpenConns)
+	require.Equal(t, 3, cfg.Database.Pool.MaxIdleConns)
+	require.Equal(t, 30*time.Minute, cfg.Database.Pool.ConnMaxLifetime)
+	require.Equal(t, "file-session", cfg.Auth.SessionSecret)
+	require.Equal(t, "token-from-env", cfg.Auth.Token.Secret)
+	require.Equal(t, 5*time.Minute, cfg.Auth.Token.AccessTTL)
+	require.Equal(t, 7*24*time.Hour, cfg.Auth.Token.RefreshTTL)
+	require.Equal(t, "s3", cfg.Media.Driver)
+	require.Equal(t, "http://minio:9000", cfg.Media.S3.Endpoint)
+	require.Equal(t, "us-east-1", cfg.Media.S3.Region)
+	require.Equal(t, "s3-from-env", cfg.Media.S3.SecretKey)
+	require.Equal(t, time.Hour, cfg.Media.GC.Grace)
+}
+
+func TestLoadConfigMissingExplicitFile(t *testing.T) {
+	_, err := LoadConfig(filepath.Join(t.TempDir(), "missing.yaml"))
+	require.Error(t, err)
+}
+
+func TestLoadConfigInvalidEnv(t *testing.T) {
+	t.Setenv("EPG_CONFIG", "")
+	t.Setenv("EPG_DB_CONN_MAX_LIFETIME", "forever")
+	_, err := LoadConfig("")
+	require.Error(t, err)
+}
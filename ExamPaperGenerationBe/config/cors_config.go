@@ -17,7 +17,8 @@ func Cors() gin.HandlerFunc {
 				"session, X_Requested_With, Accept, Origin, Host, Connection, Accept-Encoding, Accept-Language, DNT, "+
 				"X-CustomHeader, Keep-Alive, User-Agent, If-Modified-Since, Cache-Control, Content-Type, Pragma")
 			c.Header("Access-Control-Expose-Headers", "Content-Length, Access-Control-Allow-Origin, "+
-				"Access-Control-Allow-Headers, Cache-Control, Content-Language, Content-Type, Expires, Last-Modified, Pragma")
+				"Access-Control-Allow-Headers, Cache-Control, Content-Language, Content-Type, Expires, Last-Modified, Pragma, "+
+				"X-Changed-Questions, X-Missing-Questions")
 			c.Header("Access-Control-Max-Age", "172800")
 		}
 
@@ -0,0 +1,110 @@
+package controller
+
+import (
+	"graduation/component"
+	"graduation/entity"
+	"graduation/mapper"
+	"graduation/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ApiKeyController 定义个人 API 密钥控制器结构体
+type ApiKeyController struct {
+	users   mapper.UserRepository
+	apiKeys *component.ApiKeyManager
+	audit   *component.Auditor
+}
+
+// NewApiKeyController 创建新的个人 API 密钥控制器
+func NewApiKeyController(users mapper.UserRepository, apiKeys *component.ApiKeyManager, audit *component.Auditor) *ApiKeyController {
+	return &ApiKeyController{users: users, apiKeys: apiKeys, audit: audit}
+}
+
+// createApiKeyRequest 创建 API 密钥请求
+type createApiKeyRequest struct {
+	Name   string                 `json:"name" binding:"required"`
+	Scopes []component.Permission `json:"scopes" binding:"required"`
+}
+
+// rejectApiKeyAuth API 密钥不能用于管理 API 密钥，只能通过登录会话或访问令牌操作
+func rejectApiKeyAuth(c *gin.Context) bool {
+	if _, ok := component.CurrentApiKey(c); ok {
+		component.Forbidden(c)
+		return true
+	}
+	return false
+}
+
+// 处理 POST /apiKeys 请求，明文密钥只在创建时返回一次
+func (ac *ApiKeyController) CreateApiKey(c *gin.Context) {
+	if rejectApiKeyAuth(c) {
+		return
+	}
+	var req createApiKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.String(http.StatusBadRequest, utils.Make400Resp(err.Error()))
+		return
+	}
+	userList, err := ac.users.GetEnabledUserByUsername(component.CurrentUsername(c))
+	if err != nil {
+		c.String(http.StatusInternalServerError, utils.Make500Resp("查询用户失败"))
+		return
+	}
+	if len(userList) == 0 {
+		component.Forbidden(c)
+		return
+	}
+	plain, key, err := ac.apiKeys.Create(userList[0], req.Name, req.Scopes)
+	if err != nil {
+		c.String(http.StatusBadRequest, utils.Make400Resp(err.Error()))
+		return
+	}
+	ac.audit.Record(c, component.AuditCreate, component.AuditEntityApiKey, strconv.Itoa(key.ID), nil, key)
+	response := map[string]interface{}{
+		"key":     plain,
+		"api_key": key,
+	}
+	c.String(http.StatusOK, utils.Make200Resp("Success", response))
+}
+
+// 处理 GET /apiKeys 请求，列出当前用户的 API 密钥
+func (ac *ApiKeyController) GetApiKeys(c *gin.Context) {
+	if rejectApiKeyAuth(c) {
+		return
+	}
+	keys, err := ac.apiKeys.List(component.CurrentUsername(c))
+	if err != nil {
+		c.String(http.StatusInternalServerError, utils.Make500Resp("查询 API 密钥失败"))
+		return
+	}
+	if keys == nil {
+		keys = []entity.ApiKey{}
+	}
+	c.String(http.StatusOK, utils.Make200Resp("Success", keys))
+}
+
+// 处理 DELETE /apiKeys/:id 请求，注销当前用户的 API 密钥
+func (ac *ApiKeyController) RevokeApiKey(c *gin.Context) {
+	if rejectApiKeyAuth(c) {
+		return
+	}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.String(http.StatusBadRequest, utils.Make400Resp("无效的 ID"))
+		return
+	}
+	revoked, err := ac.apiKeys.Revoke(component.CurrentUsername(c), id)
+	if err != nil {
+		c.String(http.StatusInternalServerError, utils.Make500Resp("注销 API 密钥失败"))
+		return
+	}
+	if !revoked {
+		c.String(http.StatusNotFound, utils.MakeResp(404, "API key not found", nil))
+		return
+	}
+	ac.audit.Record(c, component.AuditRevoke, component.AuditEntityApiKey, strconv.Itoa(id), nil, nil)
+	c.String(http.StatusOK, utils.Make200Resp("Success", id))
+}
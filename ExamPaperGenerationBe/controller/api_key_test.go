@@ -0,0 +1,72 @@
+package controller
+
+import (
+	"fmt"
+	"graduation/component"
+	"graduation/entity"
+	"graduation/mapper/memory"
+	"graduation/utils"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApiKeyController(t *testing.T) {
+	password, err := utils.EncPassword("123456")
+	require.NoError(t, err)
+	users := memory.NewUserRepository(
+		entity.User{Username: "lms", Password: password, UserRole: "teacher", Enable: 1},
+	)
+	tokens := newTestTokenManager(t)
+	apiKeys := component.NewApiKeyManager(memory.NewApiKeyRepository(), users)
+	uc := NewUserController(users, tokens, component.NewLoginLimiter(component.LockoutConfig{}, users), component.NewAuditor(memory.NewAuditRepository()))
+	ac := NewApiKeyController(users, apiKeys, component.NewAuditor(memory.NewAuditRepository()))
+
+	r := newTestRouter()
+	r.Use(component.LoginHandlerInterceptor(tokens, apiKeys))
+	r.POST("/login", uc.Login)
+	r.POST("/apiKeys", ac.CreateApiKey)
+	r.GET("/apiKeys", ac.GetApiKeys)
+	r.DELETE("/apiKeys/:id", ac.RevokeApiKey)
+	ok := func(c *gin.Context) { c.String(http.StatusOK, utils.Make200Resp("Success", nil)) }
+	r.GET("/getAllQuestionBank", component.RequirePermission(component.PermQuestionRead), ok)
+	r.POST("/insertSingleQuestionBank", component.RequirePermission(component.PermQuestionWrite), ok)
+
+	resp := doJSON(t, r, http.MethodPost, "/login", gin.H{"username": "lms", "password": "123456"})
+	require.Equal(t, 200, resp.Code)
+	access := resp.Data.(map[string]interface{})["token"].(map[string]interface{})["access_token"].(string)
+
+	// 教师不能申请管理员才有的权限
+	resp = doJSONWithToken(t, r, access, http.MethodPost, "/apiKeys", gin.H{"name": "sync", "scopes": []string{"user:manage"}})
+	require.Equal(t, 400, resp.Code)
+
+	resp = doJSONWithToken(t, r, access, http.MethodPost, "/apiKeys", gin.H{"name": "sync", "scopes": []string{"question:read"}})
+	require.Equal(t, 200, resp.Code)
+	key := resp.Data.(map[string]interface{})["key"].(string)
+	id := resp.Data.(map[string]interface{})["api_key"].(map[string]interface{})["id"]
+
+	withKey := func(method, path string) int {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set(component.ApiKeyHeader, key)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+	require.Equal(t, http.StatusOK, withKey(http.MethodGet, "/getAllQuestionBank"))
+	require.Equal(t, http.StatusForbidden, withKey(http.MethodPost, "/insertSingleQuestionBank"), "outside key scopes")
+	require.Equal(t, http.StatusForbidden, withKey(http.MethodGet, "/apiKeys"), "api keys cannot manage api keys")
+
+	resp = doJSONWithToken(t, r, access, http.MethodGet, "/apiKeys", nil)
+	require.Equal(t, 200, resp.Code)
+	listed := resp.Data.([]interface{})
+	require.Len(t, listed, 1)
+	require.NotNil(t, listed[0].(map[string]interface{})["last_used_at"])
+	require.NotContains(t, listed[0], "key_hash")
+
+	resp = doJSONWithToken(t, r, access, http.MethodDelete, fmt.Sprintf("/apiKeys/%v", id), nil)
+	require.Equal(t, 200, resp.Code)
+	require.Equal(t, http.StatusForbidden, withKey(http.MethodGet, "/getAllQuestionBank"), "revoked key")
+}
@@ -0,0 +1,106 @@
+package controller
+
+import (
+	"encoding/json"
+	"graduation/entity"
+	"graduation/mapper"
+	"graduation/utils"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 审计日志分页参数
+const (
+	defaultAuditPageSize = 20
+	maxAuditPageSize     = 100
+)
+
+// AuditController 定义审计日志控制器结构体
+type AuditController struct {
+	logs mapper.AuditRepository
+}
+
+// NewAuditController 创建新的审计日志控制器
+func NewAuditController(logs mapper.AuditRepository) *AuditController {
+	return &AuditController{logs: logs}
+}
+
+// auditLogView 返回给客户端的审计日志，快照以 JSON 对象而不是字符串返回
+type auditLogView struct {
+	ID         int             `json:"id"`
+	Actor      string          `json:"actor"`
+	Action     string          `json:"action"`
+	EntityType string          `json:"entity_type"`
+	EntityID   string          `json:"entity_id"`
+	Before     json.RawMessage `json:"before"`
+	After      json.RawMessage `json:"after"`
+	Diff       json.RawMessage `json:"diff"`
+	IP         string          `json:"ip"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+func newAuditLogView(l entity.AuditLog) auditLogView {
+	return auditLogView{
+		ID:         l.ID,
+		Actor:      l.Actor,
+		Action:     l.Action,
+		EntityType: l.EntityType,
+		EntityID:   l.EntityID,
+		Before:     rawJSON(l.Before),
+		After:      rawJSON(l.After),
+		Diff:       rawJSON(l.Diff),
+		IP:         l.IP,
+		CreatedAt:  l.CreatedAt,
+	}
+}
+
+// rawJSON 空字符串返回 null
+func rawJSON(s string) json.RawMessage {
+	if s == "" {
+		return json.RawMessage("null")
+	}
+	return json.RawMessage(s)
+}
+
+// 处理 /audit 请求，按操作者、操作、实体和时间范围分页查询审计日志
+func (ac *AuditController) GetAuditLogs(c *gin.Context) {
+	start, err := parseQueryTime(c.Query("start"), false)
+	if err != nil {
+		c.String(http.StatusBadRequest, utils.Make400Resp(err.Error()))
+		return
+	}
+	end, err := parseQueryTime(c.Query("end"), true)
+	if err != nil {
+		c.String(http.StatusBadRequest, utils.Make400Resp(err.Error()))
+		return
+	}
+	page, pageSize := parsePagination(c, defaultAuditPageSize, maxAuditPageSize)
+
+	logs, total, err := ac.logs.QueryAuditLogs(mapper.AuditLogQuery{
+		Actor:      c.Query("username"),
+		Action:     c.Query("action"),
+		EntityType: c.Query("entity_type"),
+		EntityID:   c.Query("entity_id"),
+		Start:      start,
+		End:        end,
+		Offset:     (page - 1) * pageSize,
+		Limit:      pageSize,
+	})
+	if err != nil {
+		c.String(http.StatusInternalServerError, utils.Make500Resp("查询审计日志失败"))
+		return
+	}
+	items := make([]auditLogView, 0, len(logs))
+	for _, l := range logs {
+		items = append(items, newAuditLogView(l))
+	}
+	retData := map[string]interface{}{
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+		"items":     items,
+	}
+	c.String(http.StatusOK, utils.Make200Resp("Success", retData))
+}
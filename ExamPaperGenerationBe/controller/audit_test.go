@@ -0,0 +1,86 @@
+package controller
+
+import (
+	"encoding/json"
+	"graduation/component"
+	"graduation/entity"
+	"graduation/mapper/memory"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditController(t *testing.T) {
+	users := memory.NewUserRepository(
+		entity.User{Username: "applicant", Password: "hash", UserRole: "teacher"},
+	)
+	questions := memory.NewQuestionRepository(
+		entity.QuestionBank{Topic: "8086 CPU 由哪两个部件组成？", Answer: "EU 和 BIU", TopicType: "简答题", Score: 10},
+	)
+	logs := memory.NewAuditRepository()
+	auditor := component.NewAuditor(logs)
+	tokens := newTestTokenManager(t)
+	uc := NewUserController(users, tokens, component.NewLoginLimiter(component.LockoutConfig{}, users), auditor)
+	qc := NewQuestionBankController(questions, memory.NewAttachmentRepository(), memory.NewRevisionRepository(), memory.NewReviewRepository(), memory.NewHistoryRepository(), memory.NewLabelRepository(), auditor)
+	ac := NewAuditController(logs)
+
+	r := newTestRouter()
+	r.Use(component.LoginHandlerInterceptor(tokens, component.NewApiKeyManager(memory.NewApiKeyRepository(), users)))
+	r.GET("/passApply", uc.PassApply)
+	r.GET("/deleteSingleQuestionBank", qc.DeleteSingleQuestionBank)
+	r.GET("/audit", ac.GetAuditLogs)
+	admin, err := tokens.Issue(entity.User{Username: "admin", UserRole: "admin"})
+	require.NoError(t, err)
+
+	resp := doJSONWithToken(t, r, admin.AccessToken, http.MethodGet, "/passApply?username=applicant", nil)
+	require.Equal(t, 200, resp.Code)
+	resp = doJSONWithToken(t, r, admin.AccessToken, http.MethodGet, "/deleteSingleQuestionBank?id=1", nil)
+	require.Equal(t, 200, resp.Code)
+	// 删除不存在的题目不记录
+	resp = doJSONWithToken(t, r, admin.AccessToken, http.MethodGet, "/deleteSingleQuestionBank?id=99", nil)
+	require.Equal(t, 200, resp.Code)
+
+	type auditPage struct {
+		Total int64          `json:"total"`
+		Items []auditLogView `json:"items"`
+	}
+	query := func(path string) auditPage {
+		resp := doJSONWithToken(t, r, admin.AccessToken, http.MethodGet, path, nil)
+		require.Equal(t, 200, resp.Code, resp.Msg)
+		data, err := json.Marshal(resp.Data)
+		require.NoError(t, err)
+		var page auditPage
+		require.NoError(t, json.Unmarshal(data, &page))
+		return page
+	}
+
+	page := query("/audit")
+	require.EqualValues(t, 2, page.Total)
+	require.Equal(t, component.AuditDelete, page.Items[0].Action, "newest first")
+
+	page = query("/audit?entity_type=user&username=admin")
+	require.Len(t, page.Items, 1)
+	approve := page.Items[0]
+	require.Equal(t, component.AuditApprove, approve.Action)
+	require.Equal(t, "applicant", approve.EntityID)
+	require.NotContains(t, string(approve.Before), `"password"`)
+	var diff map[string]component.AuditChange
+	require.NoError(t, json.Unmarshal(approve.Diff, &diff))
+	require.Equal(t, map[string]component.AuditChange{"enable": {Before: 0.0, After: 1.0}}, diff)
+
+	page = query("/audit?entity_type=question&entity_id=1")
+	require.Len(t, page.Items, 1)
+	require.Contains(t, string(page.Items[0].Before), "EU 和 BIU")
+	require.Equal(t, "null", string(page.Items[0].After))
+
+	today := time.Now().Format(time.DateOnly)
+	require.EqualValues(t, 2, query("/audit?start="+today+"&end="+today).Total)
+	tomorrow := time.Now().AddDate(0, 0, 1).Format(time.DateOnly)
+	require.EqualValues(t, 0, query("/audit?start="+tomorrow).Total)
+	require.EqualValues(t, 0, query("/audit?username=nobody").Total)
+
+	resp = doJSONWithToken(t, r, admin.AccessToken, http.MethodGet, "/audit?start=yesterday", nil)
+	require.Equal(t, 400, resp.Code)
+}
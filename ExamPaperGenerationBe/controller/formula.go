@@ -0,0 +1,98 @@
+package controller
+
+import (
+	"fmt"
+	"graduation/entity"
+	"graduation/services"
+	"graduation/utils"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// validateQuestionFormulas 检查题干、答案、选项和表格中的 LaTeX 公式
+func validateQuestionFormulas(q *entity.QuestionBank) error {
+	if err := validateFormula("题干", q.Topic); err != nil {
+		return err
+	}
+	if err := validateFormula("答案", q.Answer); err != nil {
+		return err
+	}
+	for _, o := range q.Options {
+		if err := validateFormula("选项 "+o.Label, o.Content); err != nil {
+			return err
+		}
+	}
+	if err := validateTableFormulas("题干表格", q.TopicTable); err != nil {
+		return err
+	}
+	return validateTableFormulas("答案表格", q.AnswerTable)
+}
+
+// validateTableFormulas 检查表格单元格中的公式
+func validateTableFormulas(field string, table *entity.ContentTable) error {
+	if table == nil {
+		return nil
+	}
+	for i, row := range table.Rows {
+		for j, cell := range row {
+			if err := validateFormula(fmt.Sprintf("%s第 %d 行第 %d 列", field, i+1, j+1), cell); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func validateFormula(field, text string) error {
+	if err := services.ValidateLatex(text); err != nil {
+		return fmt.Errorf("%s中的公式有误: %w", field, err)
+	}
+	return nil
+}
+
+// renderQuestionMathML 为含公式的题干和答案生成 MathML，供前端预览
+func renderQuestionMathML(questions []entity.QuestionBank) {
+	for i := range questions {
+		questions[i].TopicMathML = renderMathML(questions[i].Topic)
+		questions[i].AnswerMathML = renderMathML(questions[i].Answer)
+	}
+}
+
+// renderMathML 文字不含公式或公式有误时返回空字符串，前端直接显示原文
+func renderMathML(text string) string {
+	if !services.HasMath(text) {
+		return ""
+	}
+	html, _, err := services.RenderMathML(text)
+	if err != nil {
+		log.Printf("Error rendering formula: %v", err)
+		return ""
+	}
+	return html
+}
+
+// PreviewFormula 把文字中的公式转换为 MathML，编辑题目时实时预览，公式有误时返回 400 和错误原因
+func (c *QuestionBankController) PreviewFormula(ctx *gin.Context) {
+	var req struct {
+		Text string `json:"text"`
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.String(http.StatusBadRequest, utils.Make400Resp("请求参数错误"))
+		return
+	}
+	if err := services.ValidateLatex(req.Text); err != nil {
+		ctx.String(http.StatusBadRequest, utils.Make400Resp(err.Error()))
+		return
+	}
+	html, hasFormula, err := services.RenderMathML(req.Text)
+	if err != nil {
+		ctx.String(http.StatusBadRequest, utils.Make400Resp(err.Error()))
+		return
+	}
+	ctx.String(http.StatusOK, utils.Make200Resp(c.default200Resp, map[string]interface{}{
+		"html":        html,
+		"has_formula": hasFormula,
+	}))
+}
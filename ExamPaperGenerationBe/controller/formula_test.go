@@ -0,0 +1,62 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"graduation/component"
+	"graduation/entity"
+	"graduation/mapper/memory"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuestionBankControllerFormulas(t *testing.T) {
+	questions := memory.NewQuestionRepository(entity.QuestionBank{Topic: "8086 的地址总线有 20 位", TopicType: "判断题"})
+	qc := NewQuestionBankController(questions, memory.NewAttachmentRepository(), memory.NewRevisionRepository(), memory.NewReviewRepository(), memory.NewHistoryRepository(), memory.NewLabelRepository(), component.NewAuditor(memory.NewAuditRepository()))
+	r := newTestRouter()
+	r.POST("/insertSingleQuestionBank", qc.InsertSingleQuestionBank)
+	r.GET("/getQuestionBankById", qc.GetQuestionBankById)
+	r.POST("/previewFormula", qc.PreviewFormula)
+
+	resp := doJSON(t, r, http.MethodPost, "/insertSingleQuestionBank", map[string]interface{}{
+		"topic": "20 位地址总线的寻址空间为 $2^{20}$ 字节", "answer": `$\text{1MB}$`, "topic_type": "填空题", "difficulty": "2",
+	})
+	require.Equal(t, 200, resp.Code, resp.Msg)
+
+	resp = doJSON(t, r, http.MethodGet, "/getQuestionBankById?id=2", nil)
+	require.Equal(t, 200, resp.Code, resp.Msg)
+	var found []entity.QuestionBank
+	data, err := json.Marshal(resp.Data)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, &found))
+	require.Len(t, found, 1)
+	require.Contains(t, found[0].TopicMathML, "<msup><mn>2</mn><mn>20</mn></msup>")
+	require.Contains(t, found[0].AnswerMathML, "<mtext>1MB</mtext>")
+
+	resp = doJSON(t, r, http.MethodGet, "/getQuestionBankById?id=1", nil)
+	require.NotContains(t, resp.Data.([]interface{})[0], "topic_mathml")
+
+	// 公式有误时不保存
+	var buf bytes.Buffer
+	require.NoError(t, json.NewEncoder(&buf).Encode(map[string]interface{}{
+		"topic": "选择正确的表达式", "topic_type": "选择题",
+		"options": []map[string]interface{}{{"label": "A", "content": `$\frac{1}{2$`}, {"label": "B", "content": "$x$"}},
+	}))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/insertSingleQuestionBank", &buf))
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	require.Contains(t, w.Body.String(), "选项 A")
+	all, err := questions.GetAllQuestionBank()
+	require.NoError(t, err)
+	require.Len(t, all, 2)
+
+	resp = doJSON(t, r, http.MethodPost, "/previewFormula", map[string]string{"text": `$\overline{Y}$`})
+	require.Equal(t, 200, resp.Code, resp.Msg)
+	require.Equal(t, true, resp.Data.(map[string]interface{})["has_formula"])
+	resp = doJSON(t, r, http.MethodPost, "/previewFormula", map[string]string{"text": `$\unknown$`})
+	require.Equal(t, 400, resp.Code)
+	require.Contains(t, resp.Msg, `\unknown`)
+}
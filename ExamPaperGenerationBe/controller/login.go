@@ -2,16 +2,45 @@ package controller
 
 import (
 	"fmt"
+	"graduation/component"
 	"graduation/entity"
 	"graduation/mapper"
 	"graduation/utils"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/gin-contrib/sessions"
 	"github.com/gin-gonic/gin"
 )
 
+// UserController 定义用户控制器结构体
+type UserController struct {
+	users   mapper.UserRepository
+	tokens  *component.TokenManager
+	limiter *component.LoginLimiter
+	audit   *component.Auditor
+}
+
+// NewUserController 创建新的用户控制器
+func NewUserController(users mapper.UserRepository, tokens *component.TokenManager, limiter *component.LoginLimiter, audit *component.Auditor) *UserController {
+	return &UserController{users: users, tokens: tokens, limiter: limiter, audit: audit}
+}
+
+// auditUser 获取用户记录作为审计快照，用户不存在时返回 nil
+func (uc *UserController) auditUser(username string) interface{} {
+	userList, err := uc.users.GetUserByUsername(username)
+	if err != nil || len(userList) == 0 {
+		return nil
+	}
+	return userList[0]
+}
+
+// refreshTokenRequest 刷新令牌和退出登录时提交的刷新令牌
+type refreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
 // 处理 /permission_denied 请求
 func PermissionDenied(c *gin.Context) {
 	response := utils.Make403Resp("Permission denied")
@@ -40,47 +69,177 @@ func GetLoginStatus(c *gin.Context) {
 }
 
 // 处理 /Login 请求
-func Login(c *gin.Context) {
+func (uc *UserController) Login(c *gin.Context) {
 	var user entity.User
 	if err := c.ShouldBindJSON(&user); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	ip := c.ClientIP()
+	if status, locked := uc.limiter.CheckIP(ip); locked {
+		respondLoginLocked(c, status)
+		return
+	}
 	user.LastLogin = time.Now()
-	var userList []entity.User
-	//mapper.DB.Where("username = ? AND password = ?", user.Username, user.Password).Find(&userList)
-	mapper.DB.Where("username = ? and enable = 1", user.Username).Find(&userList)
-	if len(userList) > 0 {
-		if !utils.DecPassword(user.Password, userList[0].Password) { // 密码错误
-			response := utils.Make403Resp("Permission denied")
-			c.String(http.StatusOK, response)
+	userList, err := uc.users.GetEnabledUserByUsername(user.Username)
+	if err != nil {
+		response := utils.Make500Resp("查询用户失败")
+		c.String(http.StatusInternalServerError, response)
+		return
+	}
+	if len(userList) == 0 {
+		uc.loginFailed(c, ip, nil)
+		return
+	}
+	// 锁定期间不再校验密码
+	if status, locked := uc.limiter.CheckUser(userList[0]); locked {
+		respondLoginLocked(c, status)
+		return
+	}
+	if !utils.DecPassword(user.Password, userList[0].Password) { // 密码错误
+		uc.loginFailed(c, ip, &userList[0])
+		return
+	}
+	if err := uc.limiter.RecordSuccess(userList[0]); err != nil {
+		response := utils.Make500Resp("重置登录失败次数失败")
+		c.String(http.StatusInternalServerError, response)
+		return
+	}
+	uc.users.UpdateLastLoginTime(user.Username, user.LastLogin)
+	session := sessions.Default(c)
+	session.Set("username", userList[0].Username)
+	session.Set("user_role", userList[0].UserRole)
+	session.Set("last_login", userList[0].LastLogin)
+	session.Set("must_change_password", userList[0].MustChangePassword)
+	if err := session.Save(); err != nil {
+		response := utils.Make500Resp("保存session失败")
+		c.String(http.StatusInternalServerError, response)
+		return
+	}
+	tokenPair, err := uc.tokens.Issue(userList[0])
+	if err != nil {
+		response := utils.Make500Resp("签发令牌失败")
+		c.String(http.StatusInternalServerError, response)
+		return
+	}
+	retData := map[string]interface{}{
+		"username":   userList[0].Username,
+		"user_role":  userList[0].UserRole,
+		"last_login": userList[0].LastLogin.Format(time.RFC3339),
+		"token":      tokenPair,
+		// 为 true 时需要先调用 /changePassword 修改密码
+		"must_change_password": userList[0].MustChangePassword,
+	}
+	response := utils.Make200Resp("Success", retData)
+	c.String(http.StatusOK, response)
+}
+
+// loginFailed 记录登录失败，返回剩余尝试次数或锁定信息
+func (uc *UserController) loginFailed(c *gin.Context, ip string, user *entity.User) {
+	status, err := uc.limiter.RecordFailure(ip, user)
+	if err != nil {
+		response := utils.Make500Resp("记录登录失败次数失败")
+		c.String(http.StatusInternalServerError, response)
+		return
+	}
+	if status.Locked {
+		respondLoginLocked(c, status)
+		return
+	}
+	response := utils.MakeResp(403, "Permission denied", status)
+	c.String(http.StatusOK, response)
+}
+
+// respondLoginLocked 返回账户或 IP 被临时锁定的响应
+func respondLoginLocked(c *gin.Context, status component.LockoutStatus) {
+	c.Header("Retry-After", strconv.FormatInt(status.RetryAfter, 10))
+	response := utils.MakeResp(http.StatusTooManyRequests, "登录失败次数过多，请稍后再试", status)
+	c.String(http.StatusOK, response)
+}
+
+// 处理 /unlockUser 请求，管理员解除用户或 IP 的登录锁定
+func (uc *UserController) UnlockUser(c *gin.Context) {
+	username := c.Query("username")
+	ip := c.Query("ip")
+	if username == "" && ip == "" {
+		c.String(http.StatusBadRequest, utils.Make400Resp("缺少 username 或 ip"))
+		return
+	}
+	if username != "" {
+		userList, err := uc.users.GetUserByUsername(username)
+		if err != nil {
+			c.String(http.StatusInternalServerError, utils.Make500Resp("查询用户失败"))
 			return
 		}
-		mapper.DB.Model(&user).Where("username = ?", user.Username).Update("last_login", user.LastLogin)
-		session := sessions.Default(c)
-		session.Set("username", userList[0].Username)
-		session.Set("user_role", userList[0].UserRole)
-		session.Set("last_login", userList[0].LastLogin)
-		if err := session.Save(); err != nil {
-			response := utils.Make500Resp("保存session失败")
-			c.String(http.StatusInternalServerError, response)
+		if len(userList) == 0 {
+			c.String(http.StatusNotFound, utils.MakeResp(404, "User not found", nil))
 			return
 		}
-		retData := map[string]interface{}{
-			"username":   userList[0].Username,
-			"user_role":  userList[0].UserRole,
-			"last_login": userList[0].LastLogin.Format(time.RFC3339),
+		if err := uc.limiter.UnlockUser(username); err != nil {
+			c.String(http.StatusInternalServerError, utils.Make500Resp("解除锁定失败"))
+			return
 		}
-		response := utils.Make200Resp("Success", retData)
-		c.String(http.StatusOK, response)
-	} else {
-		response := utils.Make403Resp("Permission denied")
-		c.String(http.StatusOK, response)
+		uc.audit.Record(c, component.AuditUnlock, component.AuditEntityUser, username, userList[0], uc.auditUser(username))
+	}
+	if ip != "" {
+		uc.limiter.UnlockIP(ip)
+		uc.audit.Record(c, component.AuditUnlock, component.AuditEntityLoginIP, ip, nil, nil)
 	}
+	c.String(http.StatusOK, utils.Make200Resp("Success", nil))
 }
 
-// 处理 /logout 请求
-func Logout(c *gin.Context) {
+// 处理 /refreshToken 请求，使用刷新令牌换取新的令牌，旧的刷新令牌随即失效
+func (uc *UserController) RefreshToken(c *gin.Context) {
+	var req refreshTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.RefreshToken == "" {
+		c.String(http.StatusBadRequest, utils.Make400Resp("缺少 refresh_token"))
+		return
+	}
+	claims, err := uc.tokens.Parse(req.RefreshToken, component.TokenTypeRefresh)
+	if err != nil {
+		component.Forbidden(c)
+		return
+	}
+	// 用户被删除或禁用后不再允许刷新
+	userList, err := uc.users.GetEnabledUserByUsername(claims.Subject)
+	if err != nil {
+		c.String(http.StatusInternalServerError, utils.Make500Resp("查询用户失败"))
+		return
+	}
+	if len(userList) == 0 {
+		component.Forbidden(c)
+		return
+	}
+	if err := uc.tokens.Revoke(claims); err != nil {
+		c.String(http.StatusInternalServerError, utils.Make500Resp("注销令牌失败"))
+		return
+	}
+	tokenPair, err := uc.tokens.Issue(userList[0])
+	if err != nil {
+		c.String(http.StatusInternalServerError, utils.Make500Resp("签发令牌失败"))
+		return
+	}
+	c.String(http.StatusOK, utils.Make200Resp("Success", tokenPair))
+}
+
+// 处理 /logout 请求，同时注销本次请求的访问令牌和请求体中的刷新令牌
+func (uc *UserController) Logout(c *gin.Context) {
+	if claims, ok := component.CurrentTokenClaims(c); ok {
+		if err := uc.tokens.Revoke(claims); err != nil {
+			c.String(http.StatusInternalServerError, utils.Make500Resp("注销令牌失败"))
+			return
+		}
+	}
+	var req refreshTokenRequest
+	if c.ShouldBindJSON(&req) == nil && req.RefreshToken != "" {
+		claims, err := uc.tokens.Parse(req.RefreshToken, component.TokenTypeRefresh)
+		if err == nil && claims.Subject == component.CurrentUsername(c) {
+			if err := uc.tokens.Revoke(claims); err != nil {
+				c.String(http.StatusInternalServerError, utils.Make500Resp("注销令牌失败"))
+				return
+			}
+		}
+	}
 	session := sessions.Default(c)
 	session.Clear()
 	session.Save()
@@ -89,7 +248,7 @@ func Logout(c *gin.Context) {
 }
 
 // 处理 /registered 请求
-func Registered(c *gin.Context) {
+func (uc *UserController) Registered(c *gin.Context) {
 	var user entity.User
 	var err error
 	if err = c.ShouldBindJSON(&user); err != nil {
@@ -97,16 +256,30 @@ func Registered(c *gin.Context) {
 		fmt.Println(err.Error())
 		return
 	}
-	var userByUsername []entity.User
-	mapper.DB.Where("username = ?", user.Username).Find(&userByUsername)
+	if user.UserRole == "" {
+		user.UserRole = component.RoleUser
+	}
+	if !component.IsRegistrableRole(user.UserRole) {
+		response := utils.Make400Resp("不支持的用户角色")
+		c.String(http.StatusBadRequest, response)
+		return
+	}
+	userByUsername, _ := uc.users.GetUserByUsername(user.Username)
 	if len(userByUsername) > 0 {
 		response := utils.Make500Resp("用户名重复")
 		c.String(http.StatusInternalServerError, response)
 		fmt.Println(response)
 		return
 	}
+	if err = utils.ValidatePassword(user.Password); err != nil {
+		c.String(http.StatusBadRequest, utils.Make400Resp(err.Error()))
+		return
+	}
 	user.LastLogin = time.Now()
 	user.Enable = 0
+	user.MustChangePassword = false
+	user.FailedLoginCount = 0
+	user.LockedUntil = nil
 	user.Password, err = utils.EncPassword(user.Password)
 	user.SimilarityThreshold = 0.5
 	if err != nil {
@@ -114,61 +287,64 @@ func Registered(c *gin.Context) {
 		fmt.Println(err.Error())
 		return
 	}
-	result := mapper.DB.Create(&user)
-	if result.Error != nil {
+	rowsAffected, err := uc.users.AddNewUser(&user)
+	if err != nil {
 		response := utils.Make500Resp("注册失败")
 		c.String(http.StatusInternalServerError, response)
 		fmt.Println(response)
 		return
 	}
-	response := utils.Make200Resp("Success", result.RowsAffected)
+	uc.audit.Record(c, component.AuditCreate, component.AuditEntityUser, user.Username, nil, user)
+	response := utils.Make200Resp("Success", rowsAffected)
 	c.String(http.StatusOK, response)
 }
 
 // 处理 /getApplyUser 请求
-func GetApplyUser(c *gin.Context) {
-	session := sessions.Default(c)
-	userRole := session.Get("user_role")
-	if userRole == nil || userRole.(string) != "admin" {
-		response := utils.Make403Resp("Permission denied")
-		c.String(http.StatusForbidden, response)
-		return
-	}
-	var applyUser []entity.User
-	mapper.DB.Where("enable = 0").Find(&applyUser)
+func (uc *UserController) GetApplyUser(c *gin.Context) {
+	applyUser, _ := uc.users.GetApplyUser()
 	response := utils.Make200Resp("Success", applyUser)
 	c.String(http.StatusOK, response)
 }
 
 // 处理 /getAllUser 请求
-func GetAllUser(c *gin.Context) {
-	var allUser []entity.User
-	mapper.DB.Select("id, username, user_role, last_login, enable").
-		Find(&allUser)
+func (uc *UserController) GetAllUser(c *gin.Context) {
+	allUser, _ := uc.users.GetAllUser()
 	response := utils.Make200Resp("Success", allUser)
 	c.String(http.StatusOK, response)
 }
 
 // 处理 /deleteUser 请求
-func DeleteUser(c *gin.Context) {
+func (uc *UserController) DeleteUser(c *gin.Context) {
 	username := c.Query("username")
-	result := mapper.DB.Where("username = ?", username).Delete(&entity.User{})
-	response := utils.Make200Resp("Success", result.RowsAffected)
+	before := uc.auditUser(username)
+	rowsAffected, _ := uc.users.DeleteUser(username)
+	if rowsAffected > 0 {
+		uc.audit.Record(c, component.AuditDelete, component.AuditEntityUser, username, before, nil)
+	}
+	response := utils.Make200Resp("Success", rowsAffected)
 	c.String(http.StatusOK, response)
 }
 
 // 处理 /passApply 请求
-func PassApply(c *gin.Context) {
+func (uc *UserController) PassApply(c *gin.Context) {
 	username := c.Query("username")
-	result := mapper.DB.Model(&entity.User{}).Where("username = ?", username).Update("enable", 1)
-	response := utils.Make200Resp("Success", result.RowsAffected)
+	before := uc.auditUser(username)
+	rowsAffected, _ := uc.users.PassApply(username)
+	if rowsAffected > 0 {
+		uc.audit.Record(c, component.AuditApprove, component.AuditEntityUser, username, before, uc.auditUser(username))
+	}
+	response := utils.Make200Resp("Success", rowsAffected)
 	c.String(http.StatusOK, response)
 }
 
 // 处理 /deleteApply 请求
-func DeleteApply(c *gin.Context) {
+func (uc *UserController) DeleteApply(c *gin.Context) {
 	username := c.Query("username")
-	result := mapper.DB.Where("username = ? AND enable = 0", username).Delete(&entity.User{})
-	response := utils.Make200Resp("Success", result.RowsAffected)
+	before := uc.auditUser(username)
+	rowsAffected, _ := uc.users.DeleteApply(username)
+	if rowsAffected > 0 {
+		uc.audit.Record(c, component.AuditReject, component.AuditEntityUser, username, before, nil)
+	}
+	response := utils.Make200Resp("Success", rowsAffected)
 	c.String(http.StatusOK, response)
 }
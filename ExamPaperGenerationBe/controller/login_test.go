@@ -0,0 +1,189 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"graduation/component"
+	"graduation/entity"
+	"graduation/mapper/memory"
+	"graduation/utils"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-contrib/sessions/cookie"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestRouter 创建带 cookie session 的测试路由
+func newTestRouter() *gin.Engine {
+	gob.Register(time.Time{})
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(sessions.Sessions("mysession", cookie.NewStore([]byte("test"))))
+	return r
+}
+
+// newTestTokenManager 创建使用内存注销记录的令牌管理器
+func newTestTokenManager(t *testing.T) *component.TokenManager {
+	t.Helper()
+	tokens, err := component.NewTokenManager(component.TokenConfig{Secret: "test"}, memory.NewTokenRepository())
+	require.NoError(t, err)
+	return tokens
+}
+
+// doJSON 发送 JSON 请求并解析统一响应
+func doJSON(t *testing.T, r *gin.Engine, method, path string, body interface{}) utils.Response {
+	t.Helper()
+	return doJSONWithToken(t, r, "", method, path, body)
+}
+
+// doJSONWithToken 携带 Bearer 访问令牌发送 JSON 请求
+func doJSONWithToken(t *testing.T, r *gin.Engine, token, method, path string, body interface{}) utils.Response {
+	t.Helper()
+	var buf bytes.Buffer
+	if body != nil {
+		require.NoError(t, json.NewEncoder(&buf).Encode(body))
+	}
+	req := httptest.NewRequest(method, path, &buf)
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	var resp utils.Response
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp), w.Body.String())
+	return resp
+}
+
+func TestUserControllerLogin(t *testing.T) {
+	password, err := utils.EncPassword("123456")
+	require.NoError(t, err)
+	users := memory.NewUserRepository(
+		entity.User{Username: "teacher", Password: password, UserRole: "user", Enable: 1},
+		entity.User{Username: "pending", Password: password, UserRole: "user", Enable: 0},
+	)
+	uc := NewUserController(users, newTestTokenManager(t), component.NewLoginLimiter(component.LockoutConfig{}, users), component.NewAuditor(memory.NewAuditRepository()))
+	r := newTestRouter()
+	r.POST("/login", uc.Login)
+
+	resp := doJSON(t, r, http.MethodPost, "/login", gin.H{"username": "teacher", "password": "123456"})
+	require.Equal(t, 200, resp.Code)
+
+	resp = doJSON(t, r, http.MethodPost, "/login", gin.H{"username": "teacher", "password": "wrong"})
+	require.Equal(t, 403, resp.Code)
+
+	// 未审核通过的用户不能登录
+	resp = doJSON(t, r, http.MethodPost, "/login", gin.H{"username": "pending", "password": "123456"})
+	require.Equal(t, 403, resp.Code)
+}
+
+func TestUserControllerRegisteredAndPassApply(t *testing.T) {
+	users := memory.NewUserRepository()
+	uc := NewUserController(users, newTestTokenManager(t), component.NewLoginLimiter(component.LockoutConfig{}, users), component.NewAuditor(memory.NewAuditRepository()))
+	r := newTestRouter()
+	r.POST("/registered", uc.Registered)
+	r.GET("/passApply", uc.PassApply)
+
+	resp := doJSON(t, r, http.MethodPost, "/registered", gin.H{"username": "alice", "password": "123456", "user_role": "user"})
+	require.Equal(t, 400, resp.Code, "weak password")
+
+	resp = doJSON(t, r, http.MethodPost, "/registered", gin.H{"username": "alice", "password": "alice2025", "user_role": "user"})
+	require.Equal(t, 200, resp.Code)
+
+	resp = doJSON(t, r, http.MethodPost, "/registered", gin.H{"username": "alice", "password": "alice2025", "user_role": "user"})
+	require.Equal(t, 500, resp.Code, "duplicate username")
+
+	applies, err := users.GetApplyUser()
+	require.NoError(t, err)
+	require.Len(t, applies, 1)
+
+	resp = doJSON(t, r, http.MethodGet, "/passApply?username=alice", nil)
+	require.Equal(t, 200, resp.Code)
+	enabled, err := users.GetEnabledUserByUsername("alice")
+	require.NoError(t, err)
+	require.Len(t, enabled, 1)
+}
+
+func TestUserControllerBearerToken(t *testing.T) {
+	password, err := utils.EncPassword("123456")
+	require.NoError(t, err)
+	users := memory.NewUserRepository(
+		entity.User{Username: "script", Password: password, UserRole: "teacher", Enable: 1},
+	)
+	tokens := newTestTokenManager(t)
+	uc := NewUserController(users, tokens, component.NewLoginLimiter(component.LockoutConfig{}, users), component.NewAuditor(memory.NewAuditRepository()))
+	r := newTestRouter()
+	r.Use(component.LoginHandlerInterceptor(tokens, component.NewApiKeyManager(memory.NewApiKeyRepository(), users)))
+	r.POST("/login", uc.Login)
+	r.POST("/refreshToken", uc.RefreshToken)
+	r.POST("/logout", uc.Logout)
+	r.GET("/whoami", func(c *gin.Context) {
+		c.String(http.StatusOK, utils.Make200Resp("Success", component.CurrentUsername(c)))
+	})
+
+	resp := doJSON(t, r, http.MethodPost, "/login", gin.H{"username": "script", "password": "123456"})
+	require.Equal(t, 200, resp.Code)
+	pair := resp.Data.(map[string]interface{})["token"].(map[string]interface{})
+	access := pair["access_token"].(string)
+	refresh := pair["refresh_token"].(string)
+
+	// 不带 cookie，仅凭 Bearer 令牌访问
+	resp = doJSONWithToken(t, r, access, http.MethodGet, "/whoami", nil)
+	require.Equal(t, 200, resp.Code)
+	require.Equal(t, "script", resp.Data)
+
+	resp = doJSONWithToken(t, r, refresh, http.MethodGet, "/whoami", nil)
+	require.Equal(t, 403, resp.Code, "refresh token must not be accepted as access token")
+
+	// 刷新后旧的刷新令牌失效
+	resp = doJSON(t, r, http.MethodPost, "/refreshToken", gin.H{"refresh_token": refresh})
+	require.Equal(t, 200, resp.Code)
+	newRefresh := resp.Data.(map[string]interface{})["refresh_token"].(string)
+	resp = doJSON(t, r, http.MethodPost, "/refreshToken", gin.H{"refresh_token": refresh})
+	require.Equal(t, 403, resp.Code)
+
+	// 退出登录后访问令牌和刷新令牌都被注销
+	resp = doJSONWithToken(t, r, access, http.MethodPost, "/logout", gin.H{"refresh_token": newRefresh})
+	require.Equal(t, 200, resp.Code)
+	resp = doJSONWithToken(t, r, access, http.MethodGet, "/whoami", nil)
+	require.Equal(t, 403, resp.Code)
+	resp = doJSON(t, r, http.MethodPost, "/refreshToken", gin.H{"refresh_token": newRefresh})
+	require.Equal(t, 403, resp.Code)
+}
+
+func TestUserControllerLoginLockout(t *testing.T) {
+	password, err := utils.EncPassword("123456")
+	require.NoError(t, err)
+	users := memory.NewUserRepository(
+		entity.User{Username: "teacher", Password: password, UserRole: "teacher", Enable: 1},
+	)
+	limiter := component.NewLoginLimiter(component.LockoutConfig{MaxUserFailures: 2}, users)
+	uc := NewUserController(users, newTestTokenManager(t), limiter, component.NewAuditor(memory.NewAuditRepository()))
+	r := newTestRouter()
+	r.POST("/login", uc.Login)
+	r.GET("/unlockUser", uc.UnlockUser)
+
+	resp := doJSON(t, r, http.MethodPost, "/login", gin.H{"username": "teacher", "password": "wrong"})
+	require.Equal(t, 403, resp.Code)
+	require.EqualValues(t, 1, resp.Data.(map[string]interface{})["remaining_attempts"])
+
+	resp = doJSON(t, r, http.MethodPost, "/login", gin.H{"username": "teacher", "password": "wrong"})
+	require.Equal(t, http.StatusTooManyRequests, resp.Code)
+	require.NotEmpty(t, resp.Data.(map[string]interface{})["locked_until"])
+
+	// 锁定期间即使密码正确也不能登录
+	resp = doJSON(t, r, http.MethodPost, "/login", gin.H{"username": "teacher", "password": "123456"})
+	require.Equal(t, http.StatusTooManyRequests, resp.Code)
+
+	resp = doJSON(t, r, http.MethodGet, "/unlockUser?username=teacher", nil)
+	require.Equal(t, 200, resp.Code)
+	resp = doJSON(t, r, http.MethodPost, "/login", gin.H{"username": "teacher", "password": "123456"})
+	require.Equal(t, 200, resp.Code)
+}
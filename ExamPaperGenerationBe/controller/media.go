@@ -0,0 +1,187 @@
+package controller
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"graduation/entity"
+	"graduation/media"
+	"graduation/utils"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// mediaCacheControl 媒体文件按内容寻址，同一地址的内容不会变化，浏览器可以长期缓存。
+// 文件需要登录后访问，不允许代理服务器缓存
+const mediaCacheControl = "private, max-age=31536000, immutable"
+
+// MediaController 定义媒体文件控制器结构体，按地址读取题目图片等媒体文件
+type MediaController struct {
+	store media.Store
+}
+
+// NewMediaController 创建新的媒体文件控制器
+func NewMediaController(store media.Store) *MediaController {
+	return &MediaController{store: store}
+}
+
+// etagMatch 判断 If-None-Match 请求头中是否包含 etag
+func etagMatch(header, etag string) bool {
+	for _, v := range strings.Split(header, ",") {
+		v = strings.TrimPrefix(strings.TrimSpace(v), "W/")
+		if v == etag || v == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// 处理 GET /media/:id 请求，返回媒体文件内容，内容类型根据文件开头的内容判断，不是图片的文件作为附件下载。
+// w 参数为缩略图宽度，只支持 media.ThumbnailWidths 中的尺寸，原图不比该宽度宽时返回原图
+func (mc *MediaController) GetMedia(ctx *gin.Context) {
+	key := ctx.Param("id")
+	if !media.ValidKey(key) {
+		ctx.String(http.StatusNotFound, utils.MakeResp(http.StatusNotFound, "Media not found", nil))
+		return
+	}
+	width := 0
+	if w := ctx.Query("w"); w != "" {
+		var err error
+		if width, err = strconv.Atoi(w); err != nil || !media.ValidThumbnailWidth(width) {
+			ctx.String(http.StatusBadRequest, utils.Make400Resp("不支持的缩略图宽度，可选 64、128、256、512"))
+			return
+		}
+	}
+	etag := `"` + key + `"`
+	if width > 0 {
+		etag = `"` + key + "-w" + strconv.Itoa(width) + `"`
+	}
+
+	// 先确认文件存在、能生成缩略图，再比较 ETag，不存在的文件和不是图片的文件不能返回 304
+	rc, err := mc.store.Open(ctx.Request.Context(), key)
+	if errors.Is(err, media.ErrNotFound) {
+		ctx.String(http.StatusNotFound, utils.MakeResp(http.StatusNotFound, "Media not found", nil))
+		return
+	}
+	if err != nil {
+		log.Printf("Error opening media %s: %v", key, err)
+		ctx.String(http.StatusInternalServerError, utils.Make500Resp("读取文件失败"))
+		return
+	}
+	defer rc.Close()
+
+	if width > 0 {
+		data, err := io.ReadAll(rc)
+		if err != nil {
+			log.Printf("Error reading media %s: %v", key, err)
+			ctx.String(http.StatusInternalServerError, utils.Make500Resp("读取文件失败"))
+			return
+		}
+		_, err = media.CheckImageSize(bytes.NewReader(data))
+		if errors.Is(err, media.ErrNotImage) {
+			ctx.String(http.StatusBadRequest, utils.Make400Resp("文件不是图片，不能生成缩略图"))
+			return
+		}
+		if errors.Is(err, media.ErrImageTooLarge) {
+			ctx.String(http.StatusBadRequest, utils.Make400Resp("图片尺寸过大，不能生成缩略图"))
+			return
+		}
+		if notModified(ctx, etag) {
+			return
+		}
+		thumbnail, contentType, err := media.Thumbnail(data, width)
+		if errors.Is(err, media.ErrNotImage) {
+			ctx.String(http.StatusBadRequest, utils.Make400Resp("文件不是图片，不能生成缩略图"))
+			return
+		}
+		if err != nil {
+			log.Printf("Error generating thumbnail of media %s: %v", key, err)
+			ctx.String(http.StatusInternalServerError, utils.Make500Resp("生成缩略图失败"))
+			return
+		}
+		if thumbnail == nil {
+			thumbnail, contentType = data, http.DetectContentType(data)
+		}
+		setMediaHeaders(ctx, etag, contentType)
+		ctx.Data(http.StatusOK, contentType, thumbnail)
+		return
+	}
+
+	if notModified(ctx, etag) {
+		return
+	}
+	// 按文件开头的内容判断类型，不依赖上传时的文件名或存储中记录的类型
+	reader := bufio.NewReaderSize(rc, 512)
+	head, _ := reader.Peek(512)
+	contentType := http.DetectContentType(head)
+	setMediaHeaders(ctx, etag, contentType)
+	ctx.DataFromReader(http.StatusOK, -1, contentType, reader, nil)
+}
+
+// notModified 请求头 If-None-Match 与 etag 匹配时返回 304 并返回 true
+func notModified(ctx *gin.Context, etag string) bool {
+	if !etagMatch(ctx.GetHeader("If-None-Match"), etag) {
+		return false
+	}
+	ctx.Header("ETag", etag)
+	ctx.Header("Cache-Control", mediaCacheControl)
+	ctx.Status(http.StatusNotModified)
+	return true
+}
+
+// setMediaHeaders 设置缓存和内容类型相关的响应头
+func setMediaHeaders(ctx *gin.Context, etag, contentType string) {
+	ctx.Header("ETag", etag)
+	ctx.Header("Cache-Control", mediaCacheControl)
+	ctx.Header("X-Content-Type-Options", "nosniff")
+	if !strings.HasPrefix(contentType, "image/") {
+		ctx.Header("Content-Disposition", "attachment")
+	}
+}
+
+// fillQuestionImageURLs 填写题干图片的访问地址和缩略图地址，列表接口不再返回图片内容
+func fillQuestionImageURLs(questions []entity.QuestionBank) {
+	for i := range questions {
+		questions[i].TopicImageURL = media.URL(questions[i].TopicImagePath)
+		questions[i].TopicThumbURL = media.ThumbnailURL(questions[i].TopicImagePath, media.DefaultThumbnailWidth)
+		fillAttachmentURLs(questions[i].Attachments)
+	}
+}
+
+// fillAttachmentURLs 填写附件的访问地址和缩略图地址
+func fillAttachmentURLs(attachments []entity.QuestionAttachment) {
+	for i := range attachments {
+		attachments[i] = attachmentWithURLs(attachments[i])
+	}
+}
+
+// attachmentWithURLs 返回填写了访问地址和缩略图地址的附件
+func attachmentWithURLs(attachment entity.QuestionAttachment) entity.QuestionAttachment {
+	attachment.URL = media.URL(attachment.Path)
+	attachment.ThumbnailURL = media.ThumbnailURL(attachment.Path, media.DefaultThumbnailWidth)
+	return attachment
+}
+
+// fillMaterialImageURLs 填写材料图片的访问地址
+func fillMaterialImageURLs(materials []entity.QuestionMaterial) {
+	for i := range materials {
+		materials[i] = materialWithURLs(materials[i])
+	}
+}
+
+// materialWithURLs 返回填写了图片访问地址的材料，ImageURLs 与 ImagePaths 一一对应
+func materialWithURLs(material entity.QuestionMaterial) entity.QuestionMaterial {
+	if len(material.ImagePaths) == 0 {
+		return material
+	}
+	material.ImageURLs = make([]string, len(material.ImagePaths))
+	for i, path := range material.ImagePaths {
+		material.ImageURLs[i] = media.URL(path)
+	}
+	return material
+}
@@ -0,0 +1,111 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"graduation/entity"
+	"graduation/media"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// getMedia 请求媒体文件，返回原始响应
+func getMedia(r http.Handler, path, ifNoneMatch string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if ifNoneMatch != "" {
+		req.Header.Set("If-None-Match", ifNoneMatch)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestGetMedia(t *testing.T) {
+	store := media.NewLocalStore(t.TempDir())
+	picture, err := store.Put(context.Background(), bytes.NewReader(testPNG(t, 600, 300)))
+	require.NoError(t, err)
+	text, err := store.Put(context.Background(), bytes.NewReader([]byte("<html><script>alert(1)</script></html>")))
+	require.NoError(t, err)
+	r := newTestRouter()
+	r.GET("/media/:id", NewMediaController(store).GetMedia)
+
+	// 原图，内容类型按文件内容判断
+	url := media.URL(media.Ref(picture.Key))
+	w := getMedia(r, url, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "image/png", w.Header().Get("Content-Type"))
+	require.Equal(t, `"`+picture.Key+`"`, w.Header().Get("ETag"))
+	require.Contains(t, w.Header().Get("Cache-Control"), "immutable")
+	require.Empty(t, w.Header().Get("Content-Disposition"))
+	require.Equal(t, int(picture.Size), w.Body.Len())
+
+	w = getMedia(r, url, `W/"other", "`+picture.Key+`"`)
+	require.Equal(t, http.StatusNotModified, w.Code)
+	require.Empty(t, w.Body.Bytes())
+
+	// 缩略图等比缩小，ETag 与原图不同
+	w = getMedia(r, media.ThumbnailURL(media.Ref(picture.Key), 128), "")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "image/png", w.Header().Get("Content-Type"))
+	require.Equal(t, `"`+picture.Key+`-w128"`, w.Header().Get("ETag"))
+	config, err := png.DecodeConfig(w.Body)
+	require.NoError(t, err)
+	require.Equal(t, image.Point{X: 128, Y: 64}, image.Point{X: config.Width, Y: config.Height})
+
+	// 原图比缩略图窄时返回原图
+	small, err := store.Put(context.Background(), bytes.NewReader(testPNG(t, 100, 50)))
+	require.NoError(t, err)
+	w = getMedia(r, media.ThumbnailURL(media.Ref(small.Key), 512), "")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, int(small.Size), w.Body.Len())
+	w = getMedia(r, url+"?w=1024", "")
+	require.Equal(t, http.StatusBadRequest, w.Code)
+
+	// 不是图片的文件作为附件下载，不能生成缩略图
+	w = getMedia(r, media.URL(media.Ref(text.Key)), "")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+	require.Equal(t, "attachment", w.Header().Get("Content-Disposition"))
+	require.Equal(t, "nosniff", w.Header().Get("X-Content-Type-Options"))
+	w = getMedia(r, media.URL(media.Ref(text.Key))+"?w=64", "")
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	// 带 If-None-Match 时也先检查能否生成缩略图
+	w = getMedia(r, media.URL(media.Ref(text.Key))+"?w=64", `"`+text.Key+`-w64"`)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	w = getMedia(r, media.ThumbnailURL(media.Ref(picture.Key), 128), `"`+picture.Key+`-w128"`)
+	require.Equal(t, http.StatusNotModified, w.Code)
+
+	// 声明的像素数过大的图片不生成缩略图，原图仍可下载
+	huge, err := store.Put(context.Background(), bytes.NewReader(testDeclaredPNG(t, 30000, 30000)))
+	require.NoError(t, err)
+	w = getMedia(r, media.ThumbnailURL(media.Ref(huge.Key), 64), "")
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	require.Contains(t, w.Body.String(), "图片尺寸过大")
+
+	require.NoError(t, store.Delete(context.Background(), picture.Key))
+	require.Equal(t, http.StatusNotFound, getMedia(r, url, "").Code)
+	require.Equal(t, http.StatusNotFound, getMedia(r, url, `"`+picture.Key+`"`).Code)
+	require.Equal(t, http.StatusNotFound, getMedia(r, "/media/..%2Fsecret", "").Code)
+}
+
+func TestFillImageURLs(t *testing.T) {
+	key := "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+	questions := []entity.QuestionBank{
+		{TopicImagePath: media.Ref(key), Attachments: []entity.QuestionAttachment{{Path: media.Ref(key)}}},
+		{TopicImagePath: "resources/images/legacy.jpg"},
+	}
+	fillQuestionImageURLs(questions)
+	require.Equal(t, "/media/"+key, questions[0].TopicImageURL)
+	require.Equal(t, "/media/"+key+"?w=256", questions[0].TopicThumbURL)
+	require.Equal(t, "/media/"+key, questions[0].Attachments[0].URL)
+	// 旧版本的图片路径没有访问地址，需要先执行 media import
+	require.Empty(t, questions[1].TopicImageURL)
+
+	material := materialWithURLs(entity.QuestionMaterial{ImagePaths: []string{"old.png", media.Ref(key)}})
+	require.Equal(t, []string{"", "/media/" + key}, material.ImageURLs)
+}
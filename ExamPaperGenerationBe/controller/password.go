@@ -0,0 +1,133 @@
+package controller
+
+import (
+	"graduation/component"
+	"graduation/utils"
+	"net/http"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+)
+
+// changePasswordRequest 修改密码请求
+type changePasswordRequest struct {
+	OldPassword string `json:"old_password" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required"`
+}
+
+// 处理 /changePassword 请求，用户验证旧密码后修改自己的密码
+func (uc *UserController) ChangePassword(c *gin.Context) {
+	if rejectApiKeyAuth(c) {
+		return
+	}
+	var req changePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.String(http.StatusBadRequest, utils.Make400Resp(err.Error()))
+		return
+	}
+	userList, err := uc.users.GetEnabledUserByUsername(component.CurrentUsername(c))
+	if err != nil {
+		c.String(http.StatusInternalServerError, utils.Make500Resp("查询用户失败"))
+		return
+	}
+	if len(userList) == 0 {
+		component.Forbidden(c)
+		return
+	}
+	user := userList[0]
+	if status, locked := uc.limiter.CheckUser(user); locked {
+		respondLoginLocked(c, status)
+		return
+	}
+	// 旧密码错误与登录失败一样计入锁定次数
+	if !utils.DecPassword(req.OldPassword, user.Password) {
+		uc.loginFailed(c, c.ClientIP(), &user)
+		return
+	}
+	if err := utils.ValidatePassword(req.NewPassword); err != nil {
+		c.String(http.StatusBadRequest, utils.Make400Resp(err.Error()))
+		return
+	}
+	if req.NewPassword == req.OldPassword {
+		c.String(http.StatusBadRequest, utils.Make400Resp("新密码不能与旧密码相同"))
+		return
+	}
+	hash, err := utils.EncPassword(req.NewPassword)
+	if err != nil {
+		c.String(http.StatusInternalServerError, utils.Make500Resp("加密密码失败"))
+		return
+	}
+	if _, err := uc.users.UpdatePassword(user.Username, hash, false); err != nil {
+		c.String(http.StatusInternalServerError, utils.Make500Resp("修改密码失败"))
+		return
+	}
+	if err := uc.limiter.RecordSuccess(user); err != nil {
+		c.String(http.StatusInternalServerError, utils.Make500Resp("重置登录失败次数失败"))
+		return
+	}
+	uc.audit.Record(c, component.AuditChangePassword, component.AuditEntityUser, user.Username, user, uc.auditUser(user.Username))
+	user.MustChangePassword = false
+
+	retData := map[string]interface{}{"username": user.Username}
+	if claims, ok := component.CurrentTokenClaims(c); ok {
+		// 使用令牌访问时注销当前令牌，并签发不带改密标记的新令牌
+		if err := uc.tokens.Revoke(claims); err != nil {
+			c.String(http.StatusInternalServerError, utils.Make500Resp("注销令牌失败"))
+			return
+		}
+		tokenPair, err := uc.tokens.Issue(user)
+		if err != nil {
+			c.String(http.StatusInternalServerError, utils.Make500Resp("签发令牌失败"))
+			return
+		}
+		retData["token"] = tokenPair
+	} else {
+		session := sessions.Default(c)
+		session.Set("must_change_password", false)
+		if err := session.Save(); err != nil {
+			c.String(http.StatusInternalServerError, utils.Make500Resp("保存session失败"))
+			return
+		}
+	}
+	c.String(http.StatusOK, utils.Make200Resp("Success", retData))
+}
+
+// 处理 /resetPassword 请求，管理员为用户生成一次性临时密码，用户登录后必须先修改密码
+func (uc *UserController) ResetPassword(c *gin.Context) {
+	username := c.Query("username")
+	if username == "" {
+		c.String(http.StatusBadRequest, utils.Make400Resp("缺少 username"))
+		return
+	}
+	before := uc.auditUser(username)
+	tempPassword, err := utils.GenerateTempPassword()
+	if err != nil {
+		c.String(http.StatusInternalServerError, utils.Make500Resp("生成临时密码失败"))
+		return
+	}
+	hash, err := utils.EncPassword(tempPassword)
+	if err != nil {
+		c.String(http.StatusInternalServerError, utils.Make500Resp("加密密码失败"))
+		return
+	}
+	rowsAffected, err := uc.users.UpdatePassword(username, hash, true)
+	if err != nil {
+		c.String(http.StatusInternalServerError, utils.Make500Resp("重置密码失败"))
+		return
+	}
+	if rowsAffected == 0 {
+		c.String(http.StatusNotFound, utils.MakeResp(404, "User not found", nil))
+		return
+	}
+	// 重置密码的同时解除登录锁定
+	if err := uc.limiter.UnlockUser(username); err != nil {
+		c.String(http.StatusInternalServerError, utils.Make500Resp("解除锁定失败"))
+		return
+	}
+	uc.audit.Record(c, component.AuditResetPassword, component.AuditEntityUser, username, before, uc.auditUser(username))
+	retData := map[string]interface{}{
+		"username":      username,
+		"temp_password": tempPassword,
+	}
+	c.String(http.StatusOK, utils.Make200Resp("Success", retData))
+}
@@ -0,0 +1,72 @@
+package controller
+
+import (
+	"graduation/component"
+	"graduation/entity"
+	"graduation/mapper/memory"
+	"graduation/utils"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserControllerResetAndChangePassword(t *testing.T) {
+	password, err := utils.EncPassword("teacher2025")
+	require.NoError(t, err)
+	users := memory.NewUserRepository(
+		entity.User{Username: "teacher", Password: password, UserRole: "teacher", Enable: 1},
+	)
+	tokens := newTestTokenManager(t)
+	uc := NewUserController(users, tokens, component.NewLoginLimiter(component.LockoutConfig{}, users), component.NewAuditor(memory.NewAuditRepository()))
+	r := newTestRouter()
+	r.Use(component.LoginHandlerInterceptor(tokens, component.NewApiKeyManager(memory.NewApiKeyRepository(), users)))
+	r.POST("/login", uc.Login)
+	r.POST("/changePassword", uc.ChangePassword)
+	r.POST("/resetPassword", uc.ResetPassword)
+	r.GET("/getAllQuestionBank", func(c *gin.Context) {
+		c.String(http.StatusOK, utils.Make200Resp("Success", nil))
+	})
+
+	// 管理员重置密码（权限由路由上的 RequirePermission 控制，这里直接调用）
+	resp := doJSON(t, r, http.MethodPost, "/resetPassword?username=nobody", nil)
+	require.Equal(t, 403, resp.Code, "interceptor requires login")
+	admin, err := tokens.Issue(entity.User{Username: "admin", UserRole: "admin"})
+	require.NoError(t, err)
+	resp = doJSONWithToken(t, r, admin.AccessToken, http.MethodPost, "/resetPassword?username=nobody", nil)
+	require.Equal(t, 404, resp.Code)
+	resp = doJSONWithToken(t, r, admin.AccessToken, http.MethodPost, "/resetPassword?username=teacher", nil)
+	require.Equal(t, 200, resp.Code)
+	tempPassword := resp.Data.(map[string]interface{})["temp_password"].(string)
+
+	resp = doJSON(t, r, http.MethodPost, "/login", gin.H{"username": "teacher", "password": "teacher2025"})
+	require.Equal(t, 403, resp.Code, "old password no longer works")
+
+	resp = doJSON(t, r, http.MethodPost, "/login", gin.H{"username": "teacher", "password": tempPassword})
+	require.Equal(t, 200, resp.Code)
+	data := resp.Data.(map[string]interface{})
+	require.Equal(t, true, data["must_change_password"])
+	access := data["token"].(map[string]interface{})["access_token"].(string)
+
+	// 修改密码前不能访问其他接口
+	resp = doJSONWithToken(t, r, access, http.MethodGet, "/getAllQuestionBank", nil)
+	require.Equal(t, 403, resp.Code)
+
+	resp = doJSONWithToken(t, r, access, http.MethodPost, "/changePassword", gin.H{"old_password": tempPassword, "new_password": "short1"})
+	require.Equal(t, 400, resp.Code, "weak new password")
+	resp = doJSONWithToken(t, r, access, http.MethodPost, "/changePassword", gin.H{"old_password": "wrong-old1", "new_password": "newSecret2025"})
+	require.Equal(t, 403, resp.Code, "wrong old password")
+	resp = doJSONWithToken(t, r, access, http.MethodPost, "/changePassword", gin.H{"old_password": tempPassword, "new_password": "newSecret2025"})
+	require.Equal(t, 200, resp.Code)
+	newAccess := resp.Data.(map[string]interface{})["token"].(map[string]interface{})["access_token"].(string)
+
+	resp = doJSONWithToken(t, r, access, http.MethodGet, "/getAllQuestionBank", nil)
+	require.Equal(t, 403, resp.Code, "old token revoked")
+	resp = doJSONWithToken(t, r, newAccess, http.MethodGet, "/getAllQuestionBank", nil)
+	require.Equal(t, 200, resp.Code)
+
+	resp = doJSON(t, r, http.MethodPost, "/login", gin.H{"username": "teacher", "password": "newSecret2025"})
+	require.Equal(t, 200, resp.Code)
+	require.Equal(t, false, resp.Data.(map[string]interface{})["must_change_password"])
+}
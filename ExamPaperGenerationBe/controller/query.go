@@ -0,0 +1,80 @@
+package controller
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// parsePagination 解析 page、page_size 参数，page 从 1 开始，page_size 超出范围时使用默认值
+func parsePagination(c *gin.Context, defaultSize, maxSize int) (int, int) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if page < 1 {
+		page = 1
+	}
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", strconv.Itoa(defaultSize)))
+	if pageSize < 1 || pageSize > maxSize {
+		pageSize = defaultSize
+	}
+	return page, pageSize
+}
+
+// parseQueryTime 解析 RFC3339 时间或 2006-01-02 格式的日期，endOfDay 为 true 时日期取次日零点
+func parseQueryTime(value string, endOfDay bool) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation(time.DateOnly, value, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("无效的时间: %s", value)
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
+
+// queryInt 解析可选的整数参数，未传时返回 nil
+func queryInt(c *gin.Context, key string) (*int, error) {
+	value := c.Query(key)
+	if value == "" {
+		return nil, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return nil, fmt.Errorf("无效的 %s: %s", key, value)
+	}
+	return &n, nil
+}
+
+// queryFloat 解析可选的浮点数参数，未传时返回 nil
+func queryFloat(c *gin.Context, key string) (*float64, error) {
+	value := c.Query(key)
+	if value == "" {
+		return nil, nil
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return nil, fmt.Errorf("无效的 %s: %s", key, value)
+	}
+	return &f, nil
+}
+
+// queryList 解析可重复或逗号分隔的参数，例如 a=1&a=2 或 a=1,2
+func queryList(c *gin.Context, key string) []string {
+	var out []string
+	for _, value := range c.QueryArray(key) {
+		for _, v := range strings.Split(value, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				out = append(out, v)
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,457 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"graduation/component"
+	"graduation/entity"
+	"graduation/mapper"
+	"graduation/media"
+	"graduation/services"
+	"graduation/utils"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AttachmentController 定义题目附件控制器结构体，管理题干、选项和答案中的图片
+type AttachmentController struct {
+	attachments mapper.AttachmentRepository
+	questions   mapper.QuestionRepository
+	audit       *component.Auditor
+}
+
+// NewAttachmentController 创建新的题目附件控制器
+func NewAttachmentController(attachments mapper.AttachmentRepository, questions mapper.QuestionRepository, audit *component.Auditor) *AttachmentController {
+	return &AttachmentController{attachments: attachments, questions: questions, audit: audit}
+}
+
+// attachmentRequest 修改附件请求，未提交的字段保持原值
+type attachmentRequest struct {
+	Target       *string  `json:"target"`
+	OptionLabel  *string  `json:"option_label"`
+	Name         *string  `json:"name"`
+	Caption      *string  `json:"caption"`
+	DisplayWidth *float64 `json:"display_width"`
+	SortOrder    *int     `json:"sort_order"`
+}
+
+// reorderRequest 调整附件顺序请求，按 ids 的顺序重新编号
+type reorderRequest struct {
+	IDs []int `json:"ids" binding:"required"`
+}
+
+// saveAttachmentImage 检查上传的文件是否为图片并保存到媒体存储，返回引用和图片像素尺寸
+func saveAttachmentImage(ctx context.Context, header *multipart.FileHeader) (string, image.Point, error) {
+	file, err := header.Open()
+	if err != nil {
+		return "", image.Point{}, err
+	}
+	defer file.Close()
+	config, err := media.CheckImageSize(file)
+	if errors.Is(err, media.ErrImageTooLarge) {
+		return "", image.Point{}, errImageTooLarge
+	}
+	if err != nil {
+		return "", image.Point{}, errImageFormat
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return "", image.Point{}, err
+	}
+	ref, err := media.Save(ctx, file)
+	if err != nil {
+		return "", image.Point{}, err
+	}
+	return ref, image.Point{X: config.Width, Y: config.Height}, nil
+}
+
+// errImageFormat 上传的文件不是支持的图片格式
+var errImageFormat = errors.New("只支持 PNG、JPEG 和 GIF 图片")
+
+// errImageTooLarge 上传的图片像素数超过 media.MaxImagePixels
+var errImageTooLarge = fmt.Errorf("图片尺寸过大，不能超过 %d 万像素", media.MaxImagePixels/10000)
+
+// nextAttachmentName 返回题目中下一个可用的图片名称，名称为递增的数字
+func nextAttachmentName(existing []entity.QuestionAttachment) string {
+	next := 1
+	for _, a := range existing {
+		if n, err := strconv.Atoi(a.Name); err == nil && n >= next {
+			next = n + 1
+		}
+	}
+	return strconv.Itoa(next)
+}
+
+// nextSortOrder 返回排在同一位置所有图片之后的顺序号
+func nextSortOrder(existing []entity.QuestionAttachment, target, optionLabel string) int {
+	next := 0
+	for _, a := range entity.AttachmentsFor(existing, target, optionLabel) {
+		next = max(next, a.SortOrder+1)
+	}
+	return next
+}
+
+// validateAttachment 检查附件的位置、名称是否有效：选项图片的选项必须存在，名称在题目内不能重复
+func validateAttachment(attachment *entity.QuestionAttachment, question entity.QuestionBank, existing []entity.QuestionAttachment) error {
+	if err := attachment.Validate(); err != nil {
+		return err
+	}
+	if attachment.Target == entity.AttachmentTargetOption {
+		found := false
+		for _, o := range question.Options {
+			found = found || o.Label == attachment.OptionLabel
+		}
+		if !found {
+			return fmt.Errorf("选项 %s 不存在", attachment.OptionLabel)
+		}
+	}
+	for _, a := range existing {
+		if a.ID != attachment.ID && a.Name == attachment.Name {
+			return fmt.Errorf("图片名称 %s 已被使用", attachment.Name)
+		}
+	}
+	return validateFormula("图片说明", attachment.Caption)
+}
+
+// question 解析路径中的题目 ID 并确认题目存在，失败时已写入响应
+func (ac *AttachmentController) question(ctx *gin.Context) (entity.QuestionBank, bool) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.String(http.StatusBadRequest, utils.Make400Resp("Invalid ID"))
+		return entity.QuestionBank{}, false
+	}
+	found, err := ac.questions.GetQuestionBankById(id)
+	if err != nil || len(found) == 0 {
+		ctx.String(http.StatusNotFound, utils.MakeResp(http.StatusNotFound, "Question not found", nil))
+		return entity.QuestionBank{}, false
+	}
+	return found[0], true
+}
+
+// attachment 解析路径中的附件 ID 并确认附件存在，失败时已写入响应
+func (ac *AttachmentController) attachment(ctx *gin.Context) (entity.QuestionAttachment, bool) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.String(http.StatusBadRequest, utils.Make400Resp("Invalid ID"))
+		return entity.QuestionAttachment{}, false
+	}
+	attachment, err := ac.attachments.GetAttachmentById(id)
+	if err != nil {
+		ctx.String(http.StatusNotFound, utils.MakeResp(http.StatusNotFound, "Attachment not found", nil))
+		return entity.QuestionAttachment{}, false
+	}
+	return attachment, true
+}
+
+// 处理 GET /questions/:id/attachments 请求，按位置和顺序列出题目的全部图片
+func (ac *AttachmentController) GetQuestionAttachments(ctx *gin.Context) {
+	question, ok := ac.question(ctx)
+	if !ok {
+		return
+	}
+	attachments, err := ac.attachments.GetAttachmentsByQuestionId(question.ID)
+	if err != nil {
+		ctx.String(http.StatusInternalServerError, utils.Make500Resp("查询题目图片失败"))
+		return
+	}
+	fillAttachmentURLs(attachments)
+	ctx.String(http.StatusOK, utils.Make200Resp("Success", attachments))
+}
+
+// 处理 POST /questions/:id/attachments 请求。表单 images 字段可以上传多张图片，
+// target、option_label、caption、display_width 字段对本次上传的图片都生效，图片名称自动编号
+func (ac *AttachmentController) UploadQuestionAttachments(ctx *gin.Context) {
+	question, ok := ac.question(ctx)
+	if !ok {
+		return
+	}
+	form, err := ctx.MultipartForm()
+	if err != nil || len(form.File["images"]) == 0 {
+		ctx.String(http.StatusBadRequest, utils.Make400Resp("请选择要上传的图片"))
+		return
+	}
+	existing, err := ac.attachments.GetAttachmentsByQuestionId(question.ID)
+	if err != nil {
+		ctx.String(http.StatusInternalServerError, utils.Make500Resp("查询题目图片失败"))
+		return
+	}
+	template := entity.QuestionAttachment{
+		QuestionID:  question.ID,
+		Target:      ctx.DefaultPostForm("target", entity.AttachmentTargetTopic),
+		OptionLabel: ctx.PostForm("option_label"),
+		Caption:     ctx.PostForm("caption"),
+	}
+	if width := ctx.PostForm("display_width"); width != "" {
+		if template.DisplayWidth, err = strconv.ParseFloat(width, 64); err != nil {
+			ctx.String(http.StatusBadRequest, utils.Make400Resp("导出宽度格式错误"))
+			return
+		}
+	}
+	template.Name = nextAttachmentName(existing)
+	if err := validateAttachment(&template, question, existing); err != nil {
+		ctx.String(http.StatusBadRequest, utils.Make400Resp(err.Error()))
+		return
+	}
+
+	var created []entity.QuestionAttachment
+	for _, header := range form.File["images"] {
+		attachment := template
+		path, size, err := saveAttachmentImage(ctx.Request.Context(), header)
+		if errors.Is(err, errImageFormat) || errors.Is(err, errImageTooLarge) {
+			ctx.String(http.StatusBadRequest, utils.Make400Resp(fmt.Sprintf("%s: %v", header.Filename, err)))
+			return
+		}
+		if err != nil {
+			log.Printf("Error saving question attachment: %v", err)
+			ctx.String(http.StatusInternalServerError, utils.Make500Resp("保存图片失败"))
+			return
+		}
+		attachment.Name = nextAttachmentName(existing)
+		attachment.SortOrder = nextSortOrder(existing, attachment.Target, attachment.OptionLabel)
+		attachment.Path, attachment.Width, attachment.Height = path, size.X, size.Y
+		if err := ac.attachments.CreateAttachment(&attachment); err != nil {
+			ctx.String(http.StatusInternalServerError, utils.Make500Resp("保存图片失败"))
+			return
+		}
+		existing = append(existing, attachment)
+		created = append(created, attachment)
+	}
+	syncTopicImagePath(ac.questions, ac.attachments, question.ID)
+	ac.audit.Record(ctx, component.AuditCreate, component.AuditEntityAttachment, strconv.Itoa(question.ID), nil, created)
+	fillAttachmentURLs(created)
+	ctx.String(http.StatusOK, utils.Make200Resp("Success", created))
+}
+
+// 处理 PUT /attachments/:id 请求，修改图片的位置、名称、说明文字、导出宽度或顺序
+func (ac *AttachmentController) UpdateQuestionAttachment(ctx *gin.Context) {
+	existing, ok := ac.attachment(ctx)
+	if !ok {
+		return
+	}
+	var req attachmentRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.String(http.StatusBadRequest, utils.Make400Resp(err.Error()))
+		return
+	}
+	updated := existing
+	if req.Target != nil {
+		updated.Target = *req.Target
+		updated.OptionLabel = ""
+	}
+	if req.OptionLabel != nil {
+		updated.OptionLabel = *req.OptionLabel
+	}
+	if req.Name != nil {
+		updated.Name = *req.Name
+	}
+	if req.Caption != nil {
+		updated.Caption = *req.Caption
+	}
+	if req.DisplayWidth != nil {
+		updated.DisplayWidth = *req.DisplayWidth
+	}
+	if req.SortOrder != nil {
+		updated.SortOrder = *req.SortOrder
+	}
+	updated.UpdateTime = time.Now()
+
+	found, err := ac.questions.GetQuestionBankById(existing.QuestionID)
+	if err != nil || len(found) == 0 {
+		ctx.String(http.StatusNotFound, utils.MakeResp(http.StatusNotFound, "Question not found", nil))
+		return
+	}
+	siblings, err := ac.attachments.GetAttachmentsByQuestionId(existing.QuestionID)
+	if err != nil {
+		ctx.String(http.StatusInternalServerError, utils.Make500Resp("查询题目图片失败"))
+		return
+	}
+	if err := validateAttachment(&updated, found[0], siblings); err != nil {
+		ctx.String(http.StatusBadRequest, utils.Make400Resp(err.Error()))
+		return
+	}
+	if _, err := ac.attachments.UpdateAttachment(&updated); err != nil {
+		ctx.String(http.StatusInternalServerError, utils.Make500Resp("修改图片失败"))
+		return
+	}
+	syncTopicImagePath(ac.questions, ac.attachments, existing.QuestionID)
+	ac.audit.Record(ctx, component.AuditUpdate, component.AuditEntityAttachment, strconv.Itoa(existing.QuestionID), existing, updated)
+	ctx.String(http.StatusOK, utils.Make200Resp("Success", attachmentWithURLs(updated)))
+}
+
+// 处理 PUT /questions/:id/attachments/order 请求，按请求中的顺序重新排列图片，未列出的图片排在最后
+func (ac *AttachmentController) ReorderQuestionAttachments(ctx *gin.Context) {
+	question, ok := ac.question(ctx)
+	if !ok {
+		return
+	}
+	var req reorderRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.String(http.StatusBadRequest, utils.Make400Resp(err.Error()))
+		return
+	}
+	existing, err := ac.attachments.GetAttachmentsByQuestionId(question.ID)
+	if err != nil {
+		ctx.String(http.StatusInternalServerError, utils.Make500Resp("查询题目图片失败"))
+		return
+	}
+	order := make(map[int]int, len(req.IDs))
+	for i, id := range req.IDs {
+		order[id] = i
+	}
+	byID := make(map[int]entity.QuestionAttachment, len(existing))
+	for _, a := range existing {
+		byID[a.ID] = a
+	}
+	for _, id := range req.IDs {
+		if _, ok := byID[id]; !ok {
+			ctx.String(http.StatusBadRequest, utils.Make400Resp(fmt.Sprintf("图片 %d 不属于该题目", id)))
+			return
+		}
+	}
+	for i, a := range existing {
+		sortOrder, ok := order[a.ID]
+		if !ok {
+			sortOrder = len(req.IDs) + i
+		}
+		if sortOrder == a.SortOrder {
+			continue
+		}
+		a.SortOrder = sortOrder
+		a.UpdateTime = time.Now()
+		if _, err := ac.attachments.UpdateAttachment(&a); err != nil {
+			ctx.String(http.StatusInternalServerError, utils.Make500Resp("调整图片顺序失败"))
+			return
+		}
+	}
+	syncTopicImagePath(ac.questions, ac.attachments, question.ID)
+	reordered, _ := ac.attachments.GetAttachmentsByQuestionId(question.ID)
+	ac.audit.Record(ctx, component.AuditUpdate, component.AuditEntityAttachment, strconv.Itoa(question.ID), existing, reordered)
+	fillAttachmentURLs(reordered)
+	ctx.String(http.StatusOK, utils.Make200Resp("Success", reordered))
+}
+
+// 处理 DELETE /attachments/:id 请求，删除图片记录，文字中引用该图片的位置标记在导出时忽略
+func (ac *AttachmentController) DeleteQuestionAttachment(ctx *gin.Context) {
+	attachment, ok := ac.attachment(ctx)
+	if !ok {
+		return
+	}
+	deleteCount, err := ac.attachments.DeleteAttachment(attachment.ID)
+	if err != nil {
+		ctx.String(http.StatusInternalServerError, utils.Make500Resp("删除图片失败"))
+		return
+	}
+	syncTopicImagePath(ac.questions, ac.attachments, attachment.QuestionID)
+	services.ReleaseMedia(ctx.Request.Context(), attachment.Path)
+	ac.audit.Record(ctx, component.AuditDelete, component.AuditEntityAttachment, strconv.Itoa(attachment.QuestionID), attachment, nil)
+	ctx.String(http.StatusOK, utils.Make200Resp("Success", map[string]interface{}{"deleteCount": deleteCount}))
+}
+
+// syncTopicImagePath 把题目的 topic_image_path 设为第一张题干图片，供只认识单张图片的旧接口使用
+func syncTopicImagePath(questions mapper.QuestionRepository, attachments mapper.AttachmentRepository, questionID int) {
+	found, err := attachments.GetAttachmentsByQuestionId(questionID)
+	if err != nil {
+		log.Printf("Error getting attachments of question %d: %v", questionID, err)
+		return
+	}
+	path := ""
+	if topic := entity.AttachmentsFor(found, entity.AttachmentTargetTopic, ""); len(topic) > 0 {
+		path = topic[0].Path
+	}
+	if _, err := questions.SetTopicImagePath(questionID, path); err != nil {
+		log.Printf("Error updating topic image of question %d: %v", questionID, err)
+	}
+}
+
+// replaceLegacyAttachment 旧接口上传的题干图片或选项图片同时保存为附件。replacedPath 为被替换的旧图片，
+// 对应的附件删除，新图片排在它原来的位置；没有旧图片时排在最前面
+func replaceLegacyAttachment(attachments mapper.AttachmentRepository, questionID int, target, optionLabel, path, replacedPath string) error {
+	existing, err := attachments.GetAttachmentsByQuestionId(questionID)
+	if err != nil {
+		return err
+	}
+	sameTarget := entity.AttachmentsFor(existing, target, optionLabel)
+	attachment := entity.QuestionAttachment{
+		QuestionID:  questionID,
+		Target:      target,
+		OptionLabel: optionLabel,
+		Name:        nextAttachmentName(existing),
+		Path:        path,
+	}
+	if len(sameTarget) > 0 {
+		attachment.SortOrder = sameTarget[0].SortOrder - 1
+	}
+	for _, a := range sameTarget {
+		if a.Path == path {
+			return nil
+		}
+		if replacedPath != "" && a.Path == replacedPath {
+			attachment.Name, attachment.Caption, attachment.DisplayWidth, attachment.SortOrder = a.Name, a.Caption, a.DisplayWidth, a.SortOrder
+			if _, err := attachments.DeleteAttachment(a.ID); err != nil {
+				return err
+			}
+			break
+		}
+	}
+	if config, err := readImageConfig(context.Background(), path); err == nil {
+		attachment.Width, attachment.Height = config.Width, config.Height
+	}
+	return attachments.CreateAttachment(&attachment)
+}
+
+// readImageConfig 读取图片的格式和尺寸，path 为 media: 引用或旧版本的文件路径
+func readImageConfig(ctx context.Context, path string) (image.Config, error) {
+	data, err := media.ReadFile(ctx, path)
+	if err != nil {
+		return image.Config{}, err
+	}
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	return config, err
+}
+
+// loadAttachments 获取题目的附件，按题目 ID 索引
+func loadAttachments(attachments mapper.AttachmentRepository, questions []entity.QuestionBank) map[int][]entity.QuestionAttachment {
+	ids := make([]int, 0, len(questions))
+	for _, q := range questions {
+		ids = append(ids, q.ID)
+	}
+	byQuestion := make(map[int][]entity.QuestionAttachment)
+	found, err := attachments.GetAttachmentsInQuestionIds(ids)
+	if err != nil {
+		log.Printf("Error getting question attachments: %v", err)
+	}
+	for _, a := range found {
+		byQuestion[a.QuestionID] = append(byQuestion[a.QuestionID], a)
+	}
+	return byQuestion
+}
+
+// topicContent 返回导出用的题干，图片按位置标记插入。没有题干附件时沿用 topic_image_path
+func topicContent(q entity.QuestionBank, attachments map[int][]entity.QuestionAttachment) string {
+	topic := entity.AttachmentsFor(attachments[q.ID], entity.AttachmentTargetTopic, "")
+	if len(topic) == 0 && q.TopicImagePath != "" {
+		topic = []entity.QuestionAttachment{{Path: q.TopicImagePath}}
+	}
+	return services.PlaceAttachments(q.Topic, topic)
+}
+
+// optionsContent 返回导出用的选项，选项图片按位置标记插入选项文字
+func optionsContent(q entity.QuestionBank, attachments map[int][]entity.QuestionAttachment) []entity.QuestionOption {
+	return services.PlaceOptionAttachments(q.Options, attachments[q.ID])
+}
+
+// answerContent 返回导出用的答案，图片按位置标记插入
+func answerContent(q entity.QuestionBank, attachments map[int][]entity.QuestionAttachment) string {
+	return services.PlaceAttachments(q.Answer, entity.AttachmentsFor(attachments[q.ID], entity.AttachmentTargetAnswer, ""))
+}
@@ -0,0 +1,195 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"graduation/component"
+	"graduation/entity"
+	"graduation/mapper/memory"
+	"graduation/media"
+	"graduation/services"
+	"graduation/utils"
+	"hash/crc32"
+	"image"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+// doMultipart 上传文件并解析统一响应，files 的键为表单字段名
+func doMultipart(t *testing.T, r *gin.Engine, path string, fields map[string]string, files map[string][][]byte) utils.Response {
+	t.Helper()
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+	for k, v := range fields {
+		require.NoError(t, writer.WriteField(k, v))
+	}
+	for field, contents := range files {
+		for _, content := range contents {
+			part, err := writer.CreateFormFile(field, "upload.png")
+			require.NoError(t, err)
+			_, err = part.Write(content)
+			require.NoError(t, err)
+		}
+	}
+	require.NoError(t, writer.Close())
+	req := httptest.NewRequest(http.MethodPost, path, &buf)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	var resp utils.Response
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp), w.Body.String())
+	return resp
+}
+
+// testDeclaredPNG 生成一张 1×1 的 PNG，文件头中声明为 width×height
+func testDeclaredPNG(t *testing.T, width, height uint32) []byte {
+	t.Helper()
+	data := testPNG(t, 1, 1)
+	binary.BigEndian.PutUint32(data[16:], width)
+	binary.BigEndian.PutUint32(data[20:], height)
+	binary.BigEndian.PutUint32(data[29:], crc32.ChecksumIEEE(data[12:29]))
+	return data
+}
+
+// testPNG 生成指定尺寸的 PNG 图片
+func testPNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, image.NewGray(image.Rect(0, 0, width, height))))
+	return buf.Bytes()
+}
+
+// decodeAttachments 把响应数据转换为附件列表
+func decodeAttachments(t *testing.T, data interface{}) []entity.QuestionAttachment {
+	t.Helper()
+	raw, err := json.Marshal(data)
+	require.NoError(t, err)
+	var attachments []entity.QuestionAttachment
+	require.NoError(t, json.Unmarshal(raw, &attachments))
+	return attachments
+}
+
+func TestAttachmentController(t *testing.T) {
+	t.Chdir(t.TempDir())
+	questions := memory.NewQuestionRepository(entity.QuestionBank{
+		Topic:     "下图{{img:1}}中哪个是方波",
+		TopicType: entity.ChoiceTopicType,
+		Options:   []entity.QuestionOption{{Label: "A", Content: "甲"}, {Label: "B", Content: "乙"}},
+	})
+	attachments := memory.NewAttachmentRepository()
+	services.StartMediaGC(services.NewMediaCollector(media.Default(), memory.NewMediaRepository(questions, nil, attachments, nil, nil), 0), 0)
+	t.Cleanup(func() { services.StartMediaGC(nil, 0) })
+	ac := NewAttachmentController(attachments, questions, component.NewAuditor(memory.NewAuditRepository()))
+	qc := NewQuestionBankController(questions, attachments, memory.NewRevisionRepository(), memory.NewReviewRepository(), memory.NewHistoryRepository(), memory.NewLabelRepository(), component.NewAuditor(memory.NewAuditRepository()))
+	r := newTestRouter()
+	r.GET("/questions/:id/attachments", ac.GetQuestionAttachments)
+	r.POST("/questions/:id/attachments", ac.UploadQuestionAttachments)
+	r.PUT("/questions/:id/attachments/order", ac.ReorderQuestionAttachments)
+	r.PUT("/attachments/:id", ac.UpdateQuestionAttachment)
+	r.DELETE("/attachments/:id", ac.DeleteQuestionAttachment)
+	r.GET("/getQuestionBankById", qc.GetQuestionBankById)
+
+	// 一次上传两张题干图片，名称自动编号
+	resp := doMultipart(t, r, "/questions/1/attachments", map[string]string{"caption": "波形图"},
+		map[string][][]byte{"images": {testPNG(t, 400, 100), testPNG(t, 50, 50)}})
+	require.Equal(t, 200, resp.Code, resp.Msg)
+	created := decodeAttachments(t, resp.Data)
+	require.Len(t, created, 2)
+	require.Equal(t, []string{"1", "2"}, []string{created[0].Name, created[1].Name})
+	require.Equal(t, entity.AttachmentTargetTopic, created[0].Target)
+	require.Equal(t, 400, created[0].Width)
+	require.Equal(t, 100, created[0].Height)
+	// 图片保存到媒体存储，数据库中记录引用
+	key, ok := media.ParseRef(created[0].Path)
+	require.True(t, ok, created[0].Path)
+	q, _ := questions.GetQuestionBankById(1)
+	require.Equal(t, created[0].Path, q[0].TopicImagePath)
+
+	resp = doMultipart(t, r, "/questions/1/attachments", map[string]string{"target": "option", "option_label": "C"},
+		map[string][][]byte{"images": {testPNG(t, 10, 10)}})
+	require.Equal(t, 400, resp.Code)
+	require.Contains(t, resp.Msg, "选项 C 不存在")
+	resp = doMultipart(t, r, "/questions/1/attachments", nil, map[string][][]byte{"images": {[]byte("not an image")}})
+	require.Equal(t, 400, resp.Code)
+	// 文件头声明的像素数过大的图片不保存
+	resp = doMultipart(t, r, "/questions/1/attachments", nil, map[string][][]byte{"images": {testDeclaredPNG(t, 30000, 30000)}})
+	require.Equal(t, 400, resp.Code)
+	require.Contains(t, resp.Msg, "图片尺寸过大")
+	resp = doMultipart(t, r, "/questions/1/attachments", map[string]string{"target": "option", "option_label": "A"},
+		map[string][][]byte{"images": {testPNG(t, 10, 10)}})
+	require.Equal(t, 200, resp.Code, resp.Msg)
+
+	// 名称不能重复，说明文字中的公式需要正确
+	resp = doJSON(t, r, http.MethodPut, "/attachments/2", map[string]interface{}{"name": "1"})
+	require.Equal(t, 400, resp.Code)
+	resp = doJSON(t, r, http.MethodPut, "/attachments/2", map[string]interface{}{"caption": `$\frac{1}$`})
+	require.Equal(t, 400, resp.Code)
+	resp = doJSON(t, r, http.MethodPut, "/attachments/2", map[string]interface{}{"name": "wave", "caption": "", "display_width": 4.5})
+	require.Equal(t, 200, resp.Code, resp.Msg)
+
+	// 调整顺序后题干第一张图片随之变化
+	resp = doJSON(t, r, http.MethodPut, "/questions/1/attachments/order", map[string]interface{}{"ids": []int{2, 1}})
+	require.Equal(t, 200, resp.Code, resp.Msg)
+	q, _ = questions.GetQuestionBankById(1)
+	require.Equal(t, created[1].Path, q[0].TopicImagePath)
+
+	resp = doJSON(t, r, http.MethodGet, "/getQuestionBankById?id=1", nil)
+	var found []entity.QuestionBank
+	data, _ := json.Marshal(resp.Data)
+	require.NoError(t, json.Unmarshal(data, &found))
+	require.Len(t, found[0].Attachments, 3)
+
+	resp = doJSON(t, r, http.MethodDelete, "/attachments/2", nil)
+	require.Equal(t, 200, resp.Code, resp.Msg)
+	resp = doJSON(t, r, http.MethodDelete, "/attachments/1", nil)
+	require.Equal(t, 200, resp.Code, resp.Msg)
+	q, _ = questions.GetQuestionBankById(1)
+	require.Empty(t, q[0].TopicImagePath)
+	// 不再被引用的图片文件随之删除
+	_, err := media.Default().Stat(context.Background(), key)
+	require.ErrorIs(t, err, media.ErrNotFound)
+	resp = doJSON(t, r, http.MethodGet, "/questions/1/attachments", nil)
+	remaining := decodeAttachments(t, resp.Data)
+	require.Len(t, remaining, 1)
+	require.Equal(t, "A", remaining[0].OptionLabel)
+}
+
+func TestExportContentWithAttachments(t *testing.T) {
+	q := entity.QuestionBank{
+		ID:             1,
+		Topic:          "如图{{img:2}}所示，求输出{{img:9}}",
+		Answer:         "见下图",
+		TopicImagePath: "resources/images/a.png",
+		Options:        []entity.QuestionOption{{Label: "A", Content: "甲", ImagePath: "resources/images/old.png"}, {Label: "B", Content: "乙"}},
+	}
+	attachments := map[int][]entity.QuestionAttachment{1: {
+		{ID: 1, QuestionID: 1, Target: entity.AttachmentTargetTopic, Name: "1", Path: "resources/images/a.png"},
+		{ID: 2, QuestionID: 1, Target: entity.AttachmentTargetTopic, Name: "2", Path: "resources/images/b.png", Caption: "电路图"},
+		{ID: 3, QuestionID: 1, Target: entity.AttachmentTargetOption, OptionLabel: "A", Name: "3", Path: "resources/images/old.png"},
+		{ID: 4, QuestionID: 1, Target: entity.AttachmentTargetAnswer, Name: "4", Path: "resources/images/c.png"},
+	}}
+
+	// 有标记的图片插在标记处，未知的标记去掉，没有标记的图片排在最后
+	topic := topicContent(q, attachments)
+	require.Regexp(t, `^如图\[ATTACH\].*"path":"resources/images/b.png".*\[/ATTACH\]所示，求输出\[ATTACH\].*"path":"resources/images/a.png".*\[/ATTACH\]$`, topic)
+	require.NotContains(t, topic, "{{img:")
+
+	options := optionsContent(q, attachments)
+	require.Empty(t, options[0].ImagePath)
+	require.Contains(t, options[0].Content, "resources/images/old.png")
+	require.Equal(t, "乙", options[1].Content)
+	require.Equal(t, "resources/images/old.png", q.Options[0].ImagePath)
+
+	require.Contains(t, answerContent(q, attachments), "resources/images/c.png")
+
+	// 还没有附件记录的题目沿用 topic_image_path
+	require.Contains(t, topicContent(q, nil), "resources/images/a.png")
+}
@@ -1,57 +1,132 @@
 package controller
 
 import (
-	"encoding/base64"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"graduation/component"
 	"graduation/entity"
 	"graduation/mapper"
+	"graduation/media"
 	"graduation/services"
 	"graduation/utils"
 	"io"
-	"io/ioutil"
 	"log"
+	"mime/multipart"
 	"net/http"
-	"os"
-	"path/filepath"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
-// QuestionBankMapper 定义数据库操作接口
-type QuestionBankMapper interface {
-	GetAllQuestionBank() ([]entity.QuestionBank, error)
-	GetQuestionBankById(id int) ([]entity.QuestionBank, error)
-	GetDistinctTopicType() ([]string, error)
-	SearchQuestionByTopic(topicType, keyword string) ([]entity.QuestionBank, error)
-	InsertSingleQuestionBank(questionBank *entity.QuestionBank) (int64, error)
-	DeleteSingleQuestionBank(id int) (int64, error)
-	UpdateSingleQuestionBank(questionBank *entity.QuestionBank) (int64, error)
-	GetAvgDifficultyByIds(ids []int) (float64, error)
-	GetDistinctLabel1FromQuestionBank() ([]string, error)
-	GetQuestionBankCountByLabel1(label1 string) (int, error)
-	GetDistinctScoreFromQuestionBank() ([]float64, error)
-	GetQuestionBankCountByScore(score float64) (int, error)
-	GetQuestionBankByIds(ids []int, generateRange []string) ([]entity.QuestionBank, error)
-	GetAll() ([]entity.QuestionBank, error)
-}
-
 // QuestionBankController 定义问题银行控制器结构体
 type QuestionBankController struct {
-	mapper         QuestionBankMapper
+	mapper         mapper.QuestionRepository
+	attachments    mapper.AttachmentRepository
+	revisions      mapper.RevisionRepository
+	reviews        mapper.ReviewRepository
+	history        mapper.HistoryRepository
+	labels         mapper.LabelRepository
+	reviser        *services.QuestionRevisions
+	audit          *component.Auditor
 	default200Resp string
 }
 
 // NewQuestionBankController 创建新的问题银行控制器
-func NewQuestionBankController() *QuestionBankController {
+func NewQuestionBankController(questions mapper.QuestionRepository, attachments mapper.AttachmentRepository, revisions mapper.RevisionRepository, reviews mapper.ReviewRepository, history mapper.HistoryRepository, labels mapper.LabelRepository, audit *component.Auditor) *QuestionBankController {
 	return &QuestionBankController{
-		mapper:         mapper.NewQuestionBankMapper(),
+		mapper:         questions,
+		attachments:    attachments,
+		revisions:      revisions,
+		reviews:        reviews,
+		history:        history,
+		labels:         labels,
+		reviser:        services.NewQuestionRevisions(revisions),
+		audit:          audit,
 		default200Resp: "default 200 response",
 	}
 }
 
+// auditQuestion 获取题目记录作为审计快照，题目不存在时返回 nil
+func (c *QuestionBankController) auditQuestion(id int) interface{} {
+	found, err := c.mapper.GetQuestionBankById(id)
+	if err != nil || len(found) == 0 {
+		return nil
+	}
+	return found[0]
+}
+
+// baselineRevision 在修改题目之前调用，还没有版本的题目先补记修改前的内容
+func (c *QuestionBankController) baselineRevision(before entity.QuestionBank) {
+	if err := c.reviser.Baseline(before); err != nil {
+		log.Printf("Error recording initial revision of question %d: %v", before.ID, err)
+	}
+}
+
+// recordRevision 读取保存后的题目并记录为新版本，内容没有变化时不记录。
+// 写入失败只记录日志，不影响已经完成的修改
+func (c *QuestionBankController) recordRevision(ctx *gin.Context, id int, action string, source int) *entity.QuestionRevision {
+	found, err := c.mapper.GetQuestionBankById(id)
+	if err != nil || len(found) == 0 {
+		return nil
+	}
+	revision, err := c.reviser.Record(found[0], action, component.CurrentUsername(ctx), source)
+	if err != nil {
+		log.Printf("Error recording revision of question %d: %v", id, err)
+	}
+	return revision
+}
+
+// saveLegacyAttachments 把 image 和 option_image_<标号> 字段上传的图片同步为题目附件，
+// 新图片替换原来的题干图片或该选项原来的图片。old 为修改前的题目，新增题目时为 nil
+func (c *QuestionBankController) saveLegacyAttachments(q *entity.QuestionBank, old *entity.QuestionBank) {
+	oldTopic := ""
+	oldOptions := make(map[string]string)
+	if old != nil {
+		oldTopic = old.TopicImagePath
+		for _, o := range old.Options {
+			oldOptions[o.Label] = o.ImagePath
+		}
+	}
+	if q.TopicImagePath != "" && q.TopicImagePath != oldTopic {
+		if err := replaceLegacyAttachment(c.attachments, q.ID, entity.AttachmentTargetTopic, "", q.TopicImagePath, oldTopic); err != nil {
+			log.Printf("Error saving topic image of question %d: %v", q.ID, err)
+		}
+	}
+	for _, o := range q.Options {
+		if o.ImagePath == "" || o.ImagePath == oldOptions[o.Label] {
+			continue
+		}
+		if err := replaceLegacyAttachment(c.attachments, q.ID, entity.AttachmentTargetOption, o.Label, o.ImagePath, oldOptions[o.Label]); err != nil {
+			log.Printf("Error saving option image of question %d: %v", q.ID, err)
+		}
+	}
+	syncTopicImagePath(c.mapper, c.attachments, q.ID)
+}
+
+// duplicateDetector 使用当前题库创建查重索引
+func (c *QuestionBankController) duplicateDetector(threshold float64) (*services.DuplicateDetector, error) {
+	all, err := c.mapper.GetAllQuestionBank()
+	if err != nil {
+		return nil, err
+	}
+	return services.NewQuestionDuplicateDetector(all, threshold), nil
+}
+
+// findDuplicates 查找与题干重复的题目，只做提示，查询失败时记录日志并返回空列表
+func (c *QuestionBankController) findDuplicates(topic string, excludeID int) []services.DuplicateMatch {
+	detector, err := c.duplicateDetector(services.DefaultDuplicateThreshold)
+	if err != nil {
+		log.Printf("load question bank for duplicate check: %v", err)
+		return []services.DuplicateMatch{}
+	}
+	return detector.Find(topic, excludeID)
+}
+
 // GetAllQuestionBank 获取所有问题银行记录
 func (c *QuestionBankController) GetAllQuestionBank(ctx *gin.Context) {
 	allQuestionBank, err := c.mapper.GetAllQuestionBank()
@@ -60,34 +135,89 @@ func (c *QuestionBankController) GetAllQuestionBank(ctx *gin.Context) {
 		return
 	}
 
-	// 遍历所有题目，将图片转换为base64
-	for i := range allQuestionBank {
-		if allQuestionBank[i].TopicImagePath != "" {
-			// 读取图片文件
-			imgData, err := ioutil.ReadFile(allQuestionBank[i].TopicImagePath)
-			if err != nil {
-				log.Printf("Error reading image file %s: %v", allQuestionBank[i].TopicImagePath, err)
-				continue
-			}
+	fillQuestionImageURLs(allQuestionBank)
+	renderQuestionMathML(allQuestionBank)
+	ctx.String(http.StatusOK, utils.Make200Resp(c.default200Resp, allQuestionBank))
+}
 
-			// 转换为base64
-			base64Str := base64.StdEncoding.EncodeToString(imgData)
-			// 添加base64前缀
-			allQuestionBank[i].TopicImagePath = "data:image/jpeg;base64," + base64Str
-		}
-	}
+// 题库分页参数
+const (
+	defaultQuestionPageSize = 20
+	maxQuestionPageSize     = 100
+)
 
-	ctx.String(http.StatusOK, utils.Make200Resp(c.default200Resp, allQuestionBank))
+// parseQuestionBankQuery 从请求参数中解析题库查询条件
+func parseQuestionBankQuery(ctx *gin.Context) (mapper.QuestionBankQuery, error) {
+	q := mapper.QuestionBankQuery{
+		TopicTypes: queryList(ctx, "topic_type"),
+		Keyword:    ctx.Query("keyword"),
+		Answer:     ctx.Query("answer"),
+		Chapter1:   ctx.Query("chapter_1"),
+		Chapter2:   ctx.Query("chapter_2"),
+		Label1:     ctx.Query("label_1"),
+		Label2:     ctx.Query("label_2"),
+		Cursor:     ctx.Query("cursor"),
+		Statuses:   queryList(ctx, "status"),
+		Reviewer:   ctx.Query("reviewer"),
+	}
+	var err error
+	if q.MinDifficulty, err = queryInt(ctx, "min_difficulty"); err != nil {
+		return q, err
+	}
+	if q.MaxDifficulty, err = queryInt(ctx, "max_difficulty"); err != nil {
+		return q, err
+	}
+	if q.MinScore, err = queryFloat(ctx, "min_score"); err != nil {
+		return q, err
+	}
+	if q.MaxScore, err = queryFloat(ctx, "max_score"); err != nil {
+		return q, err
+	}
+	if q.UpdatedFrom, err = parseQueryTime(ctx.Query("updated_from"), false); err != nil {
+		return q, err
+	}
+	if q.UpdatedTo, err = parseQueryTime(ctx.Query("updated_to"), true); err != nil {
+		return q, err
+	}
+	if q.Sort, err = mapper.ParseQuestionBankSort(ctx.Query("sort")); err != nil {
+		return q, err
+	}
+	return q, nil
 }
 
-// GetQuestionBank 获取指定范围的问题银行记录
+// GetQuestionBank 按条件分页查询题库，支持 page/page_size 或 cursor 翻页，只转换当前页的图片
 func (c *QuestionBankController) GetQuestionBank(ctx *gin.Context) {
-	//startItemStr := ctx.Query("startItem")
-	//endItemStr := ctx.Query("endItem")
-	//startItem, _ := strconv.Atoi(startItemStr)
-	//endItem, _ := strconv.Atoi(endItemStr)
-	questionBankList, _ := c.mapper.GetAllQuestionBank()
-	ctx.String(http.StatusOK, utils.Make200Resp(c.default200Resp, questionBankList))
+	query, err := parseQuestionBankQuery(ctx)
+	if err != nil {
+		ctx.String(http.StatusBadRequest, utils.Make400Resp(err.Error()))
+		return
+	}
+	page, pageSize := parsePagination(ctx, defaultQuestionPageSize, maxQuestionPageSize)
+	query.Limit = pageSize
+	query.Offset = (page - 1) * pageSize
+
+	result, err := c.mapper.QueryQuestionBanks(query)
+	if errors.Is(err, mapper.ErrInvalidCursor) {
+		ctx.String(http.StatusBadRequest, utils.Make400Resp("无效的 cursor"))
+		return
+	}
+	if err != nil {
+		ctx.String(http.StatusInternalServerError, utils.Make500Resp("获取题库失败"))
+		return
+	}
+	if result.Items == nil {
+		result.Items = []entity.QuestionBank{}
+	}
+	fillQuestionImageURLs(result.Items)
+	renderQuestionMathML(result.Items)
+	retData := map[string]interface{}{
+		"items":       result.Items,
+		"total":       result.Total,
+		"page":        page,
+		"page_size":   pageSize,
+		"next_cursor": result.NextCursor,
+	}
+	ctx.String(http.StatusOK, utils.Make200Resp(c.default200Resp, retData))
 }
 
 // GetTopicType 获取不同的主题类型
@@ -101,23 +231,160 @@ func (c *QuestionBankController) SearchQuestionByTopic(ctx *gin.Context) {
 	topicType := ctx.Query("topicType")
 	keyword := ctx.Query("keyword")
 	questions, _ := c.mapper.SearchQuestionByTopic(topicType, keyword)
+	fillQuestionImageURLs(questions)
+	renderQuestionMathML(questions)
 	ctx.String(http.StatusOK, utils.Make200Resp(c.default200Resp, questions))
 }
 
+// 全文检索参数
+const (
+	defaultQuestionSearchLimit = 50
+	maxQuestionSearchLimit     = 200
+	questionSnippetLength      = 80
+)
+
+// questionSearchItem 全文检索结果，Highlights 中命中的文字用 <em></em> 包裹，没有命中的字段不返回
+type questionSearchItem struct {
+	entity.QuestionBank
+	Relevance  float64           `json:"relevance"`
+	Highlights map[string]string `json:"highlights"`
+}
+
+// SearchQuestionBank 全文检索题干、答案和知识点，按相关度排序并返回高亮片段
+func (c *QuestionBankController) SearchQuestionBank(ctx *gin.Context) {
+	keyword := strings.TrimSpace(ctx.Query("keyword"))
+	if keyword == "" {
+		ctx.String(http.StatusBadRequest, utils.Make400Resp("keyword 不能为空"))
+		return
+	}
+	limit := defaultQuestionSearchLimit
+	if v, err := queryInt(ctx, "limit"); err != nil {
+		ctx.String(http.StatusBadRequest, utils.Make400Resp(err.Error()))
+		return
+	} else if v != nil && *v > 0 {
+		limit = min(*v, maxQuestionSearchLimit)
+	}
+
+	hits, err := c.mapper.SearchQuestionBanks(mapper.QuestionSearchQuery{
+		Keyword:   keyword,
+		TopicType: ctx.Query("topic_type"),
+		Limit:     limit,
+	})
+	if err != nil {
+		ctx.String(http.StatusInternalServerError, utils.Make500Resp("检索题库失败"))
+		return
+	}
+	questions := make([]entity.QuestionBank, len(hits))
+	for i, h := range hits {
+		questions[i] = h.Question
+	}
+	fillQuestionImageURLs(questions)
+	renderQuestionMathML(questions)
+
+	items := make([]questionSearchItem, 0, len(hits))
+	for i, h := range hits {
+		highlights := make(map[string]string)
+		for field, text := range map[string]string{
+			"topic":   h.Question.Topic,
+			"answer":  h.Question.Answer,
+			"label_1": h.Question.Label1,
+			"label_2": h.Question.Label2,
+		} {
+			if snippet := mapper.HighlightSnippet(text, keyword, questionSnippetLength); snippet != "" {
+				highlights[field] = snippet
+			}
+		}
+		items = append(items, questionSearchItem{QuestionBank: questions[i], Relevance: h.Relevance, Highlights: highlights})
+	}
+	ctx.String(http.StatusOK, utils.Make200Resp(c.default200Resp, items))
+}
+
 type QuestionBank struct {
-	ID              string    `json:"id"`
-	Topic           string    `json:"topic"`
-	TopicMaterialID int       `json:"topic_material_id"`
-	Answer          string    `json:"answer"`
-	TopicType       string    `json:"topic_type"`
-	Score           float64   `json:"score"`
-	Difficulty      string    `json:"difficulty"`
-	Chapter1        string    `json:"chapter_1"`
-	Chapter2        string    `json:"chapter_2"`
-	Label1          string    `json:"label_1"`
-	Label2          string    `json:"label_2"`
-	TopicImagePath  string    `json:"topic_image_path"`
-	UpdateTime      time.Time `json:"update_time"`
+	ID              string                  `json:"id"`
+	Topic           string                  `json:"topic"`
+	TopicMaterialID int                     `json:"topic_material_id"`
+	Answer          string                  `json:"answer"`
+	TopicType       string                  `json:"topic_type"`
+	Score           float64                 `json:"score"`
+	Difficulty      string                  `json:"difficulty"`
+	Chapter1        string                  `json:"chapter_1"`
+	Chapter2        string                  `json:"chapter_2"`
+	Label1          string                  `json:"label_1"`
+	Label2          string                  `json:"label_2"`
+	TopicImagePath  string                  `json:"topic_image_path"`
+	Options         []entity.QuestionOption `json:"options"`
+	TopicTable      *entity.ContentTable    `json:"topic_table"`
+	AnswerTable     *entity.ContentTable    `json:"answer_table"`
+	UpdateTime      time.Time               `json:"update_time"`
+}
+
+// validateTables 检查题干表格和答案表格的结构，未提交的表格不检查
+func validateTables(req *QuestionBank) error {
+	if req.TopicTable != nil {
+		if err := req.TopicTable.Validate(); err != nil {
+			return fmt.Errorf("题干表格: %w", err)
+		}
+	}
+	if req.AnswerTable != nil {
+		if err := req.AnswerTable.Validate(); err != nil {
+			return fmt.Errorf("答案表格: %w", err)
+		}
+	}
+	return nil
+}
+
+// prepareChoiceOptions 整理选择题选项并检查选项是否完整，整理后的题干、答案和选项同步回请求数据。
+// 其他题型没有选项，改为其他题型时删除原来的选项
+func prepareChoiceOptions(q *entity.QuestionBank, req *QuestionBank) error {
+	if q.TopicType != entity.ChoiceTopicType {
+		q.Options = nil
+	}
+	q.NormalizeChoiceOptions()
+	if len(q.Options) > 0 {
+		if len(q.Options) < 2 {
+			return errors.New("选择题至少需要两个选项")
+		}
+		for _, o := range q.Options {
+			if strings.TrimSpace(o.Content) == "" && o.ImagePath == "" {
+				return fmt.Errorf("选项 %s 内容为空", o.Label)
+			}
+		}
+	}
+	req.Topic, req.Answer, req.Options = q.Topic, q.Answer, q.Options
+	return nil
+}
+
+// saveUploadedFile 把上传的文件保存到媒体存储，返回写入数据库的引用。
+// 图片的像素数超过 media.MaxImagePixels 时返回 errImageTooLarge
+func saveUploadedFile(ctx context.Context, header *multipart.FileHeader) (string, error) {
+	file, err := header.Open()
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	if _, err := media.CheckImageSize(file); errors.Is(err, media.ErrImageTooLarge) {
+		return "", errImageTooLarge
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	return media.Save(ctx, file)
+}
+
+// saveOptionImages 保存表单中 option_image_<标号> 字段上传的选项图片
+func saveOptionImages(ctx context.Context, form *multipart.Form, options []entity.QuestionOption) error {
+	for i := range options {
+		files := form.File["option_image_"+options[i].Label]
+		if len(files) == 0 {
+			continue
+		}
+		ref, err := saveUploadedFile(ctx, files[0])
+		if err != nil {
+			return err
+		}
+		options[i].ImagePath = ref
+	}
+	return nil
 }
 
 // InsertSingleQuestionBankWithImg 插入单个问题银行记录
@@ -138,45 +405,22 @@ func (c *QuestionBankController) InsertSingleQuestionBankWithImg(ctx *gin.Contex
 
 	// 处理图片上传
 	if len(form.File["image"]) > 0 {
-		file, err := form.File["image"][0].Open()
-		if err != nil {
-			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Failed to open image file"})
-			return
-		}
-		defer file.Close()
-
-		// 创建图片保存目录
-		imageDir := "resources/images"
-		if err := os.MkdirAll(imageDir, 0755); err != nil {
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create image directory"})
+		ref, err := saveUploadedFile(ctx.Request.Context(), form.File["image"][0])
+		if errors.Is(err, errImageTooLarge) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
-
-		// 生成图片文件名
-		imageName := fmt.Sprintf("question_%d.jpg", time.Now().UnixNano())
-		imagePath := filepath.Join(imageDir, imageName)
-
-		// 保存图片
-		out, err := os.Create(imagePath)
 		if err != nil {
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create image file"})
-			return
-		}
-		defer out.Close()
-
-		if _, err := io.Copy(out, file); err != nil {
 			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save image"})
 			return
 		}
-
-		// 更新图片路径
-		questionBank.TopicImagePath = imagePath
+		questionBank.TopicImagePath = ref
 	}
 
 	// 插入数据库记录
 	questionBank.UpdateTime = time.Now()
 	diff, _ := strconv.Atoi(questionBank.Difficulty)
-	insertStatus, err := c.mapper.InsertSingleQuestionBank(&entity.QuestionBank{
+	inserted := &entity.QuestionBank{
 		Topic:           questionBank.Topic,
 		TopicMaterialID: questionBank.TopicMaterialID,
 		Answer:          questionBank.Answer,
@@ -188,17 +432,45 @@ func (c *QuestionBankController) InsertSingleQuestionBankWithImg(ctx *gin.Contex
 		Label1:          questionBank.Label1,
 		Label2:          questionBank.Label2,
 		TopicImagePath:  questionBank.TopicImagePath,
+		Options:         questionBank.Options,
+		TopicTable:      questionBank.TopicTable,
+		AnswerTable:     questionBank.AnswerTable,
 		UpdateTime:      questionBank.UpdateTime,
-	})
+	}
+	if err := saveOptionImages(ctx.Request.Context(), form, inserted.Options); errors.Is(err, errImageTooLarge) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	} else if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save option image"})
+		return
+	}
+	if err := prepareChoiceOptions(inserted, &questionBank); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateTables(&questionBank); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateQuestionFormulas(inserted); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	duplicates := c.findDuplicates(inserted.Topic, 0)
+	insertStatus, err := c.mapper.InsertSingleQuestionBank(inserted)
 
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to insert database"})
 		return
 	}
+	c.saveLegacyAttachments(inserted, nil)
+	c.recordRevision(ctx, inserted.ID, entity.RevisionCreate, 0)
+	c.audit.Record(ctx, component.AuditCreate, component.AuditEntityQuestion, strconv.Itoa(inserted.ID), nil, inserted)
 
 	retJson := map[string]interface{}{
 		"insertStatus": insertStatus,
 		"insertObject": questionBank,
+		"duplicates":   duplicates,
 	}
 	ctx.String(http.StatusOK, utils.Make200Resp(c.default200Resp, retJson))
 }
@@ -211,7 +483,7 @@ func (c *QuestionBankController) InsertSingleQuestionBank(ctx *gin.Context) {
 	}
 	questionBank.UpdateTime = time.Now()
 	diff, _ := strconv.Atoi(questionBank.Difficulty)
-	commitStatus, _ := c.mapper.InsertSingleQuestionBank(&entity.QuestionBank{
+	inserted := &entity.QuestionBank{
 		Topic:           questionBank.Topic,
 		TopicMaterialID: questionBank.TopicMaterialID,
 		Answer:          questionBank.Answer,
@@ -222,20 +494,59 @@ func (c *QuestionBankController) InsertSingleQuestionBank(ctx *gin.Context) {
 		Chapter2:        questionBank.Chapter2,
 		Label1:          questionBank.Label1,
 		Label2:          questionBank.Label2,
+		Options:         questionBank.Options,
+		TopicTable:      questionBank.TopicTable,
+		AnswerTable:     questionBank.AnswerTable,
 		UpdateTime:      questionBank.UpdateTime,
-	})
+	}
+	if err := prepareChoiceOptions(inserted, &questionBank); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateTables(&questionBank); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateQuestionFormulas(inserted); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	duplicates := c.findDuplicates(inserted.Topic, 0)
+	commitStatus, err := c.mapper.InsertSingleQuestionBank(inserted)
+	if err == nil && commitStatus > 0 {
+		c.recordRevision(ctx, inserted.ID, entity.RevisionCreate, 0)
+		c.audit.Record(ctx, component.AuditCreate, component.AuditEntityQuestion, strconv.Itoa(inserted.ID), nil, inserted)
+	}
 	retJson := map[string]interface{}{
 		"insertStatus": commitStatus,
 		"insertObject": questionBank,
+		"duplicates":   duplicates,
 	}
 	ctx.String(http.StatusOK, utils.Make200Resp(c.default200Resp, retJson))
 }
 
-// DeleteSingleQuestionBank 删除单条问题银行记录
+// DeleteSingleQuestionBank 把题目移入回收站，附件、历史版本和图片保留到彻底删除时。
+// 被已锁定的试卷引用的题目不能删除
 func (c *QuestionBankController) DeleteSingleQuestionBank(ctx *gin.Context) {
 	idStr := ctx.Query("id")
 	id, _ := strconv.Atoi(idStr)
-	commitStatus, _ := c.mapper.DeleteSingleQuestionBank(id)
+	if c.rejectLockedQuestions(ctx, []int{id}) {
+		return
+	}
+	before := c.auditQuestion(id)
+	commitStatus, err := c.mapper.SoftDeleteQuestionBanks([]int{id}, component.CurrentUsername(ctx))
+	if err != nil {
+		log.Printf("Error deleting question %d: %v", id, err)
+		ctx.String(http.StatusInternalServerError, utils.Make500Resp("删除题目失败"))
+		return
+	}
+	// 检查之后试卷才被锁定时删除语句不会删除题目，再次检查以返回原因
+	if commitStatus == 0 && c.rejectLockedQuestions(ctx, []int{id}) {
+		return
+	}
+	if commitStatus > 0 {
+		c.audit.Record(ctx, component.AuditDelete, component.AuditEntityQuestion, idStr, before, nil)
+	}
 	retJson := map[string]interface{}{
 		"deleteStatus": commitStatus,
 		"deleteObject": id,
@@ -248,6 +559,15 @@ func (c *QuestionBankController) GetQuestionBankById(ctx *gin.Context) {
 	idStr := ctx.Query("id")
 	id, _ := strconv.Atoi(idStr)
 	questionBankByIdList, _ := c.mapper.GetQuestionBankById(id)
+	renderQuestionMathML(questionBankByIdList)
+	for i := range questionBankByIdList {
+		attachments, err := c.attachments.GetAttachmentsByQuestionId(questionBankByIdList[i].ID)
+		if err != nil {
+			log.Printf("Error getting attachments of question %d: %v", questionBankByIdList[i].ID, err)
+		}
+		questionBankByIdList[i].Attachments = attachments
+	}
+	fillQuestionImageURLs(questionBankByIdList)
 	ctx.String(http.StatusOK, utils.Make200Resp(c.default200Resp, questionBankByIdList))
 }
 
@@ -269,46 +589,23 @@ func (c *QuestionBankController) UpdateQuestionBankById(ctx *gin.Context) {
 
 	// 处理图片上传
 	if len(form.File["image"]) > 0 {
-		file, err := form.File["image"][0].Open()
-		if err != nil {
-			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Failed to open image file"})
+		ref, err := saveUploadedFile(ctx.Request.Context(), form.File["image"][0])
+		if errors.Is(err, errImageTooLarge) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
-		defer file.Close()
-
-		// 创建图片保存目录
-		imageDir := "resources/images"
-		if err := os.MkdirAll(imageDir, 0755); err != nil {
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create image directory"})
-			return
-		}
-
-		// 生成图片文件名
-		imageName := fmt.Sprintf("question_%s_%d.jpg", questionBank.ID, time.Now().Unix())
-		imagePath := filepath.Join(imageDir, imageName)
-
-		// 保存图片
-		out, err := os.Create(imagePath)
 		if err != nil {
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create image file"})
-			return
-		}
-		defer out.Close()
-
-		if _, err := io.Copy(out, file); err != nil {
 			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save image"})
 			return
 		}
-
-		// 更新图片路径
-		questionBank.TopicImagePath = imagePath
+		questionBank.TopicImagePath = ref
 	}
 
 	// 更新数据库记录
 	questionBank.UpdateTime = time.Now()
 	diff, _ := strconv.Atoi(questionBank.Difficulty)
 	id, _ := strconv.Atoi(questionBank.ID)
-	updateStatus, err := c.mapper.UpdateSingleQuestionBank(&entity.QuestionBank{
+	updated := &entity.QuestionBank{
 		ID:              id,
 		Topic:           questionBank.Topic,
 		TopicMaterialID: questionBank.TopicMaterialID,
@@ -321,22 +618,84 @@ func (c *QuestionBankController) UpdateQuestionBankById(ctx *gin.Context) {
 		Label1:          questionBank.Label1,
 		Label2:          questionBank.Label2,
 		TopicImagePath:  questionBank.TopicImagePath,
+		Options:         questionBank.Options,
+		TopicTable:      questionBank.TopicTable,
+		AnswerTable:     questionBank.AnswerTable,
 		UpdateTime:      questionBank.UpdateTime,
-	})
+	}
+	if err := saveOptionImages(ctx.Request.Context(), form, updated.Options); errors.Is(err, errImageTooLarge) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	} else if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save option image"})
+		return
+	}
+	if err := prepareChoiceOptions(updated, &questionBank); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateTables(&questionBank); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateQuestionFormulas(updated); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	before := c.auditQuestion(id)
+	if old, ok := before.(entity.QuestionBank); ok {
+		c.baselineRevision(old)
+	}
+	updateStatus, err := c.mapper.UpdateSingleQuestionBank(updated)
 
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update database"})
 		return
 	}
+	if old, ok := before.(entity.QuestionBank); ok {
+		c.saveLegacyAttachments(updated, &old)
+		// 被新图片替换掉的旧图片
+		services.ReleaseMedia(ctx.Request.Context(), services.QuestionMediaRefs(old)...)
+	}
+	c.recordRevision(ctx, id, entity.RevisionUpdate, 0)
+	c.audit.Record(ctx, component.AuditUpdate, component.AuditEntityQuestion, questionBank.ID, before, c.auditQuestion(id))
+	if old, ok := before.(entity.QuestionBank); ok {
+		if err := c.reopenReview(ctx, old); err != nil {
+			log.Printf("Error reopening review of question %d: %v", id, err)
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "题目已保存，但修改审核状态失败"})
+			return
+		}
+	}
 
 	retJson := map[string]interface{}{
 		"updateStatus": updateStatus,
 		"updateObject": questionBank,
+		"duplicates":   c.findDuplicates(updated.Topic, id),
 	}
 	ctx.String(http.StatusOK, utils.Make200Resp(c.default200Resp, retJson))
 }
 
-// UploadFile 上传 Excel 文件到数据库
+// importDuplicate 导入的题目与题库中已有题目或文件中前面的题目重复。
+// 预览时文件中的题目还没有 ID，ID 为 0，matches 中以负的行号表示文件中的题目
+type importDuplicate struct {
+	Row     int                       `json:"row"`
+	ID      int                       `json:"id"`
+	Topic   string                    `json:"topic"`
+	Matches []services.DuplicateMatch `json:"matches"`
+}
+
+// importErrorRows 有错误的行数，同一行的多个错误只计一次
+func importErrorRows(errs []services.ImportError) int {
+	rows := make(map[int]bool, len(errs))
+	for _, e := range errs {
+		rows[e.Row] = true
+	}
+	return len(rows)
+}
+
+// UploadFile 上传 Excel 文件导入题库。先逐行校验，任一行有误时返回 400 并在 data.errors 中列出
+// 每行的错误，题库不做任何修改；dryRun 为 true 时只校验，返回将要导入的题目和错误供预览。
+// 清空题库和插入题目在同一事务中完成，失败时全部回滚
 func (c *QuestionBankController) UploadFile(ctx *gin.Context) {
 	file, err := ctx.FormFile("file")
 	if err != nil {
@@ -345,16 +704,13 @@ func (c *QuestionBankController) UploadFile(ctx *gin.Context) {
 	}
 	isDeleteAllStr := ctx.PostForm("isDeleteAll")
 	isDeleteAll, _ := strconv.ParseBool(isDeleteAllStr)
-
-	deleteCount := 0
-	insertCount := 0
-	if isDeleteAll {
-		allQuestionBank, _ := c.mapper.GetAllQuestionBank()
-		for _, questionBank := range allQuestionBank {
-			num, _ := c.mapper.DeleteSingleQuestionBank(questionBank.ID)
-			deleteCount += int(num)
-		}
+	dryRun, _ := strconv.ParseBool(ctx.PostForm("dryRun"))
+	// 清空题库需要额外的权限
+	if isDeleteAll && !component.HasPermission(ctx, component.PermQuestionDeleteAll) {
+		component.Forbidden(ctx)
+		return
 	}
+
 	src, err := file.Open()
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -363,39 +719,205 @@ func (c *QuestionBankController) UploadFile(ctx *gin.Context) {
 	defer src.Close()
 
 	eR := services.NewExcelReader(src)
-	questionBanMap, err := eR.ReadExcel()
+	records, err := eR.ReadExcel()
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	labels, err := c.labels.GetAllQuestionLabels()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	parsed, errs := services.ParseImportRows(records, labels)
+	rows := make([]services.ImportRow, 0, len(parsed))
+	for _, row := range parsed {
+		if err := validateQuestionFormulas(&row.Question); err != nil {
+			errs = append(errs, services.ImportError{Row: row.Row, Message: err.Error()})
+			continue
+		}
+		rows = append(rows, row)
+	}
+	if errs == nil {
+		errs = []services.ImportError{}
+	}
+
+	allQuestionBank, err := c.mapper.GetAllQuestionBank()
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	for _, v := range questionBanMap {
-		questionBank := &entity.QuestionBank{
-			Topic:           v["topic"].(string),
-			TopicMaterialID: toInt(v["topic_material_id"].(string)),
-			Answer:          v["answer"].(string),
-			TopicType:       v["topic_type"].(string),
-			Score:           v["score"].(float64),
-			Difficulty:      int(v["difficulty"].(int64)),
-			Chapter1:        v["chapter_1"].(string),
-			Chapter2:        v["chapter_2"].(string),
-			Label1:          v["label_1"].(string),
-			Label2:          v["label_2"].(string),
-			UpdateTime:      time.Now(),
+	// 清空题库时把全部题目移入回收站，被已锁定的试卷引用的题目保留在题库中
+	var deletedIds, protectedIds []int
+	var deletedQuestions, remaining []entity.QuestionBank
+	if isDeleteAll {
+		allIds := make([]int, 0, len(allQuestionBank))
+		for _, q := range allQuestionBank {
+			allIds = append(allIds, q.ID)
+		}
+		protectedIds, err = c.history.GetLockedQuestionIds(allIds)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		for _, q := range allQuestionBank {
+			if slices.Contains(protectedIds, q.ID) {
+				remaining = append(remaining, q)
+			} else {
+				deletedIds = append(deletedIds, q.ID)
+				deletedQuestions = append(deletedQuestions, q)
+			}
+		}
+	} else {
+		remaining = allQuestionBank
+	}
+
+	// 查重索引包含导入后仍在题库中的题目和文件中前面的题目
+	detector := services.NewQuestionDuplicateDetector(remaining, services.DefaultDuplicateThreshold)
+	duplicates := []importDuplicate{}
+	for _, row := range rows {
+		if matches := detector.Find(row.Question.Topic, 0); len(matches) > 0 {
+			duplicates = append(duplicates, importDuplicate{Row: row.Row, Topic: row.Question.Topic, Matches: matches})
+		}
+		detector.Add(-row.Row, row.Question.Topic)
+	}
+
+	if dryRun {
+		ctx.String(http.StatusOK, utils.Make200Resp(c.default200Resp, map[string]interface{}{
+			"dryRun":       true,
+			"total":        len(records),
+			"errors":       errs,
+			"preview":      rows,
+			"deleteCount":  len(deletedIds),
+			"protectedIds": protectedIds,
+			"insertCount":  len(rows),
+			"duplicates":   duplicates,
+		}))
+		return
+	}
+	if len(errs) > 0 {
+		ctx.String(http.StatusBadRequest, utils.MakeResp(http.StatusBadRequest,
+			fmt.Sprintf("%d 行数据有误，未导入", importErrorRows(errs)), map[string]interface{}{
+				"total":  len(records),
+				"errors": errs,
+			}))
+		return
+	}
+
+	questions := make([]entity.QuestionBank, len(rows))
+	for i, row := range rows {
+		questions[i] = row.Question
+	}
+	deleteCount, err := c.mapper.ImportQuestionBanks(questions, deletedIds, component.CurrentUsername(ctx))
+	if err != nil {
+		log.Printf("Error importing question banks: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "导入失败，题库未做修改"})
+		return
+	}
+	if isDeleteAll {
+		// 清空题库只记录一条审计日志，快照中保存被删除的全部题目
+		c.audit.Record(ctx, component.AuditDeleteAll, component.AuditEntityQuestion, "", map[string]interface{}{
+			"delete_count":  deleteCount,
+			"ids":           deletedIds,
+			"protected_ids": protectedIds,
+			"questions":     deletedQuestions,
+		}, nil)
+	}
+
+	// 文件中的题目插入后才有 ID，把查重结果中的行号换成题目 ID
+	rowIds := make(map[int]int, len(rows))
+	insertedIds := make([]int, len(questions))
+	for i, q := range questions {
+		rowIds[rows[i].Row] = q.ID
+		insertedIds[i] = q.ID
+		if _, err := c.reviser.Record(q, entity.RevisionImport, component.CurrentUsername(ctx), 0); err != nil {
+			log.Printf("Error recording revision of question %d: %v", q.ID, err)
 		}
-		if v["topic_image_path"] != nil {
-			questionBank.TopicImagePath = v["topic_image_path"].(string)
+	}
+	for i := range duplicates {
+		duplicates[i].ID = rowIds[duplicates[i].Row]
+		for j, m := range duplicates[i].Matches {
+			if m.ID < 0 {
+				duplicates[i].Matches[j].ID = rowIds[-m.ID]
+			}
 		}
-		num, _ := c.mapper.InsertSingleQuestionBank(questionBank)
-		insertCount += int(num)
 	}
 
 	rs := map[string]interface{}{
-		"deleteCount": deleteCount,
-		"insertCount": insertCount,
+		"deleteCount":  deleteCount,
+		"protectedIds": protectedIds, // 清空题库时因被已锁定的试卷引用而保留的题目
+		"insertCount":  len(questions),
+		"insertedIds":  insertedIds,
+		"duplicates":   duplicates,
 	}
+	c.audit.Record(ctx, component.AuditImport, component.AuditEntityQuestion, "", nil, map[string]interface{}{
+		"file":         file.Filename,
+		"insert_count": len(questions),
+		"ids":          insertedIds,
+	})
 	ctx.String(http.StatusOK, utils.Make200Resp(c.default200Resp, rs))
 }
 
+// duplicateQuestion 重复题目报告中的题目信息
+type duplicateQuestion struct {
+	ID        int    `json:"id"`
+	Topic     string `json:"topic"`
+	TopicType string `json:"topic_type"`
+}
+
+// duplicateGroupView 重复题目报告中的一组题目
+type duplicateGroupView struct {
+	services.DuplicateGroup
+	Questions []duplicateQuestion `json:"questions"`
+}
+
+// GetDuplicateQuestions 检查整个题库，返回互相重复的题目分组，可用 threshold 调整阈值、topic_type 限定题型
+func (c *QuestionBankController) GetDuplicateQuestions(ctx *gin.Context) {
+	threshold := services.DefaultDuplicateThreshold
+	if v, err := queryFloat(ctx, "threshold"); err != nil {
+		ctx.String(http.StatusBadRequest, utils.Make400Resp(err.Error()))
+		return
+	} else if v != nil {
+		if *v <= 0 || *v > 1 {
+			ctx.String(http.StatusBadRequest, utils.Make400Resp("threshold 必须在 (0, 1] 范围内"))
+			return
+		}
+		threshold = *v
+	}
+	all, err := c.mapper.GetAllQuestionBank()
+	if err != nil {
+		ctx.String(http.StatusInternalServerError, utils.Make500Resp("获取题库失败"))
+		return
+	}
+	topicTypes := queryList(ctx, "topic_type")
+	questions := make(map[int]entity.QuestionBank, len(all))
+	detector := services.NewDuplicateDetector(threshold)
+	for _, q := range all {
+		if len(topicTypes) > 0 && !slices.Contains(topicTypes, q.TopicType) {
+			continue
+		}
+		questions[q.ID] = q
+		detector.Add(q.ID, q.Topic)
+	}
+
+	groups := services.GroupDuplicatePairs(detector.Pairs())
+	items := make([]duplicateGroupView, 0, len(groups))
+	for _, g := range groups {
+		view := duplicateGroupView{DuplicateGroup: g}
+		for _, id := range g.IDs {
+			q := questions[id]
+			view.Questions = append(view.Questions, duplicateQuestion{ID: q.ID, Topic: q.Topic, TopicType: q.TopicType})
+		}
+		items = append(items, view)
+	}
+	retData := map[string]interface{}{
+		"threshold":    threshold,
+		"total_groups": len(items),
+		"groups":       items,
+	}
+	ctx.String(http.StatusOK, utils.Make200Resp(c.default200Resp, retData))
+}
+
 // GetEachChapterCount 获取各 Label1 下的统计数量
 func (c *QuestionBankController) GetEachChapterCount(ctx *gin.Context) {
 	distinctLabel1FromQuestionBank, _ := c.mapper.GetDistinctLabel1FromQuestionBank()
@@ -427,8 +949,3 @@ func (c *QuestionBankController) GetEachScoreCount(ctx *gin.Context) {
 	}
 	ctx.String(http.StatusOK, utils.Make200Resp(c.default200Resp, ret))
 }
-
-func toInt(s string) int {
-	i, _ := strconv.Atoi(s)
-	return i
-}
@@ -0,0 +1,355 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"graduation/component"
+	"graduation/entity"
+	"graduation/mapper/memory"
+	"graduation/services"
+	"graduation/utils"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"github.com/xuri/excelize/v2"
+)
+
+func TestQuestionBankControllerGetQuestionBank(t *testing.T) {
+	questions := memory.NewQuestionRepository(
+		entity.QuestionBank{Topic: "8086 有几个段寄存器？", TopicType: "填空题", Score: 2, Difficulty: 1, Chapter1: "第二章"},
+		entity.QuestionBank{Topic: "8259A 的作用", TopicType: "简答题", Score: 10, Difficulty: 3, Chapter1: "第八章"},
+		entity.QuestionBank{Topic: "8086 的地址总线宽度", TopicType: "选择题", Score: 2, Difficulty: 2, Chapter1: "第二章"},
+		entity.QuestionBank{Topic: "8086 CPU 由哪两个部件组成？", TopicType: "简答题", Score: 10, Difficulty: 4, Chapter1: "第二章"},
+	)
+	qc := NewQuestionBankController(questions, memory.NewAttachmentRepository(), memory.NewRevisionRepository(), memory.NewReviewRepository(), memory.NewHistoryRepository(), memory.NewLabelRepository(), component.NewAuditor(memory.NewAuditRepository()))
+	r := newTestRouter()
+	r.GET("/getQuestionBank", qc.GetQuestionBank)
+
+	type questionPage struct {
+		Items      []entity.QuestionBank `json:"items"`
+		Total      int64                 `json:"total"`
+		Page       int                   `json:"page"`
+		PageSize   int                   `json:"page_size"`
+		NextCursor string                `json:"next_cursor"`
+	}
+	query := func(path string) questionPage {
+		resp := doJSON(t, r, http.MethodGet, path, nil)
+		require.Equal(t, 200, resp.Code, resp.Msg)
+		data, err := json.Marshal(resp.Data)
+		require.NoError(t, err)
+		var page questionPage
+		require.NoError(t, json.Unmarshal(data, &page))
+		return page
+	}
+
+	page := query("/getQuestionBank?chapter_1=第二章&sort=-difficulty&page_size=2")
+	require.EqualValues(t, 3, page.Total)
+	require.Equal(t, 2, page.PageSize)
+	require.Equal(t, []int{4, 3}, questionIds(page.Items))
+	require.NotEmpty(t, page.NextCursor)
+
+	page = query("/getQuestionBank?chapter_1=第二章&sort=-difficulty&page_size=2&cursor=" + page.NextCursor)
+	require.Equal(t, []int{1}, questionIds(page.Items))
+	require.Empty(t, page.NextCursor)
+
+	page = query("/getQuestionBank?chapter_1=第二章&sort=-difficulty&page_size=2&page=2")
+	require.Equal(t, []int{1}, questionIds(page.Items))
+
+	page = query("/getQuestionBank?topic_type=简答题,选择题&min_difficulty=2&max_score=5&keyword=8086")
+	require.Equal(t, []int{3}, questionIds(page.Items))
+
+	page = query("/getQuestionBank?topic_type=判断题")
+	require.EqualValues(t, 0, page.Total)
+	require.NotNil(t, page.Items)
+
+	for _, path := range []string{
+		"/getQuestionBank?sort=topic",
+		"/getQuestionBank?min_score=abc",
+		"/getQuestionBank?updated_from=yesterday",
+		"/getQuestionBank?cursor=bogus",
+	} {
+		resp := doJSON(t, r, http.MethodGet, path, nil)
+		require.Equal(t, 400, resp.Code, path)
+	}
+}
+
+func questionIds(questions []entity.QuestionBank) []int {
+	ids := make([]int, 0, len(questions))
+	for _, q := range questions {
+		ids = append(ids, q.ID)
+	}
+	return ids
+}
+
+func TestQuestionBankControllerSearchQuestionBank(t *testing.T) {
+	questions := memory.NewQuestionRepository(
+		entity.QuestionBank{Topic: "8086 有几个段寄存器？", Answer: "4", TopicType: "填空题"},
+		entity.QuestionBank{Topic: "8259A 的作用", Answer: "中断控制", TopicType: "简答题"},
+		entity.QuestionBank{Topic: "INTR 是可屏蔽中断", Answer: "对", TopicType: "判断题", Label1: "中断"},
+	)
+	qc := NewQuestionBankController(questions, memory.NewAttachmentRepository(), memory.NewRevisionRepository(), memory.NewReviewRepository(), memory.NewHistoryRepository(), memory.NewLabelRepository(), component.NewAuditor(memory.NewAuditRepository()))
+	r := newTestRouter()
+	r.GET("/searchQuestionBank", qc.SearchQuestionBank)
+
+	resp := doJSON(t, r, http.MethodGet, "/searchQuestionBank?keyword=中断", nil)
+	require.Equal(t, 200, resp.Code, resp.Msg)
+	data, err := json.Marshal(resp.Data)
+	require.NoError(t, err)
+	var items []struct {
+		ID         int               `json:"id"`
+		Relevance  float64           `json:"relevance"`
+		Highlights map[string]string `json:"highlights"`
+	}
+	require.NoError(t, json.Unmarshal(data, &items))
+	require.Len(t, items, 2)
+	require.Equal(t, 3, items[0].ID)
+	require.Positive(t, items[1].Relevance)
+	require.Equal(t, "INTR 是可屏蔽<em>中断</em>", items[0].Highlights["topic"])
+	require.Equal(t, "<em>中断</em>", items[0].Highlights["label_1"])
+	require.NotContains(t, items[0].Highlights, "answer")
+
+	resp = doJSON(t, r, http.MethodGet, "/searchQuestionBank?keyword=中断&limit=1", nil)
+	require.Len(t, resp.Data, 1)
+
+	resp = doJSON(t, r, http.MethodGet, "/searchQuestionBank", nil)
+	require.Equal(t, 400, resp.Code)
+}
+
+func TestQuestionBankControllerDuplicates(t *testing.T) {
+	questions := memory.NewQuestionRepository(
+		entity.QuestionBank{Topic: "8086 CPU 内部由哪两个部件组成？", TopicType: "简答题"},
+		entity.QuestionBank{Topic: "8259A 的作用", TopicType: "简答题"},
+		entity.QuestionBank{Topic: "8086CPU内部由哪两个部件组成", TopicType: "填空题"},
+	)
+	qc := NewQuestionBankController(questions, memory.NewAttachmentRepository(), memory.NewRevisionRepository(), memory.NewReviewRepository(), memory.NewHistoryRepository(), memory.NewLabelRepository(), component.NewAuditor(memory.NewAuditRepository()))
+	r := newTestRouter()
+	r.POST("/insertSingleQuestionBank", qc.InsertSingleQuestionBank)
+	r.GET("/getDuplicateQuestions", qc.GetDuplicateQuestions)
+
+	resp := doJSON(t, r, http.MethodPost, "/insertSingleQuestionBank", map[string]interface{}{
+		"topic": "8086 CPU 内部由哪两个部件组成", "topic_type": "简答题", "difficulty": "2",
+	})
+	require.Equal(t, 200, resp.Code, resp.Msg)
+	var inserted struct {
+		InsertStatus int `json:"insertStatus"`
+		Duplicates   []struct {
+			ID         int     `json:"id"`
+			Similarity float64 `json:"similarity"`
+		} `json:"duplicates"`
+	}
+	data, err := json.Marshal(resp.Data)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, &inserted))
+	require.Equal(t, 1, inserted.InsertStatus)
+	require.Len(t, inserted.Duplicates, 2)
+	require.Equal(t, 1.0, inserted.Duplicates[0].Similarity)
+
+	var report struct {
+		TotalGroups int `json:"total_groups"`
+		Groups      []struct {
+			IDs       []int `json:"ids"`
+			Questions []struct {
+				Topic string `json:"topic"`
+			} `json:"questions"`
+		} `json:"groups"`
+	}
+	getReport := func(path string) {
+		resp := doJSON(t, r, http.MethodGet, path, nil)
+		require.Equal(t, 200, resp.Code, resp.Msg)
+		data, err := json.Marshal(resp.Data)
+		require.NoError(t, err)
+		report.Groups = nil
+		require.NoError(t, json.Unmarshal(data, &report))
+	}
+	getReport("/getDuplicateQuestions")
+	require.Equal(t, 1, report.TotalGroups)
+	require.Equal(t, []int{1, 3, 4}, report.Groups[0].IDs)
+	require.Equal(t, "8086 CPU 内部由哪两个部件组成？", report.Groups[0].Questions[0].Topic)
+
+	getReport("/getDuplicateQuestions?topic_type=简答题")
+	require.Equal(t, []int{1, 4}, report.Groups[0].IDs)
+
+	resp = doJSON(t, r, http.MethodGet, "/getDuplicateQuestions?threshold=1.5", nil)
+	require.Equal(t, 400, resp.Code)
+}
+
+func TestQuestionBankControllerTables(t *testing.T) {
+	questions := memory.NewQuestionRepository()
+	qc := NewQuestionBankController(questions, memory.NewAttachmentRepository(), memory.NewRevisionRepository(), memory.NewReviewRepository(), memory.NewHistoryRepository(), memory.NewLabelRepository(), component.NewAuditor(memory.NewAuditRepository()))
+	r := newTestRouter()
+	r.POST("/insertSingleQuestionBank", qc.InsertSingleQuestionBank)
+	r.POST("/updateQuestionBankById", qc.UpdateQuestionBankById)
+
+	table := map[string]interface{}{
+		"header_row": true,
+		"rows":       [][]string{{"输入", ""}, {"0", "1"}},
+		"merges":     []map[string]int{{"row": 0, "col": 0, "col_span": 2}},
+	}
+	resp := doJSON(t, r, http.MethodPost, "/insertSingleQuestionBank", map[string]interface{}{
+		"topic": "根据真值表写出逻辑表达式", "topic_type": "简答题", "difficulty": "2", "topic_table": table,
+	})
+	require.Equal(t, 200, resp.Code, resp.Msg)
+	found, err := questions.GetQuestionBankById(1)
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	saved := found[0]
+	require.NotNil(t, saved.TopicTable)
+	require.Equal(t, 2, saved.TopicTable.Columns())
+	require.Nil(t, saved.AnswerTable)
+
+	// 合并区域超出表格范围
+	table["merges"] = []map[string]int{{"row": 1, "col": 1, "row_span": 2}}
+	var buf bytes.Buffer
+	require.NoError(t, json.NewEncoder(&buf).Encode(map[string]interface{}{"topic": "题干", "answer_table": table}))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/insertSingleQuestionBank", &buf))
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	require.Contains(t, w.Body.String(), "答案表格")
+	all, err := questions.GetAllQuestionBank()
+	require.NoError(t, err)
+	require.Len(t, all, 1)
+
+	// 修改时不提交表格即删除表格
+	raw, err := json.Marshal(map[string]interface{}{"id": "1", "topic": "根据真值表写出逻辑表达式", "topic_type": "简答题", "difficulty": "2"})
+	require.NoError(t, err)
+	resp = doMultipart(t, r, "/updateQuestionBankById", map[string]string{"data": string(raw)}, nil)
+	require.Equal(t, 200, resp.Code, resp.Msg)
+	found, err = questions.GetQuestionBankById(1)
+	require.NoError(t, err)
+	require.Nil(t, found[0].TopicTable)
+
+	// 题干图片声明的像素数过大时拒绝保存
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	require.NoError(t, writer.WriteField("data", string(raw)))
+	part, err := writer.CreateFormFile("image", "huge.png")
+	require.NoError(t, err)
+	_, err = part.Write(testDeclaredPNG(t, 30000, 30000))
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+	req := httptest.NewRequest(http.MethodPost, "/updateQuestionBankById", &body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	require.Contains(t, w.Body.String(), "图片尺寸过大")
+	found, err = questions.GetQuestionBankById(1)
+	require.NoError(t, err)
+	require.Empty(t, found[0].TopicImagePath)
+}
+
+// testWorkbook 生成导入用的 Excel 文件，第一行为表头，nil 表示空行
+func testWorkbook(t *testing.T, rows ...[]string) []byte {
+	t.Helper()
+	f := excelize.NewFile()
+	defer f.Close()
+	sheet := f.GetSheetName(0)
+	require.NoError(t, f.SetSheetRow(sheet, "A1", &services.ImportColumns))
+	for i, row := range rows {
+		cell, err := excelize.CoordinatesToCellName(1, i+2)
+		require.NoError(t, err)
+		require.NoError(t, f.SetSheetRow(sheet, cell, &row))
+	}
+	buf, err := f.WriteToBuffer()
+	require.NoError(t, err)
+	return buf.Bytes()
+}
+
+func TestQuestionBankControllerUploadFile(t *testing.T) {
+	questions := memory.NewQuestionRepository(
+		entity.QuestionBank{Topic: "8086 有几个段寄存器？", Answer: "4", TopicType: "填空题", Score: 2, Difficulty: 1},
+	)
+	labels := memory.NewLabelRepository(entity.QuestionLabels{Chapter1: "第二章", Label1: "8086", Label2: "寄存器"})
+	revisions := memory.NewRevisionRepository()
+	qc := NewQuestionBankController(questions, memory.NewAttachmentRepository(), revisions, memory.NewReviewRepository(), memory.NewHistoryRepository(), labels, component.NewAuditor(memory.NewAuditRepository()))
+	r := newTestRouter()
+	r.Use(func(c *gin.Context) {
+		c.Set(component.ContextUsername, "admin")
+		c.Set(component.ContextUserRole, component.RoleAdmin)
+	})
+	r.POST("/uploadFile", qc.UploadFile)
+
+	valid := []string{"8086 有几个段寄存器?", "", "4", "填空题", "2", "1", "第二章", "", "8086", "寄存器"}
+	invalid := []string{"", "x", "", "论述题", "abc", "9", "", "", "未知"}
+	other := []string{"8259A 的作用", "", "中断控制", "简答题", "10", "3"}
+	upload := func(fields map[string]string, rows ...[]string) utils.Response {
+		t.Helper()
+		return doMultipart(t, r, "/uploadFile", fields, map[string][][]byte{"file": {testWorkbook(t, rows...)}})
+	}
+	count := func() int {
+		all, err := questions.GetAllQuestionBank()
+		require.NoError(t, err)
+		return len(all)
+	}
+
+	// 任一行有误时整个文件不导入，错误按 Excel 行号报告，空行计入行号
+	resp := upload(nil, valid, nil, invalid, other)
+	require.Equal(t, 400, resp.Code)
+	errs := resp.Data.(map[string]interface{})["errors"].([]interface{})
+	require.Len(t, errs, 7)
+	for _, e := range errs {
+		require.EqualValues(t, 4, e.(map[string]interface{})["row"])
+	}
+	require.Equal(t, 1, count())
+
+	// 预览只校验，不修改题库
+	resp = upload(map[string]string{"dryRun": "true"}, valid, nil, invalid, other)
+	require.Equal(t, 200, resp.Code, resp.Msg)
+	preview := resp.Data.(map[string]interface{})
+	require.Len(t, preview["errors"], 7)
+	require.Len(t, preview["preview"], 2)
+	require.EqualValues(t, 2, preview["insertCount"])
+	duplicates := preview["duplicates"].([]interface{})
+	require.Len(t, duplicates, 1)
+	require.EqualValues(t, 2, duplicates[0].(map[string]interface{})["row"])
+	require.Equal(t, 1, count())
+
+	// 清空题库后导入，文件中重复的题目在插入后换成题目 ID
+	resp = upload(map[string]string{"isDeleteAll": "true"}, valid, other, valid)
+	require.Equal(t, 200, resp.Code, resp.Msg)
+	data := resp.Data.(map[string]interface{})
+	require.EqualValues(t, 1, data["deleteCount"])
+	require.EqualValues(t, 3, data["insertCount"])
+	require.Equal(t, []interface{}{float64(2), float64(3), float64(4)}, data["insertedIds"])
+	duplicates = data["duplicates"].([]interface{})
+	require.Len(t, duplicates, 1)
+	duplicate := duplicates[0].(map[string]interface{})
+	require.EqualValues(t, 4, duplicate["id"])
+	require.EqualValues(t, 2, duplicate["matches"].([]interface{})[0].(map[string]interface{})["id"])
+	require.Equal(t, 3, count())
+	history, err := revisions.GetQuestionRevisions(3)
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	require.Equal(t, entity.RevisionImport, history[0].Action)
+}
+
+func TestQuestionBankControllerChangeChoiceType(t *testing.T) {
+	questions := memory.NewQuestionRepository()
+	qc := NewQuestionBankController(questions, memory.NewAttachmentRepository(), memory.NewRevisionRepository(), memory.NewReviewRepository(), memory.NewHistoryRepository(), memory.NewLabelRepository(), component.NewAuditor(memory.NewAuditRepository()))
+	r := newTestRouter()
+	r.POST("/insertSingleQuestionBank", qc.InsertSingleQuestionBank)
+	r.POST("/updateQuestionBankById", qc.UpdateQuestionBankById)
+
+	options := []map[string]interface{}{{"label": "A", "content": "20"}, {"label": "B", "content": "16"}}
+	resp := doJSON(t, r, http.MethodPost, "/insertSingleQuestionBank", map[string]interface{}{
+		"topic": "8086 的地址总线宽度", "answer": "A", "topic_type": "选择题", "difficulty": "2", "options": options,
+	})
+	require.Equal(t, 200, resp.Code, resp.Msg)
+	found, err := questions.GetQuestionBankById(1)
+	require.NoError(t, err)
+	require.Len(t, found[0].Options, 2)
+
+	// 改为填空题后不再保存选项，即使请求中仍带有原来的选项
+	raw, err := json.Marshal(map[string]interface{}{"id": "1", "topic": "8086 的地址总线宽度为___位", "answer": "20", "topic_type": "填空题", "difficulty": "2", "options": options})
+	require.NoError(t, err)
+	resp = doMultipart(t, r, "/updateQuestionBankById", map[string]string{"data": string(raw)}, nil)
+	require.Equal(t, 200, resp.Code, resp.Msg)
+	found, err = questions.GetQuestionBankById(1)
+	require.NoError(t, err)
+	require.Empty(t, found[0].Options)
+	require.Equal(t, "20", found[0].Answer)
+}
@@ -2,19 +2,16 @@ package controller
 
 import (
 	"fmt"
+	"graduation/component"
 	"graduation/entity"
 	"graduation/mapper"
 	"graduation/services"
 	"graduation/utils"
 
-	"github.com/gin-contrib/sessions"
-
 	"net/http"
 	"os"
 	"time"
 
-	"encoding/base64"
-	"io/ioutil"
 	"log"
 
 	"github.com/gin-gonic/gin"
@@ -28,7 +25,8 @@ type RandomSelectRequest struct {
 	GenerateRange            []string                                    `json:"generateRange"`
 	KnowledgeWeights         []services.KnowledgePointWeight             `json:"knowledgeWeights"`
 	QuestionTypeRequirements map[string]services.QuestionTypeRequirement `json:"questionTypeRequirements"`
-	IterationsNum            int                                         `json:"iterationsNum"` // 添加迭代次数参数
+	IterationsNum            int                                         `json:"iterationsNum"`     // 添加迭代次数参数
+	IncludeUnapproved        bool                                        `json:"includeUnapproved"` // 同时从草稿和审核中的题目中抽题
 }
 
 // QuestionTypeRequirement 题型要求
@@ -37,8 +35,22 @@ type QuestionTypeRequirement struct {
 	TargetScore float64 `json:"targetScore"`
 }
 
+// QuestionGenController 定义组卷控制器结构体
+type QuestionGenController struct {
+	questions   mapper.QuestionRepository
+	users       mapper.UserRepository
+	history     mapper.HistoryRepository
+	materials   mapper.MaterialRepository
+	attachments mapper.AttachmentRepository
+}
+
+// NewQuestionGenController 创建新的组卷控制器
+func NewQuestionGenController(questions mapper.QuestionRepository, users mapper.UserRepository, history mapper.HistoryRepository, materials mapper.MaterialRepository, attachments mapper.AttachmentRepository) *QuestionGenController {
+	return &QuestionGenController{questions: questions, users: users, history: history, materials: materials, attachments: attachments}
+}
+
 // RandomSelect 随机选题
-func RandomSelect(c *gin.Context) {
+func (qc *QuestionGenController) RandomSelect(c *gin.Context) {
 	var request RandomSelectRequest
 	if err := c.ShouldBindJSON(&request); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "Invalid request parameters", "error": err.Error()})
@@ -46,27 +58,27 @@ func RandomSelect(c *gin.Context) {
 	}
 
 	// 获取所有题目
-	questions, err := getAllQuestions()
+	questions, err := qc.questions.GetAll()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "Failed to get questions", "error": err.Error()})
 		return
 	}
 
-	// 过滤题目
+	// 过滤题目，默认只从审核通过的题目中抽取
+	questions = services.SelectableQuestions(questions, request.SelectedTopicIds, request.IncludeUnapproved)
 	filteredQuestions := filterQuestionsByTopicAndRange(questions, request.SelectedTopicIds, request.GenerateRange)
 	if len(filteredQuestions) == 0 {
 		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "No questions found for the selected topics and range"})
 		return
 	}
-	session := sessions.Default(c)
-	username := session.Get("username")
-	var userInfo entity.User
-	if err := mapper.DB.Model(&entity.User{}).Where("username = ?", username).First(&userInfo).Error; err != nil {
+	username := component.CurrentUsername(c)
+	userInfo, err := qc.getUser(username)
+	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "Username not exist", "error": err.Error()})
 		return
 	}
 	// 获取需要排除的题目ID
-	excludedQuestionIds := services.GetExcludedQuestionIds(userInfo.SimilarityThreshold)
+	excludedQuestionIds := services.GetExcludedQuestionIds(qc.history, userInfo.SimilarityThreshold)
 	//excludedQuestionIds = nil
 	// 转换题型要求
 	questionTypeRequirements := make(map[string]services.QuestionTypeRequirement)
@@ -105,11 +117,11 @@ func RandomSelect(c *gin.Context) {
 		}
 	}
 
-	// 转换图片为base64
-	convertImagesToBase64(tktList)
-	convertImagesToBase64(xztList)
-	convertImagesToBase64(pdtList)
-	convertImagesToBase64(jdtList)
+	// 填写图片访问地址
+	fillQuestionImageURLs(tktList)
+	fillQuestionImageURLs(xztList)
+	fillQuestionImageURLs(pdtList)
+	fillQuestionImageURLs(jdtList)
 
 	// 返回结果
 	c.JSON(http.StatusOK, gin.H{
@@ -125,14 +137,16 @@ func RandomSelect(c *gin.Context) {
 	})
 }
 
-// getAllQuestions 获取所有题目
-func getAllQuestions() ([]entity.QuestionBank, error) {
-	var questions []entity.QuestionBank
-	result := mapper.DB.Find(&questions)
-	if result.Error != nil {
-		return nil, result.Error
+// getUser 根据用户名获取用户信息
+func (qc *QuestionGenController) getUser(username string) (entity.User, error) {
+	users, err := qc.users.GetUserByUsername(username)
+	if err != nil {
+		return entity.User{}, err
 	}
-	return questions, nil
+	if len(users) == 0 {
+		return entity.User{}, fmt.Errorf("user %s not found", username)
+	}
+	return users[0], nil
 }
 
 // filterQuestionsByTopicAndRange 根据主题ID和范围过滤题目
@@ -165,7 +179,7 @@ func filterQuestionsByTopicAndRange(questions []entity.QuestionBank, selectedTop
 }
 
 // GeneticSelect 遗传算法抽题
-func GeneticSelect(c *gin.Context) {
+func (qc *QuestionGenController) GeneticSelect(c *gin.Context) {
 	var payload RandomSelectRequest
 	if err := c.ShouldBindJSON(&payload); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -173,23 +187,23 @@ func GeneticSelect(c *gin.Context) {
 	}
 
 	// 获取所有题目
-	questions, err := getAllQuestions()
+	questions, err := qc.questions.GetAll()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	// 根据选中的知识点和生成范围过滤题目
+	// 根据选中的知识点和生成范围过滤题目，默认只从审核通过的题目中抽取
+	questions = services.SelectableQuestions(questions, payload.SelectedTopicIds, payload.IncludeUnapproved)
 	filteredQuestions := filterQuestionsByTopicAndRange(questions, payload.SelectedTopicIds, payload.GenerateRange)
-	session := sessions.Default(c)
-	username := session.Get("username")
-	var userInfo entity.User
-	if err := mapper.DB.Model(&entity.User{}).Where("username = ?", username).First(&userInfo).Error; err != nil {
+	username := component.CurrentUsername(c)
+	userInfo, err := qc.getUser(username)
+	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "Username not exist", "error": err.Error()})
 		return
 	}
 	// 获取已选过的题目ID
-	excludedQuestionIds := services.GetExcludedQuestionIds(userInfo.SimilarityThreshold)
+	excludedQuestionIds := services.GetExcludedQuestionIds(qc.history, userInfo.SimilarityThreshold)
 
 	// 转换题型要求格式
 	questionTypeRequirements := make(map[string]services.QuestionTypeRequirement)
@@ -226,11 +240,11 @@ func GeneticSelect(c *gin.Context) {
 		}
 	}
 
-	// 转换图片为base64
-	convertImagesToBase64(TKTList)
-	convertImagesToBase64(XZTList)
-	convertImagesToBase64(PDTList)
-	convertImagesToBase64(JDTList)
+	// 填写图片访问地址
+	fillQuestionImageURLs(TKTList)
+	fillQuestionImageURLs(XZTList)
+	fillQuestionImageURLs(PDTList)
+	fillQuestionImageURLs(JDTList)
 
 	response := map[string]interface{}{
 		"TKTList":  TKTList,
@@ -244,11 +258,10 @@ func GeneticSelect(c *gin.Context) {
 }
 
 // QuestionGen 处理 /questionGen 请求
-func QuestionGen(c *gin.Context) {
-	session := sessions.Default(c)
-	username, ok := session.Get("username").(string)
+func (qc *QuestionGenController) QuestionGen(c *gin.Context) {
+	username := component.CurrentUsername(c)
 	fmt.Println("username: ", username)
-	if !ok || username == "" {
+	if username == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Username is missing"})
 		return
 	}
@@ -266,14 +279,16 @@ func QuestionGen(c *gin.Context) {
 	JDTIdList := getIntList(payload, "JDTIdList")
 	testPaperName := getString(payload, "testPaperName")
 
-	questionBanks := getQuestionBanks(questionIdList, TKTIdList, XZTIdList, PDTIdList, JDTIdList)
+	questionBanks := qc.getQuestionBanks(questionIdList, TKTIdList, XZTIdList, PDTIdList, JDTIdList)
 	if len(questionBanks) == 0 {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "No valid questions found"})
 		return
 	}
 
-	wE := services.NewWordGenerator()
-	str, _ := wE.GenerateTestPaper(questionBanks, testPaperName, username)
+	materials := loadMaterials(qc.materials, questionBanks)
+	attachments := loadAttachments(qc.attachments, questionBanks)
+	wE := services.NewWordGenerator(qc.history)
+	str, _ := wE.GenerateTestPaper(questionBanks, attachments, materials, testPaperName, username)
 	fmt.Println(str)
 
 	response := map[string]interface{}{
@@ -284,10 +299,9 @@ func QuestionGen(c *gin.Context) {
 }
 
 // QuestionGen2 处理 /questionGen2 请求
-func QuestionGen2(c *gin.Context) {
-	session := sessions.Default(c)
-	username, ok := session.Get("username").(string)
-	if !ok || username == "" {
+func (qc *QuestionGenController) QuestionGen2(c *gin.Context) {
+	username := component.CurrentUsername(c)
+	if username == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Username is missing"})
 		return
 	}
@@ -305,12 +319,17 @@ func QuestionGen2(c *gin.Context) {
 	JDTIdList := getIntList(payload, "JDTIdList")
 	testPaperName := getString(payload, "testPaperName")
 
-	questionBanks := getQuestionBanks(questionIdList, TKTIdList, XZTIdList, PDTIdList, JDTIdList)
+	questionBanks := qc.getQuestionBanks(questionIdList, TKTIdList, XZTIdList, PDTIdList, JDTIdList)
 	if len(questionBanks) == 0 {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "No valid questions found"})
 		return
 	}
 
+	// 材料题按组相邻排列，材料在组内第一道题之前打印
+	questionBanks = services.GroupByMaterial(questionBanks)
+	materials := loadMaterials(qc.materials, questionBanks)
+	attachments := loadAttachments(qc.attachments, questionBanks)
+
 	// 分类题目
 	var tktQuestions, xztQuestions, pdtQuestions, jdtQuestions []entity.QuestionBank
 	for _, q := range questionBanks {
@@ -336,16 +355,16 @@ func QuestionGen2(c *gin.Context) {
 		// 计算每小题分数
 		scorePerQuestion := xztQuestions[0].Score
 		contents += fmt.Sprintf("[SECTION_TITLE]一、选择题（本大题共%d小题，每小题%.1f分，共%.1f分）[/SECTION_TITLE]\r\r", len(xztQuestions), scorePerQuestion, float64(len(xztQuestions))*scorePerQuestion)
-		for _, q := range xztQuestions {
+		for i, q := range xztQuestions {
 			totalScore += q.Score
 			scoreStr := formatScore(q.Score)
-			contents = fmt.Sprintf("%s%d、（本题%s分）%s", contents, questionNumber, scoreStr, q.Topic)
-
-			// 如果有图片，添加图片标记
-			if q.TopicImagePath != "" {
-				contents += fmt.Sprintf(" [IMAGE:%s]", q.TopicImagePath)
-			}
+			contents += materialMarker(materials, xztQuestions, i)
+			// 题干中的图片按位置标记插入，没有标记的排在题干之后
+			contents = fmt.Sprintf("%s%d、（本题%s分）%s", contents, questionNumber, scoreStr, topicContent(q, attachments))
+			contents += services.ContentTableMarker(q.TopicTable)
 
+			// 选项由导出程序按列排版
+			contents += services.ChoiceOptionsMarker(optionsContent(q, attachments))
 			contents += "\r\r[QUESTION_END]\r\r" // 使用特殊标记分隔题目
 			questionNumber++
 			totalCount++
@@ -356,15 +375,13 @@ func QuestionGen2(c *gin.Context) {
 		// 计算每小题分数
 		scorePerQuestion := tktQuestions[0].Score
 		contents += fmt.Sprintf("[SECTION_TITLE]二、填空题（本大题共%d小题，每小题%.1f分，共%.1f分）[/SECTION_TITLE]\r\r", len(tktQuestions), scorePerQuestion, float64(len(tktQuestions))*scorePerQuestion)
-		for _, q := range tktQuestions {
+		for i, q := range tktQuestions {
 			totalScore += q.Score
 			scoreStr := formatScore(q.Score)
-			contents = fmt.Sprintf("%s%d、（本题%s分）%s", contents, questionNumber, scoreStr, q.Topic)
-
-			// 如果有图片，添加图片标记
-			if q.TopicImagePath != "" {
-				contents += fmt.Sprintf(" [IMAGE:%s]", q.TopicImagePath)
-			}
+			contents += materialMarker(materials, tktQuestions, i)
+			// 题干中的图片按位置标记插入，没有标记的排在题干之后
+			contents = fmt.Sprintf("%s%d、（本题%s分）%s", contents, questionNumber, scoreStr, topicContent(q, attachments))
+			contents += services.ContentTableMarker(q.TopicTable)
 
 			contents += "\r\r[QUESTION_END]\r\r" // 使用特殊标记分隔题目
 			questionNumber++
@@ -376,15 +393,13 @@ func QuestionGen2(c *gin.Context) {
 		// 计算每小题分数
 		scorePerQuestion := pdtQuestions[0].Score
 		contents += fmt.Sprintf("[SECTION_TITLE]三、判断题（本大题共%d小题，每小题%.1f分，共%.1f分）[/SECTION_TITLE]\r\r", len(pdtQuestions), scorePerQuestion, float64(len(pdtQuestions))*scorePerQuestion)
-		for _, q := range pdtQuestions {
+		for i, q := range pdtQuestions {
 			totalScore += q.Score
 			scoreStr := formatScore(q.Score)
-			contents = fmt.Sprintf("%s%d、（本题%s分）%s", contents, questionNumber, scoreStr, q.Topic)
-
-			// 如果有图片，添加图片标记
-			if q.TopicImagePath != "" {
-				contents += fmt.Sprintf(" [IMAGE:%s]", q.TopicImagePath)
-			}
+			contents += materialMarker(materials, pdtQuestions, i)
+			// 题干中的图片按位置标记插入，没有标记的排在题干之后
+			contents = fmt.Sprintf("%s%d、（本题%s分）%s", contents, questionNumber, scoreStr, topicContent(q, attachments))
+			contents += services.ContentTableMarker(q.TopicTable)
 
 			contents += "\r\r[QUESTION_END]\r\r" // 使用特殊标记分隔题目
 			questionNumber++
@@ -396,15 +411,13 @@ func QuestionGen2(c *gin.Context) {
 		// 计算每小题分数
 		scorePerQuestion := jdtQuestions[0].Score
 		contents += fmt.Sprintf("[SECTION_TITLE]四、简答题（本大题共%d小题，每小题%.1f分，共%.1f分）[/SECTION_TITLE]\r\r", len(jdtQuestions), scorePerQuestion, float64(len(jdtQuestions))*scorePerQuestion)
-		for _, q := range jdtQuestions {
+		for i, q := range jdtQuestions {
 			totalScore += q.Score
 			scoreStr := formatScore(q.Score)
-			contents = fmt.Sprintf("%s%d、（本题%s分）%s", contents, questionNumber, scoreStr, q.Topic)
-
-			// 如果有图片，添加图片标记
-			if q.TopicImagePath != "" {
-				contents += fmt.Sprintf(" [IMAGE:%s]", q.TopicImagePath)
-			}
+			contents += materialMarker(materials, jdtQuestions, i)
+			// 题干中的图片按位置标记插入，没有标记的排在题干之后
+			contents = fmt.Sprintf("%s%d、（本题%s分）%s", contents, questionNumber, scoreStr, topicContent(q, attachments))
+			contents += services.ContentTableMarker(q.TopicTable)
 			contents += "\r\r[QUESTION_END]\r\r" // 使用特殊标记分隔题目
 			questionNumber++
 			totalCount++
@@ -425,31 +438,33 @@ func QuestionGen2(c *gin.Context) {
 		return
 	}
 
-	logHistory(questionBanks, testPaperName, username, file)
+	qc.logHistory(questionBanks, attachments, materials, testPaperName, username, file)
 	downloadFile(c, file)
 }
 
 // 处理 /getFile 请求
-func GetFile(c *gin.Context) {
-	genWord := services.NewWordGenerator()
+func (qc *QuestionGenController) GetFile(c *gin.Context) {
+	genWord := services.NewWordGenerator(qc.history)
 	file := genWord.GetFile()
 	downloadFile(c, file)
 }
 
 // 从数据库中获取题目列表
-func getQuestionBanks(ids ...[]int) []entity.QuestionBank {
+func (qc *QuestionGenController) getQuestionBanks(ids ...[]int) []entity.QuestionBank {
 	var allIds []int
 	for _, idList := range ids {
 		allIds = append(allIds, idList...)
 	}
 
-	var questionBanks []entity.QuestionBank
-	mapper.DB.Where("id IN ?", allIds).Find(&questionBanks)
+	questionBanks, err := qc.questions.GetQuestionBanksInIds(allIds)
+	if err != nil {
+		log.Println(err)
+	}
 	return questionBanks
 }
 
-// 记录历史记录
-func logHistory(questionBanks []entity.QuestionBank, testPaperName, username string, file *os.File) {
+// 记录历史记录，出题记录中保存题目、附件和材料的快照
+func (qc *QuestionGenController) logHistory(questionBanks []entity.QuestionBank, attachments map[int][]entity.QuestionAttachment, materials map[int]entity.QuestionMaterial, testPaperName, username string, file *os.File) {
 	date := time.Now()
 	uid := fmt.Sprintf("%s_%s_%d", file.Name(), uuid.New().String(), date.Unix())
 
@@ -461,28 +476,13 @@ func logHistory(questionBanks []entity.QuestionBank, testPaperName, username str
 		UpdateTime:        date,
 		Username:          username,
 	}
-	mapper.DB.Create(&testPaperGenHistory)
-	var questionGenHistoryList []entity.QuestionGenHistory
-	for _, q := range questionBanks {
-		questionGenHistory := entity.QuestionGenHistory{
-			TestPaperUID:    uid,
-			TestPaperName:   testPaperName,
-			QuestionBankID:  q.ID,
-			Topic:           q.Topic,
-			TopicMaterialID: q.TopicMaterialID,
-			Answer:          q.Answer,
-			TopicType:       q.TopicType,
-			Score:           q.Score,
-			Difficulty:      q.Difficulty,
-			Chapter1:        q.Chapter1,
-			Chapter2:        q.Chapter2,
-			Label1:          q.Label1,
-			Label2:          q.Label2,
-			UpdateTime:      date,
-		}
-		questionGenHistoryList = append(questionGenHistoryList, questionGenHistory)
+	if _, err := qc.history.InsertTestPaperGenHistory(testPaperGenHistory); err != nil {
+		log.Println(err)
+	}
+	questionGenHistoryList := services.NewQuestionGenHistories(questionBanks, attachments, materials, uid, testPaperName, date)
+	if _, err := qc.history.InsertQuestionGenHistories(questionGenHistoryList); err != nil {
+		log.Println(err)
 	}
-	mapper.DB.Create(&questionGenHistoryList)
 }
 
 // 计算平均难度
@@ -563,22 +563,3 @@ func getString(m map[string]interface{}, key string) string {
 	}
 	return ""
 }
-
-// convertImagesToBase64 将题目列表中的图片转换为base64格式
-func convertImagesToBase64(questions []entity.QuestionBank) {
-	for i := range questions {
-		if questions[i].TopicImagePath != "" {
-			// 读取图片文件
-			imgData, err := ioutil.ReadFile(questions[i].TopicImagePath)
-			if err != nil {
-				log.Printf("Error reading image file %s: %v", questions[i].TopicImagePath, err)
-				continue
-			}
-
-			// 转换为base64
-			base64Str := base64.StdEncoding.EncodeToString(imgData)
-			// 添加base64前缀
-			questions[i].TopicImagePath = "data:image/jpeg;base64," + base64Str
-		}
-	}
-}
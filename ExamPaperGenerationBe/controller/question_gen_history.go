@@ -2,32 +2,109 @@ package controller
 
 import (
 	"fmt"
+	"graduation/component"
 	"graduation/entity"
 	"graduation/mapper"
 	"graduation/services"
 	"graduation/utils"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
+// HistoryController 定义试卷生成历史控制器结构体
+type HistoryController struct {
+	history     mapper.HistoryRepository
+	questions   mapper.QuestionRepository
+	materials   mapper.MaterialRepository
+	attachments mapper.AttachmentRepository
+	audit       *component.Auditor
+}
+
+// NewHistoryController 创建新的试卷生成历史控制器
+func NewHistoryController(history mapper.HistoryRepository, questions mapper.QuestionRepository, materials mapper.MaterialRepository, attachments mapper.AttachmentRepository, audit *component.Auditor) *HistoryController {
+	return &HistoryController{history: history, questions: questions, materials: materials, attachments: attachments, audit: audit}
+}
+
+// auditTestPaper 获取试卷包含的题目作为审计快照，试卷没有题目时返回 nil
+func (hc *HistoryController) auditTestPaper(testPaperUid string) interface{} {
+	questionGenHistories, err := hc.history.GetQuestionGenHistoriesByTestPaperUid(testPaperUid)
+	if err != nil || len(questionGenHistories) == 0 {
+		return nil
+	}
+	ids := make([]int, 0, len(questionGenHistories))
+	for _, item := range questionGenHistories {
+		ids = append(ids, item.QuestionBankID)
+	}
+	return map[string]interface{}{
+		"test_paper_name":  questionGenHistories[0].TestPaperName,
+		"question_bank_id": ids,
+	}
+}
+
 // 处理 /getQuestionGenHistoriesByTestPaperUid 请求
-func GetQuestionGenHistoriesByTestPaperUid(c *gin.Context) {
+func (hc *HistoryController) GetQuestionGenHistoriesByTestPaperUid(c *gin.Context) {
 	testPaperUid := c.Query("test_paper_uid")
-	var questionGenHistories []entity.QuestionGenHistory
-	mapper.DB.Where("test_paper_uid = ?", testPaperUid).Find(&questionGenHistories)
+	questionGenHistories, _ := hc.history.GetQuestionGenHistoriesByTestPaperUid(testPaperUid)
 	resp := utils.Make200Resp("Success", questionGenHistories)
 	c.String(http.StatusOK, resp)
 }
 
+// rejectLockedTestPaper 试卷已锁定时拒绝修改并写入响应，返回是否已拒绝
+func (hc *HistoryController) rejectLockedTestPaper(c *gin.Context, testPaperUid string) bool {
+	locked, err := hc.history.IsTestPaperLocked(testPaperUid)
+	if err != nil {
+		c.String(http.StatusInternalServerError, utils.Make500Resp("查询试卷失败"))
+		return true
+	}
+	if locked {
+		c.String(http.StatusBadRequest, utils.Make400Resp("试卷已锁定，请先解锁"))
+	}
+	return locked
+}
+
+// 处理 /lockTestPaper 请求，锁定后试卷不能修改或删除，试卷引用的题目也不能删除
+func (hc *HistoryController) LockTestPaper(c *gin.Context) {
+	testPaperUid := c.Query("test_paper_uid")
+	locked, err := strconv.ParseBool(c.DefaultQuery("locked", "true"))
+	if testPaperUid == "" || err != nil {
+		c.String(http.StatusBadRequest, utils.Make400Resp("invalid test_paper_uid or locked"))
+		return
+	}
+	// MySQL 在值未变化时返回的影响行数为 0，因此先确认试卷存在
+	if names, _ := hc.history.GetTestPaperNameByTestPaperUid(testPaperUid); len(names) == 0 {
+		c.String(http.StatusNotFound, utils.MakeResp(http.StatusNotFound, "试卷不存在", nil))
+		return
+	}
+	count, err := hc.history.SetTestPaperLocked(testPaperUid, locked)
+	if err != nil {
+		c.String(http.StatusInternalServerError, utils.Make500Resp("更新试卷失败"))
+		return
+	}
+	action := component.AuditLock
+	if !locked {
+		action = component.AuditUnlock
+	}
+	hc.audit.Record(c, action, component.AuditEntityTestPaper, testPaperUid,
+		map[string]bool{"locked": !locked}, map[string]bool{"locked": locked})
+	c.String(http.StatusOK, utils.Make200Resp("Success", count))
+}
+
 // 处理 /deleteQuestionGenHistoryByTestPaperUid 请求
-func DeleteQuestionGenHistoryByTestPaperUid(c *gin.Context) {
+func (hc *HistoryController) DeleteQuestionGenHistoryByTestPaperUid(c *gin.Context) {
 	testPaperUid := c.Query("test_paper_uid")
-	var delQuestionCount int64
-	mapper.DB.Where("test_paper_uid = ?", testPaperUid).Delete(&entity.QuestionGenHistory{}).Count(&delQuestionCount)
-	var delTestPaperCount int64
-	mapper.DB.Where("test_paper_uid = ?", testPaperUid).Delete(&entity.TestPaperGenHistory{}).Count(&delTestPaperCount)
+	if hc.rejectLockedTestPaper(c, testPaperUid) {
+		return
+	}
+	before := hc.auditTestPaper(testPaperUid)
+	delQuestionCount, _ := hc.history.DeleteQuestionGenHistoryByTestPaperUid(testPaperUid)
+	delTestPaperCount, _ := hc.history.DeleteTestPaperGenHistoryByTestPaperUid(testPaperUid)
+	if delQuestionCount+delTestPaperCount > 0 {
+		hc.audit.Record(c, component.AuditDelete, component.AuditEntityTestPaper, testPaperUid, before, nil)
+	}
 	response := map[string]interface{}{
 		"delQuestionCount":  delQuestionCount,
 		"delTestPaperCount": delTestPaperCount,
@@ -37,7 +114,7 @@ func DeleteQuestionGenHistoryByTestPaperUid(c *gin.Context) {
 }
 
 // 处理 /updateQuestionGenHistory 请求
-func UpdateQuestionGenHistory(c *gin.Context) {
+func (hc *HistoryController) UpdateQuestionGenHistory(c *gin.Context) {
 	testPaperUid := c.Query("test_paper_uid")
 	questionBankIdsStr := c.QueryArray("question_bank_id")
 	var questionBankIds []int
@@ -46,9 +123,12 @@ func UpdateQuestionGenHistory(c *gin.Context) {
 		fmt.Sscanf(idStr, "%d", &id)
 		questionBankIds = append(questionBankIds, id)
 	}
+	if hc.rejectLockedTestPaper(c, testPaperUid) {
+		return
+	}
 
-	var names []string
-	mapper.DB.Table("test_paper_gen_histories").Where("test_paper_uid = ?", testPaperUid).Pluck("test_paper_name", &names)
+	before := hc.auditTestPaper(testPaperUid)
+	names, _ := hc.history.GetTestPaperNameByTestPaperUid(testPaperUid)
 	var testPaperName string
 	if len(names) > 0 {
 		testPaperName = names[0]
@@ -56,59 +136,144 @@ func UpdateQuestionGenHistory(c *gin.Context) {
 
 	var questions []entity.QuestionBank
 	for _, id := range questionBankIds {
-		var question entity.QuestionBank
-		if err := mapper.DB.Where("id = ?", id).First(&question).Error; err == nil {
-			questions = append(questions, question)
+		if found, err := hc.questions.GetQuestionBankById(id); err == nil && len(found) > 0 {
+			questions = append(questions, found[0])
 		}
 	}
 
+	// 按题库中的当前内容重新保存快照
 	date := time.Now()
-	var questionGenHistories []entity.QuestionGenHistory
-	for _, q := range questions {
-		questionGenHistory := entity.QuestionGenHistory{
-			TestPaperUID:   testPaperUid,
-			TestPaperName:  testPaperName,
-			QuestionBankID: q.ID,
-			Topic:          q.Topic,
-			Answer:         q.Answer,
-			TopicType:      q.TopicType,
-			Score:          q.Score,
-			Difficulty:     q.Difficulty,
-			Chapter1:       q.Chapter1,
-			Chapter2:       q.Chapter2,
-			Label1:         q.Label1,
-			Label2:         q.Label2,
-			UpdateTime:     date,
-		}
-		questionGenHistories = append(questionGenHistories, questionGenHistory)
-	}
+	questionGenHistories := services.NewQuestionGenHistories(questions, loadAttachments(hc.attachments, questions),
+		loadMaterials(hc.materials, questions), testPaperUid, testPaperName, date)
 
 	// 更新时间
-	updateRes := mapper.DB.Model(&entity.TestPaperGenHistory{}).
-		Where("test_paper_uid = ?", testPaperUid).
-		Update("update_time", date)
+	updateCount, _ := hc.history.UpdateTestPaperTime(testPaperUid, date)
 	// 删除旧的
-	deleteRes := mapper.DB.Where("test_paper_uid = ?", testPaperUid).Delete(&entity.QuestionGenHistory{})
+	deleteCount, _ := hc.history.DeleteQuestionGenHistoryByTestPaperUid(testPaperUid)
 	// 插入新的
-	insertRes := mapper.DB.Create(&questionGenHistories)
+	var insertCount int64
+	if len(questionGenHistories) > 0 {
+		insertCount, _ = hc.history.InsertQuestionGenHistories(questionGenHistories)
+	}
 
-	resp := utils.Make200Resp("Success", updateRes.RowsAffected+deleteRes.RowsAffected+insertRes.RowsAffected)
+	hc.audit.Record(c, component.AuditUpdate, component.AuditEntityTestPaper, testPaperUid, before, hc.auditTestPaper(testPaperUid))
+
+	resp := utils.Make200Resp("Success", updateCount+deleteCount+insertCount)
 	c.String(http.StatusOK, resp)
 }
 
-// 处理 /reExportTestPaper 请求
-func ReExportTestPaper(c *gin.Context) {
-	testPaperUid := c.Query("test_paper_uid")
-	var questionGenHistories []entity.QuestionGenHistory
-	mapper.DB.Where("test_paper_uid = ?", testPaperUid).Find(&questionGenHistories)
+// paperContent 导出试卷或答案所需的题目、附件和材料
+type paperContent struct {
+	questions   []entity.QuestionBank
+	attachments map[int][]entity.QuestionAttachment
+	materials   map[int]entity.QuestionMaterial
+	changes     []services.PaperQuestionChange // 从题库读取时，题目相对出题时的变化
+}
 
-	var questionBanks []entity.QuestionBank
-	for _, item := range questionGenHistories {
-		var question entity.QuestionBank
-		if err := mapper.DB.Where("id = ?", item.QuestionBankID).First(&question).Error; err == nil {
-			questionBanks = append(questionBanks, question)
+// snapshotContent 按出题记录中的快照还原试卷内容。旧记录没有附件和材料的快照，
+// 题干图片沿用记录中的 topic_image_path，材料从题库中读取
+func (hc *HistoryController) snapshotContent(histories []entity.QuestionGenHistory) paperContent {
+	content := paperContent{
+		attachments: make(map[int][]entity.QuestionAttachment),
+		materials:   make(map[int]entity.QuestionMaterial),
+	}
+	var legacy []entity.QuestionBank
+	for i := range histories {
+		h := &histories[i]
+		q := h.QuestionBank()
+		content.questions = append(content.questions, q)
+		if len(h.Attachments) > 0 {
+			content.attachments[q.ID] = h.Attachments
+		}
+		if h.Material != nil {
+			content.materials[h.Material.ID] = *h.Material
+		} else if h.Question == nil && q.TopicMaterialID != 0 {
+			legacy = append(legacy, q)
 		}
 	}
+	for id, m := range loadMaterials(hc.materials, legacy) {
+		if _, ok := content.materials[id]; !ok {
+			content.materials[id] = m
+		}
+	}
+	return content
+}
+
+// bankContent 从题库中读取试卷题目的当前内容，并与出题记录比较。已删除的题目不再导出
+func (hc *HistoryController) bankContent(histories []entity.QuestionGenHistory) paperContent {
+	var content paperContent
+	current := make(map[int]entity.QuestionBank)
+	for _, item := range histories {
+		if found, err := hc.questions.GetQuestionBankById(item.QuestionBankID); err == nil && len(found) > 0 {
+			content.questions = append(content.questions, found[0])
+			current[item.QuestionBankID] = found[0]
+		}
+	}
+	content.attachments = loadAttachments(hc.attachments, content.questions)
+	content.materials = loadMaterials(hc.materials, content.questions)
+	content.changes = services.ComparePaperSnapshot(histories, current, content.attachments, content.materials)
+	return content
+}
+
+// loadTestPaper 读取导出试卷或答案所需的内容。默认按出题时的快照生成，与题库中后来的修改和删除无关；
+// 请求参数 refresh 为 true 时改为从题库读取当前内容，并在响应头中列出出题后修改过和已删除的题目 ID
+func (hc *HistoryController) loadTestPaper(c *gin.Context) (paperContent, bool) {
+	testPaperUid := c.Query("test_paper_uid")
+	refresh, err := strconv.ParseBool(c.DefaultQuery("refresh", "false"))
+	if err != nil {
+		c.String(http.StatusBadRequest, utils.Make400Resp("Invalid refresh"))
+		return paperContent{}, false
+	}
+	histories, err := hc.history.GetQuestionGenHistoriesByTestPaperUid(testPaperUid)
+	if err != nil {
+		c.String(http.StatusInternalServerError, utils.Make500Resp("查询出题记录失败"))
+		return paperContent{}, false
+	}
+	if !refresh {
+		return hc.snapshotContent(histories), true
+	}
+	content := hc.bankContent(histories)
+	var changed, missing []string
+	for _, change := range content.changes {
+		id := strconv.Itoa(change.QuestionBankID)
+		if change.Status == services.PaperQuestionMissing {
+			missing = append(missing, id)
+		} else {
+			changed = append(changed, id)
+		}
+	}
+	c.Header(changedQuestionsHeader, strings.Join(changed, ","))
+	c.Header(missingQuestionsHeader, strings.Join(missing, ","))
+	return content, true
+}
+
+// 从题库重新导出时列出变化题目 ID 的响应头，多个 ID 以逗号分隔
+const (
+	changedQuestionsHeader = "X-Changed-Questions"
+	missingQuestionsHeader = "X-Missing-Questions"
+)
+
+// 处理 /getTestPaperChanges 请求，列出试卷中出题后在题库里修改过或已删除的题目
+func (hc *HistoryController) GetTestPaperChanges(c *gin.Context) {
+	histories, err := hc.history.GetQuestionGenHistoriesByTestPaperUid(c.Query("test_paper_uid"))
+	if err != nil {
+		c.String(http.StatusInternalServerError, utils.Make500Resp("查询出题记录失败"))
+		return
+	}
+	c.String(http.StatusOK, utils.Make200Resp("Success", hc.bankContent(histories).changes))
+}
+
+// 处理 /reExportTestPaper 请求，默认按出题时的快照导出试卷，refresh=true 时按题库中的当前内容导出
+func (hc *HistoryController) ReExportTestPaper(c *gin.Context) {
+	content, ok := hc.loadTestPaper(c)
+	if !ok {
+		return
+	}
+
+	// 材料题按组相邻排列，材料在组内第一道题之前打印
+	questionBanks := services.GroupByMaterial(content.questions)
+	materials := content.materials
+	attachments := content.attachments
 
 	// 分类题目
 	var tktQuestions, xztQuestions, pdtQuestions, jdtQuestions []entity.QuestionBank
@@ -135,16 +300,16 @@ func ReExportTestPaper(c *gin.Context) {
 		// 计算每小题分数
 		scorePerQuestion := xztQuestions[0].Score
 		contents += fmt.Sprintf("[SECTION_TITLE]一、选择题（本大题共%d小题，每小题%.1f分，共%.1f分）[/SECTION_TITLE]\r\r", len(xztQuestions), scorePerQuestion, float64(len(xztQuestions))*scorePerQuestion)
-		for _, q := range xztQuestions {
+		for i, q := range xztQuestions {
 			totalScore += q.Score
 			scoreStr := formatScore(q.Score)
-			contents = fmt.Sprintf("%s%d、（本题%s分）%s", contents, questionNumber, scoreStr, q.Topic)
-
-			// 如果有图片，添加图片标记
-			if q.TopicImagePath != "" {
-				contents += fmt.Sprintf(" [IMAGE:%s]", q.TopicImagePath)
-			}
+			contents += materialMarker(materials, xztQuestions, i)
+			// 题干中的图片按位置标记插入，没有标记的排在题干之后
+			contents = fmt.Sprintf("%s%d、（本题%s分）%s", contents, questionNumber, scoreStr, topicContent(q, attachments))
+			contents += services.ContentTableMarker(q.TopicTable)
 
+			// 选项由导出程序按列排版
+			contents += services.ChoiceOptionsMarker(optionsContent(q, attachments))
 			contents += "\r\r[QUESTION_END]\r\r" // 使用特殊标记分隔题目
 			questionNumber++
 			totalCount++
@@ -156,15 +321,13 @@ func ReExportTestPaper(c *gin.Context) {
 		// 计算每小题分数
 		scorePerQuestion := tktQuestions[0].Score
 		contents += fmt.Sprintf("[SECTION_TITLE]二、填空题（本大题共%d小题，每小题%.1f分，共%.1f分）[/SECTION_TITLE]\r\r", len(tktQuestions), scorePerQuestion, float64(len(tktQuestions))*scorePerQuestion)
-		for _, q := range tktQuestions {
+		for i, q := range tktQuestions {
 			totalScore += q.Score
 			scoreStr := formatScore(q.Score)
-			contents = fmt.Sprintf("%s%d、（本题%s分）%s", contents, questionNumber, scoreStr, q.Topic)
-
-			// 如果有图片，添加图片标记
-			if q.TopicImagePath != "" {
-				contents += fmt.Sprintf(" [IMAGE:%s]", q.TopicImagePath)
-			}
+			contents += materialMarker(materials, tktQuestions, i)
+			// 题干中的图片按位置标记插入，没有标记的排在题干之后
+			contents = fmt.Sprintf("%s%d、（本题%s分）%s", contents, questionNumber, scoreStr, topicContent(q, attachments))
+			contents += services.ContentTableMarker(q.TopicTable)
 
 			contents += "\r\r[QUESTION_END]\r\r" // 使用特殊标记分隔题目
 			questionNumber++
@@ -177,15 +340,13 @@ func ReExportTestPaper(c *gin.Context) {
 		// 计算每小题分数
 		scorePerQuestion := pdtQuestions[0].Score
 		contents += fmt.Sprintf("[SECTION_TITLE]三、判断题（本大题共%d小题，每小题%.1f分，共%.1f分）[/SECTION_TITLE]\r\r", len(pdtQuestions), scorePerQuestion, float64(len(pdtQuestions))*scorePerQuestion)
-		for _, q := range pdtQuestions {
+		for i, q := range pdtQuestions {
 			totalScore += q.Score
 			scoreStr := formatScore(q.Score)
-			contents = fmt.Sprintf("%s%d、（本题%s分）%s", contents, questionNumber, scoreStr, q.Topic)
-
-			// 如果有图片，添加图片标记
-			if q.TopicImagePath != "" {
-				contents += fmt.Sprintf(" [IMAGE:%s]", q.TopicImagePath)
-			}
+			contents += materialMarker(materials, pdtQuestions, i)
+			// 题干中的图片按位置标记插入，没有标记的排在题干之后
+			contents = fmt.Sprintf("%s%d、（本题%s分）%s", contents, questionNumber, scoreStr, topicContent(q, attachments))
+			contents += services.ContentTableMarker(q.TopicTable)
 
 			contents += "\r\r[QUESTION_END]\r\r" // 使用特殊标记分隔题目
 			questionNumber++
@@ -198,15 +359,13 @@ func ReExportTestPaper(c *gin.Context) {
 		// 计算每小题分数
 		scorePerQuestion := jdtQuestions[0].Score
 		contents += fmt.Sprintf("[SECTION_TITLE]四、简答题（本大题共%d小题，每小题%.1f分，共%.1f分）[/SECTION_TITLE]\r\r", len(jdtQuestions), scorePerQuestion, float64(len(jdtQuestions))*scorePerQuestion)
-		for _, q := range jdtQuestions {
+		for i, q := range jdtQuestions {
 			totalScore += q.Score
 			scoreStr := formatScore(q.Score)
-			contents = fmt.Sprintf("%s%d、（本题%s分）%s", contents, questionNumber, scoreStr, q.Topic)
-
-			// 如果有图片，添加图片标记
-			if q.TopicImagePath != "" {
-				contents += fmt.Sprintf(" [IMAGE:%s]", q.TopicImagePath)
-			}
+			contents += materialMarker(materials, jdtQuestions, i)
+			// 题干中的图片按位置标记插入，没有标记的排在题干之后
+			contents = fmt.Sprintf("%s%d、（本题%s分）%s", contents, questionNumber, scoreStr, topicContent(q, attachments))
+			contents += services.ContentTableMarker(q.TopicTable)
 
 			contents += "\r\r[QUESTION_END]\r\r" // 使用特殊标记分隔题目
 			questionNumber++
@@ -230,19 +389,14 @@ func ReExportTestPaper(c *gin.Context) {
 	downloadFile(c, file)
 }
 
-// 处理 /exportAnswer 请求
-func ExportAnswer(c *gin.Context) {
-	testPaperUid := c.Query("test_paper_uid")
-	var questionGenHistories []entity.QuestionGenHistory
-	mapper.DB.Where("test_paper_uid = ?", testPaperUid).Find(&questionGenHistories)
-
-	var questionBanks []entity.QuestionBank
-	for _, item := range questionGenHistories {
-		var question entity.QuestionBank
-		if err := mapper.DB.Where("id = ?", item.QuestionBankID).First(&question).Error; err == nil {
-			questionBanks = append(questionBanks, question)
-		}
+// 处理 /exportAnswer 请求，默认按出题时的快照导出答案，refresh=true 时按题库中的当前内容导出
+func (hc *HistoryController) ExportAnswer(c *gin.Context) {
+	content, ok := hc.loadTestPaper(c)
+	if !ok {
+		return
 	}
+	questionBanks := content.questions
+	attachments := content.attachments
 
 	// 分类题目
 	var tktQuestions, xztQuestions, pdtQuestions, jdtQuestions []entity.QuestionBank
@@ -272,7 +426,8 @@ func ExportAnswer(c *gin.Context) {
 		for _, q := range xztQuestions {
 			totalScore += q.Score
 			scoreStr := formatScore(q.Score)
-			contents = fmt.Sprintf("%s%d、（本题%s分）%s", contents, questionNumber, scoreStr, q.Answer)
+			contents = fmt.Sprintf("%s%d、（本题%s分）%s", contents, questionNumber, scoreStr, answerContent(q, attachments))
+			contents += services.ContentTableMarker(q.AnswerTable)
 			contents += "\r\r[QUESTION_END]\r\r" // 使用特殊标记分隔题目
 			questionNumber++
 			totalCount++
@@ -287,7 +442,8 @@ func ExportAnswer(c *gin.Context) {
 		for _, q := range tktQuestions {
 			totalScore += q.Score
 			scoreStr := formatScore(q.Score)
-			contents = fmt.Sprintf("%s%d、（本题%s分）%s", contents, questionNumber, scoreStr, q.Answer)
+			contents = fmt.Sprintf("%s%d、（本题%s分）%s", contents, questionNumber, scoreStr, answerContent(q, attachments))
+			contents += services.ContentTableMarker(q.AnswerTable)
 			contents += "\r\r[QUESTION_END]\r\r" // 使用特殊标记分隔题目
 			questionNumber++
 			totalCount++
@@ -302,7 +458,8 @@ func ExportAnswer(c *gin.Context) {
 		for _, q := range pdtQuestions {
 			totalScore += q.Score
 			scoreStr := formatScore(q.Score)
-			contents = fmt.Sprintf("%s%d、（本题%s分）%s", contents, questionNumber, scoreStr, q.Answer)
+			contents = fmt.Sprintf("%s%d、（本题%s分）%s", contents, questionNumber, scoreStr, answerContent(q, attachments))
+			contents += services.ContentTableMarker(q.AnswerTable)
 			contents += "\r\r[QUESTION_END]\r\r" // 使用特殊标记分隔题目
 			questionNumber++
 			totalCount++
@@ -317,7 +474,8 @@ func ExportAnswer(c *gin.Context) {
 		for _, q := range jdtQuestions {
 			totalScore += q.Score
 			scoreStr := formatScore(q.Score)
-			contents = fmt.Sprintf("%s%d、（本题%s分）%s", contents, questionNumber, scoreStr, q.Answer)
+			contents = fmt.Sprintf("%s%d、（本题%s分）%s", contents, questionNumber, scoreStr, answerContent(q, attachments))
+			contents += services.ContentTableMarker(q.AnswerTable)
 			contents += "\r\r[QUESTION_END]\r\r" // 使用特殊标记分隔题目
 			questionNumber++
 			totalCount++
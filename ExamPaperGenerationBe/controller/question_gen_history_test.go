@@ -0,0 +1,88 @@
+package controller
+
+import (
+	"encoding/json"
+	"graduation/component"
+	"graduation/entity"
+	"graduation/mapper/memory"
+	"graduation/services"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReExportFromSnapshot(t *testing.T) {
+	choice := entity.QuestionBank{Topic: "8086 的地址总线宽度", Answer: "C", TopicType: entity.ChoiceTopicType, Score: 2, TopicMaterialID: 1,
+		Options:     []entity.QuestionOption{{Label: "A", Content: "8"}, {Label: "B", Content: "16"}, {Label: "C", Content: "20"}},
+		AnswerTable: &entity.ContentTable{Rows: [][]string{{"20 位"}}}}
+	blank := entity.QuestionBank{Topic: "8086 有几个段寄存器？", Answer: "4", TopicType: "填空题", Score: 2}
+	essay := entity.QuestionBank{Topic: "8259A 的作用", Answer: "中断控制", TopicType: "简答题", Score: 10, TopicMaterialID: 1}
+	questions := memory.NewQuestionRepository(choice, blank, essay)
+	materials := memory.NewMaterialRepository(entity.QuestionMaterial{Material: "阅读下面的程序"})
+	attachments := memory.NewAttachmentRepository(entity.QuestionAttachment{QuestionID: 1, Target: entity.AttachmentTargetTopic, Name: "1", Path: "media:a"})
+	history := memory.NewHistoryRepository()
+	hc := NewHistoryController(history, questions, materials, attachments, component.NewAuditor(memory.NewAuditRepository()))
+	r := newTestRouter()
+	r.GET("/reExportTestPaper", hc.ReExportTestPaper)
+	r.GET("/exportAnswer", hc.ExportAnswer)
+	r.GET("/getTestPaperChanges", hc.GetTestPaperChanges)
+
+	var saved []entity.QuestionBank
+	for id := 1; id <= 3; id++ {
+		found, err := questions.GetQuestionBankById(id)
+		require.NoError(t, err)
+		saved = append(saved, found...)
+	}
+	histories := services.NewQuestionGenHistories(saved, loadAttachments(attachments, saved), loadMaterials(materials, saved), "p1", "期中试卷", time.Now())
+	// 第三道题模拟升级前的旧记录，没有完整快照，也不比较材料
+	histories[2].Question, histories[2].Attachments, histories[2].Material = nil, nil, nil
+	_, err := history.InsertQuestionGenHistories(histories)
+	require.NoError(t, err)
+
+	// 出题后修改题目、附件和材料，并删除一道题
+	edited := saved[0]
+	edited.Options = []entity.QuestionOption{{Label: "A", Content: "8"}, {Label: "B", Content: "16"}, {Label: "C", Content: "24"}}
+	_, err = questions.UpdateSingleQuestionBank(&edited)
+	require.NoError(t, err)
+	_, err = questions.SoftDeleteQuestionBanks([]int{2}, "admin")
+	require.NoError(t, err)
+	_, err = materials.UpdateQuestionMaterial(&entity.QuestionMaterial{ID: 1, Material: "阅读下面的汇编程序"})
+	require.NoError(t, err)
+
+	// 默认按快照还原，已删除的题目仍然导出，题目内容和表格与出题时相同
+	stored, err := history.GetQuestionGenHistoriesByTestPaperUid("p1")
+	require.NoError(t, err)
+	content := hc.snapshotContent(stored)
+	require.Len(t, content.questions, 3)
+	require.Equal(t, "20", content.questions[0].Options[2].Content)
+	require.Equal(t, choice.AnswerTable, content.questions[0].AnswerTable)
+	require.Equal(t, "media:a", content.attachments[1][0].Path)
+	require.Equal(t, "阅读下面的程序", content.materials[1].Material)
+	require.Equal(t, "8259A 的作用", content.questions[2].Topic)
+
+	for _, path := range []string{"/reExportTestPaper", "/exportAnswer"} {
+		w := getMedia(r, path+"?test_paper_uid=p1", "")
+		require.Equal(t, http.StatusOK, w.Code, path)
+		require.Empty(t, w.Header().Get(changedQuestionsHeader))
+
+		// 从题库重新导出时在响应头中列出变化的题目
+		w = getMedia(r, path+"?test_paper_uid=p1&refresh=true", "")
+		require.Equal(t, http.StatusOK, w.Code, path)
+		require.Equal(t, "1", w.Header().Get(changedQuestionsHeader))
+		require.Equal(t, "2", w.Header().Get(missingQuestionsHeader))
+	}
+	require.Equal(t, http.StatusBadRequest, getMedia(r, "/reExportTestPaper?test_paper_uid=p1&refresh=maybe", "").Code)
+
+	resp := doJSON(t, r, http.MethodGet, "/getTestPaperChanges?test_paper_uid=p1", nil)
+	require.Equal(t, 200, resp.Code, resp.Msg)
+	raw, err := json.Marshal(resp.Data)
+	require.NoError(t, err)
+	var changes []services.PaperQuestionChange
+	require.NoError(t, json.Unmarshal(raw, &changes))
+	require.Equal(t, []services.PaperQuestionChange{
+		{QuestionBankID: 1, Topic: choice.DisplayTopic(), Status: services.PaperQuestionChanged, Fields: []string{"material", "options"}},
+		{QuestionBankID: 2, Topic: blank.Topic, Status: services.PaperQuestionMissing},
+	}, changes)
+}
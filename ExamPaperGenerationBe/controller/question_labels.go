@@ -1,6 +1,7 @@
 package controller
 
 import (
+	"graduation/component"
 	"graduation/entity"
 	"graduation/mapper"
 	"graduation/utils"
@@ -11,18 +12,27 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// LabelController 定义知识点标签控制器结构体
+type LabelController struct {
+	labels mapper.LabelRepository
+	audit  *component.Auditor
+}
+
+// NewLabelController 创建新的知识点标签控制器
+func NewLabelController(labels mapper.LabelRepository, audit *component.Auditor) *LabelController {
+	return &LabelController{labels: labels, audit: audit}
+}
+
 // GetAllQuestionLabels 获取所有题目标签
-func GetAllQuestionLabels(c *gin.Context) {
-	var questionLabels []entity.QuestionLabels
-	mapper.DB.Find(&questionLabels)
+func (lc *LabelController) GetAllQuestionLabels(c *gin.Context) {
+	questionLabels, _ := lc.labels.GetAllQuestionLabels()
 	resp := utils.Make200Resp("successfully get all question labels", questionLabels)
 	c.String(http.StatusOK, resp)
 }
 
 // GetDistinctChapter1 获取不同的 chapter_1
-func GetDistinctChapter1(c *gin.Context) {
-	var distinctChapter1 []entity.QuestionLabels
-	mapper.DB.Distinct("chapter_1").Find(&distinctChapter1)
+func (lc *LabelController) GetDistinctChapter1(c *gin.Context) {
+	distinctChapter1, _ := lc.labels.GetDistinctChapter1()
 	var chapter1List []string
 	for _, label := range distinctChapter1 {
 		chapter1List = append(chapter1List, label.Chapter1)
@@ -32,9 +42,8 @@ func GetDistinctChapter1(c *gin.Context) {
 }
 
 // GetDistinctChapter2 获取不同的 chapter_2
-func GetDistinctChapter2(c *gin.Context) {
-	var distinctChapter2 []entity.QuestionLabels
-	mapper.DB.Distinct("chapter_2").Find(&distinctChapter2)
+func (lc *LabelController) GetDistinctChapter2(c *gin.Context) {
+	distinctChapter2, _ := lc.labels.GetDistinctChapter2()
 	var chapter2List []string
 	for _, label := range distinctChapter2 {
 		chapter2List = append(chapter2List, label.Chapter2)
@@ -44,10 +53,9 @@ func GetDistinctChapter2(c *gin.Context) {
 }
 
 // GetChapter2ByChapter1 根据 chapter_1 获取对应的 chapter_2
-func GetChapter2ByChapter1(c *gin.Context) {
+func (lc *LabelController) GetChapter2ByChapter1(c *gin.Context) {
 	chapter1 := c.Query("chapter1")
-	var chapter2ByChapter1 []entity.QuestionLabels
-	mapper.DB.Where("chapter_1 = ?", chapter1).Find(&chapter2ByChapter1)
+	chapter2ByChapter1, _ := lc.labels.GetChapter2ByChapter1(chapter1)
 	var chapter2List []string
 	for _, label := range chapter2ByChapter1 {
 		chapter2List = append(chapter2List, label.Chapter2)
@@ -57,9 +65,8 @@ func GetChapter2ByChapter1(c *gin.Context) {
 }
 
 // GetDistinctLabel1 获取不同的 label_1
-func GetDistinctLabel1(c *gin.Context) {
-	var distinctLabel1 []entity.QuestionLabels
-	mapper.DB.Distinct("label_1").Find(&distinctLabel1)
+func (lc *LabelController) GetDistinctLabel1(c *gin.Context) {
+	distinctLabel1, _ := lc.labels.GetDistinctLabel1()
 	var label1List []string
 	for _, label := range distinctLabel1 {
 		label1List = append(label1List, label.Label1)
@@ -69,9 +76,8 @@ func GetDistinctLabel1(c *gin.Context) {
 }
 
 // GetDistinctLabel2 获取不同的 label_2
-func GetDistinctLabel2(c *gin.Context) {
-	var distinctLabel2 []entity.QuestionLabels
-	mapper.DB.Distinct("label_2").Find(&distinctLabel2)
+func (lc *LabelController) GetDistinctLabel2(c *gin.Context) {
+	distinctLabel2, _ := lc.labels.GetDistinctLabel2()
 	var label2List []string
 	for _, label := range distinctLabel2 {
 		label2List = append(label2List, label.Label2)
@@ -89,7 +95,7 @@ func GetDistinctLabel2(c *gin.Context) {
 // @Param label body entity.QuestionLabels true "标签信息"
 // @Success 200 {object} entity.QuestionLabels
 // @Router /labels [post]
-func CreateLabel(ctx *gin.Context) {
+func (lc *LabelController) CreateLabel(ctx *gin.Context) {
 	var label entity.QuestionLabels
 	if err := ctx.ShouldBindJSON(&label); err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -98,10 +104,11 @@ func CreateLabel(ctx *gin.Context) {
 	now := time.Now()
 	label.CreatedAt = now
 	label.UpdatedAt = now
-	if err := mapper.DB.Model(&entity.QuestionLabels{}).Create(&label).Error; err != nil {
+	if err := lc.labels.CreateLabel(&label); err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create the label. Please try again later."})
 		return
 	}
+	lc.audit.Record(ctx, component.AuditCreate, component.AuditEntityLabel, strconv.Itoa(label.ID), nil, label)
 
 	ctx.JSON(http.StatusOK, label)
 }
@@ -116,7 +123,7 @@ func CreateLabel(ctx *gin.Context) {
 // @Param label body entity.QuestionLabels true "标签信息"
 // @Success 200 {object} entity.QuestionLabels
 // @Router /labels/{id} [put]
-func UpdateLabel(ctx *gin.Context) {
+func (lc *LabelController) UpdateLabel(ctx *gin.Context) {
 	id, err := strconv.Atoi(ctx.Param("id"))
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
@@ -127,63 +134,20 @@ func UpdateLabel(ctx *gin.Context) {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	label.ID = id
 	// 先检查记录是否存在
-	var existingLabel entity.QuestionLabels
-	result := mapper.DB.Model(&entity.QuestionLabels{}).Where("id = ?", id).First(&existingLabel)
-	if result.Error != nil {
+	existingLabel, err := lc.labels.GetLabelById(id)
+	if err != nil {
 		ctx.JSON(http.StatusNotFound, gin.H{"error": "Label not found"})
 		return
 	}
 
-	// 开始事务
-	tx := mapper.DB.Begin()
-	if tx.Error != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start transaction"})
-		return
-	}
-
-	// 更新标签记录
-	if err := tx.Model(&entity.QuestionLabels{}).Where("id = ?", id).Updates(&label).Error; err != nil {
-		tx.Rollback()
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update label"})
-		return
-	}
-
-	// 更新题库中对应的标签
-	if err := tx.Debug().Model(&entity.QuestionBank{}).
-		Where("chapter_1 = ? AND chapter_2 = ? AND label_1 = ? AND label_2 = ?",
-			existingLabel.Chapter1, existingLabel.Chapter2, existingLabel.Label1, existingLabel.Label2).
-		Updates(map[string]interface{}{
-			"chapter_1": label.Chapter1,
-			"chapter_2": label.Chapter2,
-			"label_1":   label.Label1,
-			"label_2":   label.Label2,
-		}).Error; err != nil {
-		tx.Rollback()
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update question bank labels"})
-		return
-	}
-
-	// 更新历史记录中对应的标签
-	if err := tx.Debug().Model(&entity.QuestionGenHistory{}).
-		Where("chapter_1 = ? AND chapter_2 = ? AND label_1 = ? AND label_2 = ?",
-			existingLabel.Chapter1, existingLabel.Chapter2, existingLabel.Label1, existingLabel.Label2).
-		Updates(map[string]interface{}{
-			"chapter_1": label.Chapter1,
-			"chapter_2": label.Chapter2,
-			"label_1":   label.Label1,
-			"label_2":   label.Label2,
-		}).Error; err != nil {
-		tx.Rollback()
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update question history labels"})
+	// 在事务中更新标签，并同步题库和历史记录中对应的标签
+	if err := lc.labels.UpdateLabel(id, label); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-
-	// 提交事务
-	if err := tx.Commit().Error; err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
-		return
+	if updated, err := lc.labels.GetLabelById(id); err == nil {
+		lc.audit.Record(ctx, component.AuditUpdate, component.AuditEntityLabel, strconv.Itoa(id), existingLabel, updated)
 	}
 
 	ctx.JSON(http.StatusOK, existingLabel)
@@ -197,17 +161,22 @@ func UpdateLabel(ctx *gin.Context) {
 // @Param id path int true "标签ID"
 // @Success 200 {object} gin.H
 // @Router /labels/{id} [delete]
-func DeleteLabel(ctx *gin.Context) {
+func (lc *LabelController) DeleteLabel(ctx *gin.Context) {
 	id, err := strconv.Atoi(ctx.Param("id"))
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
 		return
 	}
-	var label entity.QuestionLabels
-	if err := mapper.DB.Model(&label).Where("id = ?", id).Delete(&label).Error; err != nil {
+	existingLabel, err := lc.labels.GetLabelById(id)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Label not found"})
+		return
+	}
+	if err := lc.labels.DeleteLabel(id); err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	lc.audit.Record(ctx, component.AuditDelete, component.AuditEntityLabel, strconv.Itoa(id), existingLabel, nil)
 
 	ctx.JSON(http.StatusOK, gin.H{"message": "Label deleted successfully"})
 }
@@ -0,0 +1,327 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"graduation/component"
+	"graduation/entity"
+	"graduation/mapper"
+	"graduation/services"
+	"graduation/utils"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MaterialController 定义题目材料控制器结构体，多道题目可以共用一份材料（阅读材料、程序段、数据表等）
+type MaterialController struct {
+	materials mapper.MaterialRepository
+	questions mapper.QuestionRepository
+	audit     *component.Auditor
+}
+
+// NewMaterialController 创建新的题目材料控制器
+func NewMaterialController(materials mapper.MaterialRepository, questions mapper.QuestionRepository, audit *component.Auditor) *MaterialController {
+	return &MaterialController{materials: materials, questions: questions, audit: audit}
+}
+
+// linkQuestionsRequest 关联题目请求
+type linkQuestionsRequest struct {
+	QuestionIDs []int `json:"question_ids" binding:"required"`
+}
+
+// validateMaterial 检查材料内容是否完整
+func validateMaterial(material *entity.QuestionMaterial) error {
+	if material.IsEmpty() {
+		return errors.New("材料内容为空")
+	}
+	if err := validateFormula("材料标题", material.Title); err != nil {
+		return err
+	}
+	if err := validateFormula("材料正文", material.Material); err != nil {
+		return err
+	}
+	for i, table := range material.Tables {
+		if err := table.Validate(); err != nil {
+			return fmt.Errorf("第 %d 个表格: %w", i+1, err)
+		}
+		if err := validateTableFormulas(fmt.Sprintf("第 %d 个表格", i+1), &table); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// bindMaterial 解析请求中的材料。JSON 请求直接绑定；multipart 表单的 data 字段为材料 JSON，
+// images 字段上传的图片追加到 image_paths 之后
+func bindMaterial(ctx *gin.Context) (entity.QuestionMaterial, error) {
+	var material entity.QuestionMaterial
+	if ctx.ContentType() != "multipart/form-data" {
+		err := ctx.ShouldBindJSON(&material)
+		return material, err
+	}
+	form, err := ctx.MultipartForm()
+	if err != nil {
+		return material, err
+	}
+	if data := form.Value["data"]; len(data) > 0 {
+		if err := json.Unmarshal([]byte(data[0]), &material); err != nil {
+			return material, err
+		}
+	}
+	for _, header := range form.File["images"] {
+		path, err := saveUploadedFile(ctx.Request.Context(), header)
+		if errors.Is(err, errImageTooLarge) {
+			return material, err
+		}
+		if err != nil {
+			log.Printf("Error saving material image: %v", err)
+			return material, errors.New("保存材料图片失败")
+		}
+		material.ImagePaths = append(material.ImagePaths, path)
+	}
+	return material, nil
+}
+
+// materialID 解析路径中的材料 ID 并确认材料存在，失败时已写入响应
+func (mc *MaterialController) materialID(ctx *gin.Context) (entity.QuestionMaterial, bool) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.String(http.StatusBadRequest, utils.Make400Resp("Invalid ID"))
+		return entity.QuestionMaterial{}, false
+	}
+	material, err := mc.materials.GetQuestionMaterialById(id)
+	if err != nil {
+		ctx.String(http.StatusNotFound, utils.MakeResp(http.StatusNotFound, "Material not found", nil))
+		return entity.QuestionMaterial{}, false
+	}
+	return material, true
+}
+
+// linkedQuestionIds 返回引用材料的题目 ID
+func (mc *MaterialController) linkedQuestionIds(materialID int) []int {
+	questions, err := mc.questions.GetQuestionBanksByMaterialId(materialID)
+	if err != nil {
+		log.Printf("Error getting questions of material %d: %v", materialID, err)
+	}
+	ids := make([]int, 0, len(questions))
+	for _, q := range questions {
+		ids = append(ids, q.ID)
+	}
+	return ids
+}
+
+// 处理 GET /materials 请求，列出全部材料
+func (mc *MaterialController) GetQuestionMaterials(ctx *gin.Context) {
+	materials, err := mc.materials.GetAllQuestionMaterials()
+	if err != nil {
+		ctx.String(http.StatusInternalServerError, utils.Make500Resp("查询材料失败"))
+		return
+	}
+	fillMaterialImageURLs(materials)
+	ctx.String(http.StatusOK, utils.Make200Resp("Success", materials))
+}
+
+// 处理 GET /materials/:id 请求，返回材料及引用该材料的题目
+func (mc *MaterialController) GetQuestionMaterial(ctx *gin.Context) {
+	material, ok := mc.materialID(ctx)
+	if !ok {
+		return
+	}
+	questions, err := mc.questions.GetQuestionBanksByMaterialId(material.ID)
+	if err != nil {
+		ctx.String(http.StatusInternalServerError, utils.Make500Resp("查询材料题目失败"))
+		return
+	}
+	fillQuestionImageURLs(questions)
+	renderQuestionMathML(questions)
+	response := map[string]interface{}{
+		"material":  materialWithURLs(material),
+		"questions": questions,
+	}
+	ctx.String(http.StatusOK, utils.Make200Resp("Success", response))
+}
+
+// 处理 POST /materials 请求，新增材料
+func (mc *MaterialController) CreateQuestionMaterial(ctx *gin.Context) {
+	material, err := bindMaterial(ctx)
+	if err != nil {
+		ctx.String(http.StatusBadRequest, utils.Make400Resp(err.Error()))
+		return
+	}
+	material.ID = 0
+	if err := validateMaterial(&material); err != nil {
+		ctx.String(http.StatusBadRequest, utils.Make400Resp(err.Error()))
+		return
+	}
+	if err := mc.materials.CreateQuestionMaterial(&material); err != nil {
+		ctx.String(http.StatusInternalServerError, utils.Make500Resp("新增材料失败"))
+		return
+	}
+	mc.audit.Record(ctx, component.AuditCreate, component.AuditEntityMaterial, strconv.Itoa(material.ID), nil, material)
+	ctx.String(http.StatusOK, utils.Make200Resp("Success", materialWithURLs(material)))
+}
+
+// 处理 PUT /materials/:id 请求，用请求中的内容替换材料
+func (mc *MaterialController) UpdateQuestionMaterial(ctx *gin.Context) {
+	existing, ok := mc.materialID(ctx)
+	if !ok {
+		return
+	}
+	material, err := bindMaterial(ctx)
+	if err != nil {
+		ctx.String(http.StatusBadRequest, utils.Make400Resp(err.Error()))
+		return
+	}
+	material.ID = existing.ID
+	material.UpdateTime = time.Now()
+	if err := validateMaterial(&material); err != nil {
+		ctx.String(http.StatusBadRequest, utils.Make400Resp(err.Error()))
+		return
+	}
+	if _, err := mc.materials.UpdateQuestionMaterial(&material); err != nil {
+		ctx.String(http.StatusInternalServerError, utils.Make500Resp("修改材料失败"))
+		return
+	}
+	services.ReleaseMedia(ctx.Request.Context(), existing.ImagePaths...)
+	mc.audit.Record(ctx, component.AuditUpdate, component.AuditEntityMaterial, strconv.Itoa(material.ID), existing, material)
+	ctx.String(http.StatusOK, utils.Make200Resp("Success", materialWithURLs(material)))
+}
+
+// 处理 DELETE /materials/:id 请求。仍有题目引用材料时拒绝删除，force=true 时先取消这些题目的关联
+func (mc *MaterialController) DeleteQuestionMaterial(ctx *gin.Context) {
+	material, ok := mc.materialID(ctx)
+	if !ok {
+		return
+	}
+	linked := mc.linkedQuestionIds(material.ID)
+	if len(linked) > 0 {
+		if ctx.Query("force") != "true" {
+			ctx.String(http.StatusBadRequest, utils.MakeResp(http.StatusBadRequest,
+				fmt.Sprintf("材料仍被 %d 道题目引用", len(linked)), map[string]interface{}{"question_ids": linked}))
+			return
+		}
+		if _, err := mc.questions.SetQuestionMaterial(linked, 0); err != nil {
+			ctx.String(http.StatusInternalServerError, utils.Make500Resp("取消题目关联失败"))
+			return
+		}
+	}
+	deleteCount, err := mc.materials.DeleteQuestionMaterial(material.ID)
+	if err != nil {
+		ctx.String(http.StatusInternalServerError, utils.Make500Resp("删除材料失败"))
+		return
+	}
+	services.ReleaseMedia(ctx.Request.Context(), material.ImagePaths...)
+	mc.audit.Record(ctx, component.AuditDelete, component.AuditEntityMaterial, strconv.Itoa(material.ID), material, nil)
+	response := map[string]interface{}{
+		"deleteCount":       deleteCount,
+		"unlinkedQuestions": linked,
+	}
+	ctx.String(http.StatusOK, utils.Make200Resp("Success", response))
+}
+
+// 处理 POST /materials/:id/questions 请求，把题目关联到材料，题目原来关联的材料会被替换
+func (mc *MaterialController) LinkQuestions(ctx *gin.Context) {
+	material, ok := mc.materialID(ctx)
+	if !ok {
+		return
+	}
+	var req linkQuestionsRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.String(http.StatusBadRequest, utils.Make400Resp(err.Error()))
+		return
+	}
+	found, err := mc.questions.GetQuestionBanksInIds(req.QuestionIDs)
+	if err != nil {
+		ctx.String(http.StatusInternalServerError, utils.Make500Resp("查询题目失败"))
+		return
+	}
+	requested := make(map[int]bool, len(req.QuestionIDs))
+	for _, id := range req.QuestionIDs {
+		requested[id] = true
+	}
+	if len(found) != len(requested) {
+		ctx.String(http.StatusBadRequest, utils.Make400Resp("题目不存在"))
+		return
+	}
+	before := mc.linkedQuestionIds(material.ID)
+	updateCount, err := mc.questions.SetQuestionMaterial(req.QuestionIDs, material.ID)
+	if err != nil {
+		ctx.String(http.StatusInternalServerError, utils.Make500Resp("关联题目失败"))
+		return
+	}
+	after := mc.linkedQuestionIds(material.ID)
+	mc.audit.Record(ctx, component.AuditUpdate, component.AuditEntityMaterial, strconv.Itoa(material.ID),
+		map[string]interface{}{"question_ids": before}, map[string]interface{}{"question_ids": after})
+	response := map[string]interface{}{
+		"updateCount":  updateCount,
+		"question_ids": after,
+	}
+	ctx.String(http.StatusOK, utils.Make200Resp("Success", response))
+}
+
+// 处理 DELETE /materials/:id/questions/:questionId 请求，取消题目与材料的关联
+func (mc *MaterialController) UnlinkQuestion(ctx *gin.Context) {
+	material, ok := mc.materialID(ctx)
+	if !ok {
+		return
+	}
+	questionID, err := strconv.Atoi(ctx.Param("questionId"))
+	if err != nil {
+		ctx.String(http.StatusBadRequest, utils.Make400Resp("Invalid question ID"))
+		return
+	}
+	before := mc.linkedQuestionIds(material.ID)
+	if !slices.Contains(before, questionID) {
+		ctx.String(http.StatusBadRequest, utils.Make400Resp("题目未关联该材料"))
+		return
+	}
+	if _, err := mc.questions.SetQuestionMaterial([]int{questionID}, 0); err != nil {
+		ctx.String(http.StatusInternalServerError, utils.Make500Resp("取消题目关联失败"))
+		return
+	}
+	after := mc.linkedQuestionIds(material.ID)
+	mc.audit.Record(ctx, component.AuditUpdate, component.AuditEntityMaterial, strconv.Itoa(material.ID),
+		map[string]interface{}{"question_ids": before}, map[string]interface{}{"question_ids": after})
+	ctx.String(http.StatusOK, utils.Make200Resp("Success", map[string]interface{}{"question_ids": after}))
+}
+
+// loadMaterials 获取题目引用的材料，按材料 ID 索引
+func loadMaterials(materials mapper.MaterialRepository, questions []entity.QuestionBank) map[int]entity.QuestionMaterial {
+	var ids []int
+	for _, q := range questions {
+		if q.TopicMaterialID != 0 {
+			ids = append(ids, q.TopicMaterialID)
+		}
+	}
+	byID := make(map[int]entity.QuestionMaterial)
+	if len(ids) == 0 {
+		return byID
+	}
+	found, err := materials.GetQuestionMaterialsInIds(ids)
+	if err != nil {
+		log.Printf("Error getting question materials: %v", err)
+	}
+	for _, m := range found {
+		byID[m.ID] = m
+	}
+	return byID
+}
+
+// materialMarker 题目是材料题组的第一道题时返回材料标记，同组题目相邻排列，材料只在组前打印一次
+func materialMarker(materials map[int]entity.QuestionMaterial, questions []entity.QuestionBank, i int) string {
+	id := questions[i].TopicMaterialID
+	if id == 0 || (i > 0 && questions[i-1].TopicMaterialID == id) {
+		return ""
+	}
+	material, ok := materials[id]
+	if !ok {
+		return ""
+	}
+	return services.MaterialMarker(material)
+}
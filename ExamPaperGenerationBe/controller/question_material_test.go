@@ -0,0 +1,86 @@
+package controller
+
+import (
+	"encoding/json"
+	"graduation/component"
+	"graduation/entity"
+	"graduation/mapper"
+	"graduation/mapper/memory"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaterialController(t *testing.T) {
+	questions := memory.NewQuestionRepository(
+		entity.QuestionBank{Topic: "执行后 AX 的值为______", TopicType: "填空题"},
+		entity.QuestionBank{Topic: "执行后 BX 的值为______", TopicType: "填空题"},
+	)
+	audits := memory.NewAuditRepository()
+	mc := NewMaterialController(memory.NewMaterialRepository(), questions, component.NewAuditor(audits))
+	r := newTestRouter()
+	r.GET("/materials", mc.GetQuestionMaterials)
+	r.GET("/materials/:id", mc.GetQuestionMaterial)
+	r.POST("/materials", mc.CreateQuestionMaterial)
+	r.PUT("/materials/:id", mc.UpdateQuestionMaterial)
+	r.DELETE("/materials/:id", mc.DeleteQuestionMaterial)
+	r.POST("/materials/:id/questions", mc.LinkQuestions)
+	r.DELETE("/materials/:id/questions/:questionId", mc.UnlinkQuestion)
+
+	resp := doJSON(t, r, http.MethodPost, "/materials", map[string]interface{}{"title": ""})
+	require.Equal(t, 400, resp.Code)
+	resp = doJSON(t, r, http.MethodPost, "/materials", map[string]interface{}{
+		"material": "MOV AX, 1234H",
+		"tables":   []map[string]interface{}{{"rows": [][]string{}}},
+	})
+	require.Equal(t, 400, resp.Code)
+
+	resp = doJSON(t, r, http.MethodPost, "/materials", map[string]interface{}{
+		"title":    "阅读程序",
+		"material": "MOV AX, 1234H\nMOV BX, AX",
+		"tables":   []map[string]interface{}{{"header_row": true, "rows": [][]string{{"寄存器", "值"}}}},
+	})
+	require.Equal(t, 200, resp.Code, resp.Msg)
+	var created entity.QuestionMaterial
+	data, _ := json.Marshal(resp.Data)
+	require.NoError(t, json.Unmarshal(data, &created))
+	require.Equal(t, 1, created.ID)
+
+	resp = doJSON(t, r, http.MethodPost, "/materials/1/questions", map[string]interface{}{"question_ids": []int{1, 3}})
+	require.Equal(t, 400, resp.Code)
+	resp = doJSON(t, r, http.MethodPost, "/materials/1/questions", map[string]interface{}{"question_ids": []int{1, 2}})
+	require.Equal(t, 200, resp.Code, resp.Msg)
+	linked, _ := questions.GetQuestionBanksByMaterialId(1)
+	require.Len(t, linked, 2)
+
+	resp = doJSON(t, r, http.MethodGet, "/materials/1", nil)
+	require.Equal(t, 200, resp.Code)
+	require.Len(t, resp.Data.(map[string]interface{})["questions"], 2)
+
+	resp = doJSON(t, r, http.MethodPut, "/materials/1", map[string]interface{}{"material": "MOV AX, 5678H"})
+	require.Equal(t, 200, resp.Code, resp.Msg)
+	resp = doJSON(t, r, http.MethodGet, "/materials", nil)
+	require.Len(t, resp.Data, 1)
+	require.Equal(t, "MOV AX, 5678H", resp.Data.([]interface{})[0].(map[string]interface{})["material"])
+
+	resp = doJSON(t, r, http.MethodDelete, "/materials/1/questions/2", nil)
+	require.Equal(t, 200, resp.Code, resp.Msg)
+	resp = doJSON(t, r, http.MethodDelete, "/materials/1/questions/2", nil)
+	require.Equal(t, 400, resp.Code)
+
+	// 仍有题目引用时需要 force 才能删除
+	resp = doJSON(t, r, http.MethodDelete, "/materials/1", nil)
+	require.Equal(t, 400, resp.Code)
+	resp = doJSON(t, r, http.MethodDelete, "/materials/1?force=true", nil)
+	require.Equal(t, 200, resp.Code, resp.Msg)
+	q, _ := questions.GetQuestionBankById(1)
+	require.Zero(t, q[0].TopicMaterialID)
+	resp = doJSON(t, r, http.MethodGet, "/materials/1", nil)
+	require.Equal(t, 404, resp.Code)
+
+	logs, total, err := audits.QueryAuditLogs(mapper.AuditLogQuery{EntityType: component.AuditEntityMaterial, Limit: 100})
+	require.NoError(t, err)
+	require.EqualValues(t, 5, total)
+	require.Len(t, logs, 5)
+}
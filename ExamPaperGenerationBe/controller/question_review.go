@@ -0,0 +1,277 @@
+package controller
+
+import (
+	"fmt"
+	"graduation/component"
+	"graduation/entity"
+	"graduation/services"
+	"graduation/utils"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// reviewAuditActions 审核操作对应的审计日志操作类型
+var reviewAuditActions = map[string]string{
+	services.ReviewSubmit:  component.AuditSubmit,
+	services.ReviewApprove: component.AuditApprove,
+	services.ReviewReject:  component.AuditReject,
+	services.ReviewRetire:  component.AuditRetire,
+	services.ReviewRedraft: component.AuditRedraft,
+	services.ReviewReopen:  component.AuditReopen,
+}
+
+// reviewRequest 审核操作的请求，comment 为随操作填写的意见，reviewer 在提交审核和指定审核员时使用
+type reviewRequest struct {
+	IDs      []int  `json:"ids"`
+	Reviewer string `json:"reviewer"`
+	Comment  string `json:"comment"`
+}
+
+// reviewError 不能执行审核操作的题目及原因
+type reviewError struct {
+	ID     int    `json:"id"`
+	Reason string `json:"reason"`
+}
+
+// reviewTargets 解析审核请求并读取题目，有题目不存在时返回 400，失败时已写入响应
+func (c *QuestionBankController) reviewTargets(ctx *gin.Context) (reviewRequest, []entity.QuestionBank, bool) {
+	var req reviewRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.String(http.StatusBadRequest, utils.Make400Resp(err.Error()))
+		return req, nil, false
+	}
+	if len(req.IDs) == 0 {
+		ctx.String(http.StatusBadRequest, utils.Make400Resp("ids is required"))
+		return req, nil, false
+	}
+	req.Reviewer = strings.TrimSpace(req.Reviewer)
+	req.Comment = strings.TrimSpace(req.Comment)
+	questions, err := c.mapper.GetQuestionBanksInIds(req.IDs)
+	if err != nil {
+		ctx.String(http.StatusInternalServerError, utils.Make500Resp("查询题目失败"))
+		return req, nil, false
+	}
+	found := make(map[int]bool, len(questions))
+	for _, q := range questions {
+		found[q.ID] = true
+	}
+	var errs []reviewError
+	for _, id := range req.IDs {
+		if !found[id] {
+			errs = append(errs, reviewError{ID: id, Reason: "题目不存在"})
+		}
+	}
+	if len(errs) > 0 {
+		rejectReview(ctx, errs)
+		return req, nil, false
+	}
+	return req, questions, true
+}
+
+// rejectReview 有题目不能执行审核操作时返回 400，data.errors 列出这些题目及原因
+func rejectReview(ctx *gin.Context, errs []reviewError) {
+	ctx.String(http.StatusBadRequest, utils.MakeResp(http.StatusBadRequest,
+		fmt.Sprintf("%d 道题目不能执行该操作", len(errs)), map[string]interface{}{"errors": errs}))
+}
+
+// reviewQuestions 对请求中的全部题目执行审核操作，任一题目不满足条件时全部不执行。
+// 每道题目记录一条审核意见，没有填写意见时内容为空，用于查看审核经过
+func (c *QuestionBankController) reviewQuestions(ctx *gin.Context, action string) {
+	req, questions, ok := c.reviewTargets(ctx)
+	if !ok {
+		return
+	}
+	if action == services.ReviewReject && req.Comment == "" {
+		ctx.String(http.StatusBadRequest, utils.Make400Resp("退回时需要填写意见"))
+		return
+	}
+	username := component.CurrentUsername(ctx)
+	var status string
+	var errs []reviewError
+	for _, q := range questions {
+		next, err := services.NextQuestionStatus(q.Status, action)
+		if err != nil {
+			errs = append(errs, reviewError{ID: q.ID, Reason: err.Error()})
+			continue
+		}
+		// 指定了审核员的题目只能由该审核员通过或退回
+		if (action == services.ReviewApprove || action == services.ReviewReject) && q.Reviewer != "" && q.Reviewer != username {
+			errs = append(errs, reviewError{ID: q.ID, Reason: fmt.Sprintf("题目已指定审核员 %s", q.Reviewer)})
+			continue
+		}
+		status = next
+	}
+	if len(errs) > 0 {
+		rejectReview(ctx, errs)
+		return
+	}
+
+	ids := make([]int, 0, len(questions))
+	for _, q := range questions {
+		ids = append(ids, q.ID)
+	}
+	count, err := c.mapper.SetQuestionStatus(ids, status)
+	if err != nil {
+		ctx.String(http.StatusInternalServerError, utils.Make500Resp("修改审核状态失败"))
+		return
+	}
+	if action == services.ReviewSubmit && req.Reviewer != "" {
+		if _, err := c.mapper.SetQuestionReviewer(ids, req.Reviewer); err != nil {
+			ctx.String(http.StatusInternalServerError, utils.Make500Resp("指定审核员失败"))
+			return
+		}
+	}
+	for _, q := range questions {
+		comment := entity.QuestionReviewComment{QuestionID: q.ID, Action: action, Status: status, Author: username, Content: req.Comment}
+		if err := c.reviews.InsertReviewComment(&comment); err != nil {
+			log.Printf("Error saving review comment of question %d: %v", q.ID, err)
+		}
+		c.audit.Record(ctx, reviewAuditActions[action], component.AuditEntityQuestion, strconv.Itoa(q.ID),
+			map[string]string{"status": q.Status, "reviewer": q.Reviewer}, c.reviewState(q.ID))
+	}
+	ctx.String(http.StatusOK, utils.Make200Resp("Success", map[string]interface{}{
+		"count":  count,
+		"ids":    ids,
+		"status": status,
+	}))
+}
+
+// reopenReview 在修改或回滚题目之后调用，已通过的题目内容变化时回到草稿，重新审核通过前不会被自动组卷抽取。
+// 同时记录一条审核意见和审计日志，内容没有变化或题目不是已通过状态时不做处理
+func (c *QuestionBankController) reopenReview(ctx *gin.Context, before entity.QuestionBank) error {
+	if before.Status != entity.QuestionStatusApproved {
+		return nil
+	}
+	after, ok := c.auditQuestion(before.ID).(entity.QuestionBank)
+	if !ok || !services.QuestionContentChanged(before, after) {
+		return nil
+	}
+	status, err := services.NextQuestionStatus(before.Status, services.ReviewReopen)
+	if err != nil {
+		return err
+	}
+	if _, err := c.mapper.SetQuestionStatus([]int{before.ID}, status); err != nil {
+		return err
+	}
+	comment := entity.QuestionReviewComment{QuestionID: before.ID, Action: services.ReviewReopen, Status: status,
+		Author: component.CurrentUsername(ctx), Content: "题目内容已修改，需要重新审核"}
+	if err := c.reviews.InsertReviewComment(&comment); err != nil {
+		log.Printf("Error saving review comment of question %d: %v", before.ID, err)
+	}
+	c.audit.Record(ctx, component.AuditReopen, component.AuditEntityQuestion, strconv.Itoa(before.ID),
+		map[string]string{"status": before.Status, "reviewer": before.Reviewer}, c.reviewState(before.ID))
+	return nil
+}
+
+// reviewState 题目当前的审核状态和审核员，用于审计快照
+func (c *QuestionBankController) reviewState(id int) map[string]string {
+	found, err := c.mapper.GetQuestionBankById(id)
+	if err != nil || len(found) == 0 {
+		return nil
+	}
+	return map[string]string{"status": found[0].Status, "reviewer": found[0].Reviewer}
+}
+
+// 处理 POST /review/submit 请求，把草稿提交审核，可以同时指定审核员
+func (c *QuestionBankController) SubmitQuestions(ctx *gin.Context) {
+	c.reviewQuestions(ctx, services.ReviewSubmit)
+}
+
+// 处理 POST /review/approve 请求，审核通过后题目才会被自动组卷抽取
+func (c *QuestionBankController) ApproveQuestions(ctx *gin.Context) {
+	c.reviewQuestions(ctx, services.ReviewApprove)
+}
+
+// 处理 POST /review/reject 请求，把题目退回草稿，需要填写意见
+func (c *QuestionBankController) RejectQuestions(ctx *gin.Context) {
+	c.reviewQuestions(ctx, services.ReviewReject)
+}
+
+// 处理 POST /review/retire 请求，停用审核通过的题目
+func (c *QuestionBankController) RetireQuestions(ctx *gin.Context) {
+	c.reviewQuestions(ctx, services.ReviewRetire)
+}
+
+// 处理 POST /review/redraft 请求，撤回审核中的题目或重新启用停用的题目，题目回到草稿
+func (c *QuestionBankController) RedraftQuestions(ctx *gin.Context) {
+	c.reviewQuestions(ctx, services.ReviewRedraft)
+}
+
+// 处理 POST /review/assign 请求，指定或更换题目的审核员，reviewer 为空时取消指定
+func (c *QuestionBankController) AssignReviewer(ctx *gin.Context) {
+	req, questions, ok := c.reviewTargets(ctx)
+	if !ok {
+		return
+	}
+	ids := make([]int, 0, len(questions))
+	for _, q := range questions {
+		ids = append(ids, q.ID)
+	}
+	count, err := c.mapper.SetQuestionReviewer(ids, req.Reviewer)
+	if err != nil {
+		ctx.String(http.StatusInternalServerError, utils.Make500Resp("指定审核员失败"))
+		return
+	}
+	for _, q := range questions {
+		c.audit.Record(ctx, component.AuditAssign, component.AuditEntityQuestion, strconv.Itoa(q.ID),
+			map[string]string{"reviewer": q.Reviewer}, map[string]string{"reviewer": req.Reviewer})
+	}
+	ctx.String(http.StatusOK, utils.Make200Resp("Success", map[string]interface{}{
+		"count":    count,
+		"ids":      ids,
+		"reviewer": req.Reviewer,
+	}))
+}
+
+// 处理 GET /questions/:id/comments 请求，按时间先后列出题目的审核意见和状态变化
+func (c *QuestionBankController) GetReviewComments(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.String(http.StatusBadRequest, utils.Make400Resp("Invalid ID"))
+		return
+	}
+	comments, err := c.reviews.GetReviewComments(id)
+	if err != nil {
+		ctx.String(http.StatusInternalServerError, utils.Make500Resp("查询审核意见失败"))
+		return
+	}
+	if comments == nil {
+		comments = []entity.QuestionReviewComment{}
+	}
+	ctx.String(http.StatusOK, utils.Make200Resp("Success", comments))
+}
+
+// 处理 POST /questions/:id/comments 请求，对题目发表意见，不改变审核状态。
+// 出题人和审核员都可以发表意见
+func (c *QuestionBankController) AddReviewComment(ctx *gin.Context) {
+	if !component.HasPermission(ctx, component.PermQuestionWrite) && !component.HasPermission(ctx, component.PermQuestionReview) {
+		component.Forbidden(ctx)
+		return
+	}
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.String(http.StatusBadRequest, utils.Make400Resp("Invalid ID"))
+		return
+	}
+	var req struct {
+		Content string `json:"content"`
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil || strings.TrimSpace(req.Content) == "" {
+		ctx.String(http.StatusBadRequest, utils.Make400Resp("content is required"))
+		return
+	}
+	if found, err := c.mapper.GetQuestionBankById(id); err != nil || len(found) == 0 {
+		ctx.String(http.StatusNotFound, utils.MakeResp(http.StatusNotFound, "Question not found", nil))
+		return
+	}
+	comment := entity.QuestionReviewComment{QuestionID: id, Author: component.CurrentUsername(ctx), Content: strings.TrimSpace(req.Content)}
+	if err := c.reviews.InsertReviewComment(&comment); err != nil {
+		ctx.String(http.StatusInternalServerError, utils.Make500Resp("保存审核意见失败"))
+		return
+	}
+	ctx.String(http.StatusOK, utils.Make200Resp("Success", comment))
+}
@@ -0,0 +1,156 @@
+package controller
+
+import (
+	"encoding/json"
+	"graduation/component"
+	"graduation/entity"
+	"graduation/mapper"
+	"graduation/mapper/memory"
+	"graduation/services"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuestionReview(t *testing.T) {
+	questions := memory.NewQuestionRepository(
+		entity.QuestionBank{Topic: "8086 有几个段寄存器？", Answer: "4", TopicType: "填空题", Score: 2},
+		entity.QuestionBank{Topic: "8259A 的作用", Answer: "中断控制", TopicType: "简答题", Score: 10},
+	)
+	reviews := memory.NewReviewRepository()
+	users := memory.NewUserRepository()
+	tokens := newTestTokenManager(t)
+	qc := NewQuestionBankController(questions, memory.NewAttachmentRepository(), memory.NewRevisionRepository(), reviews,
+		memory.NewHistoryRepository(), memory.NewLabelRepository(), component.NewAuditor(memory.NewAuditRepository()))
+	r := newTestRouter()
+	r.Use(component.LoginHandlerInterceptor(tokens, component.NewApiKeyManager(memory.NewApiKeyRepository(), users)))
+	r.GET("/getQuestionBank", qc.GetQuestionBank)
+	r.POST("/review/submit", component.RequirePermission(component.PermQuestionWrite), qc.SubmitQuestions)
+	r.POST("/review/approve", component.RequirePermission(component.PermQuestionReview), qc.ApproveQuestions)
+	r.POST("/review/reject", component.RequirePermission(component.PermQuestionReview), qc.RejectQuestions)
+	r.POST("/review/retire", component.RequirePermission(component.PermQuestionReview), qc.RetireQuestions)
+	r.POST("/review/assign", component.RequirePermission(component.PermQuestionReview), qc.AssignReviewer)
+	r.GET("/questions/:id/comments", qc.GetReviewComments)
+	r.POST("/questions/:id/comments", qc.AddReviewComment)
+
+	token := func(username, role string) string {
+		pair, err := tokens.Issue(entity.User{Username: username, UserRole: role})
+		require.NoError(t, err)
+		return pair.AccessToken
+	}
+	teacher, alice, bob := token("teacher", component.RoleTeacher), token("alice", component.RoleReviewer), token("bob", component.RoleReviewer)
+	status := func(id int) entity.QuestionBank {
+		found, err := questions.GetQuestionBankById(id)
+		require.NoError(t, err)
+		return found[0]
+	}
+
+	// 新题目为草稿，教师没有审核权限
+	require.Equal(t, entity.QuestionStatusDraft, status(1).Status)
+	resp := doJSONWithToken(t, r, teacher, http.MethodPost, "/review/approve", map[string]interface{}{"ids": []int{1}})
+	require.Equal(t, http.StatusForbidden, resp.Code)
+
+	resp = doJSONWithToken(t, r, teacher, http.MethodPost, "/review/submit", map[string]interface{}{"ids": []int{1, 2}, "reviewer": "alice", "comment": "请审核"})
+	require.Equal(t, 200, resp.Code, resp.Msg)
+	require.Equal(t, entity.QuestionStatusInReview, status(2).Status)
+	require.Equal(t, "alice", status(2).Reviewer)
+
+	resp = doJSONWithToken(t, r, teacher, http.MethodPost, "/review/submit", map[string]interface{}{"ids": []int{99}})
+	require.Equal(t, 400, resp.Code)
+	require.Len(t, resp.Data.(map[string]interface{})["errors"], 1)
+
+	// 指定了审核员的题目只能由该审核员审核，任一题目不满足条件时全部不执行
+	resp = doJSONWithToken(t, r, bob, http.MethodPost, "/review/approve", map[string]interface{}{"ids": []int{1, 2}})
+	require.Equal(t, 400, resp.Code)
+	require.Len(t, resp.Data.(map[string]interface{})["errors"], 2)
+	// 退回需要填写意见
+	resp = doJSONWithToken(t, r, alice, http.MethodPost, "/review/reject", map[string]interface{}{"ids": []int{2}})
+	require.Equal(t, 400, resp.Code)
+	resp = doJSONWithToken(t, r, alice, http.MethodPost, "/review/reject", map[string]interface{}{"ids": []int{2}, "comment": "答案不完整"})
+	require.Equal(t, 200, resp.Code, resp.Msg)
+	require.Equal(t, entity.QuestionStatusDraft, status(2).Status)
+
+	resp = doJSONWithToken(t, r, alice, http.MethodPost, "/review/assign", map[string]interface{}{"ids": []int{1}, "reviewer": "bob"})
+	require.Equal(t, 200, resp.Code, resp.Msg)
+	resp = doJSONWithToken(t, r, bob, http.MethodPost, "/review/approve", map[string]interface{}{"ids": []int{1}})
+	require.Equal(t, 200, resp.Code, resp.Msg)
+	require.Equal(t, entity.QuestionStatusApproved, status(1).Status)
+
+	// 按审核状态和审核员查询
+	resp = doJSONWithToken(t, r, alice, http.MethodGet, "/getQuestionBank?status=draft&reviewer=alice", nil)
+	require.Equal(t, 200, resp.Code, resp.Msg)
+	require.EqualValues(t, 1, resp.Data.(map[string]interface{})["total"])
+
+	resp = doJSONWithToken(t, r, teacher, http.MethodPost, "/questions/2/comments", map[string]interface{}{"content": "已补充答案"})
+	require.Equal(t, 200, resp.Code, resp.Msg)
+	resp = doJSONWithToken(t, r, teacher, http.MethodGet, "/questions/2/comments", nil)
+	require.Equal(t, 200, resp.Code, resp.Msg)
+	data, err := json.Marshal(resp.Data)
+	require.NoError(t, err)
+	var comments []entity.QuestionReviewComment
+	require.NoError(t, json.Unmarshal(data, &comments))
+	require.Len(t, comments, 3)
+	require.Equal(t, services.ReviewSubmit, comments[0].Action)
+	require.Equal(t, "答案不完整", comments[1].Content)
+	require.Equal(t, entity.QuestionStatusDraft, comments[1].Status)
+	require.Empty(t, comments[2].Action)
+	require.Equal(t, "teacher", comments[2].Author)
+
+	resp = doJSONWithToken(t, r, alice, http.MethodPost, "/review/retire", map[string]interface{}{"ids": []int{1}})
+	require.Equal(t, 200, resp.Code, resp.Msg)
+	require.Equal(t, entity.QuestionStatusRetired, status(1).Status)
+}
+
+func TestQuestionReviewReopenAfterEdit(t *testing.T) {
+	questions := memory.NewQuestionRepository(
+		entity.QuestionBank{Topic: "8086 有几个段寄存器？", Answer: "4", TopicType: "填空题", Score: 2, Difficulty: 1, Status: entity.QuestionStatusApproved},
+	)
+	reviews := memory.NewReviewRepository()
+	logs := memory.NewAuditRepository()
+	qc := NewQuestionBankController(questions, memory.NewAttachmentRepository(), memory.NewRevisionRepository(), reviews,
+		memory.NewHistoryRepository(), memory.NewLabelRepository(), component.NewAuditor(logs))
+	r := newTestRouter()
+	r.POST("/updateQuestionBankById", qc.UpdateQuestionBankById)
+	r.POST("/questions/:id/revisions/:revision/rollback", qc.RollbackQuestion)
+
+	update := func(answer string) {
+		t.Helper()
+		raw, err := json.Marshal(map[string]interface{}{"id": "1", "topic": "8086 有几个段寄存器？", "answer": answer, "topic_type": "填空题", "score": 2, "difficulty": "1"})
+		require.NoError(t, err)
+		resp := doMultipart(t, r, "/updateQuestionBankById", map[string]string{"data": string(raw)}, nil)
+		require.Equal(t, 200, resp.Code, resp.Msg)
+	}
+	status := func() string {
+		found, err := questions.GetQuestionBankById(1)
+		require.NoError(t, err)
+		return found[0].Status
+	}
+
+	// 内容没有变化的保存不影响审核状态
+	update("4")
+	require.Equal(t, entity.QuestionStatusApproved, status())
+
+	// 已通过的题目修改后回到草稿，记录审核意见和审计日志
+	update("3")
+	require.Equal(t, entity.QuestionStatusDraft, status())
+	comments, err := reviews.GetReviewComments(1)
+	require.NoError(t, err)
+	require.Len(t, comments, 1)
+	require.Equal(t, services.ReviewReopen, comments[0].Action)
+	require.Equal(t, entity.QuestionStatusDraft, comments[0].Status)
+	entries, _, err := logs.QueryAuditLogs(mapper.AuditLogQuery{Action: component.AuditReopen})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+
+	// 回滚已通过的题目同样需要重新审核
+	_, err = questions.SetQuestionStatus([]int{1}, entity.QuestionStatusApproved)
+	require.NoError(t, err)
+	resp := doJSON(t, r, http.MethodPost, "/questions/1/revisions/1/rollback", nil)
+	require.Equal(t, 200, resp.Code, resp.Msg)
+	require.Equal(t, entity.QuestionStatusDraft, resp.Data.(map[string]interface{})["question"].(map[string]interface{})["status"])
+	require.Equal(t, entity.QuestionStatusDraft, status())
+	comments, err = reviews.GetReviewComments(1)
+	require.NoError(t, err)
+	require.Len(t, comments, 2)
+}
@@ -0,0 +1,159 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"graduation/component"
+	"graduation/entity"
+	"graduation/services"
+	"graduation/utils"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// revisionDiffView 两个版本之间发生变化的字段，From 为 0 时与空内容比较
+type revisionDiffView struct {
+	QuestionID int                              `json:"question_id"`
+	From       int                              `json:"from"`
+	To         int                              `json:"to"`
+	Changes    map[string]component.AuditChange `json:"changes"`
+}
+
+// revisionPath 解析路径中的题目 ID 和版本号并读取该版本，失败时已写入响应
+func (c *QuestionBankController) revisionPath(ctx *gin.Context) (entity.QuestionRevision, bool) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.String(http.StatusBadRequest, utils.Make400Resp("Invalid ID"))
+		return entity.QuestionRevision{}, false
+	}
+	number, err := strconv.Atoi(ctx.Param("revision"))
+	if err != nil {
+		ctx.String(http.StatusBadRequest, utils.Make400Resp("Invalid revision"))
+		return entity.QuestionRevision{}, false
+	}
+	return c.findRevision(ctx, id, number)
+}
+
+// findRevision 读取题目的指定版本，失败时已写入响应
+func (c *QuestionBankController) findRevision(ctx *gin.Context, id, number int) (entity.QuestionRevision, bool) {
+	revision, err := c.revisions.GetQuestionRevision(id, number)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		ctx.String(http.StatusNotFound, utils.MakeResp(http.StatusNotFound, "Revision not found", nil))
+		return entity.QuestionRevision{}, false
+	}
+	if err != nil {
+		ctx.String(http.StatusInternalServerError, utils.Make500Resp("查询题目版本失败"))
+		return entity.QuestionRevision{}, false
+	}
+	return revision, true
+}
+
+// snapshotFields 把快照转换为字段表，用于比较
+func snapshotFields(snapshot entity.QuestionSnapshot) map[string]interface{} {
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return nil
+	}
+	var fields map[string]interface{}
+	json.Unmarshal(data, &fields)
+	return fields
+}
+
+// 处理 GET /questions/:id/revisions 请求，按版本号倒序列出题目的全部版本。
+// 开始记录版本之前已有、之后没有修改过的题目没有版本
+func (c *QuestionBankController) GetQuestionRevisions(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.String(http.StatusBadRequest, utils.Make400Resp("Invalid ID"))
+		return
+	}
+	revisions, err := c.revisions.GetQuestionRevisions(id)
+	if err != nil {
+		ctx.String(http.StatusInternalServerError, utils.Make500Resp("查询题目版本失败"))
+		return
+	}
+	ctx.String(http.StatusOK, utils.Make200Resp("Success", revisions))
+}
+
+// 处理 GET /questions/:id/revisions/:revision 请求，返回指定版本的完整内容
+func (c *QuestionBankController) GetQuestionRevision(ctx *gin.Context) {
+	revision, ok := c.revisionPath(ctx)
+	if !ok {
+		return
+	}
+	ctx.String(http.StatusOK, utils.Make200Resp("Success", revision))
+}
+
+// 处理 GET /questions/:id/revisions/:revision/diff 请求，返回该版本相对 from 版本变化的字段，
+// from 默认为上一个版本
+func (c *QuestionBankController) DiffQuestionRevision(ctx *gin.Context) {
+	to, ok := c.revisionPath(ctx)
+	if !ok {
+		return
+	}
+	from := to.Revision - 1
+	if v := ctx.Query("from"); v != "" {
+		var err error
+		if from, err = strconv.Atoi(v); err != nil || from < 0 {
+			ctx.String(http.StatusBadRequest, utils.Make400Resp("Invalid from"))
+			return
+		}
+	}
+	var before map[string]interface{}
+	if from > 0 {
+		revision, ok := c.findRevision(ctx, to.QuestionID, from)
+		if !ok {
+			return
+		}
+		before = snapshotFields(revision.Snapshot)
+	}
+	ctx.String(http.StatusOK, utils.Make200Resp("Success", revisionDiffView{
+		QuestionID: to.QuestionID,
+		From:       from,
+		To:         to.Revision,
+		Changes:    component.AuditDiff(before, snapshotFields(to.Snapshot)),
+	}))
+}
+
+// 处理 POST /questions/:id/revisions/:revision/rollback 请求，用指定版本的内容覆盖题目，
+// 回滚本身也记录为一个新版本，之后仍可以回到回滚前的内容。题目的附件不随版本回滚
+func (c *QuestionBankController) RollbackQuestion(ctx *gin.Context) {
+	revision, ok := c.revisionPath(ctx)
+	if !ok {
+		return
+	}
+	found, err := c.mapper.GetQuestionBankById(revision.QuestionID)
+	if err != nil || len(found) == 0 {
+		ctx.String(http.StatusNotFound, utils.MakeResp(http.StatusNotFound, "Question not found", nil))
+		return
+	}
+	old := found[0]
+	restored := revision.Snapshot.Question(old.ID)
+	restored.UpdateTime = time.Now()
+	c.baselineRevision(old)
+	if _, err := c.mapper.ReplaceQuestionBank(&restored); err != nil {
+		log.Printf("Error rolling back question %d to revision %d: %v", old.ID, revision.Revision, err)
+		ctx.String(http.StatusInternalServerError, utils.Make500Resp("回滚题目失败"))
+		return
+	}
+	c.saveLegacyAttachments(&restored, &old)
+	services.ReleaseMedia(ctx.Request.Context(), services.QuestionMediaRefs(old)...)
+	created := c.recordRevision(ctx, old.ID, entity.RevisionRollback, revision.Revision)
+	c.audit.Record(ctx, component.AuditRollback, component.AuditEntityQuestion, strconv.Itoa(old.ID), old, c.auditQuestion(old.ID))
+	if err := c.reopenReview(ctx, old); err != nil {
+		log.Printf("Error reopening review of question %d: %v", old.ID, err)
+		ctx.String(http.StatusInternalServerError, utils.Make500Resp("题目已回滚，但修改审核状态失败"))
+		return
+	}
+	after := c.auditQuestion(old.ID)
+
+	ctx.String(http.StatusOK, utils.Make200Resp("Success", map[string]interface{}{
+		"question": after,
+		"revision": created, // 内容与回滚前相同时为 null
+	}))
+}
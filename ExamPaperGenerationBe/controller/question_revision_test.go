@@ -0,0 +1,113 @@
+package controller
+
+import (
+	"encoding/json"
+	"graduation/component"
+	"graduation/entity"
+	"graduation/mapper"
+	"graduation/mapper/memory"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuestionRevisions(t *testing.T) {
+	questions := memory.NewQuestionRepository(
+		entity.QuestionBank{Topic: "8086 有几个段寄存器？", Answer: "3", TopicType: "填空题", Score: 2, Difficulty: 1},
+	)
+	revisions := memory.NewRevisionRepository()
+	logs := memory.NewAuditRepository()
+	qc := NewQuestionBankController(questions, memory.NewAttachmentRepository(), revisions, memory.NewReviewRepository(), memory.NewHistoryRepository(), memory.NewLabelRepository(), component.NewAuditor(logs))
+	r := newTestRouter()
+	r.POST("/insertSingleQuestionBank", qc.InsertSingleQuestionBank)
+	r.POST("/updateQuestionBankById", qc.UpdateQuestionBankById)
+	r.GET("/deleteSingleQuestionBank", qc.DeleteSingleQuestionBank)
+	r.POST("/recycleBin/purge", qc.PurgeQuestions)
+	r.GET("/questions/:id/revisions", qc.GetQuestionRevisions)
+	r.GET("/questions/:id/revisions/:revision", qc.GetQuestionRevision)
+	r.GET("/questions/:id/revisions/:revision/diff", qc.DiffQuestionRevision)
+	r.POST("/questions/:id/revisions/:revision/rollback", qc.RollbackQuestion)
+
+	update := func(data map[string]interface{}) {
+		t.Helper()
+		raw, err := json.Marshal(data)
+		require.NoError(t, err)
+		resp := doMultipart(t, r, "/updateQuestionBankById", map[string]string{"data": string(raw)}, nil)
+		require.Equal(t, 200, resp.Code, resp.Msg)
+	}
+	list := func(id string) []entity.QuestionRevision {
+		t.Helper()
+		resp := doJSON(t, r, http.MethodGet, "/questions/"+id+"/revisions", nil)
+		require.Equal(t, 200, resp.Code, resp.Msg)
+		raw, err := json.Marshal(resp.Data)
+		require.NoError(t, err)
+		var out []entity.QuestionRevision
+		require.NoError(t, json.Unmarshal(raw, &out))
+		return out
+	}
+
+	// 已有题目第一次修改时补记修改前的内容
+	require.Empty(t, list("1"))
+	update(map[string]interface{}{"id": "1", "topic": "8086 有几个段寄存器？", "answer": "4", "topic_type": "填空题", "score": 2, "difficulty": "2"})
+	got := list("1")
+	require.Len(t, got, 2)
+	require.Equal(t, entity.RevisionUpdate, got[0].Action)
+	require.Equal(t, "4", got[0].Snapshot.Answer)
+	require.Equal(t, entity.RevisionInitial, got[1].Action)
+	require.Equal(t, "3", got[1].Snapshot.Answer)
+
+	// 内容没有变化的保存不产生新版本
+	update(map[string]interface{}{"id": "1", "topic": "8086 有几个段寄存器？", "answer": "4", "topic_type": "填空题", "score": 2, "difficulty": "2"})
+	require.Len(t, list("1"), 2)
+
+	resp := doJSON(t, r, http.MethodGet, "/questions/1/revisions/2/diff", nil)
+	require.Equal(t, 200, resp.Code, resp.Msg)
+	diff := resp.Data.(map[string]interface{})
+	require.EqualValues(t, 1, diff["from"])
+	require.Equal(t, map[string]interface{}{
+		"answer":     map[string]interface{}{"before": "3", "after": "4"},
+		"difficulty": map[string]interface{}{"before": float64(1), "after": float64(2)},
+	}, diff["changes"])
+
+	// 回滚到补记的版本，回滚本身也是一个新版本
+	resp = doJSON(t, r, http.MethodPost, "/questions/1/revisions/1/rollback", nil)
+	require.Equal(t, 200, resp.Code, resp.Msg)
+	q, _ := questions.GetQuestionBankById(1)
+	require.Equal(t, "3", q[0].Answer)
+	require.Equal(t, 1, q[0].Difficulty)
+	got = list("1")
+	require.Len(t, got, 3)
+	require.Equal(t, entity.RevisionRollback, got[0].Action)
+	require.Equal(t, 1, got[0].Source)
+	resp = doJSON(t, r, http.MethodGet, "/questions/1/revisions/3/diff?from=1", nil)
+	require.Empty(t, resp.Data.(map[string]interface{})["changes"])
+	entries, _, err := logs.QueryAuditLogs(mapper.AuditLogQuery{Action: component.AuditRollback})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+
+	resp = doJSON(t, r, http.MethodGet, "/questions/1/revisions/2", nil)
+	require.Equal(t, 200, resp.Code, resp.Msg)
+	require.Equal(t, 404, doJSON(t, r, http.MethodPost, "/questions/1/revisions/9/rollback", nil).Code)
+	require.Equal(t, 404, doJSON(t, r, http.MethodGet, "/questions/1/revisions/3/diff?from=9", nil).Code)
+	require.Equal(t, 400, doJSON(t, r, http.MethodGet, "/questions/1/revisions/latest", nil).Code)
+
+	// 新增的题目从 create 版本开始，删除题目时版本一起删除
+	resp = doJSON(t, r, http.MethodPost, "/insertSingleQuestionBank", map[string]interface{}{
+		"topic": "8259A 的作用", "answer": "中断控制", "topic_type": "简答题", "difficulty": "3",
+	})
+	require.Equal(t, 200, resp.Code, resp.Msg)
+	got = list("2")
+	require.Len(t, got, 1)
+	require.Equal(t, entity.RevisionCreate, got[0].Action)
+	require.Equal(t, "中断控制", got[0].Snapshot.Answer)
+
+	// 移入回收站时保留历史版本，彻底删除时一并删除
+	resp = doJSON(t, r, http.MethodGet, "/deleteSingleQuestionBank?id=1", nil)
+	require.Equal(t, 200, resp.Code, resp.Msg)
+	require.Len(t, list("1"), 3)
+	resp = doJSON(t, r, http.MethodPost, "/recycleBin/purge", map[string]interface{}{"ids": []int{1}})
+	require.Equal(t, 200, resp.Code, resp.Msg)
+	require.Empty(t, list("1"))
+	require.Len(t, list("2"), 1)
+}
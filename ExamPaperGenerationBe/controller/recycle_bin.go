@@ -0,0 +1,191 @@
+package controller
+
+import (
+	"fmt"
+	"graduation/component"
+	"graduation/entity"
+	"graduation/services"
+	"graduation/utils"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// recycleBinRequest 恢复或彻底删除回收站中题目的请求，all 为 true 时对回收站中的全部题目生效
+type recycleBinRequest struct {
+	IDs []int `json:"ids"`
+	All bool  `json:"all"`
+}
+
+// rejectLockedQuestions 题目被已锁定的试卷引用时拒绝删除并写入响应，返回是否已拒绝
+func (c *QuestionBankController) rejectLockedQuestions(ctx *gin.Context, ids []int) bool {
+	locked, err := c.history.GetLockedQuestionIds(ids)
+	if err != nil {
+		ctx.String(http.StatusInternalServerError, utils.Make500Resp("查询试卷引用失败"))
+		return true
+	}
+	if len(locked) == 0 {
+		return false
+	}
+	ctx.String(http.StatusBadRequest, utils.MakeResp(http.StatusBadRequest,
+		fmt.Sprintf("%d 道题目被已锁定的试卷引用，不能删除", len(locked)), map[string]interface{}{"question_ids": locked}))
+	return true
+}
+
+// purgeQuestions 彻底删除回收站中的题目，附件、历史版本和审核意见一并删除，不再被引用的图片也一并删除，返回实际删除的题目。
+// 删除题目失败时返回错误，附件、历史版本、审核意见和图片都保持不变；检查之后试卷才被锁定而未删除的题目，关联数据同样保持不变
+func (c *QuestionBankController) purgeQuestions(ctx *gin.Context, questions []entity.QuestionBank) ([]entity.QuestionBank, error) {
+	ids := make([]int, 0, len(questions))
+	for _, q := range questions {
+		ids = append(ids, q.ID)
+	}
+	count, err := c.mapper.PurgeQuestionBanks(ids)
+	if err != nil {
+		return nil, err
+	}
+	if count < int64(len(ids)) {
+		kept, err := c.mapper.GetDeletedQuestionBanksInIds(ids)
+		if err != nil {
+			return nil, err
+		}
+		keptIds := make(map[int]bool, len(kept))
+		for _, q := range kept {
+			keptIds[q.ID] = true
+		}
+		var purged []entity.QuestionBank
+		ids = ids[:0]
+		for _, q := range questions {
+			if !keptIds[q.ID] {
+				purged = append(purged, q)
+				ids = append(ids, q.ID)
+			}
+		}
+		questions = purged
+	}
+	var refs []string
+	for _, q := range questions {
+		refs = append(refs, services.QuestionMediaRefs(q)...)
+	}
+	attachments, _ := c.attachments.GetAttachmentsInQuestionIds(ids)
+	for _, a := range attachments {
+		refs = append(refs, a.Path)
+	}
+	for _, id := range ids {
+		if _, err := c.attachments.DeleteAttachmentsByQuestionId(id); err != nil {
+			log.Printf("Error deleting attachments of question %d: %v", id, err)
+		}
+		revisions, _ := c.revisions.GetQuestionRevisions(id)
+		for _, r := range revisions {
+			refs = append(refs, services.QuestionMediaRefs(r.Snapshot.Question(id))...)
+		}
+	}
+	if _, err := c.revisions.DeleteQuestionRevisions(ids); err != nil {
+		log.Printf("Error deleting revisions of purged questions: %v", err)
+	}
+	if _, err := c.reviews.DeleteReviewComments(ids); err != nil {
+		log.Printf("Error deleting review comments of purged questions: %v", err)
+	}
+	services.ReleaseMedia(ctx.Request.Context(), refs...)
+	return questions, nil
+}
+
+// deletedQuestions 按请求读取回收站中的题目，失败时已写入响应
+func (c *QuestionBankController) deletedQuestions(ctx *gin.Context) ([]entity.QuestionBank, bool) {
+	var req recycleBinRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.String(http.StatusBadRequest, utils.Make400Resp(err.Error()))
+		return nil, false
+	}
+	if !req.All && len(req.IDs) == 0 {
+		ctx.String(http.StatusBadRequest, utils.Make400Resp("ids is required"))
+		return nil, false
+	}
+	var questions []entity.QuestionBank
+	var err error
+	if req.All {
+		questions, err = c.mapper.GetDeletedQuestionBanks()
+	} else {
+		questions, err = c.mapper.GetDeletedQuestionBanksInIds(req.IDs)
+	}
+	if err != nil {
+		ctx.String(http.StatusInternalServerError, utils.Make500Resp("查询回收站失败"))
+		return nil, false
+	}
+	return questions, true
+}
+
+// 处理 GET /recycleBin 请求，按删除时间倒序列出回收站中的题目
+func (c *QuestionBankController) GetRecycleBin(ctx *gin.Context) {
+	questions, err := c.mapper.GetDeletedQuestionBanks()
+	if err != nil {
+		ctx.String(http.StatusInternalServerError, utils.Make500Resp("查询回收站失败"))
+		return
+	}
+	if questions == nil {
+		questions = []entity.QuestionBank{}
+	}
+	fillQuestionImageURLs(questions)
+	ctx.String(http.StatusOK, utils.Make200Resp("Success", questions))
+}
+
+// 处理 POST /recycleBin/restore 请求，把回收站中的题目恢复到题库，附件和历史版本保持不变
+func (c *QuestionBankController) RestoreQuestions(ctx *gin.Context) {
+	questions, ok := c.deletedQuestions(ctx)
+	if !ok {
+		return
+	}
+	ids := make([]int, 0, len(questions))
+	for _, q := range questions {
+		ids = append(ids, q.ID)
+	}
+	count, err := c.mapper.RestoreQuestionBanks(ids)
+	if err != nil {
+		ctx.String(http.StatusInternalServerError, utils.Make500Resp("恢复题目失败"))
+		return
+	}
+	for _, q := range questions {
+		c.audit.Record(ctx, component.AuditRestore, component.AuditEntityQuestion, strconv.Itoa(q.ID), nil, c.auditQuestion(q.ID))
+	}
+	ctx.String(http.StatusOK, utils.Make200Resp("Success", map[string]interface{}{
+		"restoreCount": count,
+		"ids":          ids,
+	}))
+}
+
+// 处理 POST /recycleBin/purge 请求，彻底删除回收站中的题目，被已锁定的试卷引用的题目不能删除
+func (c *QuestionBankController) PurgeQuestions(ctx *gin.Context) {
+	questions, ok := c.deletedQuestions(ctx)
+	if !ok {
+		return
+	}
+	ids := make([]int, 0, len(questions))
+	for _, q := range questions {
+		ids = append(ids, q.ID)
+	}
+	if c.rejectLockedQuestions(ctx, ids) {
+		return
+	}
+	purged, err := c.purgeQuestions(ctx, questions)
+	if err != nil {
+		log.Printf("Error purging questions %v: %v", ids, err)
+		ctx.String(http.StatusInternalServerError, utils.Make500Resp("彻底删除题目失败"))
+		return
+	}
+	ids = ids[:0]
+	for _, q := range purged {
+		ids = append(ids, q.ID)
+	}
+	count := len(purged)
+	if count > 0 {
+		c.audit.Record(ctx, component.AuditPurge, component.AuditEntityQuestion, "", map[string]interface{}{
+			"ids":       ids,
+			"questions": purged,
+		}, nil)
+	}
+	ctx.String(http.StatusOK, utils.Make200Resp("Success", map[string]interface{}{
+		"purgeCount": count,
+		"ids":        ids,
+	}))
+}
@@ -0,0 +1,194 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"graduation/component"
+	"graduation/entity"
+	"graduation/mapper/memory"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecycleBin(t *testing.T) {
+	questions := memory.NewQuestionRepository(
+		entity.QuestionBank{Topic: "8086 有几个段寄存器？", Answer: "4", TopicType: "填空题", Score: 2},
+		entity.QuestionBank{Topic: "8259A 的作用", Answer: "中断控制", TopicType: "简答题", Score: 10},
+	)
+	attachments := memory.NewAttachmentRepository(entity.QuestionAttachment{QuestionID: 2, Name: "1", Path: "media:a"})
+	history := memory.NewHistoryRepository()
+	auditor := component.NewAuditor(memory.NewAuditRepository())
+	qc := NewQuestionBankController(questions, attachments, memory.NewRevisionRepository(), memory.NewReviewRepository(), history, memory.NewLabelRepository(), auditor)
+	hc := NewHistoryController(history, questions, memory.NewMaterialRepository(), attachments, auditor)
+	r := newTestRouter()
+	r.GET("/deleteSingleQuestionBank", qc.DeleteSingleQuestionBank)
+	r.GET("/recycleBin", qc.GetRecycleBin)
+	r.POST("/recycleBin/restore", qc.RestoreQuestions)
+	r.POST("/recycleBin/purge", qc.PurgeQuestions)
+	r.POST("/lockTestPaper", hc.LockTestPaper)
+	r.GET("/deleteQuestionGenHistoryByTestPaperUid", hc.DeleteQuestionGenHistoryByTestPaperUid)
+
+	recycleBin := func() []entity.QuestionBank {
+		t.Helper()
+		resp := doJSON(t, r, http.MethodGet, "/recycleBin", nil)
+		require.Equal(t, 200, resp.Code, resp.Msg)
+		data, err := json.Marshal(resp.Data)
+		require.NoError(t, err)
+		var got []entity.QuestionBank
+		require.NoError(t, json.Unmarshal(data, &got))
+		return got
+	}
+
+	// 删除后题目进入回收站，不再出现在题库中
+	resp := doJSON(t, r, http.MethodGet, "/deleteSingleQuestionBank?id=1", nil)
+	require.Equal(t, 200, resp.Code, resp.Msg)
+	found, _ := questions.GetQuestionBankById(1)
+	require.Empty(t, found)
+	require.Equal(t, []int{1}, questionIds(recycleBin()))
+
+	resp = doJSON(t, r, http.MethodPost, "/recycleBin/restore", map[string]interface{}{"ids": []int{1}})
+	require.Equal(t, 200, resp.Code, resp.Msg)
+	require.Empty(t, recycleBin())
+	found, _ = questions.GetQuestionBankById(1)
+	require.Len(t, found, 1)
+
+	// 被已锁定的试卷引用的题目不能删除，试卷本身也不能删除
+	_, err := history.InsertTestPaperGenHistory(entity.TestPaperGenHistory{TestPaperUID: "p1", TestPaperName: "期中试卷", UpdateTime: time.Now()})
+	require.NoError(t, err)
+	_, err = history.InsertQuestionGenHistories([]entity.QuestionGenHistory{{TestPaperUID: "p1", QuestionBankID: 2}})
+	require.NoError(t, err)
+	resp = doJSON(t, r, http.MethodPost, "/lockTestPaper?test_paper_uid=p1&locked=true", nil)
+	require.Equal(t, 200, resp.Code, resp.Msg)
+	resp = doJSON(t, r, http.MethodPost, "/lockTestPaper?test_paper_uid=p2", nil)
+	require.Equal(t, 404, resp.Code)
+
+	resp = doJSON(t, r, http.MethodGet, "/deleteSingleQuestionBank?id=2", nil)
+	require.Equal(t, 400, resp.Code)
+	require.Equal(t, map[string]interface{}{"question_ids": []interface{}{float64(2)}}, resp.Data)
+	resp = doJSON(t, r, http.MethodGet, "/deleteQuestionGenHistoryByTestPaperUid?test_paper_uid=p1", nil)
+	require.Equal(t, 400, resp.Code)
+
+	// 解锁后可以删除，彻底删除时附件一并删除
+	resp = doJSON(t, r, http.MethodPost, "/lockTestPaper?test_paper_uid=p1&locked=false", nil)
+	require.Equal(t, 200, resp.Code, resp.Msg)
+	resp = doJSON(t, r, http.MethodGet, "/deleteSingleQuestionBank?id=2", nil)
+	require.Equal(t, 200, resp.Code, resp.Msg)
+	resp = doJSON(t, r, http.MethodPost, "/recycleBin/purge", map[string]interface{}{"all": true})
+	require.Equal(t, 200, resp.Code, resp.Msg)
+	require.Empty(t, recycleBin())
+	remaining, _ := attachments.GetAttachmentsByQuestionId(2)
+	require.Empty(t, remaining)
+
+	resp = doJSON(t, r, http.MethodPost, "/recycleBin/restore", map[string]interface{}{})
+	require.Equal(t, 400, resp.Code)
+}
+
+// failingPurgeRepository 移入回收站和彻底删除题目时返回错误，用于检查失败时不删除关联数据
+type failingPurgeRepository struct {
+	*memory.QuestionRepository
+}
+
+func (r failingPurgeRepository) SoftDeleteQuestionBanks(ids []int, deletedBy string) (int64, error) {
+	return 0, errors.New("database is locked")
+}
+
+func (r failingPurgeRepository) PurgeQuestionBanks(ids []int) (int64, error) {
+	return 0, errors.New("database is locked")
+}
+
+func TestRecycleBinPurgeFailure(t *testing.T) {
+	questions := memory.NewQuestionRepository(entity.QuestionBank{Topic: "8259A 的作用", Answer: "中断控制", TopicType: "简答题", Score: 10})
+	_, err := questions.SoftDeleteQuestionBanks([]int{1}, "admin")
+	require.NoError(t, err)
+	attachments := memory.NewAttachmentRepository(entity.QuestionAttachment{QuestionID: 1, Name: "1", Path: "media:a"})
+	reviews := memory.NewReviewRepository()
+	require.NoError(t, reviews.InsertReviewComment(&entity.QuestionReviewComment{QuestionID: 1, Content: "请补充答案"}))
+	qc := NewQuestionBankController(failingPurgeRepository{questions}, attachments, memory.NewRevisionRepository(), reviews,
+		memory.NewHistoryRepository(), memory.NewLabelRepository(), component.NewAuditor(memory.NewAuditRepository()))
+	r := newTestRouter()
+	r.POST("/recycleBin/purge", qc.PurgeQuestions)
+
+	// 删除失败时返回 500，附件和审核意见保留
+	resp := doJSON(t, r, http.MethodPost, "/recycleBin/purge", map[string]interface{}{"ids": []int{1}})
+	require.Equal(t, 500, resp.Code)
+	remaining, err := attachments.GetAttachmentsByQuestionId(1)
+	require.NoError(t, err)
+	require.Len(t, remaining, 1)
+	comments, err := reviews.GetReviewComments(1)
+	require.NoError(t, err)
+	require.Len(t, comments, 1)
+}
+
+func TestDeleteSingleQuestionBankFailure(t *testing.T) {
+	questions := memory.NewQuestionRepository(entity.QuestionBank{Topic: "8259A 的作用", Answer: "中断控制", TopicType: "简答题", Score: 10})
+	qc := NewQuestionBankController(failingPurgeRepository{questions}, memory.NewAttachmentRepository(), memory.NewRevisionRepository(),
+		memory.NewReviewRepository(), memory.NewHistoryRepository(), memory.NewLabelRepository(), component.NewAuditor(memory.NewAuditRepository()))
+	r := newTestRouter()
+	r.GET("/deleteSingleQuestionBank", qc.DeleteSingleQuestionBank)
+
+	resp := doJSON(t, r, http.MethodGet, "/deleteSingleQuestionBank?id=1", nil)
+	require.Equal(t, 500, resp.Code)
+	found, err := questions.GetQuestionBankById(1)
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+}
+
+// staleLockHistory 模拟删除前检查之后试卷才被锁定：stale 为 true 时下一次查询返回没有锁定的题目
+type staleLockHistory struct {
+	*memory.HistoryRepository
+	stale bool
+}
+
+func (h *staleLockHistory) GetLockedQuestionIds(questionIDs []int) ([]int, error) {
+	if h.stale {
+		h.stale = false
+		return nil, nil
+	}
+	return h.HistoryRepository.GetLockedQuestionIds(questionIDs)
+}
+
+func TestRecycleBinLockedAfterCheck(t *testing.T) {
+	questions := memory.NewQuestionRepository(
+		entity.QuestionBank{Topic: "8086 有几个段寄存器？", Answer: "4", TopicType: "填空题", Score: 2},
+		entity.QuestionBank{Topic: "8259A 的作用", Answer: "中断控制", TopicType: "简答题", Score: 10},
+		entity.QuestionBank{Topic: "8086 的地址总线宽度", Answer: "20 位", TopicType: "填空题", Score: 2},
+	)
+	_, err := questions.SoftDeleteQuestionBanks([]int{1, 2}, "admin")
+	require.NoError(t, err)
+	attachments := memory.NewAttachmentRepository(entity.QuestionAttachment{QuestionID: 2, Name: "1", Path: "media:a"})
+	history := memory.NewHistoryRepository()
+	_, err = history.InsertTestPaperGenHistory(entity.TestPaperGenHistory{TestPaperUID: "p1", TestPaperName: "期中试卷", Locked: true})
+	require.NoError(t, err)
+	_, err = history.InsertQuestionGenHistories([]entity.QuestionGenHistory{{TestPaperUID: "p1", QuestionBankID: 2}, {TestPaperUID: "p1", QuestionBankID: 3}})
+	require.NoError(t, err)
+	questions.UseHistory(history)
+	stale := &staleLockHistory{HistoryRepository: history}
+	qc := NewQuestionBankController(questions, attachments, memory.NewRevisionRepository(), memory.NewReviewRepository(),
+		stale, memory.NewLabelRepository(), component.NewAuditor(memory.NewAuditRepository()))
+	r := newTestRouter()
+	r.GET("/deleteSingleQuestionBank", qc.DeleteSingleQuestionBank)
+	r.POST("/recycleBin/purge", qc.PurgeQuestions)
+
+	// 检查通过后删除语句仍然跳过被锁定的试卷引用的题目
+	stale.stale = true
+	resp := doJSON(t, r, http.MethodGet, "/deleteSingleQuestionBank?id=3", nil)
+	require.Equal(t, 400, resp.Code)
+	found, err := questions.GetQuestionBankById(3)
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+
+	// 彻底删除时只删除未被锁定的题目，被锁定的题目及其附件保留
+	stale.stale = true
+	resp = doJSON(t, r, http.MethodPost, "/recycleBin/purge", map[string]interface{}{"ids": []int{1, 2}})
+	require.Equal(t, 200, resp.Code, resp.Msg)
+	require.Equal(t, map[string]interface{}{"purgeCount": float64(1), "ids": []interface{}{float64(1)}}, resp.Data)
+	deleted, err := questions.GetDeletedQuestionBanksInIds([]int{2})
+	require.NoError(t, err)
+	require.Len(t, deleted, 1)
+	remaining, err := attachments.GetAttachmentsByQuestionId(2)
+	require.NoError(t, err)
+	require.Len(t, remaining, 1)
+}
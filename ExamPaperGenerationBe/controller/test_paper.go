@@ -2,8 +2,7 @@ package controller
 
 import (
 	"fmt"
-	"github.com/gin-contrib/sessions"
-	"graduation/mapper"
+	"graduation/component"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -18,9 +17,8 @@ import (
 // @Param threshold body float64 true "相似度阈值(0-1)"
 // @Success 200 {object} gin.H
 // @Router /user/similarity [put]
-func SetSimilarityThreshold(ctx *gin.Context) {
-	session := sessions.Default(ctx)
-	username := session.Get("username")
+func (uc *UserController) SetSimilarityThreshold(ctx *gin.Context) {
+	username := component.CurrentUsername(ctx)
 	if username == "" {
 		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
 		return
@@ -35,23 +33,25 @@ func SetSimilarityThreshold(ctx *gin.Context) {
 	}
 
 	// 更新用户的相似度阈值
-	if err := updateUserSimilarityThreshold(username.(string), threshold.Threshold); err != nil {
+	before := uc.auditUser(username)
+	if err := uc.updateUserSimilarityThreshold(username, threshold.Threshold); err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to set similarity threshold"})
 		return
 	}
+	uc.audit.Record(ctx, component.AuditUpdate, component.AuditEntityUser, username, before, uc.auditUser(username))
 
 	ctx.JSON(http.StatusOK, gin.H{"message": "Similarity threshold updated successfully"})
 }
 
 // updateUserSimilarityThreshold 更新用户的相似度阈值
-func updateUserSimilarityThreshold(username string, threshold float64) error {
+func (uc *UserController) updateUserSimilarityThreshold(username string, threshold float64) error {
 	// 验证阈值范围
 	if threshold < 0 || threshold > 1 {
 		return fmt.Errorf("threshold must be between 0 and 1")
 	}
 
 	// 更新用户相似度阈值
-	if err := mapper.UpdateUserSimilarityThreshold(username, threshold); err != nil {
+	if err := uc.users.UpdateUserSimilarityThreshold(username, threshold); err != nil {
 		return fmt.Errorf("failed to update user similarity threshold: %v", err)
 	}
 
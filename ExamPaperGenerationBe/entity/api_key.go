@@ -0,0 +1,29 @@
+package entity
+
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+// ApiKey 表示用户创建的个人 API 密钥，只保存密钥的 SHA-256 摘要
+type ApiKey struct {
+	ID         int        `gorm:"primaryKey;column:id" json:"id"`
+	Username   string     `gorm:"column:username;size:255;index:idx_apikey_username" json:"username"`
+	Name       string     `gorm:"column:name;size:255" json:"name"`
+	Prefix     string     `gorm:"column:prefix;size:32" json:"prefix"` // 密钥前缀，便于用户辨认
+	KeyHash    string     `gorm:"column:key_hash;size:64;uniqueIndex:idx_apikey_key_hash" json:"-"`
+	Scopes     string     `gorm:"column:scopes;size:255" json:"scopes"` // 逗号分隔的权限列表
+	CreatedAt  time.Time  `gorm:"column:created_at" json:"created_at"`
+	LastUsedAt *time.Time `gorm:"column:last_used_at" json:"last_used_at"`
+	RevokedAt  *time.Time `gorm:"column:revoked_at" json:"revoked_at"`
+}
+
+// BeforeCreate 在创建记录前设置创建时间
+func (k *ApiKey) BeforeCreate(tx *gorm.DB) error {
+	k.CreatedAt = time.Now()
+	return nil
+}
+
+func (k *ApiKey) TableName() string {
+	return "apikey" // 明确指定表名
+}
@@ -0,0 +1,32 @@
+package entity
+
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+// AuditLog 表示一次修改数据的操作记录，Before/After 为操作前后实体的 JSON 快照
+type AuditLog struct {
+	ID         int       `gorm:"primaryKey;column:id" json:"id"`
+	Actor      string    `gorm:"column:actor;size:255;index:idx_auditlog_actor" json:"actor"` // 操作者用户名
+	Action     string    `gorm:"column:action;size:64" json:"action"`
+	EntityType string    `gorm:"column:entity_type;size:64;index:idx_auditlog_entity" json:"entity_type"`
+	EntityID   string    `gorm:"column:entity_id;size:255;index:idx_auditlog_entity" json:"entity_id"`
+	Before     string    `gorm:"column:before_json;type:longtext" json:"before"`
+	After      string    `gorm:"column:after_json;type:longtext" json:"after"`
+	Diff       string    `gorm:"column:diff_json;type:longtext" json:"diff"` // 发生变化的字段
+	IP         string    `gorm:"column:ip;size:64" json:"ip"`
+	CreatedAt  time.Time `gorm:"column:created_at;index:idx_auditlog_created_at" json:"created_at"`
+}
+
+// BeforeCreate 在创建记录前设置创建时间
+func (a *AuditLog) BeforeCreate(tx *gorm.DB) error {
+	if a.CreatedAt.IsZero() {
+		a.CreatedAt = time.Now()
+	}
+	return nil
+}
+
+func (a *AuditLog) TableName() string {
+	return "auditlog" // 明确指定表名
+}
@@ -0,0 +1,123 @@
+package entity
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ContentTable 材料或题目中的表格，Rows 按行保存单元格文字，各行单元格数不同时按最多的一行补齐
+type ContentTable struct {
+	HeaderRow bool         `json:"header_row"` // 第一行是否为表头
+	Rows      [][]string   `json:"rows"`
+	Merges    []TableMerge `json:"merges,omitempty"` // 合并单元格
+}
+
+// TableMerge 合并单元格：从第 Row 行第 Col 列（从 0 开始）向下合并 RowSpan 行、向右合并 ColSpan 列，
+// 显示左上角单元格的文字，被合并的其他单元格文字忽略。RowSpan、ColSpan 为 0 时按 1 处理
+type TableMerge struct {
+	Row     int `json:"row"`
+	Col     int `json:"col"`
+	RowSpan int `json:"row_span"`
+	ColSpan int `json:"col_span"`
+}
+
+// Spans 返回合并的行数和列数
+func (m TableMerge) Spans() (int, int) {
+	return max(m.RowSpan, 1), max(m.ColSpan, 1)
+}
+
+// contains 判断单元格是否在合并范围内
+func (m TableMerge) contains(row, col int) bool {
+	rowSpan, colSpan := m.Spans()
+	return row >= m.Row && row < m.Row+rowSpan && col >= m.Col && col < m.Col+colSpan
+}
+
+// Columns 返回表格的列数，各行单元格数不同时取最大值
+func (t ContentTable) Columns() int {
+	columns := 0
+	for _, row := range t.Rows {
+		columns = max(columns, len(row))
+	}
+	return columns
+}
+
+// IsEmpty 判断表格是否没有任何单元格，空表格视为没有表格
+func (t *ContentTable) IsEmpty() bool {
+	return t == nil || t.Columns() == 0
+}
+
+// Cell 返回单元格文字，超出该行单元格数时返回空字符串
+func (t ContentTable) Cell(row, col int) string {
+	if row < 0 || row >= len(t.Rows) || col < 0 || col >= len(t.Rows[row]) {
+		return ""
+	}
+	return t.Rows[row][col]
+}
+
+// MergeAt 返回覆盖该单元格的合并区域
+func (t ContentTable) MergeAt(row, col int) (TableMerge, bool) {
+	for _, m := range t.Merges {
+		if m.contains(row, col) {
+			return m, true
+		}
+	}
+	return TableMerge{}, false
+}
+
+// Validate 检查表格结构：至少一行一列，合并区域不能超出表格、不能只有一个单元格、不能互相重叠
+func (t ContentTable) Validate() error {
+	columns := t.Columns()
+	if len(t.Rows) == 0 || columns == 0 {
+		return errors.New("表格为空")
+	}
+	covered := make(map[[2]int]bool)
+	for i, m := range t.Merges {
+		rowSpan, colSpan := m.Spans()
+		if m.Row < 0 || m.Col < 0 || m.Row+rowSpan > len(t.Rows) || m.Col+colSpan > columns {
+			return fmt.Errorf("第 %d 个合并单元格超出表格范围", i+1)
+		}
+		if rowSpan*colSpan == 1 {
+			return fmt.Errorf("第 %d 个合并单元格只包含一个单元格", i+1)
+		}
+		for r := m.Row; r < m.Row+rowSpan; r++ {
+			for c := m.Col; c < m.Col+colSpan; c++ {
+				if covered[[2]int{r, c}] {
+					return fmt.Errorf("第 %d 个合并单元格与其他合并单元格重叠", i+1)
+				}
+				covered[[2]int{r, c}] = true
+			}
+		}
+	}
+	return nil
+}
+
+// ParseContentTable 解析 JSON 格式的表格并检查结构，空字符串返回 nil
+func ParseContentTable(data string) (*ContentTable, error) {
+	data = strings.TrimSpace(data)
+	if data == "" {
+		return nil, nil
+	}
+	var table ContentTable
+	if err := json.Unmarshal([]byte(data), &table); err != nil {
+		return nil, fmt.Errorf("表格格式错误: %w", err)
+	}
+	if err := table.Validate(); err != nil {
+		return nil, err
+	}
+	return &table, nil
+}
+
+// JSON 返回表格的 JSON 文本，用于出题历史的 topic_table_json 列，空表格返回 nil
+func (t *ContentTable) JSON() *string {
+	if t.IsEmpty() {
+		return nil
+	}
+	data, err := json.Marshal(t)
+	if err != nil {
+		return nil
+	}
+	s := string(data)
+	return &s
+}
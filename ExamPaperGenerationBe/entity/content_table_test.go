@@ -0,0 +1,51 @@
+package entity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestContentTableValidate(t *testing.T) {
+	table := ContentTable{
+		HeaderRow: true,
+		Rows:      [][]string{{"地址", "内容", ""}, {"2000H", "12H", "34H"}, {"2002H"}},
+		Merges:    []TableMerge{{Row: 0, Col: 1, ColSpan: 2}, {Row: 1, Col: 0, RowSpan: 2}},
+	}
+	require.NoError(t, table.Validate())
+	require.Equal(t, 3, table.Columns())
+	require.Equal(t, "", table.Cell(2, 2))
+
+	m, ok := table.MergeAt(0, 2)
+	require.True(t, ok)
+	require.Equal(t, 1, m.Col)
+	_, ok = table.MergeAt(2, 1)
+	require.False(t, ok)
+
+	require.Error(t, ContentTable{}.Validate())
+	require.Error(t, ContentTable{Rows: [][]string{{"a", "b"}}, Merges: []TableMerge{{Row: 0, Col: 1, ColSpan: 2}}}.Validate())
+	require.Error(t, ContentTable{Rows: [][]string{{"a", "b"}}, Merges: []TableMerge{{Row: 0, Col: 0}}}.Validate())
+	require.Error(t, ContentTable{Rows: [][]string{{"a", "b"}, {"c", "d"}}, Merges: []TableMerge{
+		{Row: 0, Col: 0, ColSpan: 2}, {Row: 0, Col: 1, RowSpan: 2},
+	}}.Validate())
+}
+
+func TestParseContentTable(t *testing.T) {
+	table, err := ParseContentTable(" ")
+	require.NoError(t, err)
+	require.Nil(t, table)
+
+	table, err = ParseContentTable(`{"header_row":true,"rows":[["端口","地址"],["8255A","60H"]]}`)
+	require.NoError(t, err)
+	require.True(t, table.HeaderRow)
+	require.Equal(t, `{"header_row":true,"rows":[["端口","地址"],["8255A","60H"]]}`, *table.JSON())
+
+	_, err = ParseContentTable(`{"rows":[]}`)
+	require.Error(t, err)
+	_, err = ParseContentTable(`[1,2]`)
+	require.Error(t, err)
+
+	var empty *ContentTable
+	require.True(t, empty.IsEmpty())
+	require.Nil(t, empty.JSON())
+}
@@ -0,0 +1,103 @@
+package entity
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 附件所属的位置
+const (
+	AttachmentTargetTopic  = "topic"  // 题干
+	AttachmentTargetOption = "option" // 选择题的某个选项
+	AttachmentTargetAnswer = "answer" // 答案
+)
+
+// QuestionAttachment 题目附件（图片），一道题目的题干、选项和答案都可以有多张图片。
+// 文字中写 {{img:名称}} 的位置插入对应图片，没有写位置标记的图片按顺序排在文字之后
+type QuestionAttachment struct {
+	ID           int       `gorm:"primaryKey;column:id" json:"id"`
+	QuestionID   int       `gorm:"column:question_id;index" json:"question_id"`
+	Target       string    `gorm:"column:target" json:"target"`               // topic、option 或 answer
+	OptionLabel  string    `gorm:"column:option_label" json:"option_label"`   // target 为 option 时的选项标号
+	Name         string    `gorm:"column:name" json:"name"`                   // 位置标记中使用的名称，同一道题内不重复
+	Path         string    `gorm:"column:path" json:"path"`                   // 图片文件路径
+	Caption      string    `gorm:"column:caption" json:"caption"`             // 图片下方的说明文字，可为空
+	Width        int       `gorm:"column:width" json:"width"`                 // 图片原始宽度（像素）
+	Height       int       `gorm:"column:height" json:"height"`               // 图片原始高度（像素）
+	DisplayWidth float64   `gorm:"column:display_width" json:"display_width"` // 导出宽度（厘米），为 0 时按原始大小，超过版心宽度时缩小
+	SortOrder    int       `gorm:"column:sort_order" json:"sort_order"`
+	UpdateTime   time.Time `gorm:"column:update_time" json:"update_time"`
+	URL          string    `gorm:"-" json:"url,omitempty"`           // 图片的访问地址，只用于接口返回
+	ThumbnailURL string    `gorm:"-" json:"thumbnail_url,omitempty"` // 缩略图地址
+}
+
+// BeforeCreate 在创建记录前设置更新时间
+func (a *QuestionAttachment) BeforeCreate(tx *gorm.DB) error {
+	a.UpdateTime = time.Now()
+	return nil
+}
+
+func (a *QuestionAttachment) TableName() string {
+	return "questionattachment" // 明确指定表名
+}
+
+// Validate 检查附件的位置和导出宽度
+func (a *QuestionAttachment) Validate() error {
+	switch a.Target {
+	case AttachmentTargetTopic, AttachmentTargetAnswer:
+		if a.OptionLabel != "" {
+			return errors.New("只有选项图片可以指定选项标号")
+		}
+	case AttachmentTargetOption:
+		if a.OptionLabel == "" {
+			return errors.New("选项图片缺少选项标号")
+		}
+	default:
+		return fmt.Errorf("不支持的图片位置 %q", a.Target)
+	}
+	if !attachmentNameRe.MatchString(a.Name) {
+		return fmt.Errorf("图片名称 %q 只能包含字母、数字、下划线和短横线", a.Name)
+	}
+	if a.DisplayWidth < 0 {
+		return errors.New("导出宽度不能为负数")
+	}
+	return nil
+}
+
+// attachmentNameRe 图片名称的格式，名称会写进位置标记，不能包含花括号等字符
+var attachmentNameRe = regexp.MustCompile(`^[A-Za-z0-9_-]{1,32}$`)
+
+// AttachmentPlaceholderRe 匹配文字中的图片位置标记 {{img:名称}}
+var AttachmentPlaceholderRe = regexp.MustCompile(`\{\{img:([A-Za-z0-9_-]{1,32})\}\}`)
+
+// AttachmentPlaceholder 生成图片的位置标记
+func AttachmentPlaceholder(name string) string {
+	return "{{img:" + name + "}}"
+}
+
+// AttachmentsFor 按顺序返回属于指定位置的附件，target 为 option 时只返回该选项的附件
+func AttachmentsFor(attachments []QuestionAttachment, target, optionLabel string) []QuestionAttachment {
+	var out []QuestionAttachment
+	for _, a := range attachments {
+		if a.Target == target && (target != AttachmentTargetOption || a.OptionLabel == optionLabel) {
+			out = append(out, a)
+		}
+	}
+	SortAttachments(out)
+	return out
+}
+
+// SortAttachments 按 sort_order 排序，相同时按 ID 排序
+func SortAttachments(attachments []QuestionAttachment) {
+	sort.SliceStable(attachments, func(i, j int) bool {
+		if attachments[i].SortOrder != attachments[j].SortOrder {
+			return attachments[i].SortOrder < attachments[j].SortOrder
+		}
+		return attachments[i].ID < attachments[j].ID
+	})
+}
@@ -5,26 +5,49 @@ import (
 	"time"
 )
 
+// 题目的审核状态
+const (
+	QuestionStatusDraft    = "draft"     // 草稿，新增和导入的题目
+	QuestionStatusInReview = "in_review" // 已提交，等待审核
+	QuestionStatusApproved = "approved"  // 审核通过，可用于自动组卷
+	QuestionStatusRetired  = "retired"   // 已停用，不再用于组卷
+)
+
 // QuestionBank 表示问题库实体
 type QuestionBank struct {
-	ID              int       `gorm:"primaryKey;column:id" json:"id"`
-	Topic           string    `gorm:"column:topic" json:"topic"`
-	TopicMaterialID int       `gorm:"column:topic_material_id" json:"topic_material_id"`
-	Answer          string    `gorm:"column:answer" json:"answer"`
-	TopicType       string    `gorm:"column:topic_type" json:"topic_type"`
-	Score           float64   `gorm:"column:score" json:"score"`
-	Difficulty      int       `gorm:"column:difficulty" json:"difficulty"`
-	Chapter1        string    `gorm:"column:chapter_1" json:"chapter_1"`
-	Chapter2        string    `gorm:"column:chapter_2" json:"chapter_2"`
-	Label1          string    `gorm:"column:label_1" json:"label_1"`
-	Label2          string    `gorm:"column:label_2" json:"label_2"`
-	TopicImagePath  string    `gorm:"column:topic_image_path" json:"topic_image_path"`
-	UpdateTime      time.Time `gorm:"column:update_time" json:"update_time"`
+	ID              int                  `gorm:"primaryKey;column:id" json:"id"`
+	Topic           string               `gorm:"column:topic" json:"topic"`
+	TopicMaterialID int                  `gorm:"column:topic_material_id" json:"topic_material_id"`
+	Answer          string               `gorm:"column:answer" json:"answer"`
+	TopicType       string               `gorm:"column:topic_type" json:"topic_type"`
+	Score           float64              `gorm:"column:score" json:"score"`
+	Difficulty      int                  `gorm:"column:difficulty" json:"difficulty"`
+	Chapter1        string               `gorm:"column:chapter_1" json:"chapter_1"`
+	Chapter2        string               `gorm:"column:chapter_2" json:"chapter_2"`
+	Label1          string               `gorm:"column:label_1" json:"label_1"`
+	Label2          string               `gorm:"column:label_2" json:"label_2"`
+	TopicImagePath  string               `gorm:"column:topic_image_path" json:"topic_image_path"`
+	Options         []QuestionOption     `gorm:"column:options;serializer:json" json:"options"`           // 选择题的选项，题干中不再包含选项
+	TopicTable      *ContentTable        `gorm:"column:topic_table;serializer:json" json:"topic_table"`   // 题干中的表格，排在题干文字之后
+	AnswerTable     *ContentTable        `gorm:"column:answer_table;serializer:json" json:"answer_table"` // 答案中的表格
+	UpdateTime      time.Time            `gorm:"column:update_time" json:"update_time"`
+	Status          string               `gorm:"column:status" json:"status"`                   // 审核状态，见 QuestionStatusDraft 等
+	Reviewer        string               `gorm:"column:reviewer" json:"reviewer,omitempty"`     // 指定的审核员用户名，为空时任何审核员都可以审核
+	DeletedAt       gorm.DeletedAt       `gorm:"column:deleted_at;index" json:"deleted_at"`     // 移入回收站的时间，未删除时为 null，查询时自动排除已删除的题目
+	DeletedBy       string               `gorm:"column:deleted_by" json:"deleted_by,omitempty"` // 删除题目的用户
+	TopicMathML     string               `gorm:"-" json:"topic_mathml,omitempty"`               // 题干含公式时，公式转换为 MathML 后的题干，只用于接口返回
+	AnswerMathML    string               `gorm:"-" json:"answer_mathml,omitempty"`              // 答案含公式时，公式转换为 MathML 后的答案
+	Attachments     []QuestionAttachment `gorm:"-" json:"attachments,omitempty"`                // 题干、选项和答案中的图片，只在查询单道题目时返回
+	TopicImageURL   string               `gorm:"-" json:"topic_image_url,omitempty"`            // 题干第一张图片的访问地址，只用于接口返回
+	TopicThumbURL   string               `gorm:"-" json:"topic_thumbnail_url,omitempty"`        // 题干第一张图片的缩略图地址
 }
 
-// BeforeCreate 在创建记录前设置更新时间
+// BeforeCreate 在创建记录前设置更新时间，新题目默认为草稿
 func (q *QuestionBank) BeforeCreate(tx *gorm.DB) error {
 	q.UpdateTime = time.Now()
+	if q.Status == "" {
+		q.Status = QuestionStatusDraft
+	}
 	return nil
 }
 
@@ -22,6 +22,62 @@ type QuestionGenHistory struct {
 	Label1          string    `gorm:"column:label_1" json:"label_1"`
 	Label2          string    `gorm:"column:label_2" json:"label_2"`
 	UpdateTime      time.Time `gorm:"column:update_time" json:"update_time"`
+	TopicImagePath  string    `gorm:"column:topic_image_path" json:"topic_image_path"`
+	TopicTableJSON  *string   `gorm:"column:topic_table_json" json:"topic_table_json"`
+	// 出题时题目的完整内容、附件和引用的材料，重新导出试卷和答案时使用，旧记录为空
+	Question    *QuestionSnapshot    `gorm:"column:question_json;serializer:json" json:"question,omitempty"`
+	Attachments []QuestionAttachment `gorm:"column:attachments_json;serializer:json" json:"attachments,omitempty"`
+	Material    *QuestionMaterial    `gorm:"column:material_json;serializer:json" json:"material,omitempty"`
+}
+
+// NewQuestionGenHistory 生成题目的出题记录，attachments 为题目的附件，material 为题目引用的材料（没有时为 nil）。
+// 试卷 UID、名称和时间由调用方填写
+func NewQuestionGenHistory(q QuestionBank, attachments []QuestionAttachment, material *QuestionMaterial) QuestionGenHistory {
+	snapshot := NewQuestionSnapshot(q)
+	return QuestionGenHistory{
+		QuestionBankID:  q.ID,
+		Topic:           q.DisplayTopic(),
+		TopicMaterialID: q.TopicMaterialID,
+		Answer:          q.Answer,
+		TopicType:       q.TopicType,
+		Score:           q.Score,
+		Difficulty:      q.Difficulty,
+		Chapter1:        q.Chapter1,
+		Chapter2:        q.Chapter2,
+		Label1:          q.Label1,
+		Label2:          q.Label2,
+		TopicImagePath:  q.TopicImagePath,
+		TopicTableJSON:  q.TopicTable.JSON(),
+		Question:        &snapshot,
+		Attachments:     append([]QuestionAttachment(nil), attachments...),
+		Material:        material,
+	}
+}
+
+// QuestionBank 还原出题时的题目，ID 为题库中的题目 ID。旧记录没有完整快照，
+// 只能用题干（已包含选项文字）、答案、分值、题干图片和题干表格还原
+func (q *QuestionGenHistory) QuestionBank() QuestionBank {
+	if q.Question != nil {
+		return q.Question.Question(q.QuestionBankID)
+	}
+	restored := QuestionBank{
+		ID:              q.QuestionBankID,
+		Topic:           q.Topic,
+		TopicMaterialID: q.TopicMaterialID,
+		Answer:          q.Answer,
+		TopicType:       q.TopicType,
+		Score:           q.Score,
+		Difficulty:      q.Difficulty,
+		Chapter1:        q.Chapter1,
+		Chapter2:        q.Chapter2,
+		Label1:          q.Label1,
+		Label2:          q.Label2,
+		TopicImagePath:  q.TopicImagePath,
+	}
+	if q.TopicTableJSON != nil {
+		restored.TopicTable, _ = ParseContentTable(*q.TopicTableJSON)
+	}
+	return restored
 }
 
 // BeforeCreate 在创建记录前设置更新时间
@@ -33,3 +89,46 @@ func (q *QuestionGenHistory) BeforeCreate(tx *gorm.DB) error {
 func (q *QuestionGenHistory) TableName() string {
 	return "questiongenhistory" // 明确指定表名
 }
+
+// SnapshotImagePaths 返回快照列中引用的全部图片路径，包括题干和选项图片、附件和材料图片
+func (q *QuestionGenHistory) SnapshotImagePaths() []string {
+	var paths []string
+	if q.Question != nil {
+		paths = append(paths, q.Question.TopicImagePath)
+		for _, o := range q.Question.Options {
+			paths = append(paths, o.ImagePath)
+		}
+	}
+	for _, a := range q.Attachments {
+		paths = append(paths, a.Path)
+	}
+	if q.Material != nil {
+		paths = append(paths, q.Material.ImagePaths...)
+	}
+	return paths
+}
+
+// ReplaceSnapshotImagePath 把快照列中的图片路径 old 替换为 ref，返回是否有修改
+func (q *QuestionGenHistory) ReplaceSnapshotImagePath(old, ref string) bool {
+	changed := false
+	replace := func(path *string) {
+		if *path == old {
+			*path, changed = ref, true
+		}
+	}
+	if q.Question != nil {
+		replace(&q.Question.TopicImagePath)
+		for i := range q.Question.Options {
+			replace(&q.Question.Options[i].ImagePath)
+		}
+	}
+	for i := range q.Attachments {
+		replace(&q.Attachments[i].Path)
+	}
+	if q.Material != nil {
+		for i := range q.Material.ImagePaths {
+			replace(&q.Material.ImagePaths[i])
+		}
+	}
+	return changed
+}
@@ -0,0 +1,32 @@
+package entity
+
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+// QuestionMaterial 表示题目材料实体，多道题目可通过 topic_material_id 共用同一材料
+type QuestionMaterial struct {
+	ID         int            `gorm:"primaryKey;column:id" json:"id"`
+	Title      string         `gorm:"column:title" json:"title"`                             // 材料标题，可为空
+	Material   string         `gorm:"column:material" json:"material"`                       // 材料正文
+	ImagePaths []string       `gorm:"column:image_paths;serializer:json" json:"image_paths"` // 材料中的图片，按顺序排在正文之后
+	Tables     []ContentTable `gorm:"column:tables;serializer:json" json:"tables"`           // 材料中的表格，排在图片之后
+	UpdateTime time.Time      `gorm:"column:update_time" json:"update_time"`
+	ImageURLs  []string       `gorm:"-" json:"image_urls,omitempty"` // 材料图片的访问地址，与 image_paths 一一对应，只用于接口返回
+}
+
+// BeforeCreate 在创建记录前设置更新时间
+func (m *QuestionMaterial) BeforeCreate(tx *gorm.DB) error {
+	m.UpdateTime = time.Now()
+	return nil
+}
+
+func (m *QuestionMaterial) TableName() string {
+	return "questionmaterial" // 明确指定表名
+}
+
+// IsEmpty 判断材料是否没有任何内容
+func (m *QuestionMaterial) IsEmpty() bool {
+	return m.Title == "" && m.Material == "" && len(m.ImagePaths) == 0 && len(m.Tables) == 0
+}
@@ -0,0 +1,145 @@
+package entity
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// ChoiceTopicType 选择题的题型名称
+const ChoiceTopicType = "选择题"
+
+// QuestionOption 选择题的一个选项
+type QuestionOption struct {
+	Label     string `json:"label"`                // 选项标号，如 A、B
+	Content   string `json:"content"`              // 选项文字
+	ImagePath string `json:"image_path,omitempty"` // 选项图片，可选
+	IsCorrect bool   `json:"is_correct"`           // 是否为正确选项，多选题可以有多个
+}
+
+// optionMarkerRe 匹配选项标号，例如 "A．"、"B."、"C、"，标号前必须是开头、空白或标点，
+// 避免把 "8259A." 这类文字当作选项
+var optionMarkerRe = regexp.MustCompile(`([A-H])\s*[.．、:：]`)
+
+// optionTrimChars 选项文字首尾需要去掉的分隔符
+const optionTrimChars = " \t\r\n　；;，,。"
+
+// ParseChoiceTopic 把题干和选项混在一起的选择题拆分为题干和选项，例如
+// "零的表示形式是唯一的。A．原码 B．补码 C．反码" 拆分为题干 "零的表示形式是唯一的。" 和三个选项。
+// 选项标号必须从 A 开始连续出现且至少有两个，否则返回 false；选项之后又出现 A 时视为多道题混在一起，也返回 false
+func ParseChoiceTopic(topic string) (string, []QuestionOption, bool) {
+	type marker struct {
+		label      string
+		start, end int
+	}
+	var markers []marker
+	expected := byte('A')
+	for _, loc := range optionMarkerRe.FindAllStringSubmatchIndex(topic, -1) {
+		if loc[2] > 0 {
+			prev := []rune(topic[:loc[2]])
+			if r := prev[len(prev)-1]; unicode.IsLetter(r) || unicode.IsDigit(r) {
+				continue
+			}
+		}
+		label := topic[loc[2]]
+		switch {
+		case label == expected:
+			markers = append(markers, marker{label: string(label), start: loc[0], end: loc[1]})
+			expected++
+		case label == 'A' && len(markers) > 0:
+			return topic, nil, false
+		}
+	}
+	if len(markers) < 2 {
+		return topic, nil, false
+	}
+
+	stem := strings.TrimSpace(topic[:markers[0].start])
+	options := make([]QuestionOption, len(markers))
+	for i, m := range markers {
+		end := len(topic)
+		if i+1 < len(markers) {
+			end = markers[i+1].start
+		}
+		content := strings.Trim(topic[m.end:end], optionTrimChars)
+		if content == "" {
+			return topic, nil, false
+		}
+		options[i] = QuestionOption{Label: m.label, Content: content}
+	}
+	return stem, options, true
+}
+
+// MarkCorrectOptions 根据答案文字（如 "A"、"AC"、"A,C"）标记正确选项，答案中含有标号以外的文字时不做修改
+func MarkCorrectOptions(options []QuestionOption, answer string) {
+	labels := make(map[string]bool)
+	for _, r := range strings.ToUpper(answer) {
+		switch {
+		case r >= 'A' && r <= 'Z':
+			labels[string(r)] = true
+		case unicode.IsSpace(r) || strings.ContainsRune(",，、;；", r):
+		default:
+			return
+		}
+	}
+	if len(labels) == 0 {
+		return
+	}
+	for i := range options {
+		options[i].IsCorrect = labels[options[i].Label]
+	}
+}
+
+// ChoiceAnswer 返回正确选项的标号，例如 "AC"
+func ChoiceAnswer(options []QuestionOption) string {
+	var sb strings.Builder
+	for _, o := range options {
+		if o.IsCorrect {
+			sb.WriteString(o.Label)
+		}
+	}
+	return sb.String()
+}
+
+// FormatChoiceTopic 把题干和选项重新拼接为一段文字，格式与导入的题库一致
+func FormatChoiceTopic(stem string, options []QuestionOption) string {
+	parts := []string{stem}
+	for _, o := range options {
+		parts = append(parts, o.Label+"．"+o.Content)
+	}
+	return strings.Join(parts, " ")
+}
+
+// NormalizeChoiceOptions 整理选择题的选项：没有选项时尝试从题干中拆分，缺少标号时按 A、B、C 依次补齐，
+// 没有标记正确选项时根据答案标记，再用正确选项的标号更新答案。非选择题不做处理
+func (q *QuestionBank) NormalizeChoiceOptions() {
+	if q.TopicType != ChoiceTopicType {
+		return
+	}
+	if len(q.Options) == 0 {
+		stem, options, ok := ParseChoiceTopic(q.Topic)
+		if !ok {
+			return
+		}
+		q.Topic, q.Options = stem, options
+	}
+	for i := range q.Options {
+		if q.Options[i].Label == "" {
+			q.Options[i].Label = string(rune('A' + i))
+		}
+	}
+	if ChoiceAnswer(q.Options) == "" {
+		MarkCorrectOptions(q.Options, q.Answer)
+	}
+	if answer := ChoiceAnswer(q.Options); answer != "" {
+		q.Answer = answer
+	}
+}
+
+// DisplayTopic 返回包含选项的完整题目文字，用于出题历史等只保存题目文字的地方
+func (q *QuestionBank) DisplayTopic() string {
+	if len(q.Options) == 0 {
+		return q.Topic
+	}
+	return FormatChoiceTopic(q.Topic, q.Options)
+}
@@ -0,0 +1,61 @@
+package entity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseChoiceTopic(t *testing.T) {
+	stem, options, ok := ParseChoiceTopic("某一RAM芯片其容量为512*8位,除电源和接地端外该芯片引线的最少数目是（ ） 。　 A. 21   B. 17   C. 19   D.20")
+	require.True(t, ok)
+	require.Equal(t, "某一RAM芯片其容量为512*8位,除电源和接地端外该芯片引线的最少数目是（ ） 。", stem)
+	require.Equal(t, []QuestionOption{{Label: "A", Content: "21"}, {Label: "B", Content: "17"}, {Label: "C", Content: "19"}, {Label: "D", Content: "20"}}, options)
+
+	_, options, ok = ParseChoiceTopic("常用的虚拟存储器寻址系统由 _____ 两级存储器组成。A． 主存－辅存 ；B．Cache－主存；C．Cache－辅存；D．主存—硬盘。")
+	require.True(t, ok)
+	require.Equal(t, "主存－辅存", options[0].Content)
+	require.Equal(t, "主存—硬盘", options[3].Content)
+
+	// 标号前是字母或数字时不是选项
+	stem, options, ok = ParseChoiceTopic("8259A. 的作用是（）A、中断控制 B、DMA 控制")
+	require.True(t, ok)
+	require.Equal(t, "8259A. 的作用是（）", stem)
+	require.Len(t, options, 2)
+
+	// 两道题混在一起时不拆分
+	_, _, ok = ParseChoiceTopic("RISC是( )的简称。A.精简指令系统计算机 B.大规模集成电路 CPU响应中断的时间是_____。A．中断源提出请求； B．取指周期结束；")
+	require.False(t, ok)
+	_, _, ok = ParseChoiceTopic("浮点数的表示范围和精度取决于______ 。")
+	require.False(t, ok)
+	_, _, ok = ParseChoiceTopic("只有一个选项 A. 对")
+	require.False(t, ok)
+}
+
+func TestNormalizeChoiceOptions(t *testing.T) {
+	q := QuestionBank{TopicType: ChoiceTopicType, Topic: "下列哪些是段寄存器（）A. CS B. AX C. DS D. BX", Answer: "A、C"}
+	q.NormalizeChoiceOptions()
+	require.Equal(t, "下列哪些是段寄存器（）", q.Topic)
+	require.Equal(t, "AC", q.Answer)
+	require.True(t, q.Options[0].IsCorrect)
+	require.False(t, q.Options[1].IsCorrect)
+	require.True(t, q.Options[2].IsCorrect)
+
+	// 已有选项时以正确选项为准更新答案，并补齐标号
+	q = QuestionBank{TopicType: ChoiceTopicType, Topic: "8086 的地址总线宽度", Answer: "A",
+		Options: []QuestionOption{{Content: "16 位"}, {Content: "20 位", IsCorrect: true}}}
+	q.NormalizeChoiceOptions()
+	require.Equal(t, "B", q.Answer)
+	require.Equal(t, "A", q.Options[0].Label)
+	require.Equal(t, "B", q.Options[1].Label)
+
+	// 答案不是选项标号时不标记
+	q = QuestionBank{TopicType: ChoiceTopicType, Topic: "题干 A. 甲 B. 乙", Answer: "甲"}
+	q.NormalizeChoiceOptions()
+	require.Len(t, q.Options, 2)
+	require.Equal(t, "", ChoiceAnswer(q.Options))
+
+	q = QuestionBank{TopicType: "填空题", Topic: "题干 A. 甲 B. 乙"}
+	q.NormalizeChoiceOptions()
+	require.Empty(t, q.Options)
+}
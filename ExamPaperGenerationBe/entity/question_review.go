@@ -0,0 +1,29 @@
+package entity
+
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+// QuestionReviewComment 题目的审核意见。提交、通过、退回等操作附带的意见记录操作和操作后的状态，单独发表的评论两者为空
+type QuestionReviewComment struct {
+	ID         int       `gorm:"primaryKey;column:id" json:"id"`
+	QuestionID int       `gorm:"column:question_id;index:idx_questionreviewcomment_question_id" json:"question_id"`
+	Action     string    `gorm:"column:action;size:16" json:"action,omitempty"`
+	Status     string    `gorm:"column:status;size:16" json:"status,omitempty"`
+	Author     string    `gorm:"column:author;size:255" json:"author"`
+	Content    string    `gorm:"column:content;type:text" json:"content"`
+	CreatedAt  time.Time `gorm:"column:created_at" json:"created_at"`
+}
+
+// BeforeCreate 在创建记录前设置创建时间
+func (c *QuestionReviewComment) BeforeCreate(tx *gorm.DB) error {
+	if c.CreatedAt.IsZero() {
+		c.CreatedAt = time.Now()
+	}
+	return nil
+}
+
+func (c *QuestionReviewComment) TableName() string {
+	return "questionreviewcomment" // 明确指定表名
+}
@@ -0,0 +1,98 @@
+package entity
+
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+// 题目版本的产生方式
+const (
+	RevisionInitial  = "initial" // 开始记录版本之前的题目内容，在第一次修改前补记
+	RevisionCreate   = "create"
+	RevisionUpdate   = "update"
+	RevisionImport   = "import" // Excel 导入
+	RevisionRollback = "rollback"
+)
+
+// QuestionSnapshot 题目内容和属性的快照，不含 ID、更新时间和附件
+type QuestionSnapshot struct {
+	Topic           string           `json:"topic"`
+	TopicMaterialID int              `json:"topic_material_id"`
+	Answer          string           `json:"answer"`
+	TopicType       string           `json:"topic_type"`
+	Score           float64          `json:"score"`
+	Difficulty      int              `json:"difficulty"`
+	Chapter1        string           `json:"chapter_1"`
+	Chapter2        string           `json:"chapter_2"`
+	Label1          string           `json:"label_1"`
+	Label2          string           `json:"label_2"`
+	TopicImagePath  string           `json:"topic_image_path"`
+	Options         []QuestionOption `json:"options"`
+	TopicTable      *ContentTable    `json:"topic_table"`
+	AnswerTable     *ContentTable    `json:"answer_table"`
+}
+
+// NewQuestionSnapshot 生成题目的快照
+func NewQuestionSnapshot(q QuestionBank) QuestionSnapshot {
+	return QuestionSnapshot{
+		Topic:           q.Topic,
+		TopicMaterialID: q.TopicMaterialID,
+		Answer:          q.Answer,
+		TopicType:       q.TopicType,
+		Score:           q.Score,
+		Difficulty:      q.Difficulty,
+		Chapter1:        q.Chapter1,
+		Chapter2:        q.Chapter2,
+		Label1:          q.Label1,
+		Label2:          q.Label2,
+		TopicImagePath:  q.TopicImagePath,
+		Options:         q.Options,
+		TopicTable:      q.TopicTable,
+		AnswerTable:     q.AnswerTable,
+	}
+}
+
+// Question 把快照还原为 ID 为 id 的题目
+func (s QuestionSnapshot) Question(id int) QuestionBank {
+	return QuestionBank{
+		ID:              id,
+		Topic:           s.Topic,
+		TopicMaterialID: s.TopicMaterialID,
+		Answer:          s.Answer,
+		TopicType:       s.TopicType,
+		Score:           s.Score,
+		Difficulty:      s.Difficulty,
+		Chapter1:        s.Chapter1,
+		Chapter2:        s.Chapter2,
+		Label1:          s.Label1,
+		Label2:          s.Label2,
+		TopicImagePath:  s.TopicImagePath,
+		Options:         s.Options,
+		TopicTable:      s.TopicTable,
+		AnswerTable:     s.AnswerTable,
+	}
+}
+
+// QuestionRevision 题目的一个历史版本，写入后不再修改。Revision 为题目内从 1 开始递增的版本号
+type QuestionRevision struct {
+	ID         int              `gorm:"primaryKey;column:id" json:"id"`
+	QuestionID int              `gorm:"column:question_id;uniqueIndex:idx_questionrevision_question_revision" json:"question_id"`
+	Revision   int              `gorm:"column:revision;uniqueIndex:idx_questionrevision_question_revision" json:"revision"`
+	Action     string           `gorm:"column:action;size:16" json:"action"`
+	Source     int              `gorm:"column:source" json:"source,omitempty"` // 回滚时为恢复的版本号
+	Snapshot   QuestionSnapshot `gorm:"column:snapshot;serializer:json;type:longtext" json:"snapshot"`
+	Editor     string           `gorm:"column:editor;size:255" json:"editor"` // 修改者用户名，补记的版本为空
+	CreatedAt  time.Time        `gorm:"column:created_at" json:"created_at"`
+}
+
+// BeforeCreate 在创建记录前设置创建时间
+func (r *QuestionRevision) BeforeCreate(tx *gorm.DB) error {
+	if r.CreatedAt.IsZero() {
+		r.CreatedAt = time.Now()
+	}
+	return nil
+}
+
+func (r *QuestionRevision) TableName() string {
+	return "questionrevision" // 明确指定表名
+}
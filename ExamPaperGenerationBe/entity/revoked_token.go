@@ -0,0 +1,13 @@
+package entity
+
+import "time"
+
+// RevokedToken 表示已注销的访问令牌或刷新令牌，过期后可清理
+type RevokedToken struct {
+	JTI       string    `gorm:"primaryKey;column:jti;size:64" json:"jti"`
+	ExpiresAt time.Time `gorm:"column:expires_at;index" json:"expires_at"`
+}
+
+func (t *RevokedToken) TableName() string {
+	return "revokedtoken" // 明确指定表名
+}
@@ -16,6 +16,7 @@ type TestPaperGenHistory struct {
 	UpdateTime          time.Time `gorm:"column:update_time" json:"update_time"`
 	Username            string    `gorm:"column:username" json:"username"`
 	SimilarityThreshold float64   `gorm:"column:similarity_threshold" json:"similarity_threshold"`
+	Locked              bool      `gorm:"column:locked" json:"locked"` // 已锁定的试卷不能修改或删除，引用的题目也不能删除
 }
 
 // BeforeCreate 在创建记录前设置更新时间
@@ -7,13 +7,16 @@ import (
 
 // User 表示用户实体
 type User struct {
-	ID                  int       `gorm:"primaryKey;column:id" json:"id"`
-	Username            string    `gorm:"column:username" json:"username"`
-	Password            string    `gorm:"column:password" json:"password"`
-	UserRole            string    `gorm:"column:user_role" json:"user_role"`
-	LastLogin           time.Time `gorm:"column:last_login" json:"last_login"`
-	Enable              int       `gorm:"column:enable" json:"enable"`
-	SimilarityThreshold float64   `gorm:"column:similarity_threshold" json:"similarity_threshold"`
+	ID                  int        `gorm:"primaryKey;column:id" json:"id"`
+	Username            string     `gorm:"column:username;size:255;uniqueIndex:idx_username" json:"username"`
+	Password            string     `gorm:"column:password" json:"password"`
+	UserRole            string     `gorm:"column:user_role" json:"user_role"`
+	LastLogin           time.Time  `gorm:"column:last_login" json:"last_login"`
+	Enable              int        `gorm:"column:enable" json:"enable"`
+	SimilarityThreshold float64    `gorm:"column:similarity_threshold" json:"similarity_threshold"`
+	FailedLoginCount    int        `gorm:"column:failed_login_count" json:"failed_login_count"`     // 连续登录失败次数
+	LockedUntil         *time.Time `gorm:"column:locked_until" json:"locked_until"`                 // 临时锁定截止时间
+	MustChangePassword  bool       `gorm:"column:must_change_password" json:"must_change_password"` // 下次登录后必须修改密码
 }
 
 // BeforeCreate 在创建用户记录前设置最后登录时间
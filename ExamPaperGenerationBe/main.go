@@ -1,10 +1,17 @@
 package main
 
 import (
+	"crypto/rand"
 	"encoding/gob"
+	"encoding/hex"
+	"flag"
 	"graduation/component"
 	"graduation/config"
 	"graduation/controller"
+	"graduation/mapper"
+	"graduation/media"
+	"graduation/migration"
+	"graduation/services"
 	"log"
 	"time"
 
@@ -13,108 +20,295 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func setupMiddleware(r *gin.Engine) {
+func setupMiddleware(r *gin.Engine, sessionSecret string, tokens *component.TokenManager, apiKeys *component.ApiKeyManager) {
 	gob.Register(time.Time{})
-	store := cookie.NewStore([]byte("secret"))
+	store := cookie.NewStore([]byte(sessionSecret))
 	store.Options(sessions.Options{
 		MaxAge: 3600, // 1 hour
 	})
 	r.Use(sessions.Sessions("mysession", store))
 	r.Use(config.Cors())
 	// 添加登录拦截器
-	r.Use(component.LoginHandlerInterceptor())
+	r.Use(component.LoginHandlerInterceptor(tokens, apiKeys))
 }
 
-func registerAuthRoutes(r *gin.Engine) {
+func registerAuthRoutes(r *gin.Engine, user *controller.UserController) {
 	r.GET("/permission_denied", controller.PermissionDenied)
 	r.GET("/getLoginStatus", controller.GetLoginStatus)
-	r.POST("/login", controller.Login)
-	r.POST("/logout", controller.Logout)
-	r.POST("/registered", controller.Registered)
-	r.PUT("/similarity", controller.SetSimilarityThreshold)
+	r.POST("/login", user.Login)
+	r.POST("/refreshToken", user.RefreshToken)
+	r.POST("/logout", user.Logout)
+	r.POST("/registered", user.Registered)
+	r.PUT("/similarity", user.SetSimilarityThreshold)
+	r.POST("/changePassword", user.ChangePassword)
 }
 
-func registerUserManagementRoutes(r *gin.Engine) {
-	r.GET("/getApplyUser", controller.GetApplyUser)
-	r.GET("/getAllUser", controller.GetAllUser)
-	r.GET("/deleteUser", controller.DeleteUser)
-	r.GET("/passApply", controller.PassApply)
-	r.GET("/deleteApply", controller.DeleteApply)
+func registerAuditRoutes(r *gin.Engine, audit *controller.AuditController) {
+	r.GET("/audit", component.RequirePermission(component.PermAuditRead), audit.GetAuditLogs)
+}
+
+func registerApiKeyRoutes(r *gin.Engine, apiKey *controller.ApiKeyController) {
+	// 个人 API 密钥，只能管理自己的密钥
+	apiKeysGroup := r.Group("/apiKeys")
+	{
+		apiKeysGroup.POST("", apiKey.CreateApiKey)
+		apiKeysGroup.GET("", apiKey.GetApiKeys)
+		apiKeysGroup.DELETE("/:id", apiKey.RevokeApiKey)
+	}
+}
+
+func registerUserManagementRoutes(r *gin.Engine, user *controller.UserController) {
+	admin := r.Group("", component.RequirePermission(component.PermUserManage))
+	admin.GET("/getApplyUser", user.GetApplyUser)
+	admin.GET("/getAllUser", user.GetAllUser)
+	admin.GET("/deleteUser", user.DeleteUser)
+	admin.GET("/passApply", user.PassApply)
+	admin.GET("/deleteApply", user.DeleteApply)
+	admin.GET("/unlockUser", user.UnlockUser)
+	admin.POST("/resetPassword", user.ResetPassword)
 }
 
 func registerQuestionBankRoutes(r *gin.Engine, qBan *controller.QuestionBankController) {
 	// Question bank management
-	r.GET("/getAllQuestionBank", qBan.GetAllQuestionBank)
-	r.GET("/getQuestionBank", qBan.GetQuestionBank)
-	r.GET("/getTopicType", qBan.GetTopicType)
-	r.GET("/searchQuestionByTopic", qBan.SearchQuestionByTopic)
-	r.POST("/insertSingleQuestionBank", qBan.InsertSingleQuestionBank)
-	r.POST("/insertSingleQuestionBankWithImg", qBan.InsertSingleQuestionBankWithImg)
-	r.GET("/deleteSingleQuestionBank", qBan.DeleteSingleQuestionBank)
-	r.GET("/getQuestionBankById", qBan.GetQuestionBankById)
-	r.POST("/updateQuestionBankById", qBan.UpdateQuestionBankById)
-	r.POST("/upload", qBan.UploadFile)
-	r.GET("/getEachChapterCount", qBan.GetEachChapterCount)
-	r.GET("/getEachScoreCount", qBan.GetEachScoreCount)
+	read := r.Group("", component.RequirePermission(component.PermQuestionRead))
+	read.GET("/getAllQuestionBank", qBan.GetAllQuestionBank)
+	read.GET("/getQuestionBank", qBan.GetQuestionBank)
+	read.GET("/getTopicType", qBan.GetTopicType)
+	read.GET("/searchQuestionByTopic", qBan.SearchQuestionByTopic)
+	read.GET("/searchQuestionBank", qBan.SearchQuestionBank)
+	read.GET("/getDuplicateQuestions", qBan.GetDuplicateQuestions)
+	read.GET("/getQuestionBankById", qBan.GetQuestionBankById)
+	read.GET("/getEachChapterCount", qBan.GetEachChapterCount)
+	read.GET("/getEachScoreCount", qBan.GetEachScoreCount)
+	read.POST("/previewFormula", qBan.PreviewFormula)
+
+	// /upload 的 isDeleteAll 另外需要 PermQuestionDeleteAll，在处理函数中检查
+	write := r.Group("", component.RequirePermission(component.PermQuestionWrite))
+	write.POST("/insertSingleQuestionBank", qBan.InsertSingleQuestionBank)
+	write.POST("/insertSingleQuestionBankWithImg", qBan.InsertSingleQuestionBankWithImg)
+	write.GET("/deleteSingleQuestionBank", qBan.DeleteSingleQuestionBank)
+	write.POST("/updateQuestionBankById", qBan.UpdateQuestionBankById)
+	write.POST("/upload", qBan.UploadFile)
+
+	// 回收站
+	recycleBinGroup := r.Group("/recycleBin", component.RequirePermission(component.PermQuestionWrite))
+	{
+		recycleBinGroup.GET("", qBan.GetRecycleBin)
+		recycleBinGroup.POST("/restore", qBan.RestoreQuestions)
+		recycleBinGroup.POST("/purge", qBan.PurgeQuestions)
+	}
+
+	// 题目审核
+	reviewGroup := r.Group("/review")
+	{
+		reviewGroup.POST("/submit", component.RequirePermission(component.PermQuestionWrite), qBan.SubmitQuestions)
+		reviewGroup.POST("/redraft", component.RequirePermission(component.PermQuestionWrite), qBan.RedraftQuestions)
+		reviewGroup.POST("/approve", component.RequirePermission(component.PermQuestionReview), qBan.ApproveQuestions)
+		reviewGroup.POST("/reject", component.RequirePermission(component.PermQuestionReview), qBan.RejectQuestions)
+		reviewGroup.POST("/retire", component.RequirePermission(component.PermQuestionReview), qBan.RetireQuestions)
+		reviewGroup.POST("/assign", component.RequirePermission(component.PermQuestionReview), qBan.AssignReviewer)
+	}
+	// 审核意见，发表意见需要题目编辑或审核权限，在处理函数中检查
+	commentsGroup := r.Group("/questions/:id/comments", component.RequirePermission(component.PermQuestionRead))
+	{
+		commentsGroup.GET("", qBan.GetReviewComments)
+		commentsGroup.POST("", qBan.AddReviewComment)
+	}
+
+	// 题目历史版本
+	revisionsGroup := r.Group("/questions/:id/revisions")
+	{
+		revisionsGroup.GET("", component.RequirePermission(component.PermQuestionRead), qBan.GetQuestionRevisions)
+		revisionsGroup.GET("/:revision", component.RequirePermission(component.PermQuestionRead), qBan.GetQuestionRevision)
+		revisionsGroup.GET("/:revision/diff", component.RequirePermission(component.PermQuestionRead), qBan.DiffQuestionRevision)
+		revisionsGroup.POST("/:revision/rollback", component.RequirePermission(component.PermQuestionWrite), qBan.RollbackQuestion)
+	}
 }
 
-func registerQuestionGenRoutes(r *gin.Engine) {
+func registerQuestionGenRoutes(r *gin.Engine, gen *controller.QuestionGenController, history *controller.HistoryController, label *controller.LabelController) {
 	// Question generation and history
-	r.GET("/getQuestionGenHistoriesByTestPaperUid", controller.GetQuestionGenHistoriesByTestPaperUid)
-	r.GET("/deleteQuestionGenHistoryByTestPaperUid", controller.DeleteQuestionGenHistoryByTestPaperUid)
-	r.POST("/updateQuestionGenHistory", controller.UpdateQuestionGenHistory)
-	r.GET("/reExportTestPaper", controller.ReExportTestPaper)
-	r.GET("/exportAnswer", controller.ExportAnswer)
-	r.GET("/getAllTestPaperGenHistory", controller.GetAllTestPaperGenHistory)
+	paper := r.Group("", component.RequirePermission(component.PermPaperGenerate))
+	paper.GET("/getQuestionGenHistoriesByTestPaperUid", history.GetQuestionGenHistoriesByTestPaperUid)
+	paper.GET("/deleteQuestionGenHistoryByTestPaperUid", history.DeleteQuestionGenHistoryByTestPaperUid)
+	paper.POST("/updateQuestionGenHistory", history.UpdateQuestionGenHistory)
+	paper.GET("/reExportTestPaper", history.ReExportTestPaper)
+	paper.GET("/exportAnswer", history.ExportAnswer)
+	paper.GET("/getTestPaperChanges", history.GetTestPaperChanges)
+	paper.POST("/lockTestPaper", history.LockTestPaper)
+	paper.GET("/getAllTestPaperGenHistory", history.GetAllTestPaperGenHistory)
 
 	// Question metadata
-	r.GET("/getAllQuestionLabels", controller.GetAllQuestionLabels)
-	r.GET("/getDistinctChapter1", controller.GetDistinctChapter1)
-	r.GET("/getDistinctChapter2", controller.GetDistinctChapter2)
-	r.GET("/getChapter2ByChapter1", controller.GetChapter2ByChapter1)
-	r.GET("/getDistinctLabel1", controller.GetDistinctLabel1)
-	r.GET("/getDistinctLabel2", controller.GetDistinctLabel2)
+	read := r.Group("", component.RequirePermission(component.PermQuestionRead))
+	read.GET("/getAllQuestionLabels", label.GetAllQuestionLabels)
+	read.GET("/getDistinctChapter1", label.GetDistinctChapter1)
+	read.GET("/getDistinctChapter2", label.GetDistinctChapter2)
+	read.GET("/getChapter2ByChapter1", label.GetChapter2ByChapter1)
+	read.GET("/getDistinctLabel1", label.GetDistinctLabel1)
+	read.GET("/getDistinctLabel2", label.GetDistinctLabel2)
 
 	// Question generation algorithms
-	r.POST("/randomSelect", controller.RandomSelect)
-	r.POST("/geneticSelect", controller.GeneticSelect)
-	r.POST("/questionGen", controller.QuestionGen)
-	r.POST("/questionGen2", controller.QuestionGen2)
-	r.POST("/getFile", controller.GetFile)
+	paper.POST("/randomSelect", gen.RandomSelect)
+	paper.POST("/geneticSelect", gen.GeneticSelect)
+	paper.POST("/questionGen", gen.QuestionGen)
+	paper.POST("/questionGen2", gen.QuestionGen2)
+	paper.POST("/getFile", gen.GetFile)
 }
 
-func registerLabelRoutes(r *gin.Engine) {
+func registerLabelRoutes(r *gin.Engine, label *controller.LabelController) {
 	// 题目标签相关路由
 	labelsGroup := r.Group("/labels")
 	{
-		labelsGroup.POST("", controller.CreateLabel)
-		labelsGroup.PUT("/:id", controller.UpdateLabel)
-		labelsGroup.DELETE("/:id", controller.DeleteLabel)
+		labelsGroup.POST("", component.RequirePermission(component.PermLabelWrite), label.CreateLabel)
+		labelsGroup.PUT("/:id", component.RequirePermission(component.PermLabelWrite), label.UpdateLabel)
+		labelsGroup.DELETE("/:id", component.RequirePermission(component.PermLabelDelete), label.DeleteLabel)
+	}
+}
+
+func registerMaterialRoutes(r *gin.Engine, material *controller.MaterialController) {
+	// 题目材料，多道题目共用的阅读材料、程序段或数据表
+	materialsGroup := r.Group("/materials")
+	{
+		materialsGroup.GET("", component.RequirePermission(component.PermQuestionRead), material.GetQuestionMaterials)
+		materialsGroup.GET("/:id", component.RequirePermission(component.PermQuestionRead), material.GetQuestionMaterial)
+		materialsGroup.POST("", component.RequirePermission(component.PermQuestionWrite), material.CreateQuestionMaterial)
+		materialsGroup.PUT("/:id", component.RequirePermission(component.PermQuestionWrite), material.UpdateQuestionMaterial)
+		materialsGroup.DELETE("/:id", component.RequirePermission(component.PermQuestionWrite), material.DeleteQuestionMaterial)
+		materialsGroup.POST("/:id/questions", component.RequirePermission(component.PermQuestionWrite), material.LinkQuestions)
+		materialsGroup.DELETE("/:id/questions/:questionId", component.RequirePermission(component.PermQuestionWrite), material.UnlinkQuestion)
 	}
 }
 
+func registerAttachmentRoutes(r *gin.Engine, attachment *controller.AttachmentController) {
+	// 题目图片，一道题目的题干、选项和答案都可以有多张图片
+	questionsGroup := r.Group("/questions")
+	{
+		questionsGroup.GET("/:id/attachments", component.RequirePermission(component.PermQuestionRead), attachment.GetQuestionAttachments)
+		questionsGroup.POST("/:id/attachments", component.RequirePermission(component.PermQuestionWrite), attachment.UploadQuestionAttachments)
+		questionsGroup.PUT("/:id/attachments/order", component.RequirePermission(component.PermQuestionWrite), attachment.ReorderQuestionAttachments)
+	}
+	attachmentsGroup := r.Group("/attachments")
+	{
+		attachmentsGroup.PUT("/:id", component.RequirePermission(component.PermQuestionWrite), attachment.UpdateQuestionAttachment)
+		attachmentsGroup.DELETE("/:id", component.RequirePermission(component.PermQuestionWrite), attachment.DeleteQuestionAttachment)
+	}
+}
+
+func registerMediaRoutes(r *gin.Engine, mediaCtl *controller.MediaController) {
+	// 题目图片等媒体文件，地址由 media.URL 生成，w 参数为缩略图宽度
+	r.GET("/media/:id", component.RequirePermission(component.PermQuestionRead), mediaCtl.GetMedia)
+}
+
 func main() {
+	configPath := flag.String("config", "", "配置文件路径，默认读取 EPG_CONFIG 或 "+config.DefaultConfigPath)
+	flag.Parse()
+
+	cfg, err := config.LoadConfig(*configPath)
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	// Connect database
+	db, err := mapper.Open(cfg.Database)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	defer mapper.Close()
+
+	// migrate 子命令：执行数据库迁移后退出
+	if flag.Arg(0) == "migrate" {
+		if err := runMigrate(db, flag.Args()[1:]); err != nil {
+			log.Fatalf("Migrate failed: %v", err)
+		}
+		return
+	}
+	if cfg.Database.AutoMigrate {
+		if err := migration.Migrate(db); err != nil {
+			log.Fatalf("Failed to migrate database: %v", err)
+		}
+	}
+
+	// Media store
+	store, err := media.Open(cfg.Media)
+	if err != nil {
+		log.Fatalf("Failed to open media store: %v", err)
+	}
+	media.SetDefault(store)
+	mediaRefs := mapper.NewMediaMapper()
+	collector := services.NewMediaCollector(store, mediaRefs, cfg.Media.GC.Grace)
+	// media 子命令：清理或导入媒体文件后退出
+	if flag.Arg(0) == "media" {
+		if err := runMedia(collector, mediaRefs, flag.Args()[1:]); err != nil {
+			log.Fatalf("Media command failed: %v", err)
+		}
+		return
+	}
+
+	// Repositories
+	questions := mapper.NewQuestionBankMapper()
+	history := mapper.NewHistoryMapper()
+	labels := mapper.NewQuestionLabelsMapper()
+	users := mapper.NewUserMapper()
+	materials := mapper.NewQuestionMaterialMapper()
+	attachments := mapper.NewQuestionAttachmentMapper()
+
+	ensureSecret(&cfg.Auth.SessionSecret, "session")
+	ensureSecret(&cfg.Auth.Token.Secret, "token")
+	tokens, err := component.NewTokenManager(cfg.Auth.Token, mapper.NewRevokedTokenMapper())
+	if err != nil {
+		log.Fatalf("Failed to create token manager: %v", err)
+	}
+	apiKeys := component.NewApiKeyManager(mapper.NewApiKeyMapper(), users)
+	limiter := component.NewLoginLimiter(cfg.Auth.Lockout, users)
+	auditLogs := mapper.NewAuditLogMapper()
+	auditor := component.NewAuditor(auditLogs)
+
+	services.StartHistoryCache(history)
+	services.StartMediaGC(collector, cfg.Media.GC.Interval)
+
 	r := gin.Default()
 
 	// Setup middleware
-	setupMiddleware(r)
+	setupMiddleware(r, cfg.Auth.SessionSecret, tokens, apiKeys)
 
 	// Register routes
-	registerAuthRoutes(r)
-	registerUserManagementRoutes(r)
-	qBan := controller.NewQuestionBankController()
+	userCtl := controller.NewUserController(users, tokens, limiter, auditor)
+	labelCtl := controller.NewLabelController(labels, auditor)
+	registerAuthRoutes(r, userCtl)
+	registerUserManagementRoutes(r, userCtl)
+	registerApiKeyRoutes(r, controller.NewApiKeyController(users, apiKeys, auditor))
+	registerAuditRoutes(r, controller.NewAuditController(auditLogs))
+	qBan := controller.NewQuestionBankController(questions, attachments, mapper.NewQuestionRevisionMapper(), mapper.NewQuestionReviewMapper(), history, labels, auditor)
 	registerQuestionBankRoutes(r, qBan)
-	registerQuestionGenRoutes(r)
-	registerLabelRoutes(r)
+	registerQuestionGenRoutes(r,
+		controller.NewQuestionGenController(questions, users, history, materials, attachments),
+		controller.NewHistoryController(history, questions, materials, attachments, auditor),
+		labelCtl)
+	registerLabelRoutes(r, labelCtl)
+	registerMaterialRoutes(r, controller.NewMaterialController(materials, questions, auditor))
+	registerAttachmentRoutes(r, controller.NewAttachmentController(attachments, questions, auditor))
+	registerMediaRoutes(r, controller.NewMediaController(store))
 
 	// Start server
-	addr := ":8081"
+	addr := cfg.Server.Addr
 	log.Printf("Server starting on %s", addr)
 	if err := r.Run(addr); err != nil {
 		log.Fatalf("Failed to start server: %v", err)
 	}
 }
 
+// ensureSecret 未配置密钥时随机生成，重启后已签发的 session 和令牌全部失效
+func ensureSecret(secret *string, name string) {
+	if *secret != "" {
+		return
+	}
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		log.Fatalf("Failed to generate %s secret: %v", name, err)
+	}
+	*secret = hex.EncodeToString(buf)
+	log.Printf("Warning: %s secret is not configured, using a random one", name)
+}
+
 // 说明：最后组成的试卷的整体难度怎么算出来的
 
 // 必须实现
@@ -0,0 +1,52 @@
+package mapper
+
+import (
+	"graduation/entity"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ApiKeyMapper 个人 API 密钥的数据库操作
+type ApiKeyMapper struct {
+	db *gorm.DB
+}
+
+// NewApiKeyMapper 创建一个新的 ApiKeyMapper 实例
+func NewApiKeyMapper() *ApiKeyMapper {
+	return &ApiKeyMapper{
+		db: DB,
+	}
+}
+
+// CreateApiKey 保存新的 API 密钥
+func (m *ApiKeyMapper) CreateApiKey(key *entity.ApiKey) error {
+	return m.db.Create(key).Error
+}
+
+// GetApiKeyByHash 根据密钥摘要获取未注销的 API 密钥
+func (m *ApiKeyMapper) GetApiKeyByHash(keyHash string) (entity.ApiKey, error) {
+	var key entity.ApiKey
+	result := m.db.Where("key_hash = ? AND revoked_at IS NULL", keyHash).First(&key)
+	return key, result.Error
+}
+
+// GetApiKeysByUsername 获取用户的全部 API 密钥，包括已注销的
+func (m *ApiKeyMapper) GetApiKeysByUsername(username string) ([]entity.ApiKey, error) {
+	var keys []entity.ApiKey
+	result := m.db.Where("username = ?", username).Order("id").Find(&keys)
+	return keys, result.Error
+}
+
+// RevokeApiKey 注销用户的 API 密钥，返回受影响的行数
+func (m *ApiKeyMapper) RevokeApiKey(username string, id int, revokedAt time.Time) (int64, error) {
+	result := m.db.Model(&entity.ApiKey{}).
+		Where("id = ? AND username = ? AND revoked_at IS NULL", id, username).
+		Update("revoked_at", revokedAt)
+	return result.RowsAffected, result.Error
+}
+
+// UpdateApiKeyLastUsed 更新 API 密钥最后使用时间
+func (m *ApiKeyMapper) UpdateApiKeyLastUsed(id int, lastUsed time.Time) error {
+	return m.db.Model(&entity.ApiKey{}).Where("id = ?", id).Update("last_used_at", lastUsed).Error
+}
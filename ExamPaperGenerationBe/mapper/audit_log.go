@@ -0,0 +1,71 @@
+package mapper
+
+import (
+	"graduation/entity"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// AuditLogQuery 审计日志查询条件，字段为零值时不过滤
+type AuditLogQuery struct {
+	Actor      string
+	Action     string
+	EntityType string
+	EntityID   string
+	Start      time.Time // 包含
+	End        time.Time // 不包含
+	Offset     int
+	Limit      int
+}
+
+// AuditLogMapper 审计日志的数据库操作
+type AuditLogMapper struct {
+	db *gorm.DB
+}
+
+// NewAuditLogMapper 创建一个新的 AuditLogMapper 实例
+func NewAuditLogMapper() *AuditLogMapper {
+	return &AuditLogMapper{
+		db: DB,
+	}
+}
+
+// InsertAuditLog 保存一条审计日志
+func (m *AuditLogMapper) InsertAuditLog(log *entity.AuditLog) error {
+	return m.db.Create(log).Error
+}
+
+// QueryAuditLogs 按条件分页查询审计日志，按时间倒序，同时返回符合条件的总数
+func (m *AuditLogMapper) QueryAuditLogs(q AuditLogQuery) ([]entity.AuditLog, int64, error) {
+	tx := m.db.Model(&entity.AuditLog{})
+	if q.Actor != "" {
+		tx = tx.Where("actor = ?", q.Actor)
+	}
+	if q.Action != "" {
+		tx = tx.Where("action = ?", q.Action)
+	}
+	if q.EntityType != "" {
+		tx = tx.Where("entity_type = ?", q.EntityType)
+	}
+	if q.EntityID != "" {
+		tx = tx.Where("entity_id = ?", q.EntityID)
+	}
+	if !q.Start.IsZero() {
+		tx = tx.Where("created_at >= ?", q.Start)
+	}
+	if !q.End.IsZero() {
+		tx = tx.Where("created_at < ?", q.End)
+	}
+	var total int64
+	if err := tx.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var logs []entity.AuditLog
+	tx = tx.Order("created_at DESC").Order("id DESC").Offset(q.Offset)
+	if q.Limit > 0 {
+		tx = tx.Limit(q.Limit)
+	}
+	result := tx.Find(&logs)
+	return logs, total, result.Error
+}
@@ -0,0 +1,183 @@
+package mapper
+
+import (
+	"html"
+	"math"
+	"strings"
+	"unicode"
+)
+
+// BM25 参数
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// SearchField 参与全文检索的字段及其权重
+type SearchField struct {
+	Text   string
+	Weight float64
+}
+
+// isCJK 判断是否为中日韩文字，这些文字之间没有空格分词
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
+
+// Tokenize 全文检索分词：中日韩文字按相邻两字切分（单字单独成词），字母和数字按连续片段切分并转为小写，
+// 其余字符作为分隔符。例如 "8086的中断" 切分为 ["8086", "的中", "中断"]
+func Tokenize(text string) []string {
+	var tokens []string
+	var word, cjk []rune
+	flushWord := func() {
+		if len(word) > 0 {
+			tokens = append(tokens, string(word))
+			word = word[:0]
+		}
+	}
+	flushCJK := func() {
+		if len(cjk) == 1 {
+			tokens = append(tokens, string(cjk))
+		}
+		for i := 0; i+1 < len(cjk); i++ {
+			tokens = append(tokens, string(cjk[i:i+2]))
+		}
+		cjk = cjk[:0]
+	}
+	for _, r := range text {
+		switch {
+		case isCJK(r):
+			flushWord()
+			cjk = append(cjk, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			flushCJK()
+			word = append(word, unicode.ToLower(r))
+		default:
+			flushWord()
+			flushCJK()
+		}
+	}
+	flushWord()
+	flushCJK()
+	return tokens
+}
+
+// uniqueTokens 去掉重复的词，保持原有顺序
+func uniqueTokens(tokens []string) []string {
+	seen := make(map[string]bool, len(tokens))
+	out := tokens[:0:0]
+	for _, t := range tokens {
+		if !seen[t] {
+			seen[t] = true
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// RankBM25 使用 BM25 计算每个文档与查询的相关度，文档由若干带权重的字段组成，
+// 字段权重作用于词频和文档长度。没有命中任何查询词的文档得分为 0
+func RankBM25(query string, docs [][]SearchField) []float64 {
+	terms := uniqueTokens(Tokenize(query))
+	scores := make([]float64, len(docs))
+	if len(terms) == 0 || len(docs) == 0 {
+		return scores
+	}
+
+	tfs := make([]map[string]float64, len(docs))
+	lengths := make([]float64, len(docs))
+	df := make(map[string]int, len(terms))
+	var totalLength float64
+	for i, fields := range docs {
+		tf := make(map[string]float64)
+		for _, f := range fields {
+			tokens := Tokenize(f.Text)
+			lengths[i] += f.Weight * float64(len(tokens))
+			for _, t := range tokens {
+				tf[t] += f.Weight
+			}
+		}
+		for _, t := range terms {
+			if tf[t] > 0 {
+				df[t]++
+			}
+		}
+		tfs[i] = tf
+		totalLength += lengths[i]
+	}
+	avgLength := totalLength / float64(len(docs))
+	if avgLength == 0 {
+		return scores
+	}
+
+	n := float64(len(docs))
+	for i, tf := range tfs {
+		norm := bm25K1 * (1 - bm25B + bm25B*lengths[i]/avgLength)
+		for _, t := range terms {
+			f := tf[t]
+			if f == 0 {
+				continue
+			}
+			idf := math.Log(1 + (n-float64(df[t])+0.5)/(float64(df[t])+0.5))
+			scores[i] += idf * f * (bm25K1 + 1) / (f + norm)
+		}
+	}
+	return scores
+}
+
+// HighlightSnippet 截取 text 中第一处命中附近最多 maxRunes 个字的片段，命中查询词的文字用 <em></em> 包裹，
+// 其余文字做 HTML 转义，片段被截断时首尾加省略号。没有命中时返回空字符串
+func HighlightSnippet(text, query string, maxRunes int) string {
+	runes := []rune(text)
+	lower := make([]rune, len(runes))
+	for i, r := range runes {
+		lower[i] = unicode.ToLower(r)
+	}
+	marks := make([]bool, len(runes))
+	first := -1
+	for _, term := range uniqueTokens(Tokenize(query)) {
+		t := []rune(term)
+		for i := 0; i+len(t) <= len(lower); i++ {
+			if string(lower[i:i+len(t)]) != term {
+				continue
+			}
+			for j := i; j < i+len(t); j++ {
+				marks[j] = true
+			}
+			if first < 0 || i < first {
+				first = i
+			}
+		}
+	}
+	if first < 0 {
+		return ""
+	}
+
+	start, end := 0, len(runes)
+	if maxRunes > 0 && len(runes) > maxRunes {
+		start = max(0, first-maxRunes/4)
+		end = min(len(runes), start+maxRunes)
+		start = max(0, end-maxRunes)
+	}
+	var sb strings.Builder
+	if start > 0 {
+		sb.WriteString("…")
+	}
+	for i := start; i < end; {
+		j := i
+		for j < end && marks[j] == marks[i] {
+			j++
+		}
+		chunk := html.EscapeString(string(runes[i:j]))
+		if marks[i] {
+			sb.WriteString("<em>" + chunk + "</em>")
+		} else {
+			sb.WriteString(chunk)
+		}
+		i = j
+	}
+	if end < len(runes) {
+		sb.WriteString("…")
+	}
+	return sb.String()
+}
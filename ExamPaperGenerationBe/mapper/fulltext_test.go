@@ -0,0 +1,58 @@
+package mapper
+
+import (
+	"graduation/entity"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenize(t *testing.T) {
+	require.Equal(t, []string{"8086", "的中", "中断"}, Tokenize("8086的中断"))
+	require.Equal(t, []string{"intr", "是", "cpu", "a", "b"}, Tokenize("INTR 是 CPU，a-b"))
+	require.Equal(t, []string{"可屏", "屏蔽", "eu", "biu"}, Tokenize("可屏蔽（EU/BIU）"))
+	require.Empty(t, Tokenize("，。 ？"))
+}
+
+func TestRankBM25(t *testing.T) {
+	docs := [][]SearchField{
+		{{Text: "8086 有几个段寄存器？", Weight: 1}},
+		{{Text: "8259A 中断控制器的作用", Weight: 1}},
+		{{Text: "INTR 可屏蔽中断和 NMI 非屏蔽中断的区别", Weight: 1}},
+		{{Text: "地址总线", Weight: 1}, {Text: "中断", Weight: 3}},
+	}
+	scores := RankBM25("中断", docs)
+	require.Zero(t, scores[0])
+	require.Greater(t, scores[3], scores[2])
+	require.Greater(t, scores[2], scores[1])
+	require.Equal(t, make([]float64, len(docs)), RankBM25("？", docs))
+}
+
+func TestHighlightSnippet(t *testing.T) {
+	require.Equal(t, "<em>8086</em> 的 a&lt;b <em>中断</em>", HighlightSnippet("8086 的 a<b 中断", "中断 8086", 0))
+	require.Empty(t, HighlightSnippet("段寄存器", "中断", 0))
+
+	snippet := HighlightSnippet("一二三四五六七八九十中断甲乙丙丁戊己庚辛壬癸", "中断", 8)
+	require.Equal(t, "…九十<em>中断</em>甲乙丙丁…", snippet)
+}
+
+func TestRankQuestionBanks(t *testing.T) {
+	questions := []entity.QuestionBank{
+		{ID: 1, Topic: "8086 有几个段寄存器？", TopicType: "填空题"},
+		{ID: 2, Topic: "8259A 的作用", Answer: "中断控制", TopicType: "简答题"},
+		{ID: 3, Topic: "INTR 是可屏蔽中断", Answer: "对", TopicType: "判断题"},
+		{ID: 4, Topic: "下列说法正确的是", Answer: "A", TopicType: "选择题", Label1: "中断"},
+	}
+	hits := RankQuestionBanks(questions, QuestionSearchQuery{Keyword: "中断"})
+	ids := make([]int, len(hits))
+	for i, h := range hits {
+		ids[i] = h.Question.ID
+	}
+	require.Equal(t, []int{3, 2, 4}, ids)
+
+	hits = RankQuestionBanks(questions, QuestionSearchQuery{Keyword: "中断", TopicType: "简答题"})
+	require.Len(t, hits, 1)
+	require.Equal(t, 2, hits[0].Question.ID)
+
+	require.Len(t, RankQuestionBanks(questions, QuestionSearchQuery{Keyword: "中断", Limit: 1}), 1)
+}
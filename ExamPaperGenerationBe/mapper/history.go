@@ -0,0 +1,63 @@
+package mapper
+
+import (
+	"graduation/entity"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// HistoryMapper 组合试卷生成历史和题目生成历史的映射器
+type HistoryMapper struct {
+	*QuestionGenHistoryMapper
+	*TestPaperGenHistoryGormMapper
+	db *gorm.DB
+}
+
+// NewHistoryMapper 创建一个新的 HistoryMapper 实例
+func NewHistoryMapper() *HistoryMapper {
+	return &HistoryMapper{
+		QuestionGenHistoryMapper:      NewQuestionGenHistoryMapper(),
+		TestPaperGenHistoryGormMapper: NewTestPaperGenHistoryGormMapper(),
+		db:                            DB,
+	}
+}
+
+// GetQuestionIdsUsedSince 获取指定时间之后生成的试卷中使用过的题目 ID
+func (m *HistoryMapper) GetQuestionIdsUsedSince(since time.Time) ([]int, error) {
+	var questionIds []int
+	result := m.db.Model(&entity.QuestionGenHistory{}).
+		Select("DISTINCT questiongenhistory.question_bank_id").
+		Joins("JOIN testpapergenhistory ON testpapergenhistory.test_paper_uid = questiongenhistory.test_paper_uid").
+		Where("testpapergenhistory.update_time >= ?", since).
+		Pluck("question_bank_id", &questionIds)
+	return questionIds, result.Error
+}
+
+// SetTestPaperLocked 锁定或解锁试卷
+func (m *HistoryMapper) SetTestPaperLocked(testPaperUID string, locked bool) (int64, error) {
+	result := m.db.Model(&entity.TestPaperGenHistory{}).Where("test_paper_uid = ?", testPaperUID).Update("locked", locked)
+	return result.RowsAffected, result.Error
+}
+
+// IsTestPaperLocked 判断试卷是否已锁定，试卷不存在时返回 false
+func (m *HistoryMapper) IsTestPaperLocked(testPaperUID string) (bool, error) {
+	var count int64
+	result := m.db.Model(&entity.TestPaperGenHistory{}).Where("test_paper_uid = ? AND locked = ?", testPaperUID, true).Count(&count)
+	return count > 0, result.Error
+}
+
+// GetLockedQuestionIds 返回 questionIDs 中被已锁定的试卷引用的题目 ID
+func (m *HistoryMapper) GetLockedQuestionIds(questionIDs []int) ([]int, error) {
+	if len(questionIDs) == 0 {
+		return nil, nil
+	}
+	var ids []int
+	result := m.db.Model(&entity.QuestionGenHistory{}).
+		Select("DISTINCT questiongenhistory.question_bank_id").
+		Joins("JOIN testpapergenhistory ON testpapergenhistory.test_paper_uid = questiongenhistory.test_paper_uid").
+		Where("testpapergenhistory.locked = ? AND questiongenhistory.question_bank_id IN ?", true, questionIDs).
+		Order("questiongenhistory.question_bank_id").
+		Pluck("question_bank_id", &ids)
+	return ids, result.Error
+}
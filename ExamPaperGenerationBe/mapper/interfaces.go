@@ -0,0 +1,162 @@
+package mapper
+
+import (
+	"graduation/entity"
+	"time"
+)
+
+// QuestionRepository 题库数据访问接口，GORM 实现为 QuestionBankMapper
+type QuestionRepository interface {
+	GetAllQuestionBank() ([]entity.QuestionBank, error)
+	GetQuestionBankById(id int) ([]entity.QuestionBank, error)
+	GetQuestionBanksInIds(ids []int) ([]entity.QuestionBank, error)
+	GetDistinctTopicType() ([]string, error)
+	SearchQuestionByTopic(topicType, keyword string) ([]entity.QuestionBank, error)
+	InsertSingleQuestionBank(questionBank *entity.QuestionBank) (int64, error)
+	UpdateSingleQuestionBank(questionBank *entity.QuestionBank) (int64, error)
+	GetAvgDifficultyByIds(ids []int) (float64, error)
+	GetDistinctLabel1FromQuestionBank() ([]string, error)
+	GetQuestionBankCountByLabel1(label1 string) (int, error)
+	GetDistinctScoreFromQuestionBank() ([]float64, error)
+	GetQuestionBankCountByScore(score float64) (int, error)
+	GetQuestionBankByIds(ids []int, generateRange []string) ([]entity.QuestionBank, error)
+	GetAll() ([]entity.QuestionBank, error)
+	QueryQuestionBanks(q QuestionBankQuery) (QuestionBankPage, error)
+	SearchQuestionBanks(q QuestionSearchQuery) ([]QuestionSearchHit, error)
+	GetQuestionBanksByMaterialId(materialID int) ([]entity.QuestionBank, error)
+	SetQuestionMaterial(ids []int, materialID int) (int64, error)
+	SetTopicImagePath(id int, path string) (int64, error)
+	ReplaceQuestionBank(questionBank *entity.QuestionBank) (int64, error)
+	SoftDeleteQuestionBanks(ids []int, deletedBy string) (int64, error)
+	ImportQuestionBanks(questions []entity.QuestionBank, deleteIds []int, deletedBy string) (int64, error)
+	GetDeletedQuestionBanks() ([]entity.QuestionBank, error)
+	GetDeletedQuestionBanksInIds(ids []int) ([]entity.QuestionBank, error)
+	RestoreQuestionBanks(ids []int) (int64, error)
+	PurgeQuestionBanks(ids []int) (int64, error)
+	SetQuestionStatus(ids []int, status string) (int64, error)
+	SetQuestionReviewer(ids []int, reviewer string) (int64, error)
+}
+
+// MaterialRepository 题目材料数据访问接口，GORM 实现为 QuestionMaterialMapper
+type MaterialRepository interface {
+	GetAllQuestionMaterials() ([]entity.QuestionMaterial, error)
+	GetQuestionMaterialById(id int) (entity.QuestionMaterial, error)
+	GetQuestionMaterialsInIds(ids []int) ([]entity.QuestionMaterial, error)
+	CreateQuestionMaterial(material *entity.QuestionMaterial) error
+	UpdateQuestionMaterial(material *entity.QuestionMaterial) (int64, error)
+	DeleteQuestionMaterial(id int) (int64, error)
+}
+
+// AttachmentRepository 题目附件数据访问接口，GORM 实现为 QuestionAttachmentMapper
+type AttachmentRepository interface {
+	GetAttachmentsByQuestionId(questionID int) ([]entity.QuestionAttachment, error)
+	GetAttachmentsInQuestionIds(questionIDs []int) ([]entity.QuestionAttachment, error)
+	GetAttachmentById(id int) (entity.QuestionAttachment, error)
+	CreateAttachment(attachment *entity.QuestionAttachment) error
+	UpdateAttachment(attachment *entity.QuestionAttachment) (int64, error)
+	DeleteAttachment(id int) (int64, error)
+	DeleteAttachmentsByQuestionId(questionID int) (int64, error)
+}
+
+// RevisionRepository 题目历史版本数据访问接口，GORM 实现为 QuestionRevisionMapper
+type RevisionRepository interface {
+	InsertQuestionRevision(revision *entity.QuestionRevision) error
+	GetQuestionRevisions(questionID int) ([]entity.QuestionRevision, error)
+	GetQuestionRevision(questionID, revision int) (entity.QuestionRevision, error)
+	GetLatestQuestionRevision(questionID int) (entity.QuestionRevision, error)
+	DeleteQuestionRevisions(questionIDs []int) (int64, error)
+}
+
+// ReviewRepository 题目审核意见数据访问接口，GORM 实现为 QuestionReviewMapper
+type ReviewRepository interface {
+	InsertReviewComment(comment *entity.QuestionReviewComment) error
+	GetReviewComments(questionID int) ([]entity.QuestionReviewComment, error)
+	DeleteReviewComments(questionIDs []int) (int64, error)
+}
+
+// MediaRepository 查询数据库中引用的媒体文件，GORM 实现为 MediaMapper
+type MediaRepository interface {
+	// GetMediaRefs 返回题目、选项、材料、附件、题目历史版本和生成历史中保存的图片路径，包含全部 media: 引用，可能重复或夹杂旧版本的文件路径
+	GetMediaRefs() ([]string, error)
+}
+
+// HistoryRepository 试卷及题目生成历史数据访问接口，GORM 实现为 HistoryMapper
+type HistoryRepository interface {
+	InsertTestPaperGenHistory(testPaperGenHistory entity.TestPaperGenHistory) (int64, error)
+	QueryAllTestPaperGenHistory() ([]entity.TestPaperGenHistory, error)
+	GetTestPaperNameByTestPaperUid(testPaperUID string) ([]string, error)
+	UpdateTestPaperTime(testPaperUID string, date time.Time) (int64, error)
+	DeleteTestPaperGenHistoryByTestPaperUid(testPaperUID string) (int64, error)
+	InsertQuestionGenHistories(list []entity.QuestionGenHistory) (int64, error)
+	GetQuestionGenHistoriesByTestPaperUid(testPaperUid string) ([]entity.QuestionGenHistory, error)
+	DeleteQuestionGenHistoryByTestPaperUid(testPaperUid string) (int64, error)
+	GetQuestionIdsUsedSince(since time.Time) ([]int, error)
+	SetTestPaperLocked(testPaperUID string, locked bool) (int64, error)
+	IsTestPaperLocked(testPaperUID string) (bool, error)
+	GetLockedQuestionIds(questionIDs []int) ([]int, error)
+}
+
+// LabelRepository 知识点标签数据访问接口，GORM 实现为 QuestionLabelsMapper
+type LabelRepository interface {
+	GetAllQuestionLabels() ([]entity.QuestionLabels, error)
+	GetDistinctChapter1() ([]entity.QuestionLabels, error)
+	GetDistinctChapter2() ([]entity.QuestionLabels, error)
+	GetChapter2ByChapter1(chapter1 string) ([]entity.QuestionLabels, error)
+	GetDistinctLabel1() ([]entity.QuestionLabels, error)
+	GetDistinctLabel2() ([]entity.QuestionLabels, error)
+	GetLabelById(id int) (entity.QuestionLabels, error)
+	CreateLabel(label *entity.QuestionLabels) error
+	UpdateLabel(id int, label entity.QuestionLabels) error
+	DeleteLabel(id int) error
+}
+
+// UserRepository 用户数据访问接口，GORM 实现为 UserMapper
+type UserRepository interface {
+	GetUserByUsername(username string) ([]entity.User, error)
+	GetEnabledUserByUsername(username string) ([]entity.User, error)
+	AddNewUser(user *entity.User) (int64, error)
+	UpdateLastLoginTime(username string, lastLogin time.Time) error
+	GetApplyUser() ([]entity.User, error)
+	GetAllUser() ([]entity.User, error)
+	DeleteUser(username string) (int64, error)
+	PassApply(username string) (int64, error)
+	DeleteApply(username string) (int64, error)
+	UpdateUserSimilarityThreshold(username string, threshold float64) error
+	UpdateLoginFailures(username string, failedCount int, lockedUntil *time.Time) error
+	UpdatePassword(username, passwordHash string, mustChange bool) (int64, error)
+}
+
+// TokenRepository 令牌注销记录数据访问接口，GORM 实现为 RevokedTokenMapper
+type TokenRepository interface {
+	RevokeToken(jti string, expiresAt time.Time) error
+	IsTokenRevoked(jti string) (bool, error)
+	DeleteExpiredRevokedTokens(now time.Time) (int64, error)
+}
+
+// ApiKeyRepository 个人 API 密钥数据访问接口，GORM 实现为 ApiKeyMapper
+type ApiKeyRepository interface {
+	CreateApiKey(key *entity.ApiKey) error
+	GetApiKeyByHash(keyHash string) (entity.ApiKey, error)
+	GetApiKeysByUsername(username string) ([]entity.ApiKey, error)
+	RevokeApiKey(username string, id int, revokedAt time.Time) (int64, error)
+	UpdateApiKeyLastUsed(id int, lastUsed time.Time) error
+}
+
+// AuditRepository 审计日志数据访问接口，GORM 实现为 AuditLogMapper
+type AuditRepository interface {
+	InsertAuditLog(log *entity.AuditLog) error
+	QueryAuditLogs(q AuditLogQuery) ([]entity.AuditLog, int64, error)
+}
+
+var (
+	_ QuestionRepository   = (*QuestionBankMapper)(nil)
+	_ MaterialRepository   = (*QuestionMaterialMapper)(nil)
+	_ AttachmentRepository = (*QuestionAttachmentMapper)(nil)
+	_ RevisionRepository   = (*QuestionRevisionMapper)(nil)
+	_ HistoryRepository    = (*HistoryMapper)(nil)
+	_ LabelRepository      = (*QuestionLabelsMapper)(nil)
+	_ UserRepository       = (*UserMapper)(nil)
+	_ TokenRepository      = (*RevokedTokenMapper)(nil)
+	_ ApiKeyRepository     = (*ApiKeyMapper)(nil)
+	_ AuditRepository      = (*AuditLogMapper)(nil)
+)
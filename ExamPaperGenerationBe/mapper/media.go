@@ -0,0 +1,206 @@
+package mapper
+
+import (
+	"graduation/entity"
+	"graduation/media"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// MediaMapper 查询各表中保存的媒体文件引用
+type MediaMapper struct {
+	db *gorm.DB
+}
+
+// NewMediaMapper 创建一个新的 MediaMapper 实例
+func NewMediaMapper() *MediaMapper {
+	return &MediaMapper{
+		db: DB,
+	}
+}
+
+// historySnapshotLike 按 LIKE 筛选出题记录快照列的条件，三个参数相同
+const historySnapshotLike = "question_json LIKE ? OR attachments_json LIKE ? OR material_json LIKE ?"
+
+// GetMediaRefs 返回全部 media: 引用。选项和材料图片保存在 JSON 列中，先用 LIKE 筛选再解析
+func (m *MediaMapper) GetMediaRefs() ([]string, error) {
+	return m.imagePaths(" LIKE ?", media.RefPrefix+"%", "%"+media.RefPrefix+"%", func(path string) bool {
+		_, ok := media.ParseRef(path)
+		return ok
+	})
+}
+
+// GetLegacyImagePaths 返回旧版本直接保存在磁盘上的图片路径，即不是 media: 引用的非空路径
+func (m *MediaMapper) GetLegacyImagePaths() ([]string, error) {
+	return m.imagePaths(" NOT LIKE ?", media.RefPrefix+"%", "%_%", func(path string) bool {
+		_, ok := media.ParseRef(path)
+		return path != "" && !ok
+	})
+}
+
+// imagePaths 按 column+cond 和 arg 查询路径列，按 LIKE jsonLike 筛选 JSON 列，解析后只保留 keep 为真的路径
+func (m *MediaMapper) imagePaths(cond, arg, jsonLike string, keep func(string) bool) ([]string, error) {
+	var paths []string
+	add := func(found ...string) {
+		for _, path := range found {
+			if keep(path) {
+				paths = append(paths, path)
+			}
+		}
+	}
+	for _, q := range []struct {
+		model  interface{}
+		column string
+	}{
+		{&entity.QuestionBank{}, "topic_image_path"},
+		{&entity.QuestionAttachment{}, "path"},
+		{&entity.QuestionGenHistory{}, "topic_image_path"},
+	} {
+		var found []string
+		if err := m.db.Unscoped().Model(q.model).Where(q.column+cond, arg).Pluck(q.column, &found).Error; err != nil {
+			return nil, err
+		}
+		add(found...)
+	}
+
+	// 回收站中的题目仍可恢复，其中的图片同样计入引用
+	var questions []entity.QuestionBank
+	if err := m.db.Unscoped().Select("id", "options").Where("options LIKE ?", jsonLike).Find(&questions).Error; err != nil {
+		return nil, err
+	}
+	for _, q := range questions {
+		for _, o := range q.Options {
+			add(o.ImagePath)
+		}
+	}
+	var materials []entity.QuestionMaterial
+	if err := m.db.Select("id", "image_paths").Where("image_paths LIKE ?", jsonLike).Find(&materials).Error; err != nil {
+		return nil, err
+	}
+	for _, mat := range materials {
+		add(mat.ImagePaths...)
+	}
+	var revisions []entity.QuestionRevision
+	if err := m.db.Select("id", "snapshot").Where("snapshot LIKE ?", jsonLike).Find(&revisions).Error; err != nil {
+		return nil, err
+	}
+	for _, rev := range revisions {
+		add(rev.Snapshot.TopicImagePath)
+		for _, o := range rev.Snapshot.Options {
+			add(o.ImagePath)
+		}
+	}
+	var histories []entity.QuestionGenHistory
+	if err := m.db.Select("id", "question_json", "attachments_json", "material_json").
+		Where(historySnapshotLike, jsonLike, jsonLike, jsonLike).Find(&histories).Error; err != nil {
+		return nil, err
+	}
+	for _, h := range histories {
+		add(h.SnapshotImagePaths()...)
+	}
+	return paths, nil
+}
+
+// ReplaceImagePath 把各表（包括回收站中的题目、题目历史版本和出题记录快照）中保存的图片路径 old 全部替换为 ref，返回修改的记录数。
+// 用于把旧版本直接保存在磁盘上的图片导入媒体存储
+func (m *MediaMapper) ReplaceImagePath(old, ref string) (int64, error) {
+	var count int64
+	err := m.db.Transaction(func(tx *gorm.DB) error {
+		for _, q := range []struct {
+			model  interface{}
+			column string
+		}{
+			{&entity.QuestionBank{}, "topic_image_path"},
+			{&entity.QuestionAttachment{}, "path"},
+			{&entity.QuestionGenHistory{}, "topic_image_path"},
+		} {
+			result := tx.Unscoped().Model(q.model).Where(q.column+" = ?", old).Update(q.column, ref)
+			if result.Error != nil {
+				return result.Error
+			}
+			count += result.RowsAffected
+		}
+
+		// JSON 列中的反斜杠会被转义，只用文件名筛选，解析后再精确比较
+		contains := "%" + old[strings.LastIndexAny(old, `/\`)+1:] + "%"
+		var questions []entity.QuestionBank
+		if err := tx.Unscoped().Select("id", "options").Where("options LIKE ?", contains).Find(&questions).Error; err != nil {
+			return err
+		}
+		for _, q := range questions {
+			changed := false
+			for i := range q.Options {
+				if q.Options[i].ImagePath == old {
+					q.Options[i].ImagePath, changed = ref, true
+				}
+			}
+			if !changed {
+				continue
+			}
+			if err := tx.Unscoped().Model(&q).Select("options").Updates(&q).Error; err != nil {
+				return err
+			}
+			count++
+		}
+		var materials []entity.QuestionMaterial
+		if err := tx.Select("id", "image_paths").Where("image_paths LIKE ?", contains).Find(&materials).Error; err != nil {
+			return err
+		}
+		for _, mat := range materials {
+			changed := false
+			for i := range mat.ImagePaths {
+				if mat.ImagePaths[i] == old {
+					mat.ImagePaths[i], changed = ref, true
+				}
+			}
+			if !changed {
+				continue
+			}
+			if err := tx.Model(&mat).Select("image_paths").Updates(&mat).Error; err != nil {
+				return err
+			}
+			count++
+		}
+		// 历史版本中的路径只是换成导入后的引用，图片内容不变
+		var revisions []entity.QuestionRevision
+		if err := tx.Select("id", "snapshot").Where("snapshot LIKE ?", contains).Find(&revisions).Error; err != nil {
+			return err
+		}
+		for _, rev := range revisions {
+			changed := false
+			if rev.Snapshot.TopicImagePath == old {
+				rev.Snapshot.TopicImagePath, changed = ref, true
+			}
+			for i := range rev.Snapshot.Options {
+				if rev.Snapshot.Options[i].ImagePath == old {
+					rev.Snapshot.Options[i].ImagePath, changed = ref, true
+				}
+			}
+			if !changed {
+				continue
+			}
+			if err := tx.Model(&rev).Select("snapshot").Updates(&rev).Error; err != nil {
+				return err
+			}
+			count++
+		}
+		// 出题记录中的快照同样只换成导入后的引用
+		var histories []entity.QuestionGenHistory
+		if err := tx.Select("id", "question_json", "attachments_json", "material_json").
+			Where(historySnapshotLike, contains, contains, contains).Find(&histories).Error; err != nil {
+			return err
+		}
+		for _, h := range histories {
+			if !h.ReplaceSnapshotImagePath(old, ref) {
+				continue
+			}
+			if err := tx.Model(&h).Select("question_json", "attachments_json", "material_json").Updates(&h).Error; err != nil {
+				return err
+			}
+			count++
+		}
+		return nil
+	})
+	return count, err
+}
@@ -0,0 +1,80 @@
+package memory
+
+import (
+	"graduation/entity"
+	"graduation/mapper"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var _ mapper.ApiKeyRepository = (*ApiKeyRepository)(nil)
+
+// ApiKeyRepository 基于内存的个人 API 密钥实现，用于单元测试
+type ApiKeyRepository struct {
+	mu     sync.RWMutex
+	nextID int
+	rows   []entity.ApiKey
+}
+
+// NewApiKeyRepository 创建内存 API 密钥库
+func NewApiKeyRepository() *ApiKeyRepository {
+	return &ApiKeyRepository{}
+}
+
+func (r *ApiKeyRepository) CreateApiKey(key *entity.ApiKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.nextID++
+	key.ID = r.nextID
+	key.CreatedAt = time.Now()
+	r.rows = append(r.rows, *key)
+	return nil
+}
+
+func (r *ApiKeyRepository) GetApiKeyByHash(keyHash string) (entity.ApiKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, k := range r.rows {
+		if k.KeyHash == keyHash && k.RevokedAt == nil {
+			return k, nil
+		}
+	}
+	return entity.ApiKey{}, gorm.ErrRecordNotFound
+}
+
+func (r *ApiKeyRepository) GetApiKeysByUsername(username string) ([]entity.ApiKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var out []entity.ApiKey
+	for _, k := range r.rows {
+		if k.Username == username {
+			out = append(out, k)
+		}
+	}
+	return out, nil
+}
+
+func (r *ApiKeyRepository) RevokeApiKey(username string, id int, revokedAt time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.rows {
+		if r.rows[i].ID == id && r.rows[i].Username == username && r.rows[i].RevokedAt == nil {
+			r.rows[i].RevokedAt = &revokedAt
+			return 1, nil
+		}
+	}
+	return 0, nil
+}
+
+func (r *ApiKeyRepository) UpdateApiKeyLastUsed(id int, lastUsed time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.rows {
+		if r.rows[i].ID == id {
+			r.rows[i].LastUsedAt = &lastUsed
+		}
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package memory
+
+import (
+	"graduation/entity"
+	"graduation/mapper"
+	"sort"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+var _ mapper.AttachmentRepository = (*AttachmentRepository)(nil)
+
+// AttachmentRepository 基于内存的题目附件实现，用于单元测试
+type AttachmentRepository struct {
+	mu     sync.RWMutex
+	nextID int
+	rows   map[int]entity.QuestionAttachment
+}
+
+// NewAttachmentRepository 创建内存附件库，可传入初始数据
+func NewAttachmentRepository(seed ...entity.QuestionAttachment) *AttachmentRepository {
+	r := &AttachmentRepository{rows: make(map[int]entity.QuestionAttachment)}
+	for i := range seed {
+		r.CreateAttachment(&seed[i])
+	}
+	return r
+}
+
+// list 返回满足条件的附件，按题目和排列顺序
+func (r *AttachmentRepository) list(match func(a entity.QuestionAttachment) bool) []entity.QuestionAttachment {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var out []entity.QuestionAttachment
+	for _, a := range r.rows {
+		if match(a) {
+			out = append(out, a)
+		}
+	}
+	entity.SortAttachments(out)
+	sort.SliceStable(out, func(i, j int) bool { return out[i].QuestionID < out[j].QuestionID })
+	return out
+}
+
+func (r *AttachmentRepository) GetAttachmentsByQuestionId(questionID int) ([]entity.QuestionAttachment, error) {
+	return r.list(func(a entity.QuestionAttachment) bool { return a.QuestionID == questionID }), nil
+}
+
+func (r *AttachmentRepository) GetAttachmentsInQuestionIds(questionIDs []int) ([]entity.QuestionAttachment, error) {
+	ids := intSet(questionIDs)
+	return r.list(func(a entity.QuestionAttachment) bool { return ids[a.QuestionID] }), nil
+}
+
+func (r *AttachmentRepository) GetAttachmentById(id int) (entity.QuestionAttachment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	a, ok := r.rows[id]
+	if !ok {
+		return entity.QuestionAttachment{}, gorm.ErrRecordNotFound
+	}
+	return a, nil
+}
+
+func (r *AttachmentRepository) CreateAttachment(attachment *entity.QuestionAttachment) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if attachment.ID == 0 {
+		r.nextID++
+		attachment.ID = r.nextID
+	} else if attachment.ID > r.nextID {
+		r.nextID = attachment.ID
+	}
+	attachment.BeforeCreate(nil)
+	r.rows[attachment.ID] = *attachment
+	return nil
+}
+
+func (r *AttachmentRepository) UpdateAttachment(attachment *entity.QuestionAttachment) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	old, ok := r.rows[attachment.ID]
+	if !ok {
+		return 0, nil
+	}
+	// 与 GORM 实现一致，图片文件和尺寸不随修改变化
+	updated := *attachment
+	updated.QuestionID, updated.Path, updated.Width, updated.Height = old.QuestionID, old.Path, old.Width, old.Height
+	r.rows[attachment.ID] = updated
+	return 1, nil
+}
+
+func (r *AttachmentRepository) DeleteAttachment(id int) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.rows[id]; !ok {
+		return 0, nil
+	}
+	delete(r.rows, id)
+	return 1, nil
+}
+
+func (r *AttachmentRepository) DeleteAttachmentsByQuestionId(questionID int) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var affected int64
+	for id, a := range r.rows {
+		if a.QuestionID == questionID {
+			delete(r.rows, id)
+			affected++
+		}
+	}
+	return affected, nil
+}
@@ -0,0 +1,67 @@
+package memory
+
+import (
+	"graduation/entity"
+	"graduation/mapper"
+	"sort"
+	"sync"
+	"time"
+)
+
+var _ mapper.AuditRepository = (*AuditRepository)(nil)
+
+// AuditRepository 基于内存的审计日志实现，用于单元测试
+type AuditRepository struct {
+	mu     sync.RWMutex
+	nextID int
+	rows   []entity.AuditLog
+}
+
+// NewAuditRepository 创建内存审计日志库
+func NewAuditRepository() *AuditRepository {
+	return &AuditRepository{}
+}
+
+func (r *AuditRepository) InsertAuditLog(log *entity.AuditLog) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.nextID++
+	log.ID = r.nextID
+	if log.CreatedAt.IsZero() {
+		log.CreatedAt = time.Now()
+	}
+	r.rows = append(r.rows, *log)
+	return nil
+}
+
+func (r *AuditRepository) QueryAuditLogs(q mapper.AuditLogQuery) ([]entity.AuditLog, int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var matched []entity.AuditLog
+	for _, l := range r.rows {
+		if (q.Actor != "" && l.Actor != q.Actor) ||
+			(q.Action != "" && l.Action != q.Action) ||
+			(q.EntityType != "" && l.EntityType != q.EntityType) ||
+			(q.EntityID != "" && l.EntityID != q.EntityID) ||
+			(!q.Start.IsZero() && l.CreatedAt.Before(q.Start)) ||
+			(!q.End.IsZero() && !l.CreatedAt.Before(q.End)) {
+			continue
+		}
+		matched = append(matched, l)
+	}
+	sort.SliceStable(matched, func(i, j int) bool {
+		if !matched[i].CreatedAt.Equal(matched[j].CreatedAt) {
+			return matched[i].CreatedAt.After(matched[j].CreatedAt)
+		}
+		return matched[i].ID > matched[j].ID
+	})
+	total := int64(len(matched))
+	if q.Offset >= len(matched) {
+		return nil, total, nil
+	}
+	matched = matched[q.Offset:]
+	if q.Limit > 0 && q.Limit < len(matched) {
+		matched = matched[:q.Limit]
+	}
+	return matched, total, nil
+}
@@ -0,0 +1,190 @@
+package memory
+
+import (
+	"graduation/entity"
+	"graduation/mapper"
+	"sort"
+	"sync"
+	"time"
+)
+
+var _ mapper.HistoryRepository = (*HistoryRepository)(nil)
+
+// HistoryRepository 基于内存的试卷和题目生成历史实现，用于单元测试
+type HistoryRepository struct {
+	mu        sync.RWMutex
+	papers    []entity.TestPaperGenHistory
+	questions []entity.QuestionGenHistory
+	nextID    int
+}
+
+// NewHistoryRepository 创建内存生成历史
+func NewHistoryRepository() *HistoryRepository {
+	return &HistoryRepository{}
+}
+
+func (r *HistoryRepository) InsertTestPaperGenHistory(testPaperGenHistory entity.TestPaperGenHistory) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.nextID++
+	testPaperGenHistory.ID = r.nextID
+	if testPaperGenHistory.UpdateTime.IsZero() {
+		testPaperGenHistory.UpdateTime = time.Now()
+	}
+	r.papers = append(r.papers, testPaperGenHistory)
+	return 1, nil
+}
+
+func (r *HistoryRepository) QueryAllTestPaperGenHistory() ([]entity.TestPaperGenHistory, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := append([]entity.TestPaperGenHistory(nil), r.papers...)
+	sort.SliceStable(out, func(i, j int) bool { return out[i].UpdateTime.After(out[j].UpdateTime) })
+	return out, nil
+}
+
+func (r *HistoryRepository) GetTestPaperNameByTestPaperUid(testPaperUID string) ([]string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var names []string
+	for _, p := range r.papers {
+		if p.TestPaperUID == testPaperUID {
+			names = append(names, p.TestPaperName)
+		}
+	}
+	return names, nil
+}
+
+func (r *HistoryRepository) UpdateTestPaperTime(testPaperUID string, date time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var n int64
+	for i := range r.papers {
+		if r.papers[i].TestPaperUID == testPaperUID {
+			r.papers[i].UpdateTime = date
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (r *HistoryRepository) DeleteTestPaperGenHistoryByTestPaperUid(testPaperUID string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	kept := r.papers[:0]
+	var n int64
+	for _, p := range r.papers {
+		if p.TestPaperUID == testPaperUID {
+			n++
+			continue
+		}
+		kept = append(kept, p)
+	}
+	r.papers = kept
+	return n, nil
+}
+
+func (r *HistoryRepository) InsertQuestionGenHistories(list []entity.QuestionGenHistory) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, h := range list {
+		r.nextID++
+		h.ID = r.nextID
+		r.questions = append(r.questions, h)
+	}
+	return int64(len(list)), nil
+}
+
+func (r *HistoryRepository) GetQuestionGenHistoriesByTestPaperUid(testPaperUid string) ([]entity.QuestionGenHistory, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var out []entity.QuestionGenHistory
+	for _, h := range r.questions {
+		if h.TestPaperUID == testPaperUid {
+			out = append(out, h)
+		}
+	}
+	return out, nil
+}
+
+func (r *HistoryRepository) DeleteQuestionGenHistoryByTestPaperUid(testPaperUid string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	kept := r.questions[:0]
+	var n int64
+	for _, h := range r.questions {
+		if h.TestPaperUID == testPaperUid {
+			n++
+			continue
+		}
+		kept = append(kept, h)
+	}
+	r.questions = kept
+	return n, nil
+}
+
+func (r *HistoryRepository) GetQuestionIdsUsedSince(since time.Time) ([]int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	recent := make(map[string]bool)
+	for _, p := range r.papers {
+		if !p.UpdateTime.Before(since) {
+			recent[p.TestPaperUID] = true
+		}
+	}
+	seen := make(map[int]bool)
+	var ids []int
+	for _, h := range r.questions {
+		if recent[h.TestPaperUID] && !seen[h.QuestionBankID] {
+			seen[h.QuestionBankID] = true
+			ids = append(ids, h.QuestionBankID)
+		}
+	}
+	return ids, nil
+}
+
+func (r *HistoryRepository) SetTestPaperLocked(testPaperUID string, locked bool) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var n int64
+	for i := range r.papers {
+		if r.papers[i].TestPaperUID == testPaperUID {
+			r.papers[i].Locked = locked
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (r *HistoryRepository) IsTestPaperLocked(testPaperUID string) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, p := range r.papers {
+		if p.TestPaperUID == testPaperUID && p.Locked {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *HistoryRepository) GetLockedQuestionIds(questionIDs []int) ([]int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	locked := make(map[string]bool)
+	for _, p := range r.papers {
+		if p.Locked {
+			locked[p.TestPaperUID] = true
+		}
+	}
+	wanted := intSet(questionIDs)
+	seen := make(map[int]bool)
+	var ids []int
+	for _, h := range r.questions {
+		if locked[h.TestPaperUID] && wanted[h.QuestionBankID] && !seen[h.QuestionBankID] {
+			seen[h.QuestionBankID] = true
+			ids = append(ids, h.QuestionBankID)
+		}
+	}
+	sort.Ints(ids)
+	return ids, nil
+}
@@ -0,0 +1,135 @@
+package memory
+
+import (
+	"graduation/entity"
+	"graduation/mapper"
+	"sort"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+var _ mapper.LabelRepository = (*LabelRepository)(nil)
+
+// LabelRepository 基于内存的知识点标签实现，用于单元测试
+type LabelRepository struct {
+	mu     sync.RWMutex
+	nextID int
+	rows   map[int]entity.QuestionLabels
+}
+
+// NewLabelRepository 创建内存标签库，可传入初始数据
+func NewLabelRepository(seed ...entity.QuestionLabels) *LabelRepository {
+	r := &LabelRepository{rows: make(map[int]entity.QuestionLabels)}
+	for i := range seed {
+		r.CreateLabel(&seed[i])
+	}
+	return r
+}
+
+func (r *LabelRepository) all() []entity.QuestionLabels {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make([]entity.QuestionLabels, 0, len(r.rows))
+	for _, l := range r.rows {
+		out = append(out, l)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+// distinct 按指定字段去重，结果中只保留该字段，与 GORM Distinct 查询一致
+func (r *LabelRepository) distinct(rows []entity.QuestionLabels, field func(l entity.QuestionLabels) entity.QuestionLabels) []entity.QuestionLabels {
+	seen := make(map[entity.QuestionLabels]bool)
+	var out []entity.QuestionLabels
+	for _, l := range rows {
+		v := field(l)
+		if !seen[v] {
+			seen[v] = true
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+func (r *LabelRepository) GetAllQuestionLabels() ([]entity.QuestionLabels, error) {
+	return r.all(), nil
+}
+
+func (r *LabelRepository) GetDistinctChapter1() ([]entity.QuestionLabels, error) {
+	return r.distinct(r.all(), func(l entity.QuestionLabels) entity.QuestionLabels {
+		return entity.QuestionLabels{Chapter1: l.Chapter1}
+	}), nil
+}
+
+func (r *LabelRepository) GetDistinctChapter2() ([]entity.QuestionLabels, error) {
+	return r.distinct(r.all(), func(l entity.QuestionLabels) entity.QuestionLabels {
+		return entity.QuestionLabels{Chapter2: l.Chapter2}
+	}), nil
+}
+
+func (r *LabelRepository) GetChapter2ByChapter1(chapter1 string) ([]entity.QuestionLabels, error) {
+	var rows []entity.QuestionLabels
+	for _, l := range r.all() {
+		if l.Chapter1 == chapter1 {
+			rows = append(rows, l)
+		}
+	}
+	return r.distinct(rows, func(l entity.QuestionLabels) entity.QuestionLabels {
+		return entity.QuestionLabels{Chapter2: l.Chapter2}
+	}), nil
+}
+
+func (r *LabelRepository) GetDistinctLabel1() ([]entity.QuestionLabels, error) {
+	return r.distinct(r.all(), func(l entity.QuestionLabels) entity.QuestionLabels {
+		return entity.QuestionLabels{Label1: l.Label1}
+	}), nil
+}
+
+func (r *LabelRepository) GetDistinctLabel2() ([]entity.QuestionLabels, error) {
+	return r.distinct(r.all(), func(l entity.QuestionLabels) entity.QuestionLabels {
+		return entity.QuestionLabels{Label2: l.Label2}
+	}), nil
+}
+
+func (r *LabelRepository) GetLabelById(id int) (entity.QuestionLabels, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	l, ok := r.rows[id]
+	if !ok {
+		return entity.QuestionLabels{}, gorm.ErrRecordNotFound
+	}
+	return l, nil
+}
+
+func (r *LabelRepository) CreateLabel(label *entity.QuestionLabels) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.nextID++
+	label.ID = r.nextID
+	r.rows[label.ID] = *label
+	return nil
+}
+
+// UpdateLabel 只更新标签本身，内存实现不级联更新题库
+func (r *LabelRepository) UpdateLabel(id int, label entity.QuestionLabels) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	old, ok := r.rows[id]
+	if !ok {
+		return gorm.ErrRecordNotFound
+	}
+	label.ID = id
+	if label.CreatedAt.IsZero() {
+		label.CreatedAt = old.CreatedAt
+	}
+	r.rows[id] = label
+	return nil
+}
+
+func (r *LabelRepository) DeleteLabel(id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.rows, id)
+	return nil
+}
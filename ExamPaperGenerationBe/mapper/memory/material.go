@@ -0,0 +1,101 @@
+package memory
+
+import (
+	"graduation/entity"
+	"graduation/mapper"
+	"sort"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+var _ mapper.MaterialRepository = (*MaterialRepository)(nil)
+
+// MaterialRepository 基于内存的题目材料实现，用于单元测试
+type MaterialRepository struct {
+	mu     sync.RWMutex
+	nextID int
+	rows   map[int]entity.QuestionMaterial
+}
+
+// NewMaterialRepository 创建内存材料库，可传入初始数据
+func NewMaterialRepository(seed ...entity.QuestionMaterial) *MaterialRepository {
+	r := &MaterialRepository{rows: make(map[int]entity.QuestionMaterial)}
+	for i := range seed {
+		r.CreateQuestionMaterial(&seed[i])
+	}
+	return r
+}
+
+func (r *MaterialRepository) GetAllQuestionMaterials() ([]entity.QuestionMaterial, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make([]entity.QuestionMaterial, 0, len(r.rows))
+	for _, m := range r.rows {
+		out = append(out, m)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].UpdateTime.Equal(out[j].UpdateTime) {
+			return out[i].UpdateTime.After(out[j].UpdateTime)
+		}
+		return out[i].ID > out[j].ID
+	})
+	return out, nil
+}
+
+func (r *MaterialRepository) GetQuestionMaterialById(id int) (entity.QuestionMaterial, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	m, ok := r.rows[id]
+	if !ok {
+		return entity.QuestionMaterial{}, gorm.ErrRecordNotFound
+	}
+	return m, nil
+}
+
+func (r *MaterialRepository) GetQuestionMaterialsInIds(ids []int) ([]entity.QuestionMaterial, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var out []entity.QuestionMaterial
+	for id := range intSet(ids) {
+		if m, ok := r.rows[id]; ok {
+			out = append(out, m)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out, nil
+}
+
+func (r *MaterialRepository) CreateQuestionMaterial(material *entity.QuestionMaterial) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if material.ID == 0 {
+		r.nextID++
+		material.ID = r.nextID
+	} else if material.ID > r.nextID {
+		r.nextID = material.ID
+	}
+	material.BeforeCreate(nil)
+	r.rows[material.ID] = *material
+	return nil
+}
+
+func (r *MaterialRepository) UpdateQuestionMaterial(material *entity.QuestionMaterial) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.rows[material.ID]; !ok {
+		return 0, nil
+	}
+	r.rows[material.ID] = *material
+	return 1, nil
+}
+
+func (r *MaterialRepository) DeleteQuestionMaterial(id int) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.rows[id]; !ok {
+		return 0, nil
+	}
+	delete(r.rows, id)
+	return 1, nil
+}
@@ -0,0 +1,69 @@
+package memory
+
+import (
+	"graduation/mapper"
+)
+
+var _ mapper.MediaRepository = (*MediaRepository)(nil)
+
+// MediaRepository 基于内存的媒体文件引用查询，从其他内存库中收集图片路径，用于单元测试。
+// 不需要的库可以传 nil
+type MediaRepository struct {
+	questions   *QuestionRepository
+	materials   *MaterialRepository
+	attachments *AttachmentRepository
+	history     *HistoryRepository
+	revisions   *RevisionRepository
+}
+
+// NewMediaRepository 创建内存媒体文件引用查询
+func NewMediaRepository(questions *QuestionRepository, materials *MaterialRepository, attachments *AttachmentRepository, history *HistoryRepository, revisions *RevisionRepository) *MediaRepository {
+	return &MediaRepository{questions: questions, materials: materials, attachments: attachments, history: history, revisions: revisions}
+}
+
+func (r *MediaRepository) GetMediaRefs() ([]string, error) {
+	var refs []string
+	if r.questions != nil {
+		r.questions.mu.RLock()
+		for _, q := range r.questions.rows {
+			refs = append(refs, q.TopicImagePath)
+			for _, o := range q.Options {
+				refs = append(refs, o.ImagePath)
+			}
+		}
+		r.questions.mu.RUnlock()
+	}
+	if r.materials != nil {
+		r.materials.mu.RLock()
+		for _, m := range r.materials.rows {
+			refs = append(refs, m.ImagePaths...)
+		}
+		r.materials.mu.RUnlock()
+	}
+	if r.attachments != nil {
+		r.attachments.mu.RLock()
+		for _, a := range r.attachments.rows {
+			refs = append(refs, a.Path)
+		}
+		r.attachments.mu.RUnlock()
+	}
+	if r.history != nil {
+		r.history.mu.RLock()
+		for _, h := range r.history.questions {
+			refs = append(refs, h.TopicImagePath)
+			refs = append(refs, h.SnapshotImagePaths()...)
+		}
+		r.history.mu.RUnlock()
+	}
+	if r.revisions != nil {
+		r.revisions.mu.RLock()
+		for _, rev := range r.revisions.rows {
+			refs = append(refs, rev.Snapshot.TopicImagePath)
+			for _, o := range rev.Snapshot.Options {
+				refs = append(refs, o.ImagePath)
+			}
+		}
+		r.revisions.mu.RUnlock()
+	}
+	return refs, nil
+}
@@ -0,0 +1,377 @@
+package memory
+
+import (
+	"graduation/entity"
+	"graduation/mapper"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var _ mapper.QuestionRepository = (*QuestionRepository)(nil)
+
+// QuestionRepository 基于内存的题库实现，用于单元测试
+type QuestionRepository struct {
+	mu      sync.RWMutex
+	nextID  int
+	rows    map[int]entity.QuestionBank
+	history *HistoryRepository
+}
+
+// NewQuestionRepository 创建内存题库，可传入初始数据
+func NewQuestionRepository(seed ...entity.QuestionBank) *QuestionRepository {
+	r := &QuestionRepository{rows: make(map[int]entity.QuestionBank)}
+	for i := range seed {
+		r.InsertSingleQuestionBank(&seed[i])
+	}
+	return r
+}
+
+// UseHistory 关联试卷生成历史，移入回收站和彻底删除时与 GORM 实现一样跳过被已锁定的试卷引用的题目
+func (r *QuestionRepository) UseHistory(history *HistoryRepository) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.history = history
+}
+
+// lockedSet 返回 ids 中被已锁定的试卷引用的题目，调用方需持有锁
+func (r *QuestionRepository) lockedSet(ids []int) map[int]bool {
+	if r.history == nil {
+		return nil
+	}
+	locked, _ := r.history.GetLockedQuestionIds(ids)
+	return intSet(locked)
+}
+
+// list 返回满足条件的记录，按 ID 升序。与 GORM 软删除一致，不包含回收站中的题目
+func (r *QuestionRepository) list(match func(q entity.QuestionBank) bool) []entity.QuestionBank {
+	return r.listAll(func(q entity.QuestionBank) bool {
+		return !q.DeletedAt.Valid && (match == nil || match(q))
+	})
+}
+
+// listAll 返回满足条件的记录，包括回收站中的题目，按 ID 升序
+func (r *QuestionRepository) listAll(match func(q entity.QuestionBank) bool) []entity.QuestionBank {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var out []entity.QuestionBank
+	for _, q := range r.rows {
+		if match(q) {
+			out = append(out, q)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+func (r *QuestionRepository) GetAllQuestionBank() ([]entity.QuestionBank, error) {
+	out := r.list(nil)
+	sort.SliceStable(out, func(i, j int) bool { return out[i].UpdateTime.After(out[j].UpdateTime) })
+	return out, nil
+}
+
+func (r *QuestionRepository) GetQuestionBankById(id int) ([]entity.QuestionBank, error) {
+	return r.list(func(q entity.QuestionBank) bool { return q.ID == id }), nil
+}
+
+func (r *QuestionRepository) GetQuestionBanksInIds(ids []int) ([]entity.QuestionBank, error) {
+	set := intSet(ids)
+	return r.list(func(q entity.QuestionBank) bool { return set[q.ID] }), nil
+}
+
+func (r *QuestionRepository) GetDistinctTopicType() ([]string, error) {
+	return distinctStrings(r.list(nil), func(q entity.QuestionBank) string { return q.TopicType }), nil
+}
+
+func (r *QuestionRepository) SearchQuestionByTopic(topicType, keyword string) ([]entity.QuestionBank, error) {
+	return r.list(func(q entity.QuestionBank) bool {
+		return (topicType == "" || q.TopicType == topicType) &&
+			(keyword == "" || strings.Contains(q.Topic, keyword))
+	}), nil
+}
+
+func (r *QuestionRepository) InsertSingleQuestionBank(questionBank *entity.QuestionBank) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if questionBank.ID == 0 {
+		r.nextID++
+		questionBank.ID = r.nextID
+	} else if questionBank.ID > r.nextID {
+		r.nextID = questionBank.ID
+	}
+	questionBank.BeforeCreate(nil)
+	r.rows[questionBank.ID] = *questionBank
+	return 1, nil
+}
+
+func (r *QuestionRepository) SoftDeleteQuestionBanks(ids []int, deletedBy string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var affected int64
+	locked := r.lockedSet(ids)
+	for _, id := range ids {
+		if q, ok := r.rows[id]; ok && !q.DeletedAt.Valid && !locked[id] {
+			q.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+			q.DeletedBy = deletedBy
+			r.rows[id] = q
+			affected++
+		}
+	}
+	return affected, nil
+}
+
+// ImportQuestionBanks 在一次加锁中完成移入回收站和插入，与 GORM 实现的事务对应
+func (r *QuestionRepository) ImportQuestionBanks(questions []entity.QuestionBank, deleteIds []int, deletedBy string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var deleted int64
+	locked := r.lockedSet(deleteIds)
+	for _, id := range deleteIds {
+		if q, ok := r.rows[id]; ok && !q.DeletedAt.Valid && !locked[id] {
+			q.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+			q.DeletedBy = deletedBy
+			r.rows[id] = q
+			deleted++
+		}
+	}
+	for i := range questions {
+		r.nextID++
+		questions[i].ID = r.nextID
+		questions[i].BeforeCreate(nil)
+		r.rows[questions[i].ID] = questions[i]
+	}
+	return deleted, nil
+}
+
+func (r *QuestionRepository) GetDeletedQuestionBanks() ([]entity.QuestionBank, error) {
+	out := r.listAll(func(q entity.QuestionBank) bool { return q.DeletedAt.Valid })
+	sort.SliceStable(out, func(i, j int) bool { return out[i].DeletedAt.Time.After(out[j].DeletedAt.Time) })
+	return out, nil
+}
+
+func (r *QuestionRepository) GetDeletedQuestionBanksInIds(ids []int) ([]entity.QuestionBank, error) {
+	set := intSet(ids)
+	return r.listAll(func(q entity.QuestionBank) bool { return q.DeletedAt.Valid && set[q.ID] }), nil
+}
+
+func (r *QuestionRepository) RestoreQuestionBanks(ids []int) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var affected int64
+	for _, id := range ids {
+		if q, ok := r.rows[id]; ok && q.DeletedAt.Valid {
+			q.DeletedAt, q.DeletedBy = gorm.DeletedAt{}, ""
+			r.rows[id] = q
+			affected++
+		}
+	}
+	return affected, nil
+}
+
+func (r *QuestionRepository) PurgeQuestionBanks(ids []int) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var affected int64
+	locked := r.lockedSet(ids)
+	for _, id := range ids {
+		if q, ok := r.rows[id]; ok && q.DeletedAt.Valid && !locked[id] {
+			delete(r.rows, id)
+			affected++
+		}
+	}
+	return affected, nil
+}
+
+func (r *QuestionRepository) SetQuestionStatus(ids []int, status string) (int64, error) {
+	return r.setField(ids, func(q *entity.QuestionBank) { q.Status = status })
+}
+
+func (r *QuestionRepository) SetQuestionReviewer(ids []int, reviewer string) (int64, error) {
+	return r.setField(ids, func(q *entity.QuestionBank) { q.Reviewer = reviewer })
+}
+
+// setField 修改未删除的题目，返回修改的数量
+func (r *QuestionRepository) setField(ids []int, set func(q *entity.QuestionBank)) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var affected int64
+	for _, id := range ids {
+		if q, ok := r.rows[id]; ok && !q.DeletedAt.Valid {
+			set(&q)
+			r.rows[id] = q
+			affected++
+		}
+	}
+	return affected, nil
+}
+
+func (r *QuestionRepository) GetQuestionBanksByMaterialId(materialID int) ([]entity.QuestionBank, error) {
+	return r.list(func(q entity.QuestionBank) bool { return q.TopicMaterialID == materialID }), nil
+}
+
+func (r *QuestionRepository) SetQuestionMaterial(ids []int, materialID int) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var affected int64
+	for _, id := range ids {
+		if q, ok := r.rows[id]; ok && !q.DeletedAt.Valid {
+			q.TopicMaterialID = materialID
+			r.rows[id] = q
+			affected++
+		}
+	}
+	return affected, nil
+}
+
+func (r *QuestionRepository) SetTopicImagePath(id int, path string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	q, ok := r.rows[id]
+	if !ok || q.DeletedAt.Valid {
+		return 0, nil
+	}
+	q.TopicImagePath = path
+	r.rows[id] = q
+	return 1, nil
+}
+
+// ReplaceQuestionBank 与 GORM 实现一致，删除和审核相关的字段保持不变
+func (r *QuestionRepository) ReplaceQuestionBank(questionBank *entity.QuestionBank) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	old, ok := r.rows[questionBank.ID]
+	if !ok || old.DeletedAt.Valid {
+		return 0, nil
+	}
+	n := *questionBank
+	n.DeletedAt, n.DeletedBy, n.Status, n.Reviewer = old.DeletedAt, old.DeletedBy, old.Status, old.Reviewer
+	r.rows[n.ID] = n
+	return 1, nil
+}
+
+// UpdateSingleQuestionBank 与 GORM 实现一致，编辑界面提交的列总是写入，
+// 材料和题干图片只在有值时更新，审核和删除相关的列保持不变
+func (r *QuestionRepository) UpdateSingleQuestionBank(questionBank *entity.QuestionBank) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	old, ok := r.rows[questionBank.ID]
+	if !ok || old.DeletedAt.Valid {
+		return 0, nil
+	}
+	n := *questionBank
+	if n.TopicMaterialID == 0 {
+		n.TopicMaterialID = old.TopicMaterialID
+	}
+	if n.TopicImagePath == "" {
+		n.TopicImagePath = old.TopicImagePath
+	}
+	n.Status, n.Reviewer = old.Status, old.Reviewer
+	n.DeletedAt, n.DeletedBy = old.DeletedAt, old.DeletedBy
+	r.rows[n.ID] = n
+	return 1, nil
+}
+
+func (r *QuestionRepository) GetAvgDifficultyByIds(ids []int) (float64, error) {
+	rows, _ := r.GetQuestionBanksInIds(ids)
+	if len(rows) == 0 {
+		return 0, nil
+	}
+	total := 0
+	for _, q := range rows {
+		total += q.Difficulty
+	}
+	return float64(total) / float64(len(rows)), nil
+}
+
+func (r *QuestionRepository) GetDistinctLabel1FromQuestionBank() ([]string, error) {
+	return distinctStrings(r.list(nil), func(q entity.QuestionBank) string { return q.Label1 }), nil
+}
+
+func (r *QuestionRepository) GetQuestionBankCountByLabel1(label1 string) (int, error) {
+	return len(r.list(func(q entity.QuestionBank) bool { return q.Label1 == label1 })), nil
+}
+
+func (r *QuestionRepository) GetDistinctScoreFromQuestionBank() ([]float64, error) {
+	seen := make(map[float64]bool)
+	var out []float64
+	for _, q := range r.list(nil) {
+		if !seen[q.Score] {
+			seen[q.Score] = true
+			out = append(out, q.Score)
+		}
+	}
+	return out, nil
+}
+
+func (r *QuestionRepository) GetQuestionBankCountByScore(score float64) (int, error) {
+	return len(r.list(func(q entity.QuestionBank) bool { return q.Score == score })), nil
+}
+
+func (r *QuestionRepository) GetQuestionBankByIds(ids []int, generateRange []string) ([]entity.QuestionBank, error) {
+	excluded := intSet(ids)
+	types := make(map[string]bool)
+	for _, t := range generateRange {
+		types[t] = true
+	}
+	return r.list(func(q entity.QuestionBank) bool {
+		return !excluded[q.ID] && (len(generateRange) == 0 || types[q.TopicType])
+	}), nil
+}
+
+func (r *QuestionRepository) GetAll() ([]entity.QuestionBank, error) {
+	return r.list(nil), nil
+}
+
+func (r *QuestionRepository) QueryQuestionBanks(q mapper.QuestionBankQuery) (mapper.QuestionBankPage, error) {
+	var page mapper.QuestionBankPage
+	rows := r.list(func(row entity.QuestionBank) bool { return mapper.MatchQuestionBankQuery(row, q) })
+	page.Total = int64(len(rows))
+	sort.SliceStable(rows, func(i, j int) bool { return mapper.CompareQuestionBanks(rows[i], rows[j], q.Sort) < 0 })
+	if q.Cursor != "" {
+		after, err := mapper.DecodeQuestionBankCursor(q.Cursor, q.Sort)
+		if err != nil {
+			return page, err
+		}
+		start := sort.Search(len(rows), func(i int) bool { return mapper.CompareQuestionBanks(rows[i], after, q.Sort) > 0 })
+		rows = rows[start:]
+	} else if q.Offset > 0 {
+		rows = rows[min(q.Offset, len(rows)):]
+	}
+	if q.Limit > 0 && len(rows) >= q.Limit {
+		rows = rows[:q.Limit]
+		page.NextCursor = mapper.EncodeQuestionBankCursor(rows[len(rows)-1], q.Sort)
+	}
+	page.Items = rows
+	return page, nil
+}
+
+func (r *QuestionRepository) SearchQuestionBanks(q mapper.QuestionSearchQuery) ([]mapper.QuestionSearchHit, error) {
+	if strings.TrimSpace(q.Keyword) == "" {
+		return nil, nil
+	}
+	return mapper.RankQuestionBanks(r.list(nil), q), nil
+}
+
+func intSet(ids []int) map[int]bool {
+	set := make(map[int]bool, len(ids))
+	for _, id := range ids {
+		set[id] = true
+	}
+	return set
+}
+
+func distinctStrings[T any](rows []T, field func(T) string) []string {
+	seen := make(map[string]bool)
+	var out []string
+	for _, row := range rows {
+		v := field(row)
+		if !seen[v] {
+			seen[v] = true
+			out = append(out, v)
+		}
+	}
+	return out
+}
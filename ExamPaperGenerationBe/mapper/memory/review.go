@@ -0,0 +1,61 @@
+package memory
+
+import (
+	"graduation/entity"
+	"graduation/mapper"
+	"sync"
+	"time"
+)
+
+var _ mapper.ReviewRepository = (*ReviewRepository)(nil)
+
+// ReviewRepository 基于内存的题目审核意见实现，用于单元测试
+type ReviewRepository struct {
+	mu     sync.RWMutex
+	nextID int
+	rows   []entity.QuestionReviewComment
+}
+
+// NewReviewRepository 创建内存审核意见库
+func NewReviewRepository() *ReviewRepository {
+	return &ReviewRepository{}
+}
+
+func (r *ReviewRepository) InsertReviewComment(comment *entity.QuestionReviewComment) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.nextID++
+	comment.ID = r.nextID
+	if comment.CreatedAt.IsZero() {
+		comment.CreatedAt = time.Now()
+	}
+	r.rows = append(r.rows, *comment)
+	return nil
+}
+
+func (r *ReviewRepository) GetReviewComments(questionID int) ([]entity.QuestionReviewComment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var out []entity.QuestionReviewComment
+	for _, row := range r.rows {
+		if row.QuestionID == questionID {
+			out = append(out, row)
+		}
+	}
+	return out, nil
+}
+
+func (r *ReviewRepository) DeleteReviewComments(questionIDs []int) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ids := intSet(questionIDs)
+	kept := r.rows[:0]
+	for _, row := range r.rows {
+		if !ids[row.QuestionID] {
+			kept = append(kept, row)
+		}
+	}
+	n := int64(len(r.rows) - len(kept))
+	r.rows = kept
+	return n, nil
+}
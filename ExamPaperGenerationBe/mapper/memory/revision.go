@@ -0,0 +1,90 @@
+package memory
+
+import (
+	"graduation/entity"
+	"graduation/mapper"
+	"sort"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var _ mapper.RevisionRepository = (*RevisionRepository)(nil)
+
+// RevisionRepository 基于内存的题目历史版本实现，用于单元测试
+type RevisionRepository struct {
+	mu     sync.RWMutex
+	nextID int
+	rows   []entity.QuestionRevision
+}
+
+// NewRevisionRepository 创建内存题目历史版本库
+func NewRevisionRepository() *RevisionRepository {
+	return &RevisionRepository{}
+}
+
+func (r *RevisionRepository) InsertQuestionRevision(revision *entity.QuestionRevision) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	revision.Revision = 1
+	for _, row := range r.rows {
+		if row.QuestionID == revision.QuestionID && row.Revision >= revision.Revision {
+			revision.Revision = row.Revision + 1
+		}
+	}
+	r.nextID++
+	revision.ID = r.nextID
+	if revision.CreatedAt.IsZero() {
+		revision.CreatedAt = time.Now()
+	}
+	r.rows = append(r.rows, *revision)
+	return nil
+}
+
+func (r *RevisionRepository) GetQuestionRevisions(questionID int) ([]entity.QuestionRevision, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var out []entity.QuestionRevision
+	for _, row := range r.rows {
+		if row.QuestionID == questionID {
+			out = append(out, row)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Revision > out[j].Revision })
+	return out, nil
+}
+
+func (r *RevisionRepository) GetQuestionRevision(questionID, revision int) (entity.QuestionRevision, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, row := range r.rows {
+		if row.QuestionID == questionID && row.Revision == revision {
+			return row, nil
+		}
+	}
+	return entity.QuestionRevision{}, gorm.ErrRecordNotFound
+}
+
+func (r *RevisionRepository) GetLatestQuestionRevision(questionID int) (entity.QuestionRevision, error) {
+	revisions, _ := r.GetQuestionRevisions(questionID)
+	if len(revisions) == 0 {
+		return entity.QuestionRevision{}, gorm.ErrRecordNotFound
+	}
+	return revisions[0], nil
+}
+
+func (r *RevisionRepository) DeleteQuestionRevisions(questionIDs []int) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ids := intSet(questionIDs)
+	kept := r.rows[:0]
+	for _, row := range r.rows {
+		if !ids[row.QuestionID] {
+			kept = append(kept, row)
+		}
+	}
+	n := int64(len(r.rows) - len(kept))
+	r.rows = kept
+	return n, nil
+}
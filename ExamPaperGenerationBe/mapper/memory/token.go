@@ -0,0 +1,49 @@
+package memory
+
+import (
+	"graduation/mapper"
+	"sync"
+	"time"
+)
+
+var _ mapper.TokenRepository = (*TokenRepository)(nil)
+
+// TokenRepository 基于内存的令牌注销记录实现，用于单元测试
+type TokenRepository struct {
+	mu      sync.RWMutex
+	revoked map[string]time.Time
+}
+
+// NewTokenRepository 创建内存令牌注销记录
+func NewTokenRepository() *TokenRepository {
+	return &TokenRepository{revoked: make(map[string]time.Time)}
+}
+
+func (r *TokenRepository) RevokeToken(jti string, expiresAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.revoked[jti]; !ok {
+		r.revoked[jti] = expiresAt
+	}
+	return nil
+}
+
+func (r *TokenRepository) IsTokenRevoked(jti string) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, ok := r.revoked[jti]
+	return ok, nil
+}
+
+func (r *TokenRepository) DeleteExpiredRevokedTokens(now time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var n int64
+	for jti, exp := range r.revoked {
+		if exp.Before(now) {
+			delete(r.revoked, jti)
+			n++
+		}
+	}
+	return n, nil
+}
@@ -0,0 +1,138 @@
+package memory
+
+import (
+	"fmt"
+	"graduation/entity"
+	"graduation/mapper"
+	"sort"
+	"sync"
+	"time"
+)
+
+var _ mapper.UserRepository = (*UserRepository)(nil)
+
+// UserRepository 基于内存的用户实现，用于单元测试
+type UserRepository struct {
+	mu     sync.RWMutex
+	nextID int
+	rows   map[string]entity.User
+}
+
+// NewUserRepository 创建内存用户库，可传入初始数据
+func NewUserRepository(seed ...entity.User) *UserRepository {
+	r := &UserRepository{rows: make(map[string]entity.User)}
+	for i := range seed {
+		r.AddNewUser(&seed[i])
+	}
+	return r
+}
+
+func (r *UserRepository) find(match func(u entity.User) bool) []entity.User {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var out []entity.User
+	for _, u := range r.rows {
+		if match(u) {
+			out = append(out, u)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+// remove 删除满足条件的用户，返回删除数量
+func (r *UserRepository) remove(username string, match func(u entity.User) bool) int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if u, ok := r.rows[username]; ok && match(u) {
+		delete(r.rows, username)
+		return 1
+	}
+	return 0
+}
+
+func (r *UserRepository) GetUserByUsername(username string) ([]entity.User, error) {
+	return r.find(func(u entity.User) bool { return u.Username == username }), nil
+}
+
+func (r *UserRepository) GetEnabledUserByUsername(username string) ([]entity.User, error) {
+	return r.find(func(u entity.User) bool { return u.Username == username && u.Enable == 1 }), nil
+}
+
+func (r *UserRepository) AddNewUser(user *entity.User) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.rows[user.Username]; ok {
+		return 0, fmt.Errorf("duplicate username: %s", user.Username)
+	}
+	r.nextID++
+	user.ID = r.nextID
+	user.BeforeCreate(nil)
+	r.rows[user.Username] = *user
+	return 1, nil
+}
+
+func (r *UserRepository) UpdateLastLoginTime(username string, lastLogin time.Time) error {
+	return r.update(username, func(u *entity.User) { u.LastLogin = lastLogin })
+}
+
+func (r *UserRepository) GetApplyUser() ([]entity.User, error) {
+	return r.find(func(u entity.User) bool { return u.Enable == 0 }), nil
+}
+
+func (r *UserRepository) GetAllUser() ([]entity.User, error) {
+	users := r.find(func(u entity.User) bool { return true })
+	for i := range users {
+		users[i].Password = ""
+	}
+	return users, nil
+}
+
+func (r *UserRepository) DeleteUser(username string) (int64, error) {
+	return r.remove(username, func(u entity.User) bool { return true }), nil
+}
+
+func (r *UserRepository) PassApply(username string) (int64, error) {
+	if err := r.update(username, func(u *entity.User) { u.Enable = 1 }); err != nil {
+		return 0, nil
+	}
+	return 1, nil
+}
+
+func (r *UserRepository) DeleteApply(username string) (int64, error) {
+	return r.remove(username, func(u entity.User) bool { return u.Enable == 0 }), nil
+}
+
+func (r *UserRepository) UpdateUserSimilarityThreshold(username string, threshold float64) error {
+	return r.update(username, func(u *entity.User) { u.SimilarityThreshold = threshold })
+}
+
+func (r *UserRepository) UpdateLoginFailures(username string, failedCount int, lockedUntil *time.Time) error {
+	return r.update(username, func(u *entity.User) {
+		u.FailedLoginCount = failedCount
+		u.LockedUntil = lockedUntil
+	})
+}
+
+func (r *UserRepository) UpdatePassword(username, passwordHash string, mustChange bool) (int64, error) {
+	err := r.update(username, func(u *entity.User) {
+		u.Password = passwordHash
+		u.MustChangePassword = mustChange
+	})
+	if err != nil {
+		return 0, nil
+	}
+	return 1, nil
+}
+
+func (r *UserRepository) update(username string, fn func(u *entity.User)) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	u, ok := r.rows[username]
+	if !ok {
+		return fmt.Errorf("user not found")
+	}
+	fn(&u)
+	r.rows[username] = u
+	return nil
+}
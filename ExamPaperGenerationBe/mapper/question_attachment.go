@@ -0,0 +1,69 @@
+package mapper
+
+import (
+	"graduation/entity"
+
+	"gorm.io/gorm"
+)
+
+// QuestionAttachmentMapper 题目附件的数据库操作
+type QuestionAttachmentMapper struct {
+	db *gorm.DB
+}
+
+// NewQuestionAttachmentMapper 创建一个新的 QuestionAttachmentMapper 实例
+func NewQuestionAttachmentMapper() *QuestionAttachmentMapper {
+	return &QuestionAttachmentMapper{
+		db: DB,
+	}
+}
+
+// questionAttachmentColumns 修改附件时写入的列，空值也需要写入以便清空说明文字或导出宽度
+var questionAttachmentColumns = []string{"target", "option_label", "name", "caption", "display_width", "sort_order", "update_time"}
+
+// GetAttachmentsByQuestionId 获取题目的全部附件，按排列顺序
+func (m *QuestionAttachmentMapper) GetAttachmentsByQuestionId(questionID int) ([]entity.QuestionAttachment, error) {
+	var attachments []entity.QuestionAttachment
+	result := m.db.Where("question_id = ?", questionID).Order("sort_order").Order("id").Find(&attachments)
+	return attachments, result.Error
+}
+
+// GetAttachmentsInQuestionIds 获取多道题目的附件，按题目和排列顺序
+func (m *QuestionAttachmentMapper) GetAttachmentsInQuestionIds(questionIDs []int) ([]entity.QuestionAttachment, error) {
+	var attachments []entity.QuestionAttachment
+	if len(questionIDs) == 0 {
+		return attachments, nil
+	}
+	result := m.db.Where("question_id IN ?", questionIDs).Order("question_id").Order("sort_order").Order("id").Find(&attachments)
+	return attachments, result.Error
+}
+
+// GetAttachmentById 根据 ID 获取附件
+func (m *QuestionAttachmentMapper) GetAttachmentById(id int) (entity.QuestionAttachment, error) {
+	var attachment entity.QuestionAttachment
+	result := m.db.Where("id = ?", id).First(&attachment)
+	return attachment, result.Error
+}
+
+// CreateAttachment 新增附件
+func (m *QuestionAttachmentMapper) CreateAttachment(attachment *entity.QuestionAttachment) error {
+	return m.db.Create(attachment).Error
+}
+
+// UpdateAttachment 修改附件的位置、名称、说明文字和导出宽度，图片文件不变
+func (m *QuestionAttachmentMapper) UpdateAttachment(attachment *entity.QuestionAttachment) (int64, error) {
+	result := m.db.Model(attachment).Select(questionAttachmentColumns).Updates(attachment)
+	return result.RowsAffected, result.Error
+}
+
+// DeleteAttachment 根据 ID 删除附件
+func (m *QuestionAttachmentMapper) DeleteAttachment(id int) (int64, error) {
+	result := m.db.Delete(&entity.QuestionAttachment{}, id)
+	return result.RowsAffected, result.Error
+}
+
+// DeleteAttachmentsByQuestionId 删除题目的全部附件
+func (m *QuestionAttachmentMapper) DeleteAttachmentsByQuestionId(questionID int) (int64, error) {
+	result := m.db.Where("question_id = ?", questionID).Delete(&entity.QuestionAttachment{})
+	return result.RowsAffected, result.Error
+}
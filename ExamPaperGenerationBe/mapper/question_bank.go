@@ -1,9 +1,11 @@
 package mapper
 
 import (
+	"fmt"
 	"gorm.io/gorm"
 	"graduation/entity"
-	"os"
+	"strings"
+	"time"
 )
 
 // QuestionBankMapper 接口定义
@@ -25,6 +27,106 @@ func (m *QuestionBankMapper) GetAllQuestionBank() ([]entity.QuestionBank, error)
 	return questionBanks, result.Error
 }
 
+// QueryQuestionBanks 按条件过滤、排序并分页查询题库，同时返回符合条件的总数
+func (m *QuestionBankMapper) QueryQuestionBanks(q QuestionBankQuery) (QuestionBankPage, error) {
+	var page QuestionBankPage
+	tx := m.db.Model(&entity.QuestionBank{})
+	if len(q.TopicTypes) > 0 {
+		tx = tx.Where("topic_type IN ?", q.TopicTypes)
+	}
+	if q.Keyword != "" {
+		tx = tx.Where("topic LIKE ?", "%"+q.Keyword+"%")
+	}
+	if q.Answer != "" {
+		tx = tx.Where("answer LIKE ?", "%"+q.Answer+"%")
+	}
+	if q.MinDifficulty != nil {
+		tx = tx.Where("difficulty >= ?", *q.MinDifficulty)
+	}
+	if q.MaxDifficulty != nil {
+		tx = tx.Where("difficulty <= ?", *q.MaxDifficulty)
+	}
+	for column, value := range map[string]string{
+		"chapter_1": q.Chapter1,
+		"chapter_2": q.Chapter2,
+		"label_1":   q.Label1,
+		"label_2":   q.Label2,
+		"reviewer":  q.Reviewer,
+	} {
+		if value != "" {
+			tx = tx.Where(column+" = ?", value)
+		}
+	}
+	if q.MinScore != nil {
+		tx = tx.Where("score >= ?", *q.MinScore)
+	}
+	if q.MaxScore != nil {
+		tx = tx.Where("score <= ?", *q.MaxScore)
+	}
+	if !q.UpdatedFrom.IsZero() {
+		tx = tx.Where("update_time >= ?", q.UpdatedFrom)
+	}
+	if !q.UpdatedTo.IsZero() {
+		tx = tx.Where("update_time < ?", q.UpdatedTo)
+	}
+	if len(q.Statuses) > 0 {
+		tx = tx.Where("status IN ?", q.Statuses)
+	}
+	if err := tx.Count(&page.Total).Error; err != nil {
+		return page, err
+	}
+
+	sorts := normalizeQuestionSort(q.Sort)
+	if q.Cursor != "" {
+		after, err := DecodeQuestionBankCursor(q.Cursor, sorts)
+		if err != nil {
+			return page, err
+		}
+		cond, args := keysetCondition(after, sorts)
+		tx = tx.Where(cond, args...)
+	} else if q.Offset > 0 {
+		tx = tx.Offset(q.Offset)
+	}
+	for _, s := range sorts {
+		if s.Desc {
+			tx = tx.Order(s.Field + " DESC")
+		} else {
+			tx = tx.Order(s.Field + " ASC")
+		}
+	}
+	if q.Limit > 0 {
+		tx = tx.Limit(q.Limit)
+	}
+	if err := tx.Find(&page.Items).Error; err != nil {
+		return page, err
+	}
+	if q.Limit > 0 && len(page.Items) == q.Limit {
+		page.NextCursor = EncodeQuestionBankCursor(page.Items[len(page.Items)-1], sorts)
+	}
+	return page, nil
+}
+
+// keysetCondition 生成排在游标之后的条件：(a > ?) OR (a = ? AND b > ?) OR ...
+func keysetCondition(after entity.QuestionBank, sorts []QuestionBankSort) (string, []interface{}) {
+	var ors []string
+	var args []interface{}
+	for i, s := range sorts {
+		var ands []string
+		for _, prev := range sorts[:i] {
+			ands = append(ands, prev.Field+" = ?")
+			args = append(args, QuestionSortValue(after, prev.Field))
+		}
+		op := " > ?"
+		if s.Desc {
+			op = " < ?"
+		}
+		ands = append(ands, s.Field+op)
+		args = append(args, QuestionSortValue(after, s.Field))
+		ors = append(ors, "("+strings.Join(ands, " AND ")+")")
+	}
+	return strings.Join(ors, " OR "), args
+}
+
 // GetDistinctTopicType 获取所有不同的题目类型
 func (m *QuestionBankMapper) GetDistinctTopicType() ([]string, error) {
 	var topicTypes []string
@@ -46,31 +148,152 @@ func (m *QuestionBankMapper) SearchQuestionByTopic(topicType, keyword string) ([
 	return questionBanks, result.Error
 }
 
+// SearchQuestionBanks 全文检索题干、答案和知识点，按相关度降序返回。
+// MySQL 使用 ngram 全文索引，其他数据库取出候选题目后在程序中按 BM25 排序
+func (m *QuestionBankMapper) SearchQuestionBanks(q QuestionSearchQuery) ([]QuestionSearchHit, error) {
+	if strings.TrimSpace(q.Keyword) == "" {
+		return nil, nil
+	}
+	if m.db.Dialector.Name() != "mysql" {
+		var candidates []entity.QuestionBank
+		tx := m.db
+		if q.TopicType != "" {
+			tx = tx.Where("topic_type = ?", q.TopicType)
+		}
+		if err := tx.Find(&candidates).Error; err != nil {
+			return nil, err
+		}
+		return RankQuestionBanks(candidates, q), nil
+	}
+
+	type row struct {
+		entity.QuestionBank
+		Relevance float64 `gorm:"column:relevance"`
+	}
+	var rows []row
+	const match = "MATCH (topic, answer, label_1, label_2) AGAINST (? IN NATURAL LANGUAGE MODE)"
+	tx := m.db.Model(&entity.QuestionBank{}).
+		Select("*, "+match+" AS relevance", q.Keyword).
+		Where(match, q.Keyword)
+	if q.TopicType != "" {
+		tx = tx.Where("topic_type = ?", q.TopicType)
+	}
+	tx = tx.Order("relevance DESC").Order("id")
+	if q.Limit > 0 {
+		tx = tx.Limit(q.Limit)
+	}
+	if err := tx.Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	hits := make([]QuestionSearchHit, 0, len(rows))
+	for _, r := range rows {
+		hits = append(hits, QuestionSearchHit{Question: r.QuestionBank, Relevance: r.Relevance})
+	}
+	return hits, nil
+}
+
 // InsertSingleQuestionBank 插入单条题库记录
 func (m *QuestionBankMapper) InsertSingleQuestionBank(questionBank *entity.QuestionBank) (int64, error) {
 	result := m.db.Create(questionBank)
 	return result.RowsAffected, result.Error
 }
 
-// DeleteSingleQuestionBank 根据 ID 删除单条题库记录
-func (m *QuestionBankMapper) DeleteSingleQuestionBank(id int) (int64, error) {
-	var tmp entity.QuestionBank
-	if err := m.db.First(&tmp, id).Error; err != nil {
+// notLockedQuestion 排除被已锁定的试卷引用的题目。条件与删除语句在同一条 SQL 中执行，
+// 删除前检查之后才锁定的试卷引用的题目也不会被删除
+const notLockedQuestion = `NOT EXISTS (SELECT 1 FROM questiongenhistory
+	JOIN testpapergenhistory ON testpapergenhistory.test_paper_uid = questiongenhistory.test_paper_uid
+	WHERE testpapergenhistory.locked = ? AND questiongenhistory.question_bank_id = questionbank.id)`
+
+// SoftDeleteQuestionBanks 把题目移入回收站，记录删除时间和删除人，已在回收站中的题目和被已锁定的试卷引用的题目不受影响
+func (m *QuestionBankMapper) SoftDeleteQuestionBanks(ids []int, deletedBy string) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	result := m.db.Model(&entity.QuestionBank{}).Where("id IN ?", ids).Where(notLockedQuestion, true).Updates(map[string]interface{}{
+		"deleted_at": time.Now(),
+		"deleted_by": deletedBy,
+	})
+	return result.RowsAffected, result.Error
+}
+
+// ImportQuestionBanks 在同一事务中把 deleteIds 对应的题目移入回收站并插入导入的题目，
+// 任一步失败时全部回滚。被已锁定的试卷引用的题目保留在题库中。插入后回填 questions 中的 ID，返回移入回收站的题目数
+func (m *QuestionBankMapper) ImportQuestionBanks(questions []entity.QuestionBank, deleteIds []int, deletedBy string) (int64, error) {
+	var deleted int64
+	err := m.db.Transaction(func(tx *gorm.DB) error {
+		if len(deleteIds) > 0 {
+			result := tx.Model(&entity.QuestionBank{}).Where("id IN ?", deleteIds).Where(notLockedQuestion, true).Updates(map[string]interface{}{
+				"deleted_at": time.Now(),
+				"deleted_by": deletedBy,
+			})
+			if result.Error != nil {
+				return fmt.Errorf("failed to delete question banks: %w", result.Error)
+			}
+			deleted = result.RowsAffected
+		}
+		if len(questions) == 0 {
+			return nil
+		}
+		if err := tx.CreateInBatches(questions, 100).Error; err != nil {
+			return fmt.Errorf("failed to insert question banks: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
 		return 0, err
 	}
-	// 删除数据库记录
-	result := m.db.Delete(&entity.QuestionBank{}, id)
-	if result.Error != nil {
-		return result.RowsAffected, result.Error
+	return deleted, nil
+}
+
+// GetDeletedQuestionBanks 获取回收站中的题目，按删除时间降序排列
+func (m *QuestionBankMapper) GetDeletedQuestionBanks() ([]entity.QuestionBank, error) {
+	var questionBanks []entity.QuestionBank
+	result := m.db.Unscoped().Where("deleted_at IS NOT NULL").Order("deleted_at desc").Order("id").Find(&questionBanks)
+	return questionBanks, result.Error
+}
+
+// GetDeletedQuestionBanksInIds 根据 ID 列表获取回收站中的题目
+func (m *QuestionBankMapper) GetDeletedQuestionBanksInIds(ids []int) ([]entity.QuestionBank, error) {
+	var questionBanks []entity.QuestionBank
+	result := m.db.Unscoped().Where("id IN ? AND deleted_at IS NOT NULL", ids).Find(&questionBanks)
+	return questionBanks, result.Error
+}
+
+// RestoreQuestionBanks 把回收站中的题目恢复到题库
+func (m *QuestionBankMapper) RestoreQuestionBanks(ids []int) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
 	}
-	// 如果记录存在且图片路径不为空，则删除图片文件
-	if result.RowsAffected > 0 && tmp.TopicImagePath != "" {
-		err := os.Remove(tmp.TopicImagePath)
-		if err != nil {
-			return result.RowsAffected, err
-		}
+	result := m.db.Unscoped().Model(&entity.QuestionBank{}).Where("id IN ? AND deleted_at IS NOT NULL", ids).
+		Updates(map[string]interface{}{"deleted_at": nil, "deleted_by": ""})
+	return result.RowsAffected, result.Error
+}
+
+// PurgeQuestionBanks 彻底删除回收站中的题目，不在回收站中的题目和被已锁定的试卷引用的题目不受影响
+func (m *QuestionBankMapper) PurgeQuestionBanks(ids []int) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
 	}
-	return result.RowsAffected, nil
+	result := m.db.Unscoped().Where("id IN ? AND deleted_at IS NOT NULL", ids).Where(notLockedQuestion, true).Delete(&entity.QuestionBank{})
+	return result.RowsAffected, result.Error
+}
+
+// SetQuestionStatus 修改题目的审核状态，不改变更新时间
+func (m *QuestionBankMapper) SetQuestionStatus(ids []int, status string) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	result := m.db.Model(&entity.QuestionBank{}).Where("id IN ?", ids).Update("status", status)
+	return result.RowsAffected, result.Error
+}
+
+// SetQuestionReviewer 指定题目的审核员，reviewer 为空时取消指定
+func (m *QuestionBankMapper) SetQuestionReviewer(ids []int, reviewer string) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	result := m.db.Model(&entity.QuestionBank{}).Where("id IN ?", ids).Update("reviewer", reviewer)
+	return result.RowsAffected, result.Error
 }
 
 // GetQuestionBankById 根据 ID 获取题库记录
@@ -80,10 +303,59 @@ func (m *QuestionBankMapper) GetQuestionBankById(id int) ([]entity.QuestionBank,
 	return questionBanks, result.Error
 }
 
-// UpdateSingleQuestionBank 更新单条题库记录
+// GetQuestionBanksInIds 根据 ID 列表获取题库记录
+func (m *QuestionBankMapper) GetQuestionBanksInIds(ids []int) ([]entity.QuestionBank, error) {
+	var questionBanks []entity.QuestionBank
+	result := m.db.Where("id IN ?", ids).Find(&questionBanks)
+	return questionBanks, result.Error
+}
+
+// questionEditColumns 修改题目时由编辑界面提交的列，零值也会写入，选项或表格为 nil 时清空
+var questionEditColumns = []string{
+	"topic", "answer", "topic_type", "score", "difficulty", "chapter_1", "chapter_2",
+	"label_1", "label_2", "options", "topic_table", "answer_table", "update_time",
+}
+
+// UpdateSingleQuestionBank 更新单条题库记录，写入 questionEditColumns 中的列。
+// 材料和题干图片只在有值时更新，分别由材料接口和图片上传维护
 func (m *QuestionBankMapper) UpdateSingleQuestionBank(questionBank *entity.QuestionBank) (int64, error) {
-	//result := m.db.Model(questionBank).Where("id =?", questionBank.ID).Updates(questionBank)
-	result := m.db.Model(questionBank).Updates(questionBank)
+	columns := append([]string{}, questionEditColumns...)
+	if questionBank.TopicMaterialID != 0 {
+		columns = append(columns, "topic_material_id")
+	}
+	if questionBank.TopicImagePath != "" {
+		columns = append(columns, "topic_image_path")
+	}
+	result := m.db.Model(questionBank).Select(columns).Updates(questionBank)
+	return result.RowsAffected, result.Error
+}
+
+// GetQuestionBanksByMaterialId 获取引用指定材料的题目，按 ID 升序
+func (m *QuestionBankMapper) GetQuestionBanksByMaterialId(materialID int) ([]entity.QuestionBank, error) {
+	var questionBanks []entity.QuestionBank
+	result := m.db.Where("topic_material_id = ?", materialID).Order("id").Find(&questionBanks)
+	return questionBanks, result.Error
+}
+
+// SetQuestionMaterial 把题目关联到指定材料，materialID 为 0 时取消关联
+func (m *QuestionBankMapper) SetQuestionMaterial(ids []int, materialID int) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	result := m.db.Model(&entity.QuestionBank{}).Where("id IN ?", ids).Update("topic_material_id", materialID)
+	return result.RowsAffected, result.Error
+}
+
+// SetTopicImagePath 修改题干图片路径，path 为空时清空
+func (m *QuestionBankMapper) SetTopicImagePath(id int, path string) (int64, error) {
+	result := m.db.Model(&entity.QuestionBank{}).Where("id = ?", id).Update("topic_image_path", path)
+	return result.RowsAffected, result.Error
+}
+
+// ReplaceQuestionBank 用 questionBank 覆盖题目的全部字段，与 UpdateSingleQuestionBank 不同，零值也会写入。
+// 删除和审核相关的列保持不变
+func (m *QuestionBankMapper) ReplaceQuestionBank(questionBank *entity.QuestionBank) (int64, error) {
+	result := m.db.Model(questionBank).Select("*").Omit("id", "deleted_at", "deleted_by", "status", "reviewer").Updates(questionBank)
 	return result.RowsAffected, result.Error
 }
 
@@ -0,0 +1,337 @@
+package mapper
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"graduation/entity"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrInvalidCursor 游标无法解析，或与本次查询的排序方式不一致
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// questionSortFields 允许排序的字段，字段名与列名相同
+var questionSortFields = map[string]bool{
+	"id":          true,
+	"topic_type":  true,
+	"score":       true,
+	"difficulty":  true,
+	"chapter_1":   true,
+	"chapter_2":   true,
+	"label_1":     true,
+	"label_2":     true,
+	"update_time": true,
+}
+
+// QuestionBankSort 单个排序字段
+type QuestionBankSort struct {
+	Field string
+	Desc  bool
+}
+
+// QuestionBankQuery 题库分页查询条件，字段为零值或 nil 时不过滤。
+// 设置 Cursor 时按游标翻页并忽略 Offset
+type QuestionBankQuery struct {
+	TopicTypes    []string
+	Keyword       string // 题干包含的文字
+	Answer        string // 答案包含的文字
+	MinDifficulty *int
+	MaxDifficulty *int
+	Chapter1      string
+	Chapter2      string
+	Label1        string
+	Label2        string
+	MinScore      *float64
+	MaxScore      *float64
+	UpdatedFrom   time.Time // 包含
+	UpdatedTo     time.Time // 不包含
+	Statuses      []string  // 审核状态
+	Reviewer      string    // 指定的审核员
+	Sort          []QuestionBankSort
+	Cursor        string
+	Offset        int
+	Limit         int
+}
+
+// QuestionBankPage 题库分页查询结果，Total 为符合过滤条件的总数，NextCursor 为空表示没有下一页
+type QuestionBankPage struct {
+	Items      []entity.QuestionBank `json:"items"`
+	Total      int64                 `json:"total"`
+	NextCursor string                `json:"next_cursor"`
+}
+
+// QuestionSearchQuery 题库全文检索条件
+type QuestionSearchQuery struct {
+	Keyword   string
+	TopicType string // 为空时不限题型
+	Limit     int    // 小于等于 0 时不限数量
+}
+
+// QuestionSearchHit 全文检索命中的题目及相关度
+type QuestionSearchHit struct {
+	Question  entity.QuestionBank
+	Relevance float64
+}
+
+// 全文检索中各字段的权重，题干最重要
+var questionSearchWeights = struct{ Topic, Answer, Label float64 }{Topic: 3, Answer: 1.5, Label: 1}
+
+// RankQuestionBanks 在程序中按 BM25 计算相关度并排序，用于没有全文索引的数据库和内存实现
+func RankQuestionBanks(candidates []entity.QuestionBank, q QuestionSearchQuery) []QuestionSearchHit {
+	docs := make([][]SearchField, len(candidates))
+	for i, c := range candidates {
+		docs[i] = []SearchField{
+			{Text: c.Topic, Weight: questionSearchWeights.Topic},
+			{Text: c.Answer, Weight: questionSearchWeights.Answer},
+			{Text: c.Label1 + " " + c.Label2, Weight: questionSearchWeights.Label},
+		}
+	}
+	scores := RankBM25(q.Keyword, docs)
+	var hits []QuestionSearchHit
+	for i, score := range scores {
+		if score > 0 && (q.TopicType == "" || candidates[i].TopicType == q.TopicType) {
+			hits = append(hits, QuestionSearchHit{Question: candidates[i], Relevance: score})
+		}
+	}
+	sort.SliceStable(hits, func(i, j int) bool {
+		if hits[i].Relevance != hits[j].Relevance {
+			return hits[i].Relevance > hits[j].Relevance
+		}
+		return hits[i].Question.ID < hits[j].Question.ID
+	})
+	if q.Limit > 0 && len(hits) > q.Limit {
+		hits = hits[:q.Limit]
+	}
+	return hits
+}
+
+// ParseQuestionBankSort 解析逗号分隔的排序字段，字段前加 - 表示降序，例如 "-update_time,score"。
+// 为空时按更新时间降序
+func ParseQuestionBankSort(s string) ([]QuestionBankSort, error) {
+	if strings.TrimSpace(s) == "" {
+		return []QuestionBankSort{{Field: "update_time", Desc: true}}, nil
+	}
+	var sorts []QuestionBankSort
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		desc := strings.HasPrefix(part, "-")
+		field := strings.TrimPrefix(strings.TrimPrefix(part, "-"), "+")
+		if !questionSortFields[field] {
+			return nil, fmt.Errorf("unsupported sort field: %s", field)
+		}
+		if seen[field] {
+			continue
+		}
+		seen[field] = true
+		sorts = append(sorts, QuestionBankSort{Field: field, Desc: desc})
+	}
+	return sorts, nil
+}
+
+// normalizeQuestionSort 补充 ID 作为最后的排序字段，保证排序结果唯一，游标翻页依赖这一点
+func normalizeQuestionSort(sorts []QuestionBankSort) []QuestionBankSort {
+	if len(sorts) == 0 {
+		sorts = []QuestionBankSort{{Field: "update_time", Desc: true}}
+	}
+	for _, s := range sorts {
+		if s.Field == "id" {
+			return sorts
+		}
+	}
+	out := make([]QuestionBankSort, len(sorts), len(sorts)+1)
+	copy(out, sorts)
+	return append(out, QuestionBankSort{Field: "id"})
+}
+
+// sortKey 排序方式的文本表示，保存在游标中用于校验
+func sortKey(sorts []QuestionBankSort) string {
+	parts := make([]string, len(sorts))
+	for i, s := range sorts {
+		parts[i] = s.Field
+		if s.Desc {
+			parts[i] = "-" + s.Field
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// QuestionSortValue 获取题目在排序字段上的值
+func QuestionSortValue(q entity.QuestionBank, field string) interface{} {
+	switch field {
+	case "id":
+		return q.ID
+	case "topic_type":
+		return q.TopicType
+	case "score":
+		return q.Score
+	case "difficulty":
+		return q.Difficulty
+	case "chapter_1":
+		return q.Chapter1
+	case "chapter_2":
+		return q.Chapter2
+	case "label_1":
+		return q.Label1
+	case "label_2":
+		return q.Label2
+	case "update_time":
+		return q.UpdateTime
+	}
+	return nil
+}
+
+// compareSortValue 比较同一字段的两个值
+func compareSortValue(a, b interface{}) int {
+	switch av := a.(type) {
+	case int:
+		bv := b.(int)
+		if av < bv {
+			return -1
+		} else if av > bv {
+			return 1
+		}
+	case float64:
+		bv := b.(float64)
+		if av < bv {
+			return -1
+		} else if av > bv {
+			return 1
+		}
+	case string:
+		return strings.Compare(av, b.(string))
+	case time.Time:
+		return av.Compare(b.(time.Time))
+	}
+	return 0
+}
+
+// CompareQuestionBanks 按排序方式比较两道题，a 排在 b 之前时返回负数
+func CompareQuestionBanks(a, b entity.QuestionBank, sorts []QuestionBankSort) int {
+	for _, s := range normalizeQuestionSort(sorts) {
+		c := compareSortValue(QuestionSortValue(a, s.Field), QuestionSortValue(b, s.Field))
+		if s.Desc {
+			c = -c
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// questionCursor 游标内容，V 依次为排序字段的值
+type questionCursor struct {
+	S string            `json:"s"`
+	V []json.RawMessage `json:"v"`
+}
+
+// EncodeQuestionBankCursor 生成指向题目 q 之后的游标
+func EncodeQuestionBankCursor(q entity.QuestionBank, sorts []QuestionBankSort) string {
+	sorts = normalizeQuestionSort(sorts)
+	c := questionCursor{S: sortKey(sorts)}
+	for _, s := range sorts {
+		v, _ := json.Marshal(QuestionSortValue(q, s.Field))
+		c.V = append(c.V, v)
+	}
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeQuestionBankCursor 解析游标，返回只填充了排序字段的题目，用于比较
+func DecodeQuestionBankCursor(cursor string, sorts []QuestionBankSort) (entity.QuestionBank, error) {
+	var q entity.QuestionBank
+	sorts = normalizeQuestionSort(sorts)
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return q, ErrInvalidCursor
+	}
+	var c questionCursor
+	if json.Unmarshal(data, &c) != nil || c.S != sortKey(sorts) || len(c.V) != len(sorts) {
+		return q, ErrInvalidCursor
+	}
+	for i, s := range sorts {
+		var target interface{}
+		switch s.Field {
+		case "id":
+			target = &q.ID
+		case "topic_type":
+			target = &q.TopicType
+		case "score":
+			target = &q.Score
+		case "difficulty":
+			target = &q.Difficulty
+		case "chapter_1":
+			target = &q.Chapter1
+		case "chapter_2":
+			target = &q.Chapter2
+		case "label_1":
+			target = &q.Label1
+		case "label_2":
+			target = &q.Label2
+		case "update_time":
+			target = &q.UpdateTime
+		}
+		if json.Unmarshal(c.V[i], target) != nil {
+			return q, ErrInvalidCursor
+		}
+	}
+	return q, nil
+}
+
+// MatchQuestionBankQuery 判断题目是否满足查询的过滤条件，不考虑分页，供内存实现使用
+func MatchQuestionBankQuery(q entity.QuestionBank, query QuestionBankQuery) bool {
+	if len(query.TopicTypes) > 0 && !containsString(query.TopicTypes, q.TopicType) {
+		return false
+	}
+	if query.Keyword != "" && !strings.Contains(q.Topic, query.Keyword) {
+		return false
+	}
+	if query.Answer != "" && !strings.Contains(q.Answer, query.Answer) {
+		return false
+	}
+	if query.MinDifficulty != nil && q.Difficulty < *query.MinDifficulty {
+		return false
+	}
+	if query.MaxDifficulty != nil && q.Difficulty > *query.MaxDifficulty {
+		return false
+	}
+	if (query.Chapter1 != "" && q.Chapter1 != query.Chapter1) ||
+		(query.Chapter2 != "" && q.Chapter2 != query.Chapter2) ||
+		(query.Label1 != "" && q.Label1 != query.Label1) ||
+		(query.Label2 != "" && q.Label2 != query.Label2) {
+		return false
+	}
+	if query.MinScore != nil && q.Score < *query.MinScore {
+		return false
+	}
+	if query.MaxScore != nil && q.Score > *query.MaxScore {
+		return false
+	}
+	if !query.UpdatedFrom.IsZero() && q.UpdateTime.Before(query.UpdatedFrom) {
+		return false
+	}
+	if !query.UpdatedTo.IsZero() && !q.UpdateTime.Before(query.UpdatedTo) {
+		return false
+	}
+	if len(query.Statuses) > 0 && !containsString(query.Statuses, q.Status) {
+		return false
+	}
+	if query.Reviewer != "" && q.Reviewer != query.Reviewer {
+		return false
+	}
+	return true
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,132 @@
+package mapper
+
+import (
+	"graduation/entity"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseQuestionBankSort(t *testing.T) {
+	sorts, err := ParseQuestionBankSort("")
+	require.NoError(t, err)
+	require.Equal(t, []QuestionBankSort{{Field: "update_time", Desc: true}}, sorts)
+
+	sorts, err = ParseQuestionBankSort("-score, difficulty,-score")
+	require.NoError(t, err)
+	require.Equal(t, []QuestionBankSort{{Field: "score", Desc: true}, {Field: "difficulty"}}, sorts)
+
+	_, err = ParseQuestionBankSort("topic")
+	require.Error(t, err)
+}
+
+func TestQueryQuestionBanks(t *testing.T) {
+	openTestDB(t)
+
+	qm := NewQuestionBankMapper()
+	base := time.Date(2025, 6, 1, 8, 0, 0, 0, time.Local)
+	for i, q := range []entity.QuestionBank{
+		{Topic: "8086 有几个段寄存器？", Answer: "4", TopicType: "填空题", Score: 2, Difficulty: 1, Label1: "Intel8086微处理器"},
+		{Topic: "8259A 的作用", Answer: "中断控制", TopicType: "简答题", Score: 10, Difficulty: 3, Label1: "中断"},
+		{Topic: "8086 的地址总线宽度", Answer: "20 位", TopicType: "选择题", Score: 2, Difficulty: 2, Label1: "Intel8086微处理器"},
+		{Topic: "INTR 是可屏蔽中断", Answer: "对", TopicType: "判断题", Score: 2, Difficulty: 2, Label1: "中断"},
+		{Topic: "8086 CPU 由哪两个部件组成？", Answer: "EU 和 BIU", TopicType: "简答题", Score: 10, Difficulty: 4, Label1: "Intel8086微处理器"},
+	} {
+		_, err := qm.InsertSingleQuestionBank(&q)
+		require.NoError(t, err)
+		// BeforeCreate 会覆盖更新时间，插入后再改
+		require.NoError(t, qm.db.Model(&entity.QuestionBank{}).Where("id = ?", q.ID).
+			Update("update_time", base.Add(time.Duration(i)*time.Hour)).Error)
+	}
+
+	minDifficulty := 2
+	page, err := qm.QueryQuestionBanks(QuestionBankQuery{
+		TopicTypes:    []string{"简答题", "选择题"},
+		MinDifficulty: &minDifficulty,
+		Sort:          []QuestionBankSort{{Field: "difficulty", Desc: true}},
+	})
+	require.NoError(t, err)
+	require.EqualValues(t, 3, page.Total)
+	require.Equal(t, []int{5, 2, 3}, questionIds(page.Items))
+
+	maxScore := 2.0
+	page, err = qm.QueryQuestionBanks(QuestionBankQuery{
+		Keyword:     "8086",
+		MaxScore:    &maxScore,
+		UpdatedFrom: base.Add(time.Hour),
+	})
+	require.NoError(t, err)
+	require.Equal(t, []int{3}, questionIds(page.Items))
+
+	// 游标翻页与偏移翻页的结果一致
+	sorts := []QuestionBankSort{{Field: "score", Desc: true}, {Field: "label_1"}}
+	all, err := qm.QueryQuestionBanks(QuestionBankQuery{Sort: sorts})
+	require.NoError(t, err)
+	require.Empty(t, all.NextCursor)
+	var paged []entity.QuestionBank
+	cursor := ""
+	for {
+		page, err := qm.QueryQuestionBanks(QuestionBankQuery{Sort: sorts, Cursor: cursor, Limit: 2})
+		require.NoError(t, err)
+		require.EqualValues(t, 5, page.Total)
+		paged = append(paged, page.Items...)
+		if page.NextCursor == "" {
+			break
+		}
+		cursor = page.NextCursor
+	}
+	require.Equal(t, questionIds(all.Items), questionIds(paged))
+
+	page, err = qm.QueryQuestionBanks(QuestionBankQuery{Sort: sorts, Offset: 2, Limit: 2})
+	require.NoError(t, err)
+	require.Equal(t, questionIds(all.Items[2:4]), questionIds(page.Items))
+
+	// 默认按更新时间降序，游标也要能处理时间字段
+	page, err = qm.QueryQuestionBanks(QuestionBankQuery{Limit: 2})
+	require.NoError(t, err)
+	require.Equal(t, []int{5, 4}, questionIds(page.Items))
+	page, err = qm.QueryQuestionBanks(QuestionBankQuery{Limit: 2, Cursor: page.NextCursor})
+	require.NoError(t, err)
+	require.Equal(t, []int{3, 2}, questionIds(page.Items))
+
+	_, err = qm.QueryQuestionBanks(QuestionBankQuery{Sort: sorts, Cursor: page.NextCursor})
+	require.ErrorIs(t, err, ErrInvalidCursor, "cursor from a different sort")
+}
+
+func questionIds(questions []entity.QuestionBank) []int {
+	ids := make([]int, 0, len(questions))
+	for _, q := range questions {
+		ids = append(ids, q.ID)
+	}
+	return ids
+}
+
+func TestSearchQuestionBanks(t *testing.T) {
+	openTestDB(t)
+
+	qm := NewQuestionBankMapper()
+	for _, q := range []entity.QuestionBank{
+		{Topic: "8086 有几个段寄存器？", Answer: "4", TopicType: "填空题"},
+		{Topic: "8259A 的作用", Answer: "中断控制", TopicType: "简答题"},
+		{Topic: "INTR 是可屏蔽中断", Answer: "对", TopicType: "判断题"},
+	} {
+		_, err := qm.InsertSingleQuestionBank(&q)
+		require.NoError(t, err)
+	}
+
+	hits, err := qm.SearchQuestionBanks(QuestionSearchQuery{Keyword: "屏蔽中断"})
+	require.NoError(t, err)
+	require.Len(t, hits, 2)
+	require.Equal(t, "INTR 是可屏蔽中断", hits[0].Question.Topic)
+	require.Greater(t, hits[0].Relevance, hits[1].Relevance)
+
+	hits, err = qm.SearchQuestionBanks(QuestionSearchQuery{Keyword: "中断", TopicType: "简答题"})
+	require.NoError(t, err)
+	require.Len(t, hits, 1)
+	require.Equal(t, "8259A 的作用", hits[0].Question.Topic)
+
+	hits, err = qm.SearchQuestionBanks(QuestionSearchQuery{Keyword: "  "})
+	require.NoError(t, err)
+	require.Empty(t, hits)
+}
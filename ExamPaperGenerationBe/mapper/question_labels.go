@@ -1,6 +1,7 @@
 package mapper
 
 import (
+	"fmt"
 	"gorm.io/gorm"
 	"graduation/entity"
 )
@@ -58,3 +59,54 @@ func (m *QuestionLabelsMapper) GetDistinctLabel2() ([]entity.QuestionLabels, err
 	result := m.db.Distinct("label_2").Find(&labels)
 	return labels, result.Error
 }
+
+// GetLabelById 根据 ID 获取标签
+func (m *QuestionLabelsMapper) GetLabelById(id int) (entity.QuestionLabels, error) {
+	var label entity.QuestionLabels
+	result := m.db.Where("id = ?", id).First(&label)
+	return label, result.Error
+}
+
+// CreateLabel 创建标签
+func (m *QuestionLabelsMapper) CreateLabel(label *entity.QuestionLabels) error {
+	return m.db.Create(label).Error
+}
+
+// UpdateLabel 更新标签，并在同一事务中同步题库和生成历史中引用该标签的记录
+func (m *QuestionLabelsMapper) UpdateLabel(id int, label entity.QuestionLabels) error {
+	return m.db.Transaction(func(tx *gorm.DB) error {
+		var existing entity.QuestionLabels
+		if err := tx.Where("id = ?", id).First(&existing).Error; err != nil {
+			return err
+		}
+		label.ID = id
+		if err := tx.Model(&entity.QuestionLabels{}).Where("id = ?", id).Updates(&label).Error; err != nil {
+			return fmt.Errorf("failed to update label: %w", err)
+		}
+		updates := map[string]interface{}{
+			"chapter_1": label.Chapter1,
+			"chapter_2": label.Chapter2,
+			"label_1":   label.Label1,
+			"label_2":   label.Label2,
+		}
+		cond := "chapter_1 = ? AND chapter_2 = ? AND label_1 = ? AND label_2 = ?"
+		// 更新题库中对应的标签，回收站中的题目一并更新，恢复后仍引用同一个标签
+		if err := tx.Unscoped().Model(&entity.QuestionBank{}).
+			Where(cond, existing.Chapter1, existing.Chapter2, existing.Label1, existing.Label2).
+			Updates(updates).Error; err != nil {
+			return fmt.Errorf("failed to update question bank labels: %w", err)
+		}
+		// 更新历史记录中对应的标签
+		if err := tx.Model(&entity.QuestionGenHistory{}).
+			Where(cond, existing.Chapter1, existing.Chapter2, existing.Label1, existing.Label2).
+			Updates(updates).Error; err != nil {
+			return fmt.Errorf("failed to update question history labels: %w", err)
+		}
+		return nil
+	})
+}
+
+// DeleteLabel 根据 ID 删除标签
+func (m *QuestionLabelsMapper) DeleteLabel(id int) error {
+	return m.db.Where("id = ?", id).Delete(&entity.QuestionLabels{}).Error
+}
@@ -0,0 +1,63 @@
+package mapper
+
+import (
+	"graduation/entity"
+
+	"gorm.io/gorm"
+)
+
+// QuestionMaterialMapper 题目材料的数据库操作
+type QuestionMaterialMapper struct {
+	db *gorm.DB
+}
+
+// NewQuestionMaterialMapper 创建一个新的 QuestionMaterialMapper 实例
+func NewQuestionMaterialMapper() *QuestionMaterialMapper {
+	return &QuestionMaterialMapper{
+		db: DB,
+	}
+}
+
+// questionMaterialColumns 修改材料时写入的列，空值也需要写入以便清空标题、图片或表格
+var questionMaterialColumns = []string{"title", "material", "image_paths", "tables", "update_time"}
+
+// GetAllQuestionMaterials 获取全部材料，最近修改的在前
+func (m *QuestionMaterialMapper) GetAllQuestionMaterials() ([]entity.QuestionMaterial, error) {
+	var materials []entity.QuestionMaterial
+	result := m.db.Order("update_time DESC").Order("id DESC").Find(&materials)
+	return materials, result.Error
+}
+
+// GetQuestionMaterialById 根据 ID 获取材料
+func (m *QuestionMaterialMapper) GetQuestionMaterialById(id int) (entity.QuestionMaterial, error) {
+	var material entity.QuestionMaterial
+	result := m.db.Where("id = ?", id).First(&material)
+	return material, result.Error
+}
+
+// GetQuestionMaterialsInIds 根据 ID 列表获取材料
+func (m *QuestionMaterialMapper) GetQuestionMaterialsInIds(ids []int) ([]entity.QuestionMaterial, error) {
+	var materials []entity.QuestionMaterial
+	if len(ids) == 0 {
+		return materials, nil
+	}
+	result := m.db.Where("id IN ?", ids).Find(&materials)
+	return materials, result.Error
+}
+
+// CreateQuestionMaterial 新增材料
+func (m *QuestionMaterialMapper) CreateQuestionMaterial(material *entity.QuestionMaterial) error {
+	return m.db.Create(material).Error
+}
+
+// UpdateQuestionMaterial 修改材料的全部内容
+func (m *QuestionMaterialMapper) UpdateQuestionMaterial(material *entity.QuestionMaterial) (int64, error) {
+	result := m.db.Model(material).Select(questionMaterialColumns).Updates(material)
+	return result.RowsAffected, result.Error
+}
+
+// DeleteQuestionMaterial 根据 ID 删除材料
+func (m *QuestionMaterialMapper) DeleteQuestionMaterial(id int) (int64, error) {
+	result := m.db.Delete(&entity.QuestionMaterial{}, id)
+	return result.RowsAffected, result.Error
+}
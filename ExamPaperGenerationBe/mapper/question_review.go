@@ -0,0 +1,40 @@
+package mapper
+
+import (
+	"graduation/entity"
+
+	"gorm.io/gorm"
+)
+
+// QuestionReviewMapper 题目审核意见的数据库操作
+type QuestionReviewMapper struct {
+	db *gorm.DB
+}
+
+// NewQuestionReviewMapper 创建一个新的 QuestionReviewMapper 实例
+func NewQuestionReviewMapper() *QuestionReviewMapper {
+	return &QuestionReviewMapper{
+		db: DB,
+	}
+}
+
+// InsertReviewComment 保存一条审核意见
+func (m *QuestionReviewMapper) InsertReviewComment(comment *entity.QuestionReviewComment) error {
+	return m.db.Create(comment).Error
+}
+
+// GetReviewComments 获取题目的全部审核意见，按时间先后排列
+func (m *QuestionReviewMapper) GetReviewComments(questionID int) ([]entity.QuestionReviewComment, error) {
+	var comments []entity.QuestionReviewComment
+	result := m.db.Where("question_id = ?", questionID).Order("created_at, id").Find(&comments)
+	return comments, result.Error
+}
+
+// DeleteReviewComments 删除题目的全部审核意见，只在彻底删除题目时调用
+func (m *QuestionReviewMapper) DeleteReviewComments(questionIDs []int) (int64, error) {
+	if len(questionIDs) == 0 {
+		return 0, nil
+	}
+	result := m.db.Where("question_id IN ?", questionIDs).Delete(&entity.QuestionReviewComment{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,62 @@
+package mapper
+
+import (
+	"graduation/entity"
+
+	"gorm.io/gorm"
+)
+
+// QuestionRevisionMapper 题目历史版本的数据库操作
+type QuestionRevisionMapper struct {
+	db *gorm.DB
+}
+
+// NewQuestionRevisionMapper 创建一个新的 QuestionRevisionMapper 实例
+func NewQuestionRevisionMapper() *QuestionRevisionMapper {
+	return &QuestionRevisionMapper{
+		db: DB,
+	}
+}
+
+// InsertQuestionRevision 保存一个新版本，版本号为该题目已有的最大版本号加 1
+func (m *QuestionRevisionMapper) InsertQuestionRevision(revision *entity.QuestionRevision) error {
+	return m.db.Transaction(func(tx *gorm.DB) error {
+		var latest int
+		if err := tx.Model(&entity.QuestionRevision{}).Where("question_id = ?", revision.QuestionID).
+			Select("COALESCE(MAX(revision), 0)").Scan(&latest).Error; err != nil {
+			return err
+		}
+		revision.Revision = latest + 1
+		return tx.Create(revision).Error
+	})
+}
+
+// GetQuestionRevisions 获取题目的全部版本，按版本号倒序
+func (m *QuestionRevisionMapper) GetQuestionRevisions(questionID int) ([]entity.QuestionRevision, error) {
+	var revisions []entity.QuestionRevision
+	result := m.db.Where("question_id = ?", questionID).Order("revision DESC").Find(&revisions)
+	return revisions, result.Error
+}
+
+// GetQuestionRevision 获取题目的指定版本，不存在时返回 gorm.ErrRecordNotFound
+func (m *QuestionRevisionMapper) GetQuestionRevision(questionID, revision int) (entity.QuestionRevision, error) {
+	var found entity.QuestionRevision
+	result := m.db.Where("question_id = ? AND revision = ?", questionID, revision).First(&found)
+	return found, result.Error
+}
+
+// GetLatestQuestionRevision 获取题目的最新版本，题目没有版本时返回 gorm.ErrRecordNotFound
+func (m *QuestionRevisionMapper) GetLatestQuestionRevision(questionID int) (entity.QuestionRevision, error) {
+	var found entity.QuestionRevision
+	result := m.db.Where("question_id = ?", questionID).Order("revision DESC").First(&found)
+	return found, result.Error
+}
+
+// DeleteQuestionRevisions 删除题目的全部版本，只在彻底删除题目时调用
+func (m *QuestionRevisionMapper) DeleteQuestionRevisions(questionIDs []int) (int64, error) {
+	if len(questionIDs) == 0 {
+		return 0, nil
+	}
+	result := m.db.Where("question_id IN ?", questionIDs).Delete(&entity.QuestionRevision{})
+	return result.RowsAffected, result.Error
+}
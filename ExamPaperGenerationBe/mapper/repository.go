@@ -2,34 +2,134 @@ package mapper
 
 import (
 	"fmt"
+	"time"
+
+	"github.com/glebarez/sqlite"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 )
 
+// 支持的数据库驱动
+const (
+	DriverMysql  = "mysql"
+	DriverSqlite = "sqlite"
+)
+
+// SqliteMemory 使用内存数据库时的路径
+const SqliteMemory = ":memory:"
+
 var DB *gorm.DB
 
+// Mysql MySQL 连接信息
 type Mysql struct {
-	Username string
-	Password string
-	Host     string
-	Port     string
-	Database string
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+	Host     string `yaml:"host"`
+	Port     string `yaml:"port"`
+	Database string `yaml:"database"`
+	Params   string `yaml:"params"` // DSN 附加参数，为空时使用默认参数
+}
+
+// Sqlite SQLite 连接信息，Path 为数据库文件路径或 SqliteMemory
+type Sqlite struct {
+	Path string `yaml:"path"`
+}
+
+// Pool 数据库连接池配置，零值表示使用 database/sql 的默认值
+type Pool struct {
+	MaxIdleConns    int           `yaml:"max_idle_conns"`
+	MaxOpenConns    int           `yaml:"max_open_conns"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime"`
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time"`
 }
 
-func init() {
-	c := Mysql{
-		Username: "root",
-		Password: "123456",
-		Host:     "127.0.0.1",
-		Port:     "3306",
-		Database: "graduation",
+// Config 数据库配置
+type Config struct {
+	Driver      string `yaml:"driver"` // mysql 或 sqlite，为空时使用 mysql
+	Mysql       Mysql  `yaml:"mysql"`
+	Sqlite      Sqlite `yaml:"sqlite"`
+	Pool        Pool   `yaml:"pool"`
+	AutoMigrate bool   `yaml:"auto_migrate"` // 启动时自动执行未执行的迁移
+}
+
+const defaultMysqlParams = "charset=utf8mb4&parseTime=True&loc=Local"
+
+// DSN 生成 MySQL 连接字符串
+func (c Mysql) DSN() string {
+	params := c.Params
+	if params == "" {
+		params = defaultMysqlParams
+	}
+	return fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?%s",
+		c.Username, c.Password, c.Host, c.Port, c.Database, params)
+}
+
+// dialector 根据驱动类型创建 gorm 方言
+func (cfg Config) dialector() (gorm.Dialector, error) {
+	switch cfg.Driver {
+	case "", DriverMysql:
+		return mysql.Open(cfg.Mysql.DSN()), nil
+	case DriverSqlite:
+		if cfg.Sqlite.Path == "" {
+			return nil, fmt.Errorf("sqlite path is empty")
+		}
+		return sqlite.Open(cfg.Sqlite.Path), nil
+	default:
+		return nil, fmt.Errorf("unsupported database driver: %s", cfg.Driver)
 	}
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
-		c.Username, c.Password, c.Host, c.Port, c.Database)
-	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{})
+}
 
+// Open 根据配置建立数据库连接并设置全局 DB
+func Open(cfg Config) (*gorm.DB, error) {
+	dialector, err := cfg.dialector()
+	if err != nil {
+		return nil, err
+	}
+	db, err := gorm.Open(dialector, &gorm.Config{})
 	if err != nil {
-		panic("failed to connect database")
+		return nil, fmt.Errorf("failed to connect database: %w", err)
+	}
+	pool := cfg.Pool
+	if cfg.Driver == DriverSqlite && cfg.Sqlite.Path == SqliteMemory {
+		// 内存数据库每个连接相互独立，只能使用单个长期连接
+		pool = Pool{MaxIdleConns: 1, MaxOpenConns: 1}
+	}
+	if err := applyPool(db, pool); err != nil {
+		return nil, err
 	}
 	DB = db
+	return db, nil
+}
+
+// Close 关闭全局数据库连接
+func Close() error {
+	if DB == nil {
+		return nil
+	}
+	sqlDB, err := DB.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
+
+// applyPool 设置连接池参数
+func applyPool(db *gorm.DB, p Pool) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return fmt.Errorf("failed to get sql.DB: %w", err)
+	}
+	if p.MaxIdleConns > 0 {
+		sqlDB.SetMaxIdleConns(p.MaxIdleConns)
+	}
+	if p.MaxOpenConns > 0 {
+		sqlDB.SetMaxOpenConns(p.MaxOpenConns)
+	}
+	if p.ConnMaxLifetime > 0 {
+		sqlDB.SetConnMaxLifetime(p.ConnMaxLifetime)
+	}
+	if p.ConnMaxIdleTime > 0 {
+		sqlDB.SetConnMaxIdleTime(p.ConnMaxIdleTime)
+	}
+	return nil
 }
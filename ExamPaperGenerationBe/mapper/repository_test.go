@@ -0,0 +1,499 @@
+package mapper
+
+import (
+	"graduation/entity"
+	"graduation/media"
+	"graduation/migration"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// openTestDB 打开内存 SQLite 数据库并执行迁移
+func openTestDB(t *testing.T) {
+	t.Helper()
+	db, err := Open(Config{
+		Driver: DriverSqlite,
+		Sqlite: Sqlite{Path: SqliteMemory},
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { Close() })
+	require.NoError(t, migration.Migrate(db))
+}
+
+func TestOpenSqliteMemory(t *testing.T) {
+	openTestDB(t)
+
+	labels, err := NewQuestionLabelsMapper().GetAllQuestionLabels()
+	require.NoError(t, err)
+	require.NotEmpty(t, labels)
+
+	qm := NewQuestionBankMapper()
+	n, err := qm.InsertSingleQuestionBank(&entity.QuestionBank{
+		Topic:      "8086 CPU 由哪两个部件组成？",
+		Answer:     "EU 和 BIU",
+		TopicType:  "简答题",
+		Score:      10,
+		Difficulty: 2,
+		Label1:     "Intel8086微处理器",
+	})
+	require.NoError(t, err)
+	require.EqualValues(t, 1, n)
+
+	found, err := qm.SearchQuestionByTopic("简答题", "8086")
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+
+	um := NewUserMapper()
+	_, err = um.AddNewUser(&entity.User{Username: "teacher", Password: "x", UserRole: "user"})
+	require.NoError(t, err)
+	_, err = um.AddNewUser(&entity.User{Username: "teacher", Password: "y", UserRole: "user"})
+	require.Error(t, err, "username should be unique")
+}
+
+func TestAuditLogMapper(t *testing.T) {
+	openTestDB(t)
+
+	am := NewAuditLogMapper()
+	base := time.Date(2025, 6, 1, 8, 0, 0, 0, time.Local)
+	for i, l := range []entity.AuditLog{
+		{Actor: "admin", Action: "delete", EntityType: "question", EntityID: "1", Before: `{"id":1}`},
+		{Actor: "teacher", Action: "update", EntityType: "question", EntityID: "2"},
+		{Actor: "admin", Action: "approve", EntityType: "user", EntityID: "teacher"},
+	} {
+		l.CreatedAt = base.Add(time.Duration(i) * time.Hour)
+		require.NoError(t, am.InsertAuditLog(&l))
+	}
+
+	logs, total, err := am.QueryAuditLogs(AuditLogQuery{Actor: "admin"})
+	require.NoError(t, err)
+	require.EqualValues(t, 2, total)
+	require.Equal(t, "approve", logs[0].Action, "newest first")
+	require.Equal(t, `{"id":1}`, logs[1].Before)
+
+	logs, total, err = am.QueryAuditLogs(AuditLogQuery{
+		EntityType: "question",
+		Start:      base.Add(30 * time.Minute),
+		End:        base.Add(2 * time.Hour),
+	})
+	require.NoError(t, err)
+	require.EqualValues(t, 1, total)
+	require.Equal(t, "2", logs[0].EntityID)
+
+	logs, total, err = am.QueryAuditLogs(AuditLogQuery{Offset: 1, Limit: 1})
+	require.NoError(t, err)
+	require.EqualValues(t, 3, total)
+	require.Len(t, logs, 1)
+	require.Equal(t, "update", logs[0].Action)
+}
+
+func TestOpenUnsupportedDriver(t *testing.T) {
+	_, err := Open(Config{Driver: "oracle"})
+	require.Error(t, err)
+}
+
+func TestQuestionMaterialMapper(t *testing.T) {
+	openTestDB(t)
+	materials := NewQuestionMaterialMapper()
+	questions := NewQuestionBankMapper()
+
+	material := entity.QuestionMaterial{
+		Title:    "阅读程序",
+		Material: "MOV AX, 1234H\nMOV BX, AX",
+		Tables:   []entity.ContentTable{{HeaderRow: true, Rows: [][]string{{"寄存器", "值"}, {"AX", "1234H"}}}},
+	}
+	require.NoError(t, materials.CreateQuestionMaterial(&material))
+	require.NotZero(t, material.ID)
+
+	got, err := materials.GetQuestionMaterialById(material.ID)
+	require.NoError(t, err)
+	require.Equal(t, material.Tables, got.Tables)
+
+	// 修改时可以清空表格
+	got.Tables = nil
+	got.ImagePaths = []string{"resources/images/a.png"}
+	_, err = materials.UpdateQuestionMaterial(&got)
+	require.NoError(t, err)
+	got, err = materials.GetQuestionMaterialById(material.ID)
+	require.NoError(t, err)
+	require.Empty(t, got.Tables)
+	require.Equal(t, []string{"resources/images/a.png"}, got.ImagePaths)
+
+	q1 := entity.QuestionBank{Topic: "执行后 AX 的值为______", TopicType: "填空题"}
+	q2 := entity.QuestionBank{Topic: "执行后 BX 的值为______", TopicType: "填空题"}
+	_, err = questions.InsertSingleQuestionBank(&q1)
+	require.NoError(t, err)
+	_, err = questions.InsertSingleQuestionBank(&q2)
+	require.NoError(t, err)
+	n, err := questions.SetQuestionMaterial([]int{q1.ID, q2.ID}, material.ID)
+	require.NoError(t, err)
+	require.EqualValues(t, 2, n)
+	linked, err := questions.GetQuestionBanksByMaterialId(material.ID)
+	require.NoError(t, err)
+	require.Len(t, linked, 2)
+
+	_, err = questions.SetQuestionMaterial([]int{q2.ID}, 0)
+	require.NoError(t, err)
+	linked, err = questions.GetQuestionBanksByMaterialId(material.ID)
+	require.NoError(t, err)
+	require.Len(t, linked, 1)
+
+	n, err = materials.DeleteQuestionMaterial(material.ID)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, n)
+	_, err = materials.GetQuestionMaterialById(material.ID)
+	require.Error(t, err)
+}
+
+func TestQuestionAttachmentMapper(t *testing.T) {
+	openTestDB(t)
+	attachments := NewQuestionAttachmentMapper()
+	questions := NewQuestionBankMapper()
+
+	q := entity.QuestionBank{Topic: "根据电路图{{img:1}}写出表达式", TopicType: "简答题", TopicImagePath: "resources/images/a.png"}
+	_, err := questions.InsertSingleQuestionBank(&q)
+	require.NoError(t, err)
+	for i, a := range []entity.QuestionAttachment{
+		{Target: entity.AttachmentTargetAnswer, Name: "2", Path: "resources/images/b.png", SortOrder: 0},
+		{Target: entity.AttachmentTargetTopic, Name: "1", Path: "resources/images/a.png", Caption: "图 1 电路图", SortOrder: 1},
+	} {
+		a.QuestionID = q.ID
+		require.NoError(t, attachments.CreateAttachment(&a), i)
+	}
+
+	found, err := attachments.GetAttachmentsInQuestionIds([]int{q.ID})
+	require.NoError(t, err)
+	require.Len(t, found, 2)
+	require.Equal(t, "2", found[0].Name)
+
+	// 修改时可以清空说明文字，图片路径不变
+	topic := found[1]
+	topic.Caption = ""
+	topic.Path = "resources/images/c.png"
+	_, err = attachments.UpdateAttachment(&topic)
+	require.NoError(t, err)
+	got, err := attachments.GetAttachmentById(topic.ID)
+	require.NoError(t, err)
+	require.Empty(t, got.Caption)
+	require.Equal(t, "resources/images/a.png", got.Path)
+
+	_, err = questions.SetTopicImagePath(q.ID, "")
+	require.NoError(t, err)
+	stored, err := questions.GetQuestionBankById(q.ID)
+	require.NoError(t, err)
+	require.Empty(t, stored[0].TopicImagePath)
+
+	n, err := attachments.DeleteAttachmentsByQuestionId(q.ID)
+	require.NoError(t, err)
+	require.EqualValues(t, 2, n)
+	found, err = attachments.GetAttachmentsByQuestionId(q.ID)
+	require.NoError(t, err)
+	require.Empty(t, found)
+}
+
+func TestQuestionRevisionMapper(t *testing.T) {
+	openTestDB(t)
+	questions := NewQuestionBankMapper()
+	revisions := NewQuestionRevisionMapper()
+
+	q := entity.QuestionBank{Topic: "8086 有几个段寄存器？", Answer: "4", TopicType: "填空题", TopicImagePath: media.Ref(strings.Repeat("a", 64)),
+		Options: []entity.QuestionOption{{Label: "A", Content: "甲"}}}
+	_, err := questions.InsertSingleQuestionBank(&q)
+	require.NoError(t, err)
+	for _, action := range []string{entity.RevisionCreate, entity.RevisionUpdate} {
+		require.NoError(t, revisions.InsertQuestionRevision(&entity.QuestionRevision{QuestionID: q.ID, Action: action, Snapshot: entity.NewQuestionSnapshot(q)}))
+	}
+	require.NoError(t, revisions.InsertQuestionRevision(&entity.QuestionRevision{QuestionID: q.ID + 1, Action: entity.RevisionCreate}))
+
+	found, err := revisions.GetQuestionRevisions(q.ID)
+	require.NoError(t, err)
+	require.Len(t, found, 2)
+	require.Equal(t, []int{2, 1}, []int{found[0].Revision, found[1].Revision})
+	require.Equal(t, q.Options, found[0].Snapshot.Options)
+	latest, err := revisions.GetLatestQuestionRevision(q.ID)
+	require.NoError(t, err)
+	require.Equal(t, entity.RevisionUpdate, latest.Action)
+	_, err = revisions.GetQuestionRevision(q.ID, 3)
+	require.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	// 回滚时零值字段也要写入
+	restored := entity.QuestionSnapshot{Topic: "8086 有几个段寄存器？", TopicType: "填空题"}.Question(q.ID)
+	_, err = questions.ReplaceQuestionBank(&restored)
+	require.NoError(t, err)
+	stored, err := questions.GetQuestionBankById(q.ID)
+	require.NoError(t, err)
+	require.Empty(t, stored[0].Answer)
+	require.Empty(t, stored[0].TopicImagePath)
+	require.Empty(t, stored[0].Options)
+
+	// 只有历史版本引用的图片不会被清理
+	refs, err := NewMediaMapper().GetMediaRefs()
+	require.NoError(t, err)
+	require.Contains(t, refs, q.TopicImagePath)
+
+	n, err := revisions.DeleteQuestionRevisions([]int{q.ID})
+	require.NoError(t, err)
+	require.EqualValues(t, 2, n)
+	refs, err = NewMediaMapper().GetMediaRefs()
+	require.NoError(t, err)
+	require.Empty(t, refs)
+}
+
+func TestMediaMapper(t *testing.T) {
+	openTestDB(t)
+	questions := NewQuestionBankMapper()
+	ref := func(c byte) string { return media.Ref(strings.Repeat(string(c), 64)) }
+
+	_, err := questions.InsertSingleQuestionBank(&entity.QuestionBank{Topic: "旧图片", TopicImagePath: "resources/images/a.png"})
+	require.NoError(t, err)
+	_, err = questions.InsertSingleQuestionBank(&entity.QuestionBank{
+		Topic:          "选择题",
+		TopicImagePath: ref('a'),
+		Options:        []entity.QuestionOption{{Label: "A", Content: "甲", ImagePath: ref('b')}, {Label: "B", Content: "乙"}},
+	})
+	require.NoError(t, err)
+	require.NoError(t, NewQuestionMaterialMapper().CreateQuestionMaterial(&entity.QuestionMaterial{Title: "材料", ImagePaths: []string{ref('c')}}))
+	require.NoError(t, NewQuestionAttachmentMapper().CreateAttachment(&entity.QuestionAttachment{QuestionID: 2, Name: "1", Path: ref('a')}))
+	_, err = NewHistoryMapper().InsertQuestionGenHistories([]entity.QuestionGenHistory{{TestPaperUID: "p1", Topic: "历史", TopicImagePath: ref('d')}})
+	require.NoError(t, err)
+
+	refs, err := NewMediaMapper().GetMediaRefs()
+	require.NoError(t, err)
+	var keys []string
+	for _, r := range refs {
+		if key, ok := media.ParseRef(r); ok {
+			keys = append(keys, key[:1])
+		}
+	}
+	sort.Strings(keys)
+	require.Equal(t, []string{"a", "a", "b", "c", "d"}, keys)
+
+	// 导入旧图片后替换题干、选项和材料中的路径
+	_, err = questions.InsertSingleQuestionBank(&entity.QuestionBank{
+		Topic:   "旧选项图片",
+		Options: []entity.QuestionOption{{Label: "A", Content: "甲", ImagePath: "resources/images/a.png"}},
+	})
+	require.NoError(t, err)
+	require.NoError(t, NewQuestionMaterialMapper().CreateQuestionMaterial(&entity.QuestionMaterial{Title: "旧材料", ImagePaths: []string{"resources/images/ab.png", "resources/images/a.png"}}))
+	legacy, err := NewMediaMapper().GetLegacyImagePaths()
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"resources/images/a.png", "resources/images/a.png", "resources/images/ab.png", "resources/images/a.png"}, legacy)
+	count, err := NewMediaMapper().ReplaceImagePath("resources/images/a.png", ref('e'))
+	require.NoError(t, err)
+	require.EqualValues(t, 3, count)
+	q, err := questions.GetQuestionBankById(1)
+	require.NoError(t, err)
+	require.Equal(t, ref('e'), q[0].TopicImagePath)
+	q, err = questions.GetQuestionBankById(3)
+	require.NoError(t, err)
+	require.Equal(t, ref('e'), q[0].Options[0].ImagePath)
+	material, err := NewQuestionMaterialMapper().GetQuestionMaterialById(2)
+	require.NoError(t, err)
+	require.Equal(t, []string{"resources/images/ab.png", ref('e')}, material.ImagePaths)
+
+	// 出题记录快照中的图片同样计入引用，导入旧图片时一并替换
+	h := entity.NewQuestionGenHistory(entity.QuestionBank{
+		ID: 3, Topic: "快照", Options: []entity.QuestionOption{{Label: "A", ImagePath: "resources/images/b.png"}},
+	}, []entity.QuestionAttachment{{Target: entity.AttachmentTargetTopic, Path: ref('f')}}, &entity.QuestionMaterial{ImagePaths: []string{"resources/images/b.png"}})
+	h.TestPaperUID = "p2"
+	_, err = NewHistoryMapper().InsertQuestionGenHistories([]entity.QuestionGenHistory{h})
+	require.NoError(t, err)
+	refs, err = NewMediaMapper().GetMediaRefs()
+	require.NoError(t, err)
+	require.Contains(t, refs, ref('f'))
+	legacy, err = NewMediaMapper().GetLegacyImagePaths()
+	require.NoError(t, err)
+	require.Contains(t, legacy, "resources/images/b.png")
+	count, err = NewMediaMapper().ReplaceImagePath("resources/images/b.png", ref('g'))
+	require.NoError(t, err)
+	require.EqualValues(t, 1, count)
+	histories, err := NewHistoryMapper().GetQuestionGenHistoriesByTestPaperUid("p2")
+	require.NoError(t, err)
+	require.Equal(t, ref('g'), histories[0].Question.Options[0].ImagePath)
+	require.Equal(t, []string{ref('g')}, histories[0].Material.ImagePaths)
+	require.Equal(t, ref('f'), histories[0].Attachments[0].Path)
+}
+
+func TestQuestionRecycleBin(t *testing.T) {
+	openTestDB(t)
+	questions := NewQuestionBankMapper()
+	history := NewHistoryMapper()
+	for i, topic := range []string{"8086 有几个段寄存器？", "8259A 的作用", "8086 的地址总线宽度"} {
+		_, err := questions.InsertSingleQuestionBank(&entity.QuestionBank{Topic: topic, TopicImagePath: media.Ref(strings.Repeat(string(rune('a'+i)), 64))})
+		require.NoError(t, err)
+	}
+
+	n, err := questions.SoftDeleteQuestionBanks([]int{1, 2}, "admin")
+	require.NoError(t, err)
+	require.EqualValues(t, 2, n)
+	all, err := questions.GetAll()
+	require.NoError(t, err)
+	require.Len(t, all, 1)
+	deleted, err := questions.GetDeletedQuestionBanks()
+	require.NoError(t, err)
+	require.ElementsMatch(t, []int{1, 2}, []int{deleted[0].ID, deleted[1].ID})
+	require.Equal(t, "admin", deleted[0].DeletedBy)
+	deleted, err = questions.GetDeletedQuestionBanksInIds([]int{2, 3})
+	require.NoError(t, err)
+	require.Len(t, deleted, 1)
+
+	// 回收站中的题目仍然引用图片
+	refs, err := NewMediaMapper().GetMediaRefs()
+	require.NoError(t, err)
+	require.Len(t, refs, 3)
+
+	n, err = questions.RestoreQuestionBanks([]int{1, 3})
+	require.NoError(t, err)
+	require.EqualValues(t, 1, n)
+	restored, err := questions.GetQuestionBankById(1)
+	require.NoError(t, err)
+	require.Empty(t, restored[0].DeletedBy)
+
+	// 只能彻底删除回收站中的题目
+	n, err = questions.PurgeQuestionBanks([]int{1, 2})
+	require.NoError(t, err)
+	require.EqualValues(t, 1, n)
+	deleted, err = questions.GetDeletedQuestionBanks()
+	require.NoError(t, err)
+	require.Empty(t, deleted)
+
+	// 锁定的试卷引用的题目
+	_, err = history.InsertTestPaperGenHistory(entity.TestPaperGenHistory{TestPaperUID: "p1", TestPaperName: "期中试卷"})
+	require.NoError(t, err)
+	_, err = history.InsertQuestionGenHistories([]entity.QuestionGenHistory{{TestPaperUID: "p1", QuestionBankID: 1}, {TestPaperUID: "p1", QuestionBankID: 3}})
+	require.NoError(t, err)
+	ids, err := history.GetLockedQuestionIds([]int{1, 2, 3})
+	require.NoError(t, err)
+	require.Empty(t, ids)
+	_, err = history.SetTestPaperLocked("p1", true)
+	require.NoError(t, err)
+	locked, err := history.IsTestPaperLocked("p1")
+	require.NoError(t, err)
+	require.True(t, locked)
+	ids, err = history.GetLockedQuestionIds([]int{1, 2, 3})
+	require.NoError(t, err)
+	require.Equal(t, []int{1, 3}, ids)
+
+	// 删除语句本身跳过锁定的试卷引用的题目，不依赖调用方事先检查
+	n, err = questions.SoftDeleteQuestionBanks([]int{1, 3}, "admin")
+	require.NoError(t, err)
+	require.Zero(t, n)
+	_, err = history.SetTestPaperLocked("p1", false)
+	require.NoError(t, err)
+	n, err = questions.SoftDeleteQuestionBanks([]int{3}, "admin")
+	require.NoError(t, err)
+	require.EqualValues(t, 1, n)
+	_, err = history.SetTestPaperLocked("p1", true)
+	require.NoError(t, err)
+	n, err = questions.PurgeQuestionBanks([]int{3})
+	require.NoError(t, err)
+	require.Zero(t, n)
+	deleted, err = questions.GetDeletedQuestionBanksInIds([]int{3})
+	require.NoError(t, err)
+	require.Len(t, deleted, 1)
+}
+
+func TestQuestionReviewMapper(t *testing.T) {
+	openTestDB(t)
+	questions := NewQuestionBankMapper()
+	reviews := NewQuestionReviewMapper()
+	for _, topic := range []string{"8086 有几个段寄存器？", "8259A 的作用"} {
+		_, err := questions.InsertSingleQuestionBank(&entity.QuestionBank{Topic: topic})
+		require.NoError(t, err)
+	}
+
+	// 回滚内容时审核状态和审核员保持不变
+	_, err := questions.SetQuestionStatus([]int{1}, entity.QuestionStatusApproved)
+	require.NoError(t, err)
+	_, err = questions.SetQuestionReviewer([]int{1, 2}, "alice")
+	require.NoError(t, err)
+	restored := entity.QuestionSnapshot{Topic: "8086 有几个段寄存器"}.Question(1)
+	_, err = questions.ReplaceQuestionBank(&restored)
+	require.NoError(t, err)
+	page, err := questions.QueryQuestionBanks(QuestionBankQuery{Statuses: []string{entity.QuestionStatusApproved}, Reviewer: "alice"})
+	require.NoError(t, err)
+	require.Len(t, page.Items, 1)
+	require.Equal(t, "8086 有几个段寄存器", page.Items[0].Topic)
+	page, err = questions.QueryQuestionBanks(QuestionBankQuery{Statuses: []string{entity.QuestionStatusDraft}})
+	require.NoError(t, err)
+	require.Equal(t, []int{2}, questionIds(page.Items))
+
+	require.NoError(t, reviews.InsertReviewComment(&entity.QuestionReviewComment{QuestionID: 1, Action: "submit", Status: entity.QuestionStatusInReview, Author: "teacher"}))
+	require.NoError(t, reviews.InsertReviewComment(&entity.QuestionReviewComment{QuestionID: 1, Author: "alice", Content: "答案不完整"}))
+	require.NoError(t, reviews.InsertReviewComment(&entity.QuestionReviewComment{QuestionID: 2, Author: "alice", Content: "通过"}))
+	comments, err := reviews.GetReviewComments(1)
+	require.NoError(t, err)
+	require.Len(t, comments, 2)
+	require.Equal(t, "submit", comments[0].Action)
+	require.Equal(t, "答案不完整", comments[1].Content)
+	n, err := reviews.DeleteReviewComments([]int{1})
+	require.NoError(t, err)
+	require.EqualValues(t, 2, n)
+}
+
+func TestImportQuestionBanks(t *testing.T) {
+	openTestDB(t)
+	questions := NewQuestionBankMapper()
+	_, err := questions.InsertSingleQuestionBank(&entity.QuestionBank{Topic: "8086 有几个段寄存器？"})
+	require.NoError(t, err)
+
+	imported := []entity.QuestionBank{{Topic: "8259A 的作用"}, {Topic: "8086 的地址总线宽度"}}
+	n, err := questions.ImportQuestionBanks(imported, []int{1}, "admin")
+	require.NoError(t, err)
+	require.EqualValues(t, 1, n)
+	require.Equal(t, []int{2, 3}, []int{imported[0].ID, imported[1].ID})
+	require.Equal(t, entity.QuestionStatusDraft, imported[0].Status)
+	all, err := questions.GetAll()
+	require.NoError(t, err)
+	require.Len(t, all, 2)
+
+	// 插入失败时清空题库也一起回滚
+	n, err = questions.ImportQuestionBanks([]entity.QuestionBank{{Topic: "新题目"}, {ID: 2, Topic: "主键冲突"}}, []int{2, 3}, "admin")
+	require.Error(t, err)
+	require.Zero(t, n)
+	all, err = questions.GetAll()
+	require.NoError(t, err)
+	require.Len(t, all, 2)
+	deleted, err := questions.GetDeletedQuestionBanks()
+	require.NoError(t, err)
+	require.Len(t, deleted, 1)
+}
+
+func TestUpdateSingleQuestionBank(t *testing.T) {
+	openTestDB(t)
+	questions := NewQuestionBankMapper()
+	table := &entity.ContentTable{Rows: [][]string{{"输入", "输出"}, {"0", "1"}}}
+	q := entity.QuestionBank{Topic: "根据真值表写出逻辑表达式", TopicType: "简答题", Score: 10, Difficulty: 2,
+		TopicMaterialID: 3, TopicTable: table, AnswerTable: table}
+	_, err := questions.InsertSingleQuestionBank(&q)
+	require.NoError(t, err)
+
+	// 表格为 nil 时清空，没有提交的材料保持不变
+	n, err := questions.UpdateSingleQuestionBank(&entity.QuestionBank{ID: q.ID, Topic: q.Topic, TopicType: "简答题", Score: 5, Difficulty: 2, AnswerTable: table})
+	require.NoError(t, err)
+	require.EqualValues(t, 1, n)
+	found, err := questions.GetQuestionBankById(q.ID)
+	require.NoError(t, err)
+	require.Nil(t, found[0].TopicTable)
+	require.Equal(t, table.Rows, found[0].AnswerTable.Rows)
+	require.Equal(t, 5.0, found[0].Score)
+	require.Equal(t, 3, found[0].TopicMaterialID)
+	require.Equal(t, entity.QuestionStatusDraft, found[0].Status)
+
+	// 选项为 nil 时删除原来的选项
+	options := []entity.QuestionOption{{Label: "A", Content: "与"}, {Label: "B", Content: "或", IsCorrect: true}}
+	_, err = questions.UpdateSingleQuestionBank(&entity.QuestionBank{ID: q.ID, Topic: q.Topic, TopicType: entity.ChoiceTopicType, Options: options})
+	require.NoError(t, err)
+	found, err = questions.GetQuestionBankById(q.ID)
+	require.NoError(t, err)
+	require.Equal(t, options, found[0].Options)
+	_, err = questions.UpdateSingleQuestionBank(&entity.QuestionBank{ID: q.ID, Topic: q.Topic, TopicType: "简答题"})
+	require.NoError(t, err)
+	found, err = questions.GetQuestionBankById(q.ID)
+	require.NoError(t, err)
+	require.Empty(t, found[0].Options)
+}
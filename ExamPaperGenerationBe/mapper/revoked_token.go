@@ -0,0 +1,41 @@
+package mapper
+
+import (
+	"graduation/entity"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RevokedTokenMapper 令牌注销记录的数据库操作
+type RevokedTokenMapper struct {
+	db *gorm.DB
+}
+
+// NewRevokedTokenMapper 创建一个新的 RevokedTokenMapper 实例
+func NewRevokedTokenMapper() *RevokedTokenMapper {
+	return &RevokedTokenMapper{
+		db: DB,
+	}
+}
+
+// RevokeToken 注销令牌，重复注销时忽略
+func (m *RevokedTokenMapper) RevokeToken(jti string, expiresAt time.Time) error {
+	result := m.db.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&entity.RevokedToken{JTI: jti, ExpiresAt: expiresAt})
+	return result.Error
+}
+
+// IsTokenRevoked 判断令牌是否已注销
+func (m *RevokedTokenMapper) IsTokenRevoked(jti string) (bool, error) {
+	var count int64
+	result := m.db.Model(&entity.RevokedToken{}).Where("jti = ?", jti).Count(&count)
+	return count > 0, result.Error
+}
+
+// DeleteExpiredRevokedTokens 删除已过期的注销记录，过期令牌本身已无法通过校验
+func (m *RevokedTokenMapper) DeleteExpiredRevokedTokens(now time.Time) (int64, error) {
+	result := m.db.Where("expires_at < ?", now).Delete(&entity.RevokedToken{})
+	return result.RowsAffected, result.Error
+}
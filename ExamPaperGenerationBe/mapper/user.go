@@ -3,6 +3,7 @@ package mapper
 import (
 	"fmt"
 	"graduation/entity"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -33,8 +34,8 @@ func (m *UserMapper) AddNewUser(user *entity.User) (int64, error) {
 }
 
 // UpdateLastLoginTime 更新用户最后登录时间
-func (m *UserMapper) UpdateLastLoginTime(user *entity.User) error {
-	result := m.db.Model(user).Where("username =? AND password =?", user.Username, user.Password).Update("last_login", user.LastLogin)
+func (m *UserMapper) UpdateLastLoginTime(username string, lastLogin time.Time) error {
+	result := m.db.Model(&entity.User{}).Where("username =?", username).Update("last_login", lastLogin)
 	return result.Error
 }
 
@@ -45,17 +46,24 @@ func (m *UserMapper) GetUserByUsername(username string) ([]entity.User, error) {
 	return users, result.Error
 }
 
+// GetEnabledUserByUsername 根据用户名获取已通过审核的用户
+func (m *UserMapper) GetEnabledUserByUsername(username string) ([]entity.User, error) {
+	var users []entity.User
+	result := m.db.Where("username =? AND enable = 1", username).Find(&users)
+	return users, result.Error
+}
+
 // GetApplyUser 获取待审核用户
 func (m *UserMapper) GetApplyUser() ([]entity.User, error) {
 	var users []entity.User
-	result := m.db.Where("enable = 0 AND user_role = 'user'").Find(&users)
+	result := m.db.Where("enable = 0").Find(&users)
 	return users, result.Error
 }
 
-// GetAllUser 获取所有用户
+// GetAllUser 获取所有用户，不返回密码
 func (m *UserMapper) GetAllUser() ([]entity.User, error) {
 	var users []entity.User
-	result := m.db.Find(&users)
+	result := m.db.Select("id, username, user_role, last_login, enable, failed_login_count, locked_until, must_change_password").Find(&users)
 	return users, result.Error
 }
 
@@ -73,14 +81,14 @@ func (m *UserMapper) PassApply(username string) (int64, error) {
 
 // DeleteApply 删除用户申请
 func (m *UserMapper) DeleteApply(username string) (int64, error) {
-	result := m.db.Where("username =?", username).Delete(&entity.User{})
+	result := m.db.Where("username =? AND enable = 0", username).Delete(&entity.User{})
 	return result.RowsAffected, result.Error
 }
 
 // UpdateUserSimilarityThreshold 更新用户的相似度阈值
-func UpdateUserSimilarityThreshold(username string, threshold float64) error {
+func (m *UserMapper) UpdateUserSimilarityThreshold(username string, threshold float64) error {
 	// 更新用户相似度阈值
-	result := DB.Model(&entity.User{}).Where("username = ?", username).Update("similarity_threshold", threshold)
+	result := m.db.Model(&entity.User{}).Where("username = ?", username).Update("similarity_threshold", threshold)
 	if result.Error != nil {
 		return result.Error
 	}
@@ -89,3 +97,17 @@ func UpdateUserSimilarityThreshold(username string, threshold float64) error {
 	}
 	return nil
 }
+
+// UpdateLoginFailures 更新用户连续登录失败次数和锁定截止时间，lockedUntil 为 nil 时解除锁定
+func (m *UserMapper) UpdateLoginFailures(username string, failedCount int, lockedUntil *time.Time) error {
+	result := m.db.Model(&entity.User{}).Where("username = ?", username).
+		Updates(map[string]interface{}{"failed_login_count": failedCount, "locked_until": lockedUntil})
+	return result.Error
+}
+
+// UpdatePassword 更新用户密码摘要及是否需要在下次登录后修改密码
+func (m *UserMapper) UpdatePassword(username, passwordHash string, mustChange bool) (int64, error) {
+	result := m.db.Model(&entity.User{}).Where("username = ?", username).
+		Updates(map[string]interface{}{"password": passwordHash, "must_change_password": mustChange})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,63 @@
+package media
+
+import (
+	"fmt"
+	"time"
+)
+
+// 支持的存储驱动
+const (
+	DriverLocal = "local"
+	DriverS3    = "s3"
+)
+
+// 默认配置
+const (
+	DefaultLocalRoot = "resources/media"
+	DefaultS3Region  = "us-east-1"
+)
+
+// LocalConfig 本地磁盘存储配置
+type LocalConfig struct {
+	Root string `yaml:"root"` // 根目录，为空时使用 DefaultLocalRoot
+}
+
+// S3Config S3 兼容对象存储配置
+type S3Config struct {
+	Endpoint  string `yaml:"endpoint"` // 例如 http://127.0.0.1:9000
+	Region    string `yaml:"region"`   // 为空时使用 DefaultS3Region，MinIO 不区分区域
+	Bucket    string `yaml:"bucket"`
+	Prefix    string `yaml:"prefix"` // 对象键前缀，例如 media/
+	AccessKey string `yaml:"access_key"`
+	SecretKey string `yaml:"secret_key"`
+}
+
+// GCConfig 垃圾回收配置
+type GCConfig struct {
+	Interval time.Duration `yaml:"interval"` // 定期清理的间隔，0 表示不定期清理，只在删除题目和图片时清理
+	Grace    time.Duration `yaml:"grace"`    // 最近保存的文件即使没有被引用也不清理，避免删掉刚上传还没保存到题目中的图片
+}
+
+// Config 媒体文件存储配置
+type Config struct {
+	Driver string      `yaml:"driver"` // local 或 s3，为空时使用 local
+	Local  LocalConfig `yaml:"local"`
+	S3     S3Config    `yaml:"s3"`
+	GC     GCConfig    `yaml:"gc"`
+}
+
+// Open 根据配置创建存储
+func Open(cfg Config) (Store, error) {
+	switch cfg.Driver {
+	case "", DriverLocal:
+		root := cfg.Local.Root
+		if root == "" {
+			root = DefaultLocalRoot
+		}
+		return NewLocalStore(root), nil
+	case DriverS3:
+		return NewS3Store(cfg.S3)
+	default:
+		return nil, fmt.Errorf("unsupported media driver: %s", cfg.Driver)
+	}
+}
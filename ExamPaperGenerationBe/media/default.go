@@ -0,0 +1,45 @@
+package media
+
+import (
+	"context"
+	"io"
+	"os"
+	"sync"
+)
+
+var (
+	defaultMu    sync.RWMutex
+	defaultStore Store = NewLocalStore(DefaultLocalRoot)
+)
+
+// SetDefault 设置全局使用的存储，启动时根据配置调用
+func SetDefault(store Store) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultStore = store
+}
+
+// Default 返回全局使用的存储，未设置时为 DefaultLocalRoot 下的本地存储
+func Default() Store {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return defaultStore
+}
+
+// Save 把文件保存到全局存储，返回写入数据库的 media: 引用
+func Save(ctx context.Context, r io.Reader) (string, error) {
+	obj, err := Default().Put(ctx, r)
+	if err != nil {
+		return "", err
+	}
+	return Ref(obj.Key), nil
+}
+
+// ReadFile 读取图片路径字段指向的文件：media: 引用从全局存储读取，
+// 其他值按旧版本直接保存在磁盘上的文件路径读取
+func ReadFile(ctx context.Context, ref string) ([]byte, error) {
+	if key, ok := ParseRef(ref); ok {
+		return readAll(ctx, Default(), key)
+	}
+	return os.ReadFile(ref)
+}
@@ -0,0 +1,25 @@
+package media
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Collect 删除 candidates 中没有被引用且修改时间早于 now-grace 的对象，返回删除的键。
+// referenced 为数据库中全部引用的对象键
+func Collect(ctx context.Context, store Store, candidates []Object, referenced map[string]bool, grace time.Duration, now time.Time) ([]string, error) {
+	var deleted []string
+	var errs []error
+	for _, obj := range candidates {
+		if referenced[obj.Key] || now.Sub(obj.ModTime) < grace {
+			continue
+		}
+		if err := store.Delete(ctx, obj.Key); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		deleted = append(deleted, obj.Key)
+	}
+	return deleted, errors.Join(errs...)
+}
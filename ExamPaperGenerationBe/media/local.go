@@ -0,0 +1,130 @@
+package media
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+var _ Store = (*LocalStore)(nil)
+
+// LocalStore 保存在本地磁盘的媒体文件，按键的前两级各两个字符分目录，例如 ab/cd/abcd...
+type LocalStore struct {
+	root string
+}
+
+// NewLocalStore 创建以 root 为根目录的本地存储，目录在第一次写入时创建
+func NewLocalStore(root string) *LocalStore {
+	return &LocalStore{root: root}
+}
+
+// path 返回对象的文件路径
+func (s *LocalStore) path(key string) string {
+	return filepath.Join(s.root, key[:2], key[2:4], key)
+}
+
+func (s *LocalStore) Put(ctx context.Context, r io.Reader) (Object, error) {
+	b, err := readBlob(r)
+	if err != nil {
+		return Object{}, err
+	}
+	path := s.path(b.key)
+	now := time.Now()
+	// 内容已存在时只刷新修改时间
+	if err := os.Chtimes(path, now, now); err == nil {
+		return Object{Key: b.key, Size: int64(len(b.data)), ContentType: b.contentType, ModTime: now}, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return Object{}, err
+	}
+	// 先写临时文件再改名，读取方不会看到写了一半的文件
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return Object{}, err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(b.data); err != nil {
+		tmp.Close()
+		return Object{}, err
+	}
+	if err := tmp.Close(); err != nil {
+		return Object{}, err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return Object{}, err
+	}
+	return Object{Key: b.key, Size: int64(len(b.data)), ContentType: b.contentType, ModTime: now}, nil
+}
+
+func (s *LocalStore) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	if !ValidKey(key) {
+		return nil, ErrInvalidKey
+	}
+	f, err := os.Open(s.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return f, err
+}
+
+func (s *LocalStore) Stat(ctx context.Context, key string) (Object, error) {
+	if !ValidKey(key) {
+		return Object{}, ErrInvalidKey
+	}
+	f, err := os.Open(s.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return Object{}, ErrNotFound
+	}
+	if err != nil {
+		return Object{}, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return Object{}, err
+	}
+	contentType, err := sniffContentType(f)
+	if err != nil {
+		return Object{}, err
+	}
+	return Object{Key: key, Size: info.Size(), ContentType: contentType, ModTime: info.ModTime()}, nil
+}
+
+func (s *LocalStore) Delete(ctx context.Context, key string) error {
+	if !ValidKey(key) {
+		return ErrInvalidKey
+	}
+	err := os.Remove(s.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// List 遍历根目录，忽略临时文件和其他不是对象的文件
+func (s *LocalStore) List(ctx context.Context) ([]Object, error) {
+	var objects []Object
+	err := filepath.WalkDir(s.root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) && path == s.root {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if d.IsDir() || !ValidKey(d.Name()) {
+			return ctx.Err()
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		// 内容类型只在 Stat 时判断，列表不逐个读取文件
+		objects = append(objects, Object{Key: d.Name(), Size: info.Size(), ModTime: info.ModTime()})
+		return nil
+	})
+	return objects, err
+}
@@ -0,0 +1,142 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// pngHeader PNG 文件头，用于检查内容类型
+var pngHeader = []byte("\x89PNG\r\n\x1a\n")
+
+// testStore 对任意实现检查 Put、Open、Stat、List、Delete 的行为
+func testStore(t *testing.T, store Store) {
+	ctx := context.Background()
+	content := append(append([]byte{}, pngHeader...), "image-1"...)
+
+	obj, err := store.Put(ctx, bytes.NewReader(content))
+	require.NoError(t, err)
+	require.True(t, ValidKey(obj.Key))
+	require.Equal(t, int64(len(content)), obj.Size)
+	require.Equal(t, "image/png", obj.ContentType)
+
+	// 相同内容得到相同的键
+	again, err := store.Put(ctx, bytes.NewReader(content))
+	require.NoError(t, err)
+	require.Equal(t, obj.Key, again.Key)
+	other, err := store.Put(ctx, bytes.NewReader([]byte("plain text")))
+	require.NoError(t, err)
+	require.NotEqual(t, obj.Key, other.Key)
+
+	rc, err := store.Open(ctx, obj.Key)
+	require.NoError(t, err)
+	data, err := io.ReadAll(rc)
+	rc.Close()
+	require.NoError(t, err)
+	require.Equal(t, content, data)
+
+	stat, err := store.Stat(ctx, obj.Key)
+	require.NoError(t, err)
+	require.Equal(t, int64(len(content)), stat.Size)
+	require.Equal(t, "image/png", stat.ContentType)
+	require.WithinDuration(t, time.Now(), stat.ModTime, time.Minute)
+
+	list, err := store.List(ctx)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{obj.Key, other.Key}, objectKeys(list))
+
+	require.NoError(t, store.Delete(ctx, obj.Key))
+	require.NoError(t, store.Delete(ctx, obj.Key))
+	_, err = store.Open(ctx, obj.Key)
+	require.ErrorIs(t, err, ErrNotFound)
+	_, err = store.Stat(ctx, obj.Key)
+	require.ErrorIs(t, err, ErrNotFound)
+	_, err = store.Open(ctx, "../etc/passwd")
+	require.ErrorIs(t, err, ErrInvalidKey)
+}
+
+func objectKeys(objects []Object) []string {
+	keys := make([]string, len(objects))
+	for i, o := range objects {
+		keys[i] = o.Key
+	}
+	return keys
+}
+
+func TestLocalStore(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "media")
+	store := NewLocalStore(root)
+
+	// 根目录还不存在时列表为空
+	list, err := store.List(context.Background())
+	require.NoError(t, err)
+	require.Empty(t, list)
+
+	testStore(t, store)
+
+	// 按键的前缀分目录保存，目录中的其他文件不会被当成对象
+	obj, err := store.Put(context.Background(), bytes.NewReader([]byte("sharded")))
+	require.NoError(t, err)
+	require.FileExists(t, filepath.Join(root, obj.Key[:2], obj.Key[2:4], obj.Key))
+	require.NoError(t, os.WriteFile(filepath.Join(root, obj.Key[:2], ".upload-123"), []byte("x"), 0644))
+	list, err = store.List(context.Background())
+	require.NoError(t, err)
+	require.Contains(t, objectKeys(list), obj.Key)
+	require.NotContains(t, objectKeys(list), ".upload-123")
+
+	// 再次保存相同内容时刷新修改时间
+	old := time.Now().Add(-48 * time.Hour)
+	path := filepath.Join(root, obj.Key[:2], obj.Key[2:4], obj.Key)
+	require.NoError(t, os.Chtimes(path, old, old))
+	_, err = store.Put(context.Background(), bytes.NewReader([]byte("sharded")))
+	require.NoError(t, err)
+	stat, err := store.Stat(context.Background(), obj.Key)
+	require.NoError(t, err)
+	require.WithinDuration(t, time.Now(), stat.ModTime, time.Minute)
+}
+
+func TestPutTooLarge(t *testing.T) {
+	_, err := NewLocalStore(t.TempDir()).Put(context.Background(), io.LimitReader(zeroReader{}, MaxObjectSize+1))
+	require.ErrorIs(t, err, ErrTooLarge)
+}
+
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	clear(p)
+	return len(p), nil
+}
+
+func TestRefAndReadFile(t *testing.T) {
+	t.Chdir(t.TempDir())
+	store := NewLocalStore("store")
+	previous := Default()
+	SetDefault(store)
+	t.Cleanup(func() { SetDefault(previous) })
+
+	ref, err := Save(context.Background(), bytes.NewReader([]byte("stored")))
+	require.NoError(t, err)
+	key, ok := ParseRef(ref)
+	require.True(t, ok)
+	require.Equal(t, Ref(key), ref)
+	data, err := ReadFile(context.Background(), ref)
+	require.NoError(t, err)
+	require.Equal(t, "stored", string(data))
+
+	// 旧版本的图片路径直接读取文件
+	require.NoError(t, os.WriteFile("legacy.png", []byte("legacy"), 0644))
+	data, err = ReadFile(context.Background(), "legacy.png")
+	require.NoError(t, err)
+	require.Equal(t, "legacy", string(data))
+
+	for _, s := range []string{"legacy.png", "media:", "media:abc", Ref(key) + "x"} {
+		_, ok := ParseRef(s)
+		require.False(t, ok, s)
+	}
+}
@@ -0,0 +1,117 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// RefPrefix 数据库中保存的媒体文件引用前缀，引用格式为 media:<SHA-256>。
+// 不带前缀的路径是旧版本直接写在磁盘上的文件
+const RefPrefix = "media:"
+
+// MaxObjectSize 单个媒体文件的大小上限
+const MaxObjectSize = 32 << 20
+
+var (
+	// ErrNotFound 媒体文件不存在
+	ErrNotFound = errors.New("media: object not found")
+	// ErrTooLarge 媒体文件超过 MaxObjectSize
+	ErrTooLarge = fmt.Errorf("media: object larger than %d bytes", MaxObjectSize)
+	// ErrInvalidKey 键不是 SHA-256 的十六进制小写形式
+	ErrInvalidKey = errors.New("media: invalid object key")
+)
+
+var keyRe = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// Object 媒体文件信息，Key 为文件内容的 SHA-256
+type Object struct {
+	Key         string    `json:"key"`
+	Size        int64     `json:"size"`
+	ContentType string    `json:"content_type"`
+	ModTime     time.Time `json:"mod_time"`
+}
+
+// Store 媒体文件（题目图片等）存储。文件按内容寻址，内容相同的文件只保存一份，
+// 再次保存已有内容时刷新修改时间，垃圾回收据此跳过刚上传的文件
+type Store interface {
+	// Put 保存文件内容，返回以内容 SHA-256 为键的对象信息
+	Put(ctx context.Context, r io.Reader) (Object, error)
+	// Open 读取文件内容，文件不存在时返回 ErrNotFound
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	// Stat 查询文件信息，文件不存在时返回 ErrNotFound
+	Stat(ctx context.Context, key string) (Object, error)
+	// Delete 删除文件，文件不存在时不报错
+	Delete(ctx context.Context, key string) error
+	// List 列出全部文件
+	List(ctx context.Context) ([]Object, error)
+}
+
+// ValidKey 判断 key 是否为有效的对象键
+func ValidKey(key string) bool {
+	return keyRe.MatchString(key)
+}
+
+// Ref 返回对象键对应的引用，保存在数据库的图片路径字段中
+func Ref(key string) string {
+	return RefPrefix + key
+}
+
+// ParseRef 解析 media: 引用，返回对象键；旧版本的文件路径等其他值返回 false
+func ParseRef(ref string) (string, bool) {
+	key, ok := strings.CutPrefix(ref, RefPrefix)
+	if !ok || !ValidKey(key) {
+		return "", false
+	}
+	return key, true
+}
+
+// blob 读入内存的待保存文件
+type blob struct {
+	data        []byte
+	key         string
+	contentType string
+}
+
+// readBlob 读取全部内容并计算键和内容类型，超过 MaxObjectSize 时返回 ErrTooLarge
+func readBlob(r io.Reader) (blob, error) {
+	data, err := io.ReadAll(io.LimitReader(r, MaxObjectSize+1))
+	if err != nil {
+		return blob{}, err
+	}
+	if len(data) > MaxObjectSize {
+		return blob{}, ErrTooLarge
+	}
+	sum := sha256.Sum256(data)
+	return blob{data: data, key: hex.EncodeToString(sum[:]), contentType: http.DetectContentType(data)}, nil
+}
+
+// sniffContentType 根据文件开头的内容判断内容类型
+func sniffContentType(r io.Reader) (string, error) {
+	head := make([]byte, 512)
+	n, err := io.ReadFull(r, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	return http.DetectContentType(head[:n]), nil
+}
+
+// readAll 读取对象的全部内容
+func readAll(ctx context.Context, store Store, key string) ([]byte, error) {
+	rc, err := store.Open(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	var buf bytes.Buffer
+	_, err = buf.ReadFrom(rc)
+	return buf.Bytes(), err
+}
@@ -0,0 +1,73 @@
+package media
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"slices"
+
+	"golang.org/x/image/draw"
+)
+
+// ThumbnailWidths 支持的缩略图宽度（像素），只生成这几种尺寸，避免任意尺寸的请求占用过多资源
+var ThumbnailWidths = []int{64, 128, 256, 512}
+
+// ErrNotImage 文件不是支持的图片格式（PNG、JPEG、GIF）
+var ErrNotImage = errors.New("media: not a supported image")
+
+// MaxImagePixels 图片允许的最大像素数（宽×高）。解码需要按像素数分配内存，
+// 很小的文件也可能声明极大的尺寸，超过时上传和生成缩略图都拒绝
+const MaxImagePixels = 40_000_000
+
+// ErrImageTooLarge 图片的像素数超过 MaxImagePixels
+var ErrImageTooLarge = errors.New("media: image too large")
+
+// CheckImageSize 只读取图片头部得到尺寸，不解码像素。不是支持的图片格式时返回 ErrNotImage，
+// 像素数超过 MaxImagePixels 时返回 ErrImageTooLarge
+func CheckImageSize(r io.Reader) (image.Config, error) {
+	config, _, err := image.DecodeConfig(r)
+	if err != nil {
+		return config, ErrNotImage
+	}
+	if int64(config.Width)*int64(config.Height) > MaxImagePixels {
+		return config, ErrImageTooLarge
+	}
+	return config, nil
+}
+
+// ValidThumbnailWidth 判断是否为支持的缩略图宽度
+func ValidThumbnailWidth(width int) bool {
+	return slices.Contains(ThumbnailWidths, width)
+}
+
+// Thumbnail 把图片等比缩小到 width 像素宽，JPEG 仍编码为 JPEG，PNG 和 GIF（只取第一帧）编码为 PNG。
+// 原图不比 width 宽时返回 nil，调用方直接使用原图；像素数超过 MaxImagePixels 时返回 ErrImageTooLarge，不解码
+func Thumbnail(data []byte, width int) ([]byte, string, error) {
+	config, err := CheckImageSize(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", err
+	}
+	if config.Width <= width {
+		return nil, "", nil
+	}
+	src, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", ErrNotImage
+	}
+	bounds := src.Bounds()
+	height := max(1, bounds.Dy()*width/bounds.Dx())
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, bounds, draw.Over, nil)
+
+	var buf bytes.Buffer
+	if format == "jpeg" {
+		err = jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 85})
+		return buf.Bytes(), "image/jpeg", err
+	}
+	err = png.Encode(&buf, dst)
+	return buf.Bytes(), "image/png", err
+}
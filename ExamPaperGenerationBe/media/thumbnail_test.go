@@ -0,0 +1,71 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestThumbnail(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 800, 600)), nil))
+	data, contentType, err := Thumbnail(buf.Bytes(), 256)
+	require.NoError(t, err)
+	require.Equal(t, "image/jpeg", contentType)
+	config, err := jpeg.DecodeConfig(bytes.NewReader(data))
+	require.NoError(t, err)
+	require.Equal(t, [2]int{256, 192}, [2]int{config.Width, config.Height})
+
+	// 很扁的图片高度至少为 1 像素
+	buf.Reset()
+	require.NoError(t, png.Encode(&buf, image.NewGray(image.Rect(0, 0, 1000, 2))))
+	data, contentType, err = Thumbnail(buf.Bytes(), 64)
+	require.NoError(t, err)
+	require.Equal(t, "image/png", contentType)
+	config, err = png.DecodeConfig(bytes.NewReader(data))
+	require.NoError(t, err)
+	require.Equal(t, [2]int{64, 1}, [2]int{config.Width, config.Height})
+
+	data, _, err = Thumbnail(buf.Bytes(), 1000)
+	require.NoError(t, err)
+	require.Nil(t, data)
+
+	_, _, err = Thumbnail([]byte("not an image"), 64)
+	require.ErrorIs(t, err, ErrNotImage)
+
+	key := strings.Repeat("a", 64)
+	require.Equal(t, "/media/"+key+"?w=128", ThumbnailURL(Ref(key), 128))
+	require.Empty(t, URL("resources/images/a.png"))
+}
+
+// declaredPNG 生成一张 1×1 的 PNG，但在文件头中声明为 width×height，文件很小，完整解码时需要按声明的尺寸分配内存
+func declaredPNG(t *testing.T, width, height uint32) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, image.NewGray(image.Rect(0, 0, 1, 1))))
+	data := buf.Bytes()
+	// 8 字节签名之后是 IHDR 块：长度、类型、宽、高……，最后 4 字节为类型和数据的 CRC
+	binary.BigEndian.PutUint32(data[16:], width)
+	binary.BigEndian.PutUint32(data[20:], height)
+	binary.BigEndian.PutUint32(data[29:], crc32.ChecksumIEEE(data[12:29]))
+	return data
+}
+
+func TestThumbnailTooLarge(t *testing.T) {
+	data := declaredPNG(t, 30000, 30000)
+	_, err := CheckImageSize(bytes.NewReader(data))
+	require.ErrorIs(t, err, ErrImageTooLarge)
+	_, _, err = Thumbnail(data, 64)
+	require.ErrorIs(t, err, ErrImageTooLarge)
+
+	config, err := CheckImageSize(bytes.NewReader(declaredPNG(t, 6000, 4000)))
+	require.NoError(t, err)
+	require.Equal(t, 6000, config.Width)
+}
@@ -0,0 +1,27 @@
+package media
+
+import "strconv"
+
+// URLPrefix 读取媒体文件的接口路径，完整地址为 /media/<SHA-256>
+const URLPrefix = "/media/"
+
+// DefaultThumbnailWidth 列表中显示的缩略图宽度
+const DefaultThumbnailWidth = 256
+
+// URL 返回引用对应的访问地址，不是 media: 引用时返回空字符串
+func URL(ref string) string {
+	key, ok := ParseRef(ref)
+	if !ok {
+		return ""
+	}
+	return URLPrefix + key
+}
+
+// ThumbnailURL 返回引用对应的缩略图地址，不是 media: 引用时返回空字符串
+func ThumbnailURL(ref string, width int) string {
+	url := URL(ref)
+	if url == "" {
+		return ""
+	}
+	return url + "?w=" + strconv.Itoa(width)
+}
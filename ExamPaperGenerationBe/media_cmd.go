@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"graduation/mapper"
+	"graduation/media"
+	"graduation/services"
+	"os"
+)
+
+const mediaUsage = `usage: main [-config file] media <command>
+  gc         删除存储中没有被题目、选项、材料、附件、历史版本或生成历史引用的媒体文件，
+             配置的 media.gc.grace 内保存的文件不删除
+  import     把旧版本直接保存在磁盘上的图片导入媒体存储，并把数据库中的路径改为 media: 引用，
+             原文件保留，确认无误后可以手动删除`
+
+// runMedia 处理 media 子命令
+func runMedia(collector *services.MediaCollector, refs *mapper.MediaMapper, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing media command\n%s", mediaUsage)
+	}
+	switch args[0] {
+	case "gc":
+		deleted, err := collector.Sweep(context.Background())
+		for _, key := range deleted {
+			fmt.Printf("deleted  %s\n", key)
+		}
+		if err == nil && len(deleted) == 0 {
+			fmt.Println("no unused media")
+		}
+		return err
+	case "import":
+		return importLegacyMedia(refs)
+	default:
+		return fmt.Errorf("unknown media command %q\n%s", args[0], mediaUsage)
+	}
+}
+
+// importLegacyMedia 导入数据库中引用的旧版本图片文件，读取失败的文件跳过并打印原因
+func importLegacyMedia(refs *mapper.MediaMapper) error {
+	paths, err := refs.GetLegacyImagePaths()
+	if err != nil {
+		return err
+	}
+	seen := make(map[string]bool)
+	imported := 0
+	for _, path := range paths {
+		if seen[path] {
+			continue
+		}
+		seen[path] = true
+		file, err := os.Open(path)
+		if err != nil {
+			fmt.Printf("skipped  %s: %v\n", path, err)
+			continue
+		}
+		ref, err := media.Save(context.Background(), file)
+		file.Close()
+		if err != nil {
+			return fmt.Errorf("import %s: %w", path, err)
+		}
+		rows, err := refs.ReplaceImagePath(path, ref)
+		if err != nil {
+			return fmt.Errorf("update references of %s: %w", path, err)
+		}
+		fmt.Printf("imported %s -> %s (%d rows)\n", path, ref, rows)
+		imported++
+	}
+	fmt.Printf("%d files imported\n", imported)
+	return nil
+}
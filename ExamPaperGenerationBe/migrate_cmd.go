@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"graduation/migration"
+	"strconv"
+
+	"gorm.io/gorm"
+)
+
+const migrateUsage = `usage: main [-config file] migrate <command> [n]
+  up [n]     执行未执行的迁移，n 为执行步数，默认全部
+  down [n]   回滚已执行的迁移，n 为回滚步数，默认 1
+  status     查看迁移执行状态`
+
+// runMigrate 处理 migrate 子命令
+func runMigrate(db *gorm.DB, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing migrate command\n%s", migrateUsage)
+	}
+	steps := 0
+	if len(args) > 1 {
+		n, err := strconv.Atoi(args[1])
+		if err != nil || n <= 0 {
+			return fmt.Errorf("invalid step count %q\n%s", args[1], migrateUsage)
+		}
+		steps = n
+	}
+
+	m := migration.NewMigrator(db)
+	switch args[0] {
+	case "up":
+		done, err := m.Up(steps)
+		for _, mg := range done {
+			fmt.Printf("applied  %04d_%s\n", mg.Version, mg.Name)
+		}
+		if err == nil && len(done) == 0 {
+			fmt.Println("database is up to date")
+		}
+		return err
+	case "down":
+		done, err := m.Down(steps)
+		for _, mg := range done {
+			fmt.Printf("reverted %04d_%s\n", mg.Version, mg.Name)
+		}
+		return err
+	case "status":
+		list, err := m.Status()
+		if err != nil {
+			return err
+		}
+		for _, s := range list {
+			state := "pending"
+			if s.Applied {
+				state = "applied " + s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d_%-40s %s\n", s.Version, s.Name, state)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown migrate command %q\n%s", args[0], migrateUsage)
+	}
+}
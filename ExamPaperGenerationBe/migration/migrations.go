@@ -0,0 +1,21 @@
+package migration
+
+// migrations 所有已注册的迁移，新增迁移时在末尾追加，版本号不可复用
+var migrations = []Migration{
+	baseSchema,
+	seedQuestionLabels,
+	revokedToken,
+	apiKey,
+	userLockout,
+	userMustChangePassword,
+	auditLog,
+	questionFulltext,
+	questionOptions,
+	questionMaterialContent,
+	questionTables,
+	questionAttachments,
+	questionRevisions,
+	historySnapshots,
+	questionRecycleBin,
+	questionReview,
+}
@@ -0,0 +1,192 @@
+package migration
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Migration 一个带版本号的数据库迁移步骤
+type Migration struct {
+	Version int
+	Name    string
+	Up      func(tx *gorm.DB) error
+	Down    func(tx *gorm.DB) error
+}
+
+// SchemaMigration 迁移状态表，记录已经执行过的迁移版本
+type SchemaMigration struct {
+	Version   int       `gorm:"primaryKey;autoIncrement:false;column:version"`
+	Name      string    `gorm:"column:name;size:255"`
+	AppliedAt time.Time `gorm:"column:applied_at"`
+}
+
+func (SchemaMigration) TableName() string {
+	return "schema_migrations" // 明确指定表名
+}
+
+// Status 单个迁移的执行状态
+type Status struct {
+	Version   int        `json:"version"`
+	Name      string     `json:"name"`
+	Applied   bool       `json:"applied"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"`
+}
+
+// Migrator 迁移执行器
+type Migrator struct {
+	db         *gorm.DB
+	migrations []Migration
+}
+
+// NewMigrator 使用全部已注册迁移创建迁移执行器
+func NewMigrator(db *gorm.DB) *Migrator {
+	return newMigrator(db, migrations)
+}
+
+func newMigrator(db *gorm.DB, list []Migration) *Migrator {
+	sorted := make([]Migration, len(list))
+	copy(sorted, list)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Version < sorted[j].Version
+	})
+	return &Migrator{db: db, migrations: sorted}
+}
+
+// Migrate 执行所有未执行的迁移
+func Migrate(db *gorm.DB) error {
+	_, err := NewMigrator(db).Up(0)
+	return err
+}
+
+// Up 按版本顺序执行未执行的迁移，steps <= 0 表示全部执行，返回本次执行的迁移
+func (m *Migrator) Up(steps int) ([]Migration, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+	var done []Migration
+	for _, mg := range m.migrations {
+		if steps > 0 && len(done) >= steps {
+			break
+		}
+		if _, ok := applied[mg.Version]; ok {
+			continue
+		}
+		err := m.db.Transaction(func(tx *gorm.DB) error {
+			if err := mg.Up(tx); err != nil {
+				return err
+			}
+			return tx.Create(&SchemaMigration{
+				Version:   mg.Version,
+				Name:      mg.Name,
+				AppliedAt: time.Now(),
+			}).Error
+		})
+		if err != nil {
+			return done, fmt.Errorf("migration %04d_%s up failed: %w", mg.Version, mg.Name, err)
+		}
+		done = append(done, mg)
+	}
+	return done, nil
+}
+
+// Down 按版本倒序回滚已执行的迁移，steps <= 0 时回滚一步，返回本次回滚的迁移
+func (m *Migrator) Down(steps int) ([]Migration, error) {
+	if steps <= 0 {
+		steps = 1
+	}
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+	var done []Migration
+	for i := len(m.migrations) - 1; i >= 0 && len(done) < steps; i-- {
+		mg := m.migrations[i]
+		if _, ok := applied[mg.Version]; !ok {
+			continue
+		}
+		if mg.Down == nil {
+			return done, fmt.Errorf("migration %04d_%s is irreversible", mg.Version, mg.Name)
+		}
+		err := m.db.Transaction(func(tx *gorm.DB) error {
+			if err := mg.Down(tx); err != nil {
+				return err
+			}
+			return tx.Delete(&SchemaMigration{}, mg.Version).Error
+		})
+		if err != nil {
+			return done, fmt.Errorf("migration %04d_%s down failed: %w", mg.Version, mg.Name, err)
+		}
+		done = append(done, mg)
+	}
+	return done, nil
+}
+
+// Status 返回所有迁移的执行状态
+func (m *Migrator) Status() ([]Status, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+	list := make([]Status, 0, len(m.migrations))
+	for _, mg := range m.migrations {
+		s := Status{Version: mg.Version, Name: mg.Name}
+		if rec, ok := applied[mg.Version]; ok {
+			appliedAt := rec.AppliedAt
+			s.Applied = true
+			s.AppliedAt = &appliedAt
+		}
+		list = append(list, s)
+	}
+	return list, nil
+}
+
+// applied 查询已执行的迁移，状态表不存在时自动创建
+func (m *Migrator) applied() (map[int]SchemaMigration, error) {
+	if err := m.db.AutoMigrate(&SchemaMigration{}); err != nil {
+		return nil, fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+	var records []SchemaMigration
+	if err := m.db.Find(&records).Error; err != nil {
+		return nil, err
+	}
+	applied := make(map[int]SchemaMigration, len(records))
+	for _, r := range records {
+		applied[r.Version] = r
+	}
+	return applied, nil
+}
+
+// ensureTable 表不存在时创建，已存在时补齐缺失的列
+// 用于兼容通过旧版 tabel.sql 或实体自动建表创建的数据库
+func ensureTable(tx *gorm.DB, model interface{}) error {
+	tx = withTableOptions(tx)
+	migrator := tx.Migrator()
+	if !migrator.HasTable(model) {
+		return migrator.CreateTable(model)
+	}
+	stmt := &gorm.Statement{DB: tx}
+	if err := stmt.Parse(model); err != nil {
+		return err
+	}
+	for _, field := range stmt.Schema.Fields {
+		if field.DBName == "" || migrator.HasColumn(model, field.DBName) {
+			continue
+		}
+		if err := migrator.AddColumn(model, field.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// withTableOptions MySQL 建表时指定引擎和字符集
+func withTableOptions(tx *gorm.DB) *gorm.DB {
+	if tx.Dialector.Name() == "mysql" {
+		return tx.Set("gorm:table_options", "ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci ROW_FORMAT=DYNAMIC")
+	}
+	return tx
+}
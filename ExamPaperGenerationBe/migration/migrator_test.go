@@ -0,0 +1,189 @@
+package migration
+
+import (
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+	return db
+}
+
+func TestMigrateUpDownStatus(t *testing.T) {
+	db := openTestDB(t)
+	m := NewMigrator(db)
+
+	done, err := m.Up(0)
+	require.NoError(t, err)
+	require.Len(t, done, len(migrations))
+	require.True(t, db.Migrator().HasTable("questionmaterial"))
+	require.True(t, db.Migrator().HasColumn("questiongenhistory", "topic_table_json"))
+	require.True(t, db.Migrator().HasColumn("questionbank", "topic_table"))
+	require.True(t, db.Migrator().HasColumn("questionbank", "answer_table"))
+	require.True(t, db.Migrator().HasColumn("questiongenhistory", "question_json"))
+	require.True(t, db.Migrator().HasColumn("questionbank", "deleted_at"))
+	require.True(t, db.Migrator().HasColumn("testpapergenhistory", "locked"))
+	require.True(t, db.Migrator().HasColumn("questionbank", "status"))
+	require.True(t, db.Migrator().HasTable("questionreviewcomment"))
+
+	// 再次执行不会重复迁移
+	done, err = m.Up(0)
+	require.NoError(t, err)
+	require.Empty(t, done)
+
+	status, err := m.Status()
+	require.NoError(t, err)
+	for _, s := range status {
+		require.True(t, s.Applied, "%04d_%s", s.Version, s.Name)
+	}
+
+	done, err = m.Down(len(migrations))
+	require.NoError(t, err)
+	require.Len(t, done, len(migrations))
+	require.False(t, db.Migrator().HasTable("questionbank"))
+
+	status, err = m.Status()
+	require.NoError(t, err)
+	for _, s := range status {
+		require.False(t, s.Applied)
+	}
+}
+
+func TestBaseSchemaAddsMissingColumns(t *testing.T) {
+	db := openTestDB(t)
+	// 模拟旧版本缺少图片和表格列的历史表
+	require.NoError(t, db.Exec("CREATE TABLE questiongenhistory (id integer PRIMARY KEY, topic text)").Error)
+
+	_, err := NewMigrator(db).Up(1)
+	require.NoError(t, err)
+	require.True(t, db.Migrator().HasColumn("questiongenhistory", "topic_image_path"))
+	require.True(t, db.Migrator().HasColumn("questiongenhistory", "topic_table_json"))
+}
+
+func TestDownIrreversible(t *testing.T) {
+	db := openTestDB(t)
+	m := newMigrator(db, []Migration{{
+		Version: 1,
+		Name:    "noop",
+		Up:      func(tx *gorm.DB) error { return nil },
+	}})
+	_, err := m.Up(0)
+	require.NoError(t, err)
+	_, err = m.Down(1)
+	require.Error(t, err)
+}
+
+func TestQuestionOptionsMigration(t *testing.T) {
+	db := openTestDB(t)
+	m := NewMigrator(db)
+	_, err := m.Up(questionOptions.Version - 1)
+	require.NoError(t, err)
+	require.NoError(t, db.Exec(`INSERT INTO questionbank (id, topic, answer, topic_type) VALUES
+		(1, '在下列机器数______中，零的表示形式是唯一的。A．原码 B．补码 C．反码 D．原码和反码', 'B', '选择题'),
+		(2, '浮点数的表示范围和精度取决于______ 。', '阶码的位数和尾数的位数', '选择题'),
+		(3, '8086 有几个段寄存器？A. 2 B. 4', '4', '填空题')`).Error)
+
+	_, err = m.Up(0)
+	require.NoError(t, err)
+	var rows []questionOptionsV9
+	require.NoError(t, db.Order("id").Find(&rows).Error)
+	require.Equal(t, "在下列机器数______中，零的表示形式是唯一的。", rows[0].Topic)
+	require.JSONEq(t, `[
+		{"label":"A","content":"原码","is_correct":false},
+		{"label":"B","content":"补码","is_correct":true},
+		{"label":"C","content":"反码","is_correct":false},
+		{"label":"D","content":"原码和反码","is_correct":false}]`, rows[0].Options)
+	require.Equal(t, "浮点数的表示范围和精度取决于______ 。", rows[1].Topic)
+	require.Empty(t, rows[1].Options)
+	require.Equal(t, "8086 有几个段寄存器？A. 2 B. 4", rows[2].Topic)
+
+	_, err = m.Down(len(migrations) - questionOptions.Version + 1)
+	require.NoError(t, err)
+	require.False(t, db.Migrator().HasColumn("questionbank", "options"))
+	var topic string
+	require.NoError(t, db.Raw("SELECT topic FROM questionbank WHERE id = 1").Scan(&topic).Error)
+	require.Equal(t, "在下列机器数______中，零的表示形式是唯一的。 A．原码 B．补码 C．反码 D．原码和反码", topic)
+}
+
+func TestQuestionMaterialContentMigration(t *testing.T) {
+	db := openTestDB(t)
+	m := NewMigrator(db)
+	_, err := m.Up(questionMaterialContent.Version - 1)
+	require.NoError(t, err)
+	require.NoError(t, db.Exec(`INSERT INTO questionmaterial (id, material) VALUES (1, '阅读下面的程序段')`).Error)
+
+	_, err = m.Up(0)
+	require.NoError(t, err)
+	for _, column := range []string{"title", "image_paths", "tables"} {
+		require.True(t, db.Migrator().HasColumn("questionmaterial", column), column)
+	}
+	var material string
+	require.NoError(t, db.Raw("SELECT material FROM questionmaterial WHERE id = 1").Scan(&material).Error)
+	require.Equal(t, "阅读下面的程序段", material)
+
+	_, err = m.Down(len(migrations) - questionMaterialContent.Version + 1)
+	require.NoError(t, err)
+	require.False(t, db.Migrator().HasColumn("questionmaterial", "tables"))
+	require.True(t, db.Migrator().HasColumn("questionmaterial", "material"))
+}
+
+func TestQuestionAttachmentsMigration(t *testing.T) {
+	db := openTestDB(t)
+	m := NewMigrator(db)
+	_, err := m.Up(questionAttachments.Version - 1)
+	require.NoError(t, err)
+	require.NoError(t, db.Exec(`INSERT INTO questionbank (id, topic, topic_type, topic_image_path, options) VALUES
+		(1, '根据电路图写出逻辑表达式', '简答题', 'resources/images/question_1.jpg', NULL),
+		(2, '下列波形中，哪一个是方波', '选择题', '', '[{"label":"A","content":"","image_path":"resources/images/option_A.jpg"},{"label":"B","content":"锯齿波"}]'),
+		(3, '8086 有几个段寄存器', '填空题', '', NULL)`).Error)
+
+	_, err = m.Up(0)
+	require.NoError(t, err)
+	var rows []questionAttachmentV12
+	require.NoError(t, db.Order("id").Find(&rows).Error)
+	require.Len(t, rows, 2)
+	require.Equal(t, 1, rows[0].QuestionID)
+	require.Equal(t, "topic", rows[0].Target)
+	require.Equal(t, "1", rows[0].Name)
+	require.Equal(t, "resources/images/question_1.jpg", rows[0].Path)
+	require.Equal(t, 2, rows[1].QuestionID)
+	require.Equal(t, "option", rows[1].Target)
+	require.Equal(t, "A", rows[1].OptionLabel)
+	require.Equal(t, "resources/images/option_A.jpg", rows[1].Path)
+
+	_, err = m.Down(len(migrations) - questionAttachments.Version + 1)
+	require.NoError(t, err)
+	require.False(t, db.Migrator().HasTable("questionattachment"))
+	require.True(t, db.Migrator().HasColumn("questionbank", "topic_image_path"))
+}
+
+func TestQuestionReviewMigration(t *testing.T) {
+	db := openTestDB(t)
+	m := NewMigrator(db)
+	_, err := m.Up(questionReview.Version - 1)
+	require.NoError(t, err)
+	require.NoError(t, db.Exec(`INSERT INTO questionbank (id, topic, topic_type) VALUES (1, '8086 有几个段寄存器', '填空题')`).Error)
+
+	// 已有的题目记为审核通过，之后新增的题目默认为草稿
+	_, err = m.Up(0)
+	require.NoError(t, err)
+	require.NoError(t, db.Exec(`INSERT INTO questionbank (id, topic, topic_type) VALUES (2, '8259A 的作用', '简答题')`).Error)
+	var statuses []string
+	require.NoError(t, db.Raw("SELECT status FROM questionbank ORDER BY id").Scan(&statuses).Error)
+	require.Equal(t, []string{"approved", "draft"}, statuses)
+
+	_, err = m.Down(len(migrations) - questionReview.Version + 1)
+	require.NoError(t, err)
+	require.False(t, db.Migrator().HasColumn("questionbank", "status"))
+	require.False(t, db.Migrator().HasTable("questionreviewcomment"))
+}
@@ -0,0 +1,121 @@
+package migration
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 以下结构体是 v1 版本表结构的快照，之后的表结构变化应通过新的迁移完成，不要修改这里
+type questionBankV1 struct {
+	ID              int       `gorm:"primaryKey;column:id"`
+	Topic           string    `gorm:"column:topic;type:longtext"`
+	TopicMaterialID int       `gorm:"column:topic_material_id"`
+	Answer          string    `gorm:"column:answer;size:255"`
+	TopicType       string    `gorm:"column:topic_type;size:255"`
+	Score           float64   `gorm:"column:score;type:decimal(10,1)"`
+	Difficulty      int       `gorm:"column:difficulty"`
+	Chapter1        string    `gorm:"column:chapter_1;size:255"`
+	Chapter2        string    `gorm:"column:chapter_2;size:255"`
+	Label1          string    `gorm:"column:label_1;size:255"`
+	Label2          string    `gorm:"column:label_2;size:255"`
+	UpdateTime      time.Time `gorm:"column:update_time;type:datetime"`
+	TopicImagePath  string    `gorm:"column:topic_image_path;size:255"`
+}
+
+func (questionBankV1) TableName() string { return "questionbank" }
+
+type questionGenHistoryV1 struct {
+	ID              int       `gorm:"primaryKey;column:id"`
+	TestPaperUID    string    `gorm:"column:test_paper_uid;size:255"`
+	TestPaperName   string    `gorm:"column:test_paper_name;size:255"`
+	QuestionBankID  int       `gorm:"column:question_bank_id"`
+	Topic           string    `gorm:"column:topic;type:longtext"`
+	TopicMaterialID int       `gorm:"column:topic_material_id"`
+	Answer          string    `gorm:"column:answer;size:255"`
+	TopicType       string    `gorm:"column:topic_type;size:255"`
+	Score           float64   `gorm:"column:score;type:decimal(10,1)"`
+	Difficulty      int       `gorm:"column:difficulty"`
+	Chapter1        string    `gorm:"column:chapter_1;size:255"`
+	Chapter2        string    `gorm:"column:chapter_2;size:255"`
+	Label1          string    `gorm:"column:label_1;size:255"`
+	Label2          string    `gorm:"column:label_2;size:255"`
+	UpdateTime      time.Time `gorm:"column:update_time;type:datetime"`
+	TopicImagePath  string    `gorm:"column:topic_image_path;size:255"`
+	TopicTableJSON  *string   `gorm:"column:topic_table_json;type:json"`
+}
+
+func (questionGenHistoryV1) TableName() string { return "questiongenhistory" }
+
+type questionLabelsV1 struct {
+	ID        int       `gorm:"primaryKey;column:id"`
+	Chapter1  string    `gorm:"column:chapter_1;size:255"`
+	Chapter2  string    `gorm:"column:chapter_2;size:255"`
+	Label1    string    `gorm:"column:label_1;size:255"`
+	Label2    string    `gorm:"column:label_2;size:255"`
+	CreatedAt time.Time `gorm:"column:created_at;default:CURRENT_TIMESTAMP"`
+	UpdatedAt time.Time `gorm:"column:updated_at;default:CURRENT_TIMESTAMP"`
+}
+
+func (questionLabelsV1) TableName() string { return "questionlabels" }
+
+type questionMaterialV1 struct {
+	ID         int       `gorm:"primaryKey;column:id"`
+	Material   string    `gorm:"column:material;type:longtext"`
+	UpdateTime time.Time `gorm:"column:update_time;type:datetime"`
+}
+
+func (questionMaterialV1) TableName() string { return "questionmaterial" }
+
+type testPaperGenHistoryV1 struct {
+	ID                  int       `gorm:"primaryKey;column:id"`
+	TestPaperUID        string    `gorm:"column:test_paper_uid;size:255"`
+	TestPaperName       string    `gorm:"column:test_paper_name;size:255"`
+	QuestionCount       int       `gorm:"column:question_count"`
+	AverageDifficulty   float64   `gorm:"column:average_difficulty;type:decimal(10,2)"`
+	UpdateTime          time.Time `gorm:"column:update_time;type:datetime"`
+	Username            string    `gorm:"column:username;size:255"`
+	SimilarityThreshold float64   `gorm:"column:similarity_threshold;type:decimal(10,2);default:0.5"`
+}
+
+func (testPaperGenHistoryV1) TableName() string { return "testpapergenhistory" }
+
+type userV1 struct {
+	ID                  int       `gorm:"primaryKey;column:id"`
+	Username            string    `gorm:"column:username;size:255;uniqueIndex:idx_username"`
+	Password            string    `gorm:"column:password;size:255"`
+	UserRole            string    `gorm:"column:user_role;size:255"`
+	LastLogin           time.Time `gorm:"column:last_login;type:datetime(6)"`
+	Enable              int       `gorm:"column:enable"`
+	SimilarityThreshold float64   `gorm:"column:similarity_threshold;type:decimal(10,2);default:0.5"`
+}
+
+func (userV1) TableName() string { return "user" }
+
+// baseSchema 初始表结构，对应原 resources/tabel.sql
+var baseSchema = Migration{
+	Version: 1,
+	Name:    "base_schema",
+	Up: func(tx *gorm.DB) error {
+		for _, model := range baseSchemaModels() {
+			if err := ensureTable(tx, model); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+	Down: func(tx *gorm.DB) error {
+		return tx.Migrator().DropTable(baseSchemaModels()...)
+	},
+}
+
+func baseSchemaModels() []interface{} {
+	return []interface{}{
+		&questionBankV1{},
+		&questionGenHistoryV1{},
+		&questionLabelsV1{},
+		&questionMaterialV1{},
+		&testPaperGenHistoryV1{},
+		&userV1{},
+	}
+}
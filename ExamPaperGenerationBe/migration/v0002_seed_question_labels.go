@@ -0,0 +1,88 @@
+package migration
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// seedQuestionLabels 写入默认知识点标签，标签表已有数据时跳过
+var seedQuestionLabels = Migration{
+	Version: 2,
+	Name:    "seed_question_labels",
+	Up: func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&questionLabelsV1{}).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return nil
+		}
+		now := time.Now()
+		labels := make([]questionLabelsV1, len(defaultQuestionLabels))
+		for i, l := range defaultQuestionLabels {
+			l.CreatedAt = now
+			l.UpdatedAt = now
+			labels[i] = l
+		}
+		return tx.Create(&labels).Error
+	},
+	Down: func(tx *gorm.DB) error {
+		// 标签可能已被用户修改并被题库引用，回滚时保留数据
+		return nil
+	},
+}
+
+// defaultQuestionLabels 默认知识点标签
+var defaultQuestionLabels = []questionLabelsV1{
+	{Chapter1: "1", Chapter2: "1.1", Label1: "绪论", Label2: "微型计算机发展概况"},
+	{Chapter1: "1", Chapter2: "1.2", Label1: "绪论", Label2: "计算机中数和字符的表示"},
+	{Chapter1: "1", Chapter2: "1.3", Label1: "绪论", Label2: "微型计算机系统概论"},
+	{Chapter1: "2", Chapter2: "2.1", Label1: "Intel8086微处理器", Label2: "8086微处理器的内部结构"},
+	{Chapter1: "2", Chapter2: "2.2", Label1: "Intel8086微处理器", Label2: "8086引脚功能"},
+	{Chapter1: "2", Chapter2: "2.3", Label1: "Intel8086微处理器", Label2: "8086系统总线时序"},
+	{Chapter1: "2", Chapter2: "2.4", Label1: "Intel8086微处理器", Label2: "8086寻址方式"},
+	{Chapter1: "2", Chapter2: "2.5", Label1: "Intel8086微处理器", Label2: "8086指令系统"},
+	{Chapter1: "3", Chapter2: "3.1", Label1: "宏汇编语言程序设计", Label2: "汇编语言的语句格式"},
+	{Chapter1: "3", Chapter2: "3.2", Label1: "宏汇编语言程序设计", Label2: "汇编语言的数据项"},
+	{Chapter1: "3", Chapter2: "3.3", Label1: "宏汇编语言程序设计", Label2: "汇编语言的表达式"},
+	{Chapter1: "3", Chapter2: "3.4", Label1: "宏汇编语言程序设计", Label2: "伪指令语句"},
+	{Chapter1: "3", Chapter2: "3.5", Label1: "宏汇编语言程序设计", Label2: "汇编语言程序设计概述"},
+	{Chapter1: "3", Chapter2: "3.6", Label1: "宏汇编语言程序设计", Label2: "顺序程序设计"},
+	{Chapter1: "3", Chapter2: "3.7", Label1: "宏汇编语言程序设计", Label2: "分支程序设计"},
+	{Chapter1: "3", Chapter2: "3.8", Label1: "宏汇编语言程序设计", Label2: "循环程序设计"},
+	{Chapter1: "3", Chapter2: "3.9", Label1: "宏汇编语言程序设计", Label2: "DOS系统功能调用"},
+	{Chapter1: "3", Chapter2: "3.10", Label1: "宏汇编语言程序设计", Label2: "子程序设计"},
+	{Chapter1: "3", Chapter2: "3.11", Label1: "宏汇编语言程序设计", Label2: "宏指令"},
+	{Chapter1: "3", Chapter2: "3.12", Label1: "宏汇编语言程序设计", Label2: "汇编语言程序的建立、汇编、连接与调试"},
+	{Chapter1: "4", Chapter2: "4.1", Label1: "Intel80486微处理器", Label2: "80486内部结构"},
+	{Chapter1: "4", Chapter2: "4.2", Label1: "Intel80486微处理器", Label2: "80486的工作方式"},
+	{Chapter1: "4", Chapter2: "4.3", Label1: "Intel80486微处理器", Label2: "80486引脚功能"},
+	{Chapter1: "4", Chapter2: "4.4", Label1: "Intel80486微处理器", Label2: "80486的寻址方式"},
+	{Chapter1: "4", Chapter2: "4.5", Label1: "Intel80486微处理器", Label2: "80486常用指令介绍"},
+	{Chapter1: "4", Chapter2: "4.6", Label1: "Intel80486微处理器", Label2: "80486编程举例"},
+	{Chapter1: "5", Chapter2: "5.1", Label1: "半导体存储器", Label2: "存储器概述"},
+	{Chapter1: "5", Chapter2: "5.2", Label1: "半导体存储器", Label2: "随机存储器RAM"},
+	{Chapter1: "5", Chapter2: "5.3", Label1: "半导体存储器", Label2: "只读存储器ROM"},
+	{Chapter1: "5", Chapter2: "5.4", Label1: "半导体存储器", Label2: "存储器与CPU的连接"},
+	{Chapter1: "5", Chapter2: "5.5", Label1: "半导体存储器", Label2: "高速缓冲存储器系统"},
+	{Chapter1: "6", Chapter2: "6.1", Label1: "I/O接口技术", Label2: "I/O接口技术概述"},
+	{Chapter1: "6", Chapter2: "6.2", Label1: "I/O接口技术", Label2: "程序控制的I/O"},
+	{Chapter1: "6", Chapter2: "6.3", Label1: "I/O接口技术", Label2: "DMA方式"},
+	{Chapter1: "7", Chapter2: "7.1", Label1: "中断系统", Label2: "中断系统概述"},
+	{Chapter1: "7", Chapter2: "7.2", Label1: "中断系统", Label2: "16位微机中断系统"},
+	{Chapter1: "7", Chapter2: "7.3", Label1: "中断系统", Label2: "32位微处理器的中断"},
+	{Chapter1: "7", Chapter2: "7.4", Label1: "中断系统", Label2: "中断控制器8259A"},
+	{Chapter1: "8", Chapter2: "8.1", Label1: "常用接口芯片", Label2: "并行接口芯片8255A"},
+	{Chapter1: "8", Chapter2: "8.2", Label1: "常用接口芯片", Label2: "定时器/计数器接口芯片8253"},
+	{Chapter1: "8", Chapter2: "8.3", Label1: "常用接口芯片", Label2: "串行接口芯片8251A"},
+	{Chapter1: "8", Chapter2: "8.4", Label1: "常用接口芯片", Label2: "模拟接口"},
+	{Chapter1: "8", Chapter2: "8.5", Label1: "常用接口芯片", Label2: "多功能外围接口芯片82380"},
+	{Chapter1: "9", Chapter2: "9.1", Label1: "总线", Label2: "总线概述"},
+	{Chapter1: "9", Chapter2: "9.2", Label1: "总线", Label2: "ISA总线"},
+	{Chapter1: "9", Chapter2: "9.3", Label1: "总线", Label2: "EISA总线"},
+	{Chapter1: "9", Chapter2: "9.4", Label1: "总线", Label2: "PCI总线"},
+	{Chapter1: "10", Chapter2: "10.1", Label1: "典型微型计算机系统", Label2: "1BMPC/XT微型计算机系统"},
+	{Chapter1: "10", Chapter2: "10.2", Label1: "典型微型计算机系统", Label2: "80486微型计算机系统"},
+	{Chapter1: "10", Chapter2: "10.3", Label1: "典型微型计算机系统", Label2: "Pentium系列微型计算机系统"},
+}
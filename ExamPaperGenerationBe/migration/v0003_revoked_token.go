@@ -0,0 +1,27 @@
+package migration
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// revokedTokenV3 v3 版本令牌注销表结构快照，不要修改
+type revokedTokenV3 struct {
+	JTI       string    `gorm:"primaryKey;column:jti;size:64"`
+	ExpiresAt time.Time `gorm:"column:expires_at;type:datetime;index:idx_revokedtoken_expires_at"`
+}
+
+func (revokedTokenV3) TableName() string { return "revokedtoken" }
+
+// revokedToken 记录退出登录时注销的令牌
+var revokedToken = Migration{
+	Version: 3,
+	Name:    "revoked_token",
+	Up: func(tx *gorm.DB) error {
+		return ensureTable(tx, &revokedTokenV3{})
+	},
+	Down: func(tx *gorm.DB) error {
+		return tx.Migrator().DropTable(&revokedTokenV3{})
+	},
+}
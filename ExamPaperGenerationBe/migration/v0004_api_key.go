@@ -0,0 +1,34 @@
+package migration
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// apiKeyV4 v4 版本个人 API 密钥表结构快照，不要修改
+type apiKeyV4 struct {
+	ID         int        `gorm:"primaryKey;column:id"`
+	Username   string     `gorm:"column:username;size:255;index:idx_apikey_username"`
+	Name       string     `gorm:"column:name;size:255"`
+	Prefix     string     `gorm:"column:prefix;size:32"`
+	KeyHash    string     `gorm:"column:key_hash;size:64;uniqueIndex:idx_apikey_key_hash"`
+	Scopes     string     `gorm:"column:scopes;size:255"`
+	CreatedAt  time.Time  `gorm:"column:created_at;type:datetime"`
+	LastUsedAt *time.Time `gorm:"column:last_used_at;type:datetime"`
+	RevokedAt  *time.Time `gorm:"column:revoked_at;type:datetime"`
+}
+
+func (apiKeyV4) TableName() string { return "apikey" }
+
+// apiKey 个人 API 密钥
+var apiKey = Migration{
+	Version: 4,
+	Name:    "api_key",
+	Up: func(tx *gorm.DB) error {
+		return ensureTable(tx, &apiKeyV4{})
+	},
+	Down: func(tx *gorm.DB) error {
+		return tx.Migrator().DropTable(&apiKeyV4{})
+	},
+}
@@ -0,0 +1,47 @@
+package migration
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// userLockoutV5 v5 版本用户表新增的登录失败计数和锁定时间列，不要修改
+type userLockoutV5 struct {
+	FailedLoginCount int        `gorm:"column:failed_login_count;not null;default:0"`
+	LockedUntil      *time.Time `gorm:"column:locked_until;type:datetime"`
+}
+
+func (userLockoutV5) TableName() string { return "user" }
+
+var userLockoutColumns = []string{"FailedLoginCount", "LockedUntil"}
+
+// userLockout 用户表增加登录失败计数和临时锁定时间
+var userLockout = Migration{
+	Version: 5,
+	Name:    "user_lockout",
+	Up: func(tx *gorm.DB) error {
+		migrator := tx.Migrator()
+		for _, column := range userLockoutColumns {
+			if migrator.HasColumn(&userLockoutV5{}, column) {
+				continue
+			}
+			if err := migrator.AddColumn(&userLockoutV5{}, column); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+	Down: func(tx *gorm.DB) error {
+		migrator := tx.Migrator()
+		for _, column := range userLockoutColumns {
+			if !migrator.HasColumn(&userLockoutV5{}, column) {
+				continue
+			}
+			if err := migrator.DropColumn(&userLockoutV5{}, column); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
@@ -0,0 +1,32 @@
+package migration
+
+import (
+	"gorm.io/gorm"
+)
+
+// userMustChangePasswordV6 v6 版本用户表新增的强制改密标记列，不要修改
+type userMustChangePasswordV6 struct {
+	MustChangePassword bool `gorm:"column:must_change_password;not null;default:false"`
+}
+
+func (userMustChangePasswordV6) TableName() string { return "user" }
+
+// userMustChangePassword 用户表增加强制修改密码标记，管理员重置密码后置为 true
+var userMustChangePassword = Migration{
+	Version: 6,
+	Name:    "user_must_change_password",
+	Up: func(tx *gorm.DB) error {
+		migrator := tx.Migrator()
+		if migrator.HasColumn(&userMustChangePasswordV6{}, "MustChangePassword") {
+			return nil
+		}
+		return migrator.AddColumn(&userMustChangePasswordV6{}, "MustChangePassword")
+	},
+	Down: func(tx *gorm.DB) error {
+		migrator := tx.Migrator()
+		if !migrator.HasColumn(&userMustChangePasswordV6{}, "MustChangePassword") {
+			return nil
+		}
+		return migrator.DropColumn(&userMustChangePasswordV6{}, "MustChangePassword")
+	},
+}
@@ -0,0 +1,35 @@
+package migration
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// auditLogV7 v7 版本审计日志表结构快照，不要修改
+type auditLogV7 struct {
+	ID         int       `gorm:"primaryKey;column:id"`
+	Actor      string    `gorm:"column:actor;size:255;index:idx_auditlog_actor"`
+	Action     string    `gorm:"column:action;size:64"`
+	EntityType string    `gorm:"column:entity_type;size:64;index:idx_auditlog_entity"`
+	EntityID   string    `gorm:"column:entity_id;size:255;index:idx_auditlog_entity"`
+	Before     string    `gorm:"column:before_json;type:longtext"`
+	After      string    `gorm:"column:after_json;type:longtext"`
+	Diff       string    `gorm:"column:diff_json;type:longtext"`
+	IP         string    `gorm:"column:ip;size:64"`
+	CreatedAt  time.Time `gorm:"column:created_at;type:datetime;index:idx_auditlog_created_at"`
+}
+
+func (auditLogV7) TableName() string { return "auditlog" }
+
+// auditLog 修改操作的审计日志
+var auditLog = Migration{
+	Version: 7,
+	Name:    "audit_log",
+	Up: func(tx *gorm.DB) error {
+		return ensureTable(tx, &auditLogV7{})
+	},
+	Down: func(tx *gorm.DB) error {
+		return tx.Migrator().DropTable(&auditLogV7{})
+	},
+}
@@ -0,0 +1,32 @@
+package migration
+
+import (
+	"gorm.io/gorm"
+)
+
+// questionFulltextIndex 题库全文索引名
+const questionFulltextIndex = "idx_questionbank_fulltext"
+
+// questionFulltextV8 v8 版本全文索引所在的题库表，只用于判断索引是否存在，不要修改
+type questionFulltextV8 struct{}
+
+func (questionFulltextV8) TableName() string { return "questionbank" }
+
+// questionFulltext MySQL 下为题干、答案和知识点建立 ngram 全文索引，其他数据库在程序中检索，不需要索引
+var questionFulltext = Migration{
+	Version: 8,
+	Name:    "question_fulltext",
+	Up: func(tx *gorm.DB) error {
+		if tx.Dialector.Name() != "mysql" || tx.Migrator().HasIndex(&questionFulltextV8{}, questionFulltextIndex) {
+			return nil
+		}
+		return tx.Exec("CREATE FULLTEXT INDEX " + questionFulltextIndex +
+			" ON questionbank (topic, answer, label_1, label_2) WITH PARSER ngram").Error
+	},
+	Down: func(tx *gorm.DB) error {
+		if tx.Dialector.Name() != "mysql" || !tx.Migrator().HasIndex(&questionFulltextV8{}, questionFulltextIndex) {
+			return nil
+		}
+		return tx.Migrator().DropIndex(&questionFulltextV8{}, questionFulltextIndex)
+	},
+}
@@ -0,0 +1,173 @@
+package migration
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"gorm.io/gorm"
+)
+
+// questionOptionsV9 v9 版本题库表中与选择题选项相关的列，不要修改
+type questionOptionsV9 struct {
+	ID        int    `gorm:"primaryKey;column:id"`
+	Topic     string `gorm:"column:topic"`
+	Answer    string `gorm:"column:answer"`
+	TopicType string `gorm:"column:topic_type"`
+	Options   string `gorm:"column:options;type:longtext"`
+}
+
+func (questionOptionsV9) TableName() string { return "questionbank" }
+
+// questionOptionV9 v9 版本选项列中保存的一个选项，不要修改
+type questionOptionV9 struct {
+	Label     string `json:"label"`
+	Content   string `json:"content"`
+	ImagePath string `json:"image_path,omitempty"`
+	IsCorrect bool   `json:"is_correct"`
+}
+
+// v9 版本拆分选项所用的规则，不要修改
+var optionMarkerV9 = regexp.MustCompile(`([A-H])\s*[.．、:：]`)
+
+const optionTrimCharsV9 = " \t\r\n　；;，,。"
+
+// parseChoiceTopicV9 把题干中的选项拆分出来，选项标号必须从 A 开始连续出现且至少有两个
+func parseChoiceTopicV9(topic string) (string, []questionOptionV9, bool) {
+	type marker struct {
+		label      string
+		start, end int
+	}
+	var markers []marker
+	expected := byte('A')
+	for _, loc := range optionMarkerV9.FindAllStringSubmatchIndex(topic, -1) {
+		if loc[2] > 0 {
+			prev := []rune(topic[:loc[2]])
+			if r := prev[len(prev)-1]; unicode.IsLetter(r) || unicode.IsDigit(r) {
+				continue
+			}
+		}
+		label := topic[loc[2]]
+		switch {
+		case label == expected:
+			markers = append(markers, marker{label: string(label), start: loc[0], end: loc[1]})
+			expected++
+		case label == 'A' && len(markers) > 0:
+			return topic, nil, false
+		}
+	}
+	if len(markers) < 2 {
+		return topic, nil, false
+	}
+
+	stem := strings.TrimSpace(topic[:markers[0].start])
+	options := make([]questionOptionV9, len(markers))
+	for i, m := range markers {
+		end := len(topic)
+		if i+1 < len(markers) {
+			end = markers[i+1].start
+		}
+		content := strings.Trim(topic[m.end:end], optionTrimCharsV9)
+		if content == "" {
+			return topic, nil, false
+		}
+		options[i] = questionOptionV9{Label: m.label, Content: content}
+	}
+	return stem, options, true
+}
+
+// markCorrectOptionsV9 根据答案文字标记正确选项，答案中含有标号以外的文字时不做修改，返回正确选项的标号
+func markCorrectOptionsV9(options []questionOptionV9, answer string) string {
+	labels := make(map[string]bool)
+	for _, r := range strings.ToUpper(answer) {
+		switch {
+		case r >= 'A' && r <= 'Z':
+			labels[string(r)] = true
+		case unicode.IsSpace(r) || strings.ContainsRune(",，、;；", r):
+		default:
+			return ""
+		}
+	}
+	var sb strings.Builder
+	for i := range options {
+		options[i].IsCorrect = labels[options[i].Label]
+		if options[i].IsCorrect {
+			sb.WriteString(options[i].Label)
+		}
+	}
+	return sb.String()
+}
+
+// formatChoiceTopicV9 把题干和选项重新拼接为一段文字
+func formatChoiceTopicV9(stem string, options []questionOptionV9) string {
+	parts := []string{stem}
+	for _, o := range options {
+		parts = append(parts, o.Label+"．"+o.Content)
+	}
+	return strings.Join(parts, " ")
+}
+
+// questionOptions 题库表增加选项列，并把已有选择题题干中的选项拆分出来。
+// 无法识别选项的题目保持原样
+var questionOptions = Migration{
+	Version: 9,
+	Name:    "question_options",
+	Up: func(tx *gorm.DB) error {
+		migrator := tx.Migrator()
+		if !migrator.HasColumn(&questionOptionsV9{}, "Options") {
+			if err := migrator.AddColumn(&questionOptionsV9{}, "Options"); err != nil {
+				return err
+			}
+		}
+		var rows []questionOptionsV9
+		if err := tx.Where("topic_type = ? AND (options IS NULL OR options = '' OR options = 'null')", "选择题").
+			Find(&rows).Error; err != nil {
+			return err
+		}
+		for _, row := range rows {
+			stem, parsed, ok := parseChoiceTopicV9(row.Topic)
+			if !ok {
+				continue
+			}
+			answer := row.Answer
+			if labels := markCorrectOptionsV9(parsed, row.Answer); labels != "" {
+				answer = labels
+			}
+			options, err := json.Marshal(parsed)
+			if err != nil {
+				return err
+			}
+			if err := tx.Model(&questionOptionsV9{}).Where("id = ?", row.ID).Updates(map[string]interface{}{
+				"topic":   stem,
+				"answer":  answer,
+				"options": string(options),
+			}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+	Down: func(tx *gorm.DB) error {
+		migrator := tx.Migrator()
+		if !migrator.HasColumn(&questionOptionsV9{}, "Options") {
+			return nil
+		}
+		// 把选项拼回题干，回滚后选择题仍能正常显示
+		var rows []questionOptionsV9
+		if err := tx.Where("options IS NOT NULL AND options <> '' AND options <> 'null'").Find(&rows).Error; err != nil {
+			return err
+		}
+		for _, row := range rows {
+			var options []questionOptionV9
+			if err := json.Unmarshal([]byte(row.Options), &options); err != nil || len(options) == 0 {
+				continue
+			}
+			if err := tx.Model(&questionOptionsV9{}).Where("id = ?", row.ID).
+				Update("topic", formatChoiceTopicV9(row.Topic, options)).Error; err != nil {
+				return err
+			}
+		}
+		return migrator.DropColumn(&questionOptionsV9{}, "Options")
+	},
+}
@@ -0,0 +1,46 @@
+package migration
+
+import "gorm.io/gorm"
+
+// questionMaterialV10 v10 版本材料表新增的列，不要修改
+type questionMaterialV10 struct {
+	ID         int    `gorm:"primaryKey;column:id"`
+	Title      string `gorm:"column:title;size:255"`
+	ImagePaths string `gorm:"column:image_paths;type:longtext"`
+	Tables     string `gorm:"column:tables;type:longtext"`
+}
+
+func (questionMaterialV10) TableName() string { return "questionmaterial" }
+
+// questionMaterialFields v10 新增的列，按 questionMaterialV10 的字段名
+var questionMaterialFields = []string{"Title", "ImagePaths", "Tables"}
+
+// questionMaterialContent 材料表增加标题、图片和表格列，原有的 material 列作为材料正文
+var questionMaterialContent = Migration{
+	Version: 10,
+	Name:    "question_material_content",
+	Up: func(tx *gorm.DB) error {
+		migrator := tx.Migrator()
+		for _, field := range questionMaterialFields {
+			if migrator.HasColumn(&questionMaterialV10{}, field) {
+				continue
+			}
+			if err := migrator.AddColumn(&questionMaterialV10{}, field); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+	Down: func(tx *gorm.DB) error {
+		migrator := tx.Migrator()
+		for _, field := range questionMaterialFields {
+			if !migrator.HasColumn(&questionMaterialV10{}, field) {
+				continue
+			}
+			if err := migrator.DropColumn(&questionMaterialV10{}, field); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
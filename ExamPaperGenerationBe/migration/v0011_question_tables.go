@@ -0,0 +1,45 @@
+package migration
+
+import "gorm.io/gorm"
+
+// questionTablesV11 v11 版本题库表新增的表格列，不要修改
+type questionTablesV11 struct {
+	ID          int    `gorm:"primaryKey;column:id"`
+	TopicTable  string `gorm:"column:topic_table;type:longtext"`
+	AnswerTable string `gorm:"column:answer_table;type:longtext"`
+}
+
+func (questionTablesV11) TableName() string { return "questionbank" }
+
+// questionTableFields v11 新增的列，按 questionTablesV11 的字段名
+var questionTableFields = []string{"TopicTable", "AnswerTable"}
+
+// questionTables 题库表增加题干表格和答案表格列，内容为 entity.ContentTable 的 JSON
+var questionTables = Migration{
+	Version: 11,
+	Name:    "question_tables",
+	Up: func(tx *gorm.DB) error {
+		migrator := tx.Migrator()
+		for _, field := range questionTableFields {
+			if migrator.HasColumn(&questionTablesV11{}, field) {
+				continue
+			}
+			if err := migrator.AddColumn(&questionTablesV11{}, field); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+	Down: func(tx *gorm.DB) error {
+		migrator := tx.Migrator()
+		for _, field := range questionTableFields {
+			if !migrator.HasColumn(&questionTablesV11{}, field) {
+				continue
+			}
+			if err := migrator.DropColumn(&questionTablesV11{}, field); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
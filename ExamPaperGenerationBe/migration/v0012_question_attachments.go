@@ -0,0 +1,96 @@
+package migration
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// questionAttachmentV12 v12 版本题目附件表结构快照，不要修改
+type questionAttachmentV12 struct {
+	ID           int       `gorm:"primaryKey;column:id"`
+	QuestionID   int       `gorm:"column:question_id;index:idx_questionattachment_question"`
+	Target       string    `gorm:"column:target;size:16"`
+	OptionLabel  string    `gorm:"column:option_label;size:8"`
+	Name         string    `gorm:"column:name;size:32"`
+	Path         string    `gorm:"column:path;size:512"`
+	Caption      string    `gorm:"column:caption;size:255"`
+	Width        int       `gorm:"column:width"`
+	Height       int       `gorm:"column:height"`
+	DisplayWidth float64   `gorm:"column:display_width"`
+	SortOrder    int       `gorm:"column:sort_order"`
+	UpdateTime   time.Time `gorm:"column:update_time;type:datetime"`
+}
+
+func (questionAttachmentV12) TableName() string { return "questionattachment" }
+
+// questionImagesV12 v12 版本题库表中与图片相关的列，不要修改
+type questionImagesV12 struct {
+	ID             int    `gorm:"primaryKey;column:id"`
+	TopicImagePath string `gorm:"column:topic_image_path"`
+	Options        string `gorm:"column:options"`
+}
+
+func (questionImagesV12) TableName() string { return "questionbank" }
+
+// questionOptionV12 v12 版本选项列中与图片相关的字段，不要修改
+type questionOptionV12 struct {
+	Label     string `json:"label"`
+	ImagePath string `json:"image_path,omitempty"`
+}
+
+// questionAttachments 新增题目附件表，已有的题干图片和选项图片复制为附件。
+// 题库表的 topic_image_path 保留，作为题干第一张图片供旧接口使用
+var questionAttachments = Migration{
+	Version: 12,
+	Name:    "question_attachments",
+	Up: func(tx *gorm.DB) error {
+		if err := ensureTable(tx, &questionAttachmentV12{}); err != nil {
+			return err
+		}
+		var count int64
+		if err := tx.Model(&questionAttachmentV12{}).Count(&count).Error; err != nil || count > 0 {
+			return err
+		}
+		var rows []questionImagesV12
+		if err := tx.Where("(topic_image_path IS NOT NULL AND topic_image_path <> '') OR options LIKE ?", `%"image_path"%`).
+			Find(&rows).Error; err != nil {
+			return err
+		}
+		now := time.Now()
+		for _, row := range rows {
+			var attachments []questionAttachmentV12
+			if row.TopicImagePath != "" {
+				attachments = append(attachments, questionAttachmentV12{Target: "topic", Path: row.TopicImagePath})
+			}
+			var options []questionOptionV12
+			if row.Options != "" {
+				if err := json.Unmarshal([]byte(row.Options), &options); err != nil {
+					return err
+				}
+			}
+			for _, o := range options {
+				if o.ImagePath != "" {
+					attachments = append(attachments, questionAttachmentV12{Target: "option", OptionLabel: o.Label, Path: o.ImagePath})
+				}
+			}
+			for i := range attachments {
+				attachments[i].QuestionID = row.ID
+				attachments[i].Name = strconv.Itoa(i + 1)
+				attachments[i].UpdateTime = now
+			}
+			if len(attachments) == 0 {
+				continue
+			}
+			if err := tx.Create(&attachments).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+	Down: func(tx *gorm.DB) error {
+		return tx.Migrator().DropTable(&questionAttachmentV12{})
+	},
+}
@@ -0,0 +1,33 @@
+package migration
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// questionRevisionV13 v13 版本题目历史版本表结构快照，不要修改
+type questionRevisionV13 struct {
+	ID         int       `gorm:"primaryKey;column:id"`
+	QuestionID int       `gorm:"column:question_id;uniqueIndex:idx_questionrevision_question_revision"`
+	Revision   int       `gorm:"column:revision;uniqueIndex:idx_questionrevision_question_revision"`
+	Action     string    `gorm:"column:action;size:16"`
+	Source     int       `gorm:"column:source"`
+	Snapshot   string    `gorm:"column:snapshot;type:longtext"`
+	Editor     string    `gorm:"column:editor;size:255"`
+	CreatedAt  time.Time `gorm:"column:created_at;type:datetime"`
+}
+
+func (questionRevisionV13) TableName() string { return "questionrevision" }
+
+// questionRevisions 新增题目历史版本表。已有题目不在迁移时补记版本，第一次修改前再把原内容记为 initial 版本
+var questionRevisions = Migration{
+	Version: 13,
+	Name:    "question_revisions",
+	Up: func(tx *gorm.DB) error {
+		return ensureTable(tx, &questionRevisionV13{})
+	},
+	Down: func(tx *gorm.DB) error {
+		return tx.Migrator().DropTable(&questionRevisionV13{})
+	},
+}
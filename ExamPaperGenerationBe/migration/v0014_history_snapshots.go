@@ -0,0 +1,47 @@
+package migration
+
+import "gorm.io/gorm"
+
+// historySnapshotsV14 v14 版本出题历史表新增的快照列，不要修改
+type historySnapshotsV14 struct {
+	ID          int    `gorm:"primaryKey;column:id"`
+	Question    string `gorm:"column:question_json;type:longtext"`
+	Attachments string `gorm:"column:attachments_json;type:longtext"`
+	Material    string `gorm:"column:material_json;type:longtext"`
+}
+
+func (historySnapshotsV14) TableName() string { return "questiongenhistory" }
+
+// historySnapshotFields v14 新增的列，按 historySnapshotsV14 的字段名
+var historySnapshotFields = []string{"Question", "Attachments", "Material"}
+
+// historySnapshots 出题历史表增加题目、附件和材料的完整快照列，重新导出时按出题时的内容生成。
+// 已有记录无法补全快照，保持为空
+var historySnapshots = Migration{
+	Version: 14,
+	Name:    "history_snapshots",
+	Up: func(tx *gorm.DB) error {
+		migrator := tx.Migrator()
+		for _, field := range historySnapshotFields {
+			if migrator.HasColumn(&historySnapshotsV14{}, field) {
+				continue
+			}
+			if err := migrator.AddColumn(&historySnapshotsV14{}, field); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+	Down: func(tx *gorm.DB) error {
+		migrator := tx.Migrator()
+		for _, field := range historySnapshotFields {
+			if !migrator.HasColumn(&historySnapshotsV14{}, field) {
+				continue
+			}
+			if err := migrator.DropColumn(&historySnapshotsV14{}, field); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
@@ -0,0 +1,76 @@
+package migration
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// questionRecycleBinV15 v15 版本题库表新增的软删除列，不要修改
+type questionRecycleBinV15 struct {
+	ID        int        `gorm:"primaryKey;column:id"`
+	DeletedAt *time.Time `gorm:"column:deleted_at;type:datetime;index:idx_questionbank_deleted_at"`
+	DeletedBy string     `gorm:"column:deleted_by;size:255"`
+}
+
+func (questionRecycleBinV15) TableName() string { return "questionbank" }
+
+// testPaperLockV15 v15 版本试卷生成历史表新增的锁定列，不要修改
+type testPaperLockV15 struct {
+	ID     int  `gorm:"primaryKey;column:id"`
+	Locked bool `gorm:"column:locked;default:false"`
+}
+
+func (testPaperLockV15) TableName() string { return "testpapergenhistory" }
+
+// questionRecycleBin 题库表增加软删除列，删除的题目先移入回收站；试卷生成历史表增加锁定列
+var questionRecycleBin = Migration{
+	Version: 15,
+	Name:    "question_recycle_bin",
+	Up: func(tx *gorm.DB) error {
+		migrator := tx.Migrator()
+		for _, field := range []string{"DeletedAt", "DeletedBy"} {
+			if migrator.HasColumn(&questionRecycleBinV15{}, field) {
+				continue
+			}
+			if err := migrator.AddColumn(&questionRecycleBinV15{}, field); err != nil {
+				return err
+			}
+		}
+		if !migrator.HasIndex(&questionRecycleBinV15{}, "idx_questionbank_deleted_at") {
+			if err := migrator.CreateIndex(&questionRecycleBinV15{}, "idx_questionbank_deleted_at"); err != nil {
+				return err
+			}
+		}
+		if !migrator.HasColumn(&testPaperLockV15{}, "Locked") {
+			return migrator.AddColumn(&testPaperLockV15{}, "Locked")
+		}
+		return nil
+	},
+	Down: func(tx *gorm.DB) error {
+		migrator := tx.Migrator()
+		// 回滚前彻底删除回收站中的题目，否则回滚后它们会重新出现在题库中
+		if migrator.HasColumn(&questionRecycleBinV15{}, "DeletedAt") {
+			if err := tx.Where("deleted_at IS NOT NULL").Delete(&questionRecycleBinV15{}).Error; err != nil {
+				return err
+			}
+		}
+		if migrator.HasIndex(&questionRecycleBinV15{}, "idx_questionbank_deleted_at") {
+			if err := migrator.DropIndex(&questionRecycleBinV15{}, "idx_questionbank_deleted_at"); err != nil {
+				return err
+			}
+		}
+		for _, field := range []string{"DeletedAt", "DeletedBy"} {
+			if !migrator.HasColumn(&questionRecycleBinV15{}, field) {
+				continue
+			}
+			if err := migrator.DropColumn(&questionRecycleBinV15{}, field); err != nil {
+				return err
+			}
+		}
+		if migrator.HasColumn(&testPaperLockV15{}, "Locked") {
+			return migrator.DropColumn(&testPaperLockV15{}, "Locked")
+		}
+		return nil
+	},
+}
@@ -0,0 +1,78 @@
+package migration
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// questionReviewV16 v16 版本题库表新增的审核列，不要修改
+type questionReviewV16 struct {
+	ID       int    `gorm:"primaryKey;column:id"`
+	Status   string `gorm:"column:status;size:16;default:draft;index:idx_questionbank_status"`
+	Reviewer string `gorm:"column:reviewer;size:255"`
+}
+
+func (questionReviewV16) TableName() string { return "questionbank" }
+
+// questionReviewCommentV16 v16 版本题目审核意见表结构快照，不要修改
+type questionReviewCommentV16 struct {
+	ID         int       `gorm:"primaryKey;column:id"`
+	QuestionID int       `gorm:"column:question_id;index:idx_questionreviewcomment_question_id"`
+	Action     string    `gorm:"column:action;size:16"`
+	Status     string    `gorm:"column:status;size:16"`
+	Author     string    `gorm:"column:author;size:255"`
+	Content    string    `gorm:"column:content;type:text"`
+	CreatedAt  time.Time `gorm:"column:created_at;type:datetime"`
+}
+
+func (questionReviewCommentV16) TableName() string { return "questionreviewcomment" }
+
+// questionReview 题库表增加审核状态和审核员列，新增审核意见表。
+// 迁移前已有的题目已经在使用，全部记为审核通过，之后新增和导入的题目从草稿开始
+var questionReview = Migration{
+	Version: 16,
+	Name:    "question_review",
+	Up: func(tx *gorm.DB) error {
+		migrator := tx.Migrator()
+		if !migrator.HasColumn(&questionReviewV16{}, "Status") {
+			if err := migrator.AddColumn(&questionReviewV16{}, "Status"); err != nil {
+				return err
+			}
+			if err := tx.Model(&questionReviewV16{}).Where("1 = 1").Update("status", "approved").Error; err != nil {
+				return err
+			}
+		}
+		if !migrator.HasIndex(&questionReviewV16{}, "idx_questionbank_status") {
+			if err := migrator.CreateIndex(&questionReviewV16{}, "idx_questionbank_status"); err != nil {
+				return err
+			}
+		}
+		if !migrator.HasColumn(&questionReviewV16{}, "Reviewer") {
+			if err := migrator.AddColumn(&questionReviewV16{}, "Reviewer"); err != nil {
+				return err
+			}
+		}
+		return ensureTable(tx, &questionReviewCommentV16{})
+	},
+	Down: func(tx *gorm.DB) error {
+		migrator := tx.Migrator()
+		if err := migrator.DropTable(&questionReviewCommentV16{}); err != nil {
+			return err
+		}
+		if migrator.HasIndex(&questionReviewV16{}, "idx_questionbank_status") {
+			if err := migrator.DropIndex(&questionReviewV16{}, "idx_questionbank_status"); err != nil {
+				return err
+			}
+		}
+		for _, field := range []string{"Status", "Reviewer"} {
+			if !migrator.HasColumn(&questionReviewV16{}, field) {
+				continue
+			}
+			if err := migrator.DropColumn(&questionReviewV16{}, field); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
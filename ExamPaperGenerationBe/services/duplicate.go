@@ -0,0 +1,321 @@
+package services
+
+import (
+	"encoding/binary"
+	"graduation/entity"
+	"hash/fnv"
+	"math"
+	"sort"
+	"unicode"
+)
+
+// 题目查重参数。相似度为题干字符二元组集合的 Jaccard 系数，
+// 用 MinHash 签名和 LSH 分桶快速找出候选题目，再对候选题目计算准确的相似度
+const (
+	DefaultDuplicateThreshold = 0.8 // 相似度达到该值视为重复题目
+	duplicateShingleSize      = 2   // 中文题目按相邻两字切分
+	minHashBands              = 32
+	minHashRows               = 4
+	minHashSize               = minHashBands * minHashRows
+)
+
+// minHashSeeds MinHash 各哈希函数的种子，固定生成保证签名可重复
+var minHashSeeds = func() [minHashSize]uint64 {
+	var seeds [minHashSize]uint64
+	x := uint64(0x2545F4914F6CDD1D)
+	for i := range seeds {
+		x = splitMix64(x)
+		seeds[i] = x
+	}
+	return seeds
+}()
+
+// splitMix64 64 位整数混淆函数
+func splitMix64(x uint64) uint64 {
+	x += 0x9E3779B97F4A7C15
+	x = (x ^ (x >> 30)) * 0xBF58476D1CE4E5B9
+	x = (x ^ (x >> 27)) * 0x94D049BB133111EB
+	return x ^ (x >> 31)
+}
+
+// normalizeQuestionText 去掉空白和标点并转为小写，只保留文字、字母和数字，
+// 使 "8086 有几个段寄存器？" 与 "8086有几个段寄存器" 得到相同的结果
+func normalizeQuestionText(text string) []rune {
+	var out []rune
+	for _, r := range text {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			out = append(out, unicode.ToLower(r))
+		}
+	}
+	return out
+}
+
+// questionShingles 切分题干并返回各片段的哈希值集合，题干短于片段长度时整个题干作为一个片段
+func questionShingles(text string) map[uint64]struct{} {
+	runes := normalizeQuestionText(text)
+	shingles := make(map[uint64]struct{})
+	if len(runes) == 0 {
+		return shingles
+	}
+	add := func(s []rune) {
+		h := fnv.New64a()
+		h.Write([]byte(string(s)))
+		shingles[h.Sum64()] = struct{}{}
+	}
+	if len(runes) < duplicateShingleSize {
+		add(runes)
+		return shingles
+	}
+	for i := 0; i+duplicateShingleSize <= len(runes); i++ {
+		add(runes[i : i+duplicateShingleSize])
+	}
+	return shingles
+}
+
+// minHashSignature 计算片段集合的 MinHash 签名
+func minHashSignature(shingles map[uint64]struct{}) [minHashSize]uint64 {
+	var sig [minHashSize]uint64
+	for i := range sig {
+		sig[i] = math.MaxUint64
+	}
+	for s := range shingles {
+		for i, seed := range minHashSeeds {
+			if h := splitMix64(s ^ seed); h < sig[i] {
+				sig[i] = h
+			}
+		}
+	}
+	return sig
+}
+
+// bandKeys 把签名分成若干段，每段生成一个分桶键，两道题只要有一段相同就成为候选
+func bandKeys(sig [minHashSize]uint64) [minHashBands]uint64 {
+	var keys [minHashBands]uint64
+	buf := make([]byte, 8)
+	for b := range keys {
+		h := fnv.New64a()
+		binary.LittleEndian.PutUint64(buf, uint64(b))
+		h.Write(buf)
+		for _, v := range sig[b*minHashRows : (b+1)*minHashRows] {
+			binary.LittleEndian.PutUint64(buf, v)
+			h.Write(buf)
+		}
+		keys[b] = h.Sum64()
+	}
+	return keys
+}
+
+// shingleJaccard 计算两个片段集合的 Jaccard 系数
+func shingleJaccard(a, b map[uint64]struct{}) float64 {
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	intersection := 0
+	for s := range a {
+		if _, ok := b[s]; ok {
+			intersection++
+		}
+	}
+	union := len(a) + len(b) - intersection
+	if union == 0 {
+		return 0
+	}
+	return float64(intersection) / float64(union)
+}
+
+// DuplicateMatch 与某道题相似的题目
+type DuplicateMatch struct {
+	ID         int     `json:"id"`
+	Topic      string  `json:"topic"`
+	Similarity float64 `json:"similarity"`
+}
+
+// DuplicatePair 题库中相似的两道题，IDA 小于 IDB
+type DuplicatePair struct {
+	IDA        int     `json:"id_a"`
+	IDB        int     `json:"id_b"`
+	Similarity float64 `json:"similarity"`
+}
+
+type duplicateDoc struct {
+	topic    string
+	shingles map[uint64]struct{}
+	bands    [minHashBands]uint64
+}
+
+// DuplicateDetector 题目查重索引，不是并发安全的
+type DuplicateDetector struct {
+	threshold float64
+	ids       []int
+	docs      map[int]*duplicateDoc
+	buckets   map[uint64][]int
+}
+
+// NewDuplicateDetector 创建查重索引，threshold 不在 (0, 1] 范围内时使用默认阈值
+func NewDuplicateDetector(threshold float64) *DuplicateDetector {
+	if threshold <= 0 || threshold > 1 {
+		threshold = DefaultDuplicateThreshold
+	}
+	return &DuplicateDetector{
+		threshold: threshold,
+		docs:      make(map[int]*duplicateDoc),
+		buckets:   make(map[uint64][]int),
+	}
+}
+
+// NewQuestionDuplicateDetector 使用题库中的题目创建查重索引
+func NewQuestionDuplicateDetector(questions []entity.QuestionBank, threshold float64) *DuplicateDetector {
+	d := NewDuplicateDetector(threshold)
+	for _, q := range questions {
+		d.Add(q.ID, q.Topic)
+	}
+	return d
+}
+
+// Threshold 返回查重阈值
+func (d *DuplicateDetector) Threshold() float64 {
+	return d.threshold
+}
+
+func newDuplicateDoc(topic string) *duplicateDoc {
+	shingles := questionShingles(topic)
+	doc := &duplicateDoc{topic: topic, shingles: shingles}
+	if len(shingles) > 0 {
+		doc.bands = bandKeys(minHashSignature(shingles))
+	}
+	return doc
+}
+
+// Add 把题目加入索引，题干为空的题目不参与查重，重复添加同一 ID 时忽略
+func (d *DuplicateDetector) Add(id int, topic string) {
+	if _, ok := d.docs[id]; ok {
+		return
+	}
+	doc := newDuplicateDoc(topic)
+	if len(doc.shingles) == 0 {
+		return
+	}
+	d.ids = append(d.ids, id)
+	d.docs[id] = doc
+	for _, key := range doc.bands {
+		d.buckets[key] = append(d.buckets[key], id)
+	}
+}
+
+// candidates 返回与 doc 至少有一段签名相同的题目
+func (d *DuplicateDetector) candidates(doc *duplicateDoc) map[int]struct{} {
+	found := make(map[int]struct{})
+	for _, key := range doc.bands {
+		for _, id := range d.buckets[key] {
+			found[id] = struct{}{}
+		}
+	}
+	return found
+}
+
+// Find 查找与题干相似度不低于阈值的题目，按相似度降序返回，excludeID 用于修改题目时排除自身
+func (d *DuplicateDetector) Find(topic string, excludeID int) []DuplicateMatch {
+	doc := newDuplicateDoc(topic)
+	matches := []DuplicateMatch{}
+	if len(doc.shingles) == 0 {
+		return matches
+	}
+	for id := range d.candidates(doc) {
+		if id == excludeID {
+			continue
+		}
+		other := d.docs[id]
+		if sim := shingleJaccard(doc.shingles, other.shingles); sim >= d.threshold {
+			matches = append(matches, DuplicateMatch{ID: id, Topic: other.topic, Similarity: roundSimilarity(sim)})
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Similarity != matches[j].Similarity {
+			return matches[i].Similarity > matches[j].Similarity
+		}
+		return matches[i].ID < matches[j].ID
+	})
+	return matches
+}
+
+// Pairs 返回索引中所有相似度不低于阈值的题目对，按 IDA、IDB 升序
+func (d *DuplicateDetector) Pairs() []DuplicatePair {
+	pairs := []DuplicatePair{}
+	for _, id := range d.ids {
+		doc := d.docs[id]
+		for other := range d.candidates(doc) {
+			if other <= id {
+				continue
+			}
+			if sim := shingleJaccard(doc.shingles, d.docs[other].shingles); sim >= d.threshold {
+				pairs = append(pairs, DuplicatePair{IDA: id, IDB: other, Similarity: roundSimilarity(sim)})
+			}
+		}
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i].IDA != pairs[j].IDA {
+			return pairs[i].IDA < pairs[j].IDA
+		}
+		return pairs[i].IDB < pairs[j].IDB
+	})
+	return pairs
+}
+
+// DuplicateGroup 互相重复的一组题目，相似关系可以传递
+type DuplicateGroup struct {
+	IDs           []int           `json:"ids"`
+	MaxSimilarity float64         `json:"max_similarity"`
+	Pairs         []DuplicatePair `json:"pairs"`
+}
+
+// GroupDuplicatePairs 把相似的题目对合并成组，组内 ID 升序，组按最小 ID 升序
+func GroupDuplicatePairs(pairs []DuplicatePair) []DuplicateGroup {
+	parent := make(map[int]int)
+	var find func(int) int
+	find = func(x int) int {
+		if p, ok := parent[x]; ok && p != x {
+			parent[x] = find(p)
+			return parent[x]
+		}
+		parent[x] = x
+		return x
+	}
+	for _, p := range pairs {
+		a, b := find(p.IDA), find(p.IDB)
+		if a != b {
+			parent[max(a, b)] = min(a, b)
+		}
+	}
+
+	byRoot := make(map[int]*DuplicateGroup)
+	var roots []int
+	for _, p := range pairs {
+		root := find(p.IDA)
+		g, ok := byRoot[root]
+		if !ok {
+			g = &DuplicateGroup{}
+			byRoot[root] = g
+			roots = append(roots, root)
+		}
+		g.Pairs = append(g.Pairs, p)
+		g.MaxSimilarity = max(g.MaxSimilarity, p.Similarity)
+	}
+	for id := range parent {
+		if g, ok := byRoot[find(id)]; ok {
+			g.IDs = append(g.IDs, id)
+		}
+	}
+	sort.Ints(roots)
+	groups := make([]DuplicateGroup, 0, len(roots))
+	for _, root := range roots {
+		g := byRoot[root]
+		sort.Ints(g.IDs)
+		groups = append(groups, *g)
+	}
+	return groups
+}
+
+// roundSimilarity 相似度保留三位小数
+func roundSimilarity(sim float64) float64 {
+	return math.Round(sim*1000) / 1000
+}
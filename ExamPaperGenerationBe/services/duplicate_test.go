@@ -0,0 +1,59 @@
+package services
+
+import (
+	"graduation/entity"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDuplicateDetector(t *testing.T) {
+	d := NewQuestionDuplicateDetector([]entity.QuestionBank{
+		{ID: 1, Topic: "8086 CPU 内部由哪两个部件组成？各自的功能是什么？"},
+		{ID: 2, Topic: "8259A 中断控制器的作用是什么"},
+		{ID: 3, Topic: "8086CPU内部由哪两个部件组成，各自的功能是什么"},
+		{ID: 4, Topic: "8086 CPU 内部由哪两个部件组成？各部件的功能是什么？"},
+		{ID: 5, Topic: ""},
+	}, 0)
+	require.Equal(t, DefaultDuplicateThreshold, d.Threshold())
+
+	matches := d.Find("8086 CPU内部由哪两个部件组成？各自的功能是什么？", 0)
+	require.Len(t, matches, 3)
+	require.Equal(t, 1.0, matches[0].Similarity)
+	require.Equal(t, []int{1, 3}, []int{min(matches[0].ID, matches[1].ID), max(matches[0].ID, matches[1].ID)})
+	require.Equal(t, 4, matches[2].ID)
+	require.Less(t, matches[2].Similarity, 1.0)
+	require.GreaterOrEqual(t, matches[2].Similarity, DefaultDuplicateThreshold)
+
+	// 修改题目时排除自身
+	matches = d.Find("8259A 中断控制器的作用是什么？", 2)
+	require.Empty(t, matches)
+	require.Empty(t, d.Find("", 0))
+	require.Empty(t, d.Find("简述 DMA 的工作过程", 0))
+
+	pairs := d.Pairs()
+	require.Equal(t, []DuplicatePair{
+		{IDA: 1, IDB: 3, Similarity: 1},
+		{IDA: 1, IDB: 4, Similarity: pairs[1].Similarity},
+		{IDA: 3, IDB: 4, Similarity: pairs[2].Similarity},
+	}, pairs)
+
+	groups := GroupDuplicatePairs(pairs)
+	require.Len(t, groups, 1)
+	require.Equal(t, []int{1, 3, 4}, groups[0].IDs)
+	require.Equal(t, 1.0, groups[0].MaxSimilarity)
+	require.Len(t, groups[0].Pairs, 3)
+}
+
+func TestGroupDuplicatePairs(t *testing.T) {
+	groups := GroupDuplicatePairs([]DuplicatePair{
+		{IDA: 5, IDB: 9, Similarity: 0.9},
+		{IDA: 1, IDB: 2, Similarity: 0.85},
+		{IDA: 2, IDB: 7, Similarity: 0.95},
+	})
+	require.Len(t, groups, 2)
+	require.Equal(t, []int{1, 2, 7}, groups[0].IDs)
+	require.Equal(t, 0.95, groups[0].MaxSimilarity)
+	require.Equal(t, []int{5, 9}, groups[1].IDs)
+	require.Empty(t, GroupDuplicatePairs(nil))
+}
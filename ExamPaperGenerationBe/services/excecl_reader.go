@@ -3,17 +3,16 @@ package services
 import (
 	"fmt"
 	"github.com/xuri/excelize/v2"
+	"graduation/entity"
 	"io"
-	"strconv"
+	"os"
+	"path/filepath"
+	"strings"
 )
 
-// 系统路径配置
-const (
-	WindowsBasePath = "D:/graduation/resources/"
-	LinuxBasePath   = "/graduation/resources/"
-	ImageSubPath    = "images/"
-	TableSubPath    = "tables/"
-)
+// TableSubPath 导入时单元格中引用的表格文件所在目录，位于 resources 下。
+// 图片不再按路径保存，统一写入媒体存储，见 media 包
+const TableSubPath = "tables/"
 
 // ExcelReader 用于读取Excel文件数据
 type ExcelReader struct {
@@ -52,73 +51,52 @@ func (er *ExcelReader) ReadExcel() ([]map[string]interface{}, error) {
 	return er.processRows(rows[1:]), nil
 }
 
-// processRows 处理行数据并转换为map切片
+// processRows 处理行数据并转换为map切片。各列保留单元格原文，由 ParseImportRows 校验和转换，
+// "row" 为数据在 Excel 中的行号（表头为第 1 行），跳过的空行也计入行号
 func (er *ExcelReader) processRows(rows [][]string) []map[string]interface{} {
 	var result []map[string]interface{}
 
-	for _, row := range rows {
-		if len(row) == 0 {
+	for i, row := range rows {
+		if isBlankRow(row) {
 			continue
 		}
 
-		record := make(map[string]interface{})
-
-		// 使用列索引常量提高可读性
-		const (
-			topicCol      = 0
-			materialCol   = 1
-			answerCol     = 2
-			typeCol       = 3
-			scoreCol      = 4
-			difficultyCol = 5
-			chapter1Col   = 6
-			chapter2Col   = 7
-			label1Col     = 8
-			label2Col     = 9
-			updateTimeCol = 10
-		)
-
-		// 处理各列数据
-		if len(row) > topicCol {
-			record["topic"] = row[topicCol]
-		}
-		if len(row) > materialCol {
-			record["topic_material_id"] = row[materialCol]
-		}
-		if len(row) > answerCol {
-			record["answer"] = row[answerCol]
-		}
-		if len(row) > typeCol {
-			record["topic_type"] = row[typeCol]
-		}
-		if len(row) > scoreCol {
-			if score, err := strconv.ParseFloat(row[scoreCol], 64); err == nil {
-				record["score"] = score
+		record := map[string]interface{}{"row": i + 2}
+		for col, name := range ImportColumns {
+			if len(row) > col {
+				record[name] = row[col]
 			}
 		}
-		if len(row) > difficultyCol {
-			if difficulty, err := strconv.ParseInt(row[difficultyCol], 10, 64); err == nil {
-				record["difficulty"] = difficulty
-			}
-		}
-		if len(row) > chapter1Col {
-			record["chapter_1"] = row[chapter1Col]
-		}
-		if len(row) > chapter2Col {
-			record["chapter_2"] = row[chapter2Col]
-		}
-		if len(row) > label1Col {
-			record["label_1"] = row[label1Col]
-		}
-		if len(row) > label2Col {
-			record["label_2"] = row[label2Col]
-		}
-		if len(row) > updateTimeCol {
-			record["update_time"] = row[updateTimeCol]
-		}
 
 		result = append(result, record)
 	}
 
 	return result
 }
+
+// isBlankRow 判断一行是否没有任何内容，带格式的空行也会被 GetRows 读出
+func isBlankRow(row []string) bool {
+	for _, cell := range row {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// ReadTableCell 解析 Excel 中的表格列，单元格可以直接填写表格 JSON，
+// 也可以填写 resources/tables 目录下的 JSON 文件名，空单元格返回 nil
+func ReadTableCell(cell string) (*entity.ContentTable, error) {
+	cell = strings.TrimSpace(cell)
+	if cell == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(cell, "{") {
+		data, err := os.ReadFile(filepath.Join("resources", TableSubPath, filepath.Base(cell)))
+		if err != nil {
+			return nil, fmt.Errorf("读取表格文件失败: %w", err)
+		}
+		cell = string(data)
+	}
+	return entity.ParseContentTable(cell)
+}
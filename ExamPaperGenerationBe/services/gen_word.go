@@ -13,14 +13,19 @@ import (
 	"time"
 )
 
-type WordGenerator struct{}
+type WordGenerator struct {
+	history mapper.HistoryRepository
+}
 
-func NewWordGenerator() *WordGenerator {
-	return &WordGenerator{}
+func NewWordGenerator(history mapper.HistoryRepository) *WordGenerator {
+	return &WordGenerator{history: history}
 }
 
+// GenerateTestPaper 生成试卷并记录出题历史，attachments 和 materials 为题目的附件和引用的材料，一并保存到出题记录中
 func (wg *WordGenerator) GenerateTestPaper(
 	questions []entity.QuestionBank,
+	attachments map[int][]entity.QuestionAttachment,
+	materials map[int]entity.QuestionMaterial,
 	paperName string,
 	username string,
 ) (string, error) {
@@ -30,7 +35,7 @@ func (wg *WordGenerator) GenerateTestPaper(
 		return "", fmt.Errorf("生成文档失败: %w", err)
 	}
 	// 记录生成历史
-	if err := wg.logGenerationHistory(questions, paperName, username); err != nil {
+	if err := wg.logGenerationHistory(questions, attachments, materials, paperName, username); err != nil {
 		os.Remove(filePath) // 清理文件
 		return "", fmt.Errorf("记录历史失败: %w", err)
 	}
@@ -54,7 +59,7 @@ func (wg *WordGenerator) generateWordFile(questions []entity.QuestionBank, paper
 	for i, q := range questions {
 		totalScore += q.Score
 		tmplData.Questions = append(tmplData.Questions,
-			template.HTML(fmt.Sprintf("%d、%s<w:br />", i+1, q.Topic)))
+			template.HTML(fmt.Sprintf("%d、%s<w:br />", i+1, q.DisplayTopic())))
 	}
 
 	tmplData.TotalScore = fmt.Sprintf("%.1f", totalScore)
@@ -91,6 +96,8 @@ func (wg *WordGenerator) GetFile() *os.File {
 // ================== 历史记录处理 ==================
 func (wg *WordGenerator) logGenerationHistory(
 	questions []entity.QuestionBank,
+	attachments map[int][]entity.QuestionAttachment,
+	materials map[int]entity.QuestionMaterial,
 	paperName string,
 	username string,
 ) error {
@@ -114,7 +121,7 @@ func (wg *WordGenerator) logGenerationHistory(
 			TestPaperUID:    paperUID,
 			TestPaperName:   paperName,
 			QuestionBankID:  q.ID,
-			Topic:           q.Topic,
+			Topic:           q.DisplayTopic(),
 			TopicMaterialID: q.TopicMaterialID,
 			Answer:          q.Answer,
 			TopicType:       q.TopicType,
@@ -125,16 +132,16 @@ func (wg *WordGenerator) logGenerationHistory(
 			Label1:          q.Label1,
 			Label2:          q.Label2,
 			UpdateTime:      now,
+			TopicImagePath:  q.TopicImagePath,
+			TopicTableJSON:  q.TopicTable.JSON(),
 		}
 	}
-	inter1 := mapper.NewQuestionGenHistoryMapper()
-	insertCount1, err := inter1.InsertQuestionGenHistories(questionHistories)
+	insertCount1, err := wg.history.InsertQuestionGenHistories(questionHistories)
 	if err != nil {
 		return err
 	}
 
-	inter2 := mapper.NewTestPaperGenHistoryGormMapper()
-	insertCount2, err := inter2.InsertTestPaperGenHistory(paperHistory)
+	insertCount2, err := wg.history.InsertTestPaperGenHistory(paperHistory)
 	if err != nil {
 		return err
 	}
@@ -187,7 +194,7 @@ func main() {
 	}
 
 	// 执行生成
-	filePath, err := generator.GenerateTestPaper(questions, "期中试卷", "teacher_zhang")
+	filePath, err := generator.GenerateTestPaper(questions, nil, nil, "期中试卷", "teacher_zhang")
 	if err != nil {
 		panic(err)
 	}
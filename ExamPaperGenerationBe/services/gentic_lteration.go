@@ -122,7 +122,14 @@ func (gi *GeneticIteration) Run() []entity.QuestionBank {
 		population = newPopulation
 	}
 
-	return bestSolution
+	// 材料题整组出现在试卷中，只从未排除的题目中补齐
+	var candidates []entity.QuestionBank
+	for _, q := range gi.questions {
+		if !contains(gi.excludedQuestionIds, q.ID) {
+			candidates = append(candidates, q)
+		}
+	}
+	return KeepMaterialGroups(bestSolution, candidates, gi.selectedQuestionIds, TARGET_TOTAL_SCORE)
 }
 
 // initializePopulation 初始化种群
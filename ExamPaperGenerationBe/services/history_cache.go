@@ -2,7 +2,6 @@ package services
 
 import (
 	"fmt"
-	"graduation/entity"
 	"graduation/mapper"
 	"sync"
 	"time"
@@ -16,36 +15,29 @@ var (
 	twoYears           = -2 * 365 * 24 * time.Hour
 )
 
-// 初始化时自动刷新缓存
-func init() {
-	refreshHistoryCache()
-	go autoRefreshCache()
+// StartHistoryCache 加载历史缓存并启动定时刷新，需在数据库连接建立后调用
+func StartHistoryCache(history mapper.HistoryRepository) {
+	refreshHistoryCache(history)
+	go autoRefreshCache(history)
 }
 
-func autoRefreshCache() {
+func autoRefreshCache(history mapper.HistoryRepository) {
 	ticker := time.NewTicker(cacheExpiration)
 	defer ticker.Stop()
 
 	for range ticker.C {
-		refreshHistoryCache()
+		refreshHistoryCache(history)
 	}
 }
 
-func refreshHistoryCache() {
+func refreshHistoryCache(history mapper.HistoryRepository) {
 	fmt.Println("开始刷新历史缓存...")
 	defer fmt.Println("缓存刷新完成，最后更新时间:", lastRefreshTime)
-	// 查询两年内的试卷UID
-	var paperUIDs []string
-	mapper.DB.Model(&entity.TestPaperGenHistory{}).
-		Where("update_time >= ?", time.Now().Add(twoYears)).
-		Pluck("test_paper_uid", &paperUIDs)
-
-	// 查询对应的题目ID
-	var questionIDs []int
-	if len(paperUIDs) > 0 {
-		mapper.DB.Model(&entity.QuestionGenHistory{}).
-			Where("test_paper_uid IN ?", paperUIDs).
-			Pluck("question_bank_id", &questionIDs)
+	// 查询两年内的试卷使用过的题目ID
+	questionIDs, err := history.GetQuestionIdsUsedSince(time.Now().Add(twoYears))
+	if err != nil {
+		fmt.Println("刷新历史缓存失败:", err)
+		return
 	}
 
 	// 更新缓存
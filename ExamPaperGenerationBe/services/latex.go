@@ -0,0 +1,585 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// MathSegment 题目文字按公式切分后的一段
+type MathSegment struct {
+	Text    string // 普通文字，或公式的 LaTeX 源码（不含定界符）
+	Formula bool   // 是否为公式
+	Display bool   // 是否为行间公式
+}
+
+// mathDelimiters 公式定界符，先匹配较长的 $$
+var mathDelimiters = []struct {
+	open, close string
+	display     bool
+}{
+	{"$$", "$$", true},
+	{"$", "$", false},
+	{`\[`, `\]`, true},
+	{`\(`, `\)`, false},
+}
+
+// SplitMath 把文字切分为普通文字和公式。行内公式写作 $...$ 或 \(...\)，
+// 行间公式写作 $$...$$ 或 \[...\]，普通文字中的 $ 需要写成 \$
+func SplitMath(text string) ([]MathSegment, error) {
+	var segments []MathSegment
+	var plain strings.Builder
+	flush := func() {
+		if plain.Len() > 0 {
+			segments = append(segments, MathSegment{Text: plain.String()})
+			plain.Reset()
+		}
+	}
+	for i := 0; i < len(text); {
+		if strings.HasPrefix(text[i:], `\$`) {
+			plain.WriteByte('$')
+			i += 2
+			continue
+		}
+		matched := false
+		for _, d := range mathDelimiters {
+			if !strings.HasPrefix(text[i:], d.open) {
+				continue
+			}
+			start := i + len(d.open)
+			end := findMathClose(text, start, d.close)
+			if end < 0 {
+				return nil, fmt.Errorf("公式 %s 缺少结束符 %s（普通文字中的 $ 请写成 \\$）", d.open, d.close)
+			}
+			src := strings.TrimSpace(text[start:end])
+			if src == "" {
+				return nil, fmt.Errorf("公式 %s%s 内容为空", d.open, d.close)
+			}
+			flush()
+			segments = append(segments, MathSegment{Text: src, Formula: true, Display: d.display})
+			i = end + len(d.close)
+			matched = true
+			break
+		}
+		if !matched {
+			plain.WriteByte(text[i])
+			i++
+		}
+	}
+	flush()
+	return segments, nil
+}
+
+// findMathClose 从 start 开始查找公式结束符，跳过反斜杠转义的字符，找不到时返回 -1
+func findMathClose(text string, start int, close string) int {
+	for j := start; j < len(text); {
+		if strings.HasPrefix(text[j:], close) {
+			return j
+		}
+		if text[j] == '\\' {
+			j += 2
+			continue
+		}
+		j++
+	}
+	return -1
+}
+
+// HasMath 判断文字中是否含有公式，格式错误的文字按不含公式处理
+func HasMath(text string) bool {
+	if !strings.ContainsAny(text, `$\`) {
+		return false
+	}
+	segments, err := SplitMath(text)
+	if err != nil {
+		return false
+	}
+	for _, s := range segments {
+		if s.Formula {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateLatex 检查文字中的全部公式能否解析，出错时返回第一个错误
+func ValidateLatex(text string) error {
+	segments, err := SplitMath(text)
+	if err != nil {
+		return err
+	}
+	for _, s := range segments {
+		if !s.Formula {
+			continue
+		}
+		if _, err := ParseLatex(s.Text); err != nil {
+			return fmt.Errorf("公式 %q: %w", s.Text, err)
+		}
+	}
+	return nil
+}
+
+// mathNode 公式语法树的节点
+type mathNode interface {
+	mathNode()
+}
+
+// mathRow 按顺序排列的一组节点
+type mathRow []mathNode
+
+// mathTokenKind 公式中单个符号的类别
+type mathTokenKind int
+
+const (
+	mathIdent    mathTokenKind = iota // 变量，斜体
+	mathNumber                        // 数字
+	mathOperator                      // 运算符和括号
+	mathFunction                      // sin、log 等函数名，正体
+	mathText                          // \text 中的文字，正体
+	mathSpace                         // \, \quad 等空白
+)
+
+// mathToken 单个符号
+type mathToken struct {
+	kind mathTokenKind
+	text string
+}
+
+// mathGroup 花括号分组
+type mathGroup struct{ body mathRow }
+
+// mathFrac 分式
+type mathFrac struct{ num, den mathRow }
+
+// mathSqrt 根式，degree 为空时是平方根
+type mathSqrt struct{ degree, body mathRow }
+
+// mathScript 带上标和（或）下标的节点
+type mathScript struct {
+	base     mathNode
+	sub, sup mathRow
+}
+
+// mathAccent 上划线、下划线和 \hat 等字符上方的记号
+type mathAccent struct {
+	mark  string
+	under bool // 标记在下方，只用于 \underline
+	line  bool // 横线（\overline、\underline），Word 中用 bar 排版
+	body  mathRow
+}
+
+// mathFenced \left ... \right 包围的可伸缩括号，"." 表示省略该侧括号
+type mathFenced struct {
+	open, close string
+	body        mathRow
+}
+
+func (mathToken) mathNode()  {}
+func (mathGroup) mathNode()  {}
+func (mathFrac) mathNode()   {}
+func (mathSqrt) mathNode()   {}
+func (mathScript) mathNode() {}
+func (mathAccent) mathNode() {}
+func (mathFenced) mathNode() {}
+
+// latexSymbols 支持的符号命令
+var latexSymbols = map[string]mathToken{
+	// 希腊字母
+	"alpha": {mathIdent, "α"}, "beta": {mathIdent, "β"}, "gamma": {mathIdent, "γ"}, "delta": {mathIdent, "δ"},
+	"epsilon": {mathIdent, "ε"}, "varepsilon": {mathIdent, "ε"}, "zeta": {mathIdent, "ζ"}, "eta": {mathIdent, "η"},
+	"theta": {mathIdent, "θ"}, "iota": {mathIdent, "ι"}, "kappa": {mathIdent, "κ"}, "lambda": {mathIdent, "λ"},
+	"mu": {mathIdent, "μ"}, "nu": {mathIdent, "ν"}, "xi": {mathIdent, "ξ"}, "pi": {mathIdent, "π"},
+	"rho": {mathIdent, "ρ"}, "sigma": {mathIdent, "σ"}, "tau": {mathIdent, "τ"}, "upsilon": {mathIdent, "υ"},
+	"phi": {mathIdent, "φ"}, "varphi": {mathIdent, "φ"}, "chi": {mathIdent, "χ"}, "psi": {mathIdent, "ψ"},
+	"omega": {mathIdent, "ω"}, "Gamma": {mathIdent, "Γ"}, "Delta": {mathIdent, "Δ"}, "Theta": {mathIdent, "Θ"},
+	"Lambda": {mathIdent, "Λ"}, "Xi": {mathIdent, "Ξ"}, "Pi": {mathIdent, "Π"}, "Sigma": {mathIdent, "Σ"},
+	"Phi": {mathIdent, "Φ"}, "Psi": {mathIdent, "Ψ"}, "Omega": {mathIdent, "Ω"},
+	// 运算符和关系符
+	"times": {mathOperator, "×"}, "div": {mathOperator, "÷"}, "cdot": {mathOperator, "⋅"}, "pm": {mathOperator, "±"},
+	"mp": {mathOperator, "∓"}, "leq": {mathOperator, "≤"}, "le": {mathOperator, "≤"}, "geq": {mathOperator, "≥"},
+	"ge": {mathOperator, "≥"}, "neq": {mathOperator, "≠"}, "ne": {mathOperator, "≠"}, "approx": {mathOperator, "≈"},
+	"equiv": {mathOperator, "≡"}, "ll": {mathOperator, "≪"}, "gg": {mathOperator, "≫"},
+	"sum": {mathOperator, "∑"}, "prod": {mathOperator, "∏"}, "int": {mathOperator, "∫"},
+	"to": {mathOperator, "→"}, "rightarrow": {mathOperator, "→"}, "leftarrow": {mathOperator, "←"},
+	"Rightarrow": {mathOperator, "⇒"}, "Leftarrow": {mathOperator, "⇐"}, "Leftrightarrow": {mathOperator, "⇔"},
+	"leftrightarrow": {mathOperator, "↔"}, "oplus": {mathOperator, "⊕"}, "otimes": {mathOperator, "⊗"},
+	"odot": {mathOperator, "⊙"}, "wedge": {mathOperator, "∧"}, "land": {mathOperator, "∧"}, "vee": {mathOperator, "∨"},
+	"lor": {mathOperator, "∨"}, "neg": {mathOperator, "¬"}, "lnot": {mathOperator, "¬"}, "in": {mathOperator, "∈"},
+	"notin": {mathOperator, "∉"}, "subset": {mathOperator, "⊂"}, "subseteq": {mathOperator, "⊆"},
+	"cup": {mathOperator, "∪"}, "cap": {mathOperator, "∩"}, "forall": {mathOperator, "∀"}, "exists": {mathOperator, "∃"},
+	"ldots": {mathOperator, "…"}, "cdots": {mathOperator, "⋯"}, "circ": {mathOperator, "∘"},
+	"langle": {mathOperator, "⟨"}, "rangle": {mathOperator, "⟩"}, "lfloor": {mathOperator, "⌊"},
+	"rfloor": {mathOperator, "⌋"}, "lceil": {mathOperator, "⌈"}, "rceil": {mathOperator, "⌉"},
+	"infty": {mathIdent, "∞"}, "partial": {mathIdent, "∂"}, "emptyset": {mathIdent, "∅"},
+	// 函数名
+	"sin": {mathFunction, "sin"}, "cos": {mathFunction, "cos"}, "tan": {mathFunction, "tan"}, "cot": {mathFunction, "cot"},
+	"log": {mathFunction, "log"}, "ln": {mathFunction, "ln"}, "lg": {mathFunction, "lg"}, "exp": {mathFunction, "exp"},
+	"max": {mathFunction, "max"}, "min": {mathFunction, "min"}, "lim": {mathFunction, "lim"}, "mod": {mathFunction, "mod"},
+	// 空白
+	"quad": {mathSpace, "\u2003"}, "qquad": {mathSpace, "\u2003\u2003"},
+}
+
+// latexEscapes 反斜杠加单个字符的命令
+var latexEscapes = map[rune]mathToken{
+	',': {mathSpace, "\u2009"}, ';': {mathSpace, "\u2005"}, ':': {mathSpace, "\u2005"}, ' ': {mathSpace, " "},
+	'!': {mathSpace, ""}, '{': {mathOperator, "{"}, '}': {mathOperator, "}"}, '%': {mathOperator, "%"},
+	'$': {mathOperator, "$"}, '#': {mathOperator, "#"}, '&': {mathOperator, "&"}, '_': {mathOperator, "_"},
+	'|': {mathOperator, "‖"},
+}
+
+// latexAccents 字符上方（下方）的记号
+var latexAccents = map[string]mathAccent{
+	"overline":  {mark: "‾", line: true},
+	"underline": {mark: "_", under: true, line: true},
+	"bar":       {mark: "¯"},
+	"hat":       {mark: "^"},
+	"vec":       {mark: "→"},
+	"dot":       {mark: "˙"},
+	"tilde":     {mark: "~"},
+}
+
+// latexOperatorChars 直接输入的运算符和标点
+const latexOperatorChars = "+-=<>()[]|,;:!/*.'?@"
+
+// maxLatexDepth 公式嵌套的最大层数
+const maxLatexDepth = 32
+
+// latexParser 把 LaTeX 公式源码解析为语法树，只支持试题中常用的一部分命令
+type latexParser struct {
+	src   []rune
+	pos   int
+	depth int
+}
+
+// ParseLatex 解析一段公式源码（不含 $ 等定界符）
+func ParseLatex(src string) (mathRow, error) {
+	p := &latexParser{src: []rune(src)}
+	row, err := p.parseRow("")
+	if err != nil {
+		return nil, err
+	}
+	if len(row) == 0 {
+		return nil, errors.New("公式为空")
+	}
+	return row, nil
+}
+
+func (p *latexParser) eof() bool { return p.pos >= len(p.src) }
+
+func (p *latexParser) peek() rune { return p.src[p.pos] }
+
+func (p *latexParser) skipSpace() {
+	for !p.eof() && unicode.IsSpace(p.peek()) {
+		p.pos++
+	}
+}
+
+// hasPrefix 判断剩余源码是否以 s 开头，命令后面不能紧跟字母（\right 不匹配 \rightarrow）
+func (p *latexParser) hasPrefix(s string) bool {
+	r := []rune(s)
+	if p.pos+len(r) > len(p.src) || string(p.src[p.pos:p.pos+len(r)]) != s {
+		return false
+	}
+	if strings.HasPrefix(s, `\`) && p.pos+len(r) < len(p.src) {
+		return !isLatexLetter(p.src[p.pos+len(r)])
+	}
+	return true
+}
+
+func isLatexLetter(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z'
+}
+
+// parseRow 解析到 closer（"}"、"]"、"\right"）之前，closer 为空时解析到结尾；closer 本身不消耗
+func (p *latexParser) parseRow(closer string) (mathRow, error) {
+	p.depth++
+	defer func() { p.depth-- }()
+	if p.depth > maxLatexDepth {
+		return nil, errors.New("公式嵌套层数过多")
+	}
+	var row mathRow
+	for {
+		p.skipSpace()
+		if p.eof() {
+			if closer != "" {
+				return nil, fmt.Errorf("缺少 %s", closer)
+			}
+			return row, nil
+		}
+		if closer != "" && p.hasPrefix(closer) {
+			return row, nil
+		}
+		switch c := p.peek(); {
+		case c == '}':
+			return nil, errors.New("多余的 }")
+		case p.hasPrefix(`\right`):
+			return nil, errors.New(`\right 缺少对应的 \left`)
+		case c == '^' || c == '_':
+			p.pos++
+			arg, err := p.parseArg()
+			if err != nil {
+				return nil, err
+			}
+			if row, err = attachScript(row, c == '^', arg); err != nil {
+				return nil, err
+			}
+		default:
+			node, err := p.parseAtom()
+			if err != nil {
+				return nil, err
+			}
+			if node != nil {
+				row = append(row, node)
+			}
+		}
+	}
+}
+
+// attachScript 把上标或下标挂到前一个节点上，没有前一个节点时以空分组为底数
+func attachScript(row mathRow, sup bool, arg mathRow) (mathRow, error) {
+	var script mathScript
+	if n := len(row); n > 0 {
+		if s, ok := row[n-1].(mathScript); ok {
+			script, row = s, row[:n-1]
+		} else {
+			script, row = mathScript{base: row[n-1]}, row[:n-1]
+		}
+	} else {
+		script.base = mathGroup{}
+	}
+	if sup {
+		if script.sup != nil {
+			return nil, errors.New("重复的上标，请用花括号分组")
+		}
+		script.sup = arg
+	} else {
+		if script.sub != nil {
+			return nil, errors.New("重复的下标，请用花括号分组")
+		}
+		script.sub = arg
+	}
+	return append(row, script), nil
+}
+
+// parseArg 解析命令或上下标的参数：花括号分组，或单个符号
+func (p *latexParser) parseArg() (mathRow, error) {
+	p.skipSpace()
+	if p.eof() || p.peek() == '}' {
+		return nil, errors.New("缺少参数")
+	}
+	if p.peek() == '{' {
+		p.pos++
+		row, err := p.parseRow("}")
+		if err != nil {
+			return nil, err
+		}
+		p.pos++
+		return row, nil
+	}
+	if c := p.peek(); c >= '0' && c <= '9' {
+		// 参数只取一位数字，与 LaTeX 一致：x^23 是 x 的平方再接 3
+		p.pos++
+		return mathRow{mathToken{mathNumber, string(c)}}, nil
+	}
+	if c := p.peek(); c == '^' || c == '_' {
+		return nil, errors.New("缺少参数")
+	}
+	node, err := p.parseAtom()
+	if err != nil {
+		return nil, err
+	}
+	if node == nil {
+		return nil, errors.New("缺少参数")
+	}
+	return mathRow{node}, nil
+}
+
+// parseAtom 解析一个符号、分组或命令
+func (p *latexParser) parseAtom() (mathNode, error) {
+	c := p.peek()
+	switch {
+	case c == '{':
+		p.pos++
+		row, err := p.parseRow("}")
+		if err != nil {
+			return nil, err
+		}
+		p.pos++
+		return mathGroup{body: row}, nil
+	case c == '\\':
+		return p.parseCommand()
+	case c >= '0' && c <= '9':
+		start := p.pos
+		for !p.eof() && (p.peek() >= '0' && p.peek() <= '9' || p.peek() == '.' && p.pos+1 < len(p.src) && unicode.IsDigit(p.src[p.pos+1])) {
+			p.pos++
+		}
+		return mathToken{mathNumber, string(p.src[start:p.pos])}, nil
+	case isLatexLetter(c):
+		p.pos++
+		return mathToken{mathIdent, string(c)}, nil
+	case c == '~':
+		p.pos++
+		return mathToken{mathSpace, " "}, nil
+	case c == '-':
+		p.pos++
+		return mathToken{mathOperator, "−"}, nil
+	case c == '\'':
+		p.pos++
+		return mathToken{mathOperator, "′"}, nil
+	case strings.ContainsRune(latexOperatorChars, c):
+		p.pos++
+		return mathToken{mathOperator, string(c)}, nil
+	case c <= unicode.MaxASCII:
+		// &、#、%、$、" 等其余 ASCII 字符都不支持，必须报错而不是返回空文字，否则调用方不会前进
+		return nil, fmt.Errorf("不支持的字符 %c", c)
+	default:
+		// 中文等其他字符按正体文字处理
+		start := p.pos
+		for !p.eof() && !unicode.IsSpace(p.peek()) && p.peek() > unicode.MaxASCII {
+			p.pos++
+		}
+		return mathToken{mathText, string(p.src[start:p.pos])}, nil
+	}
+}
+
+// parseCommand 解析反斜杠开头的命令
+func (p *latexParser) parseCommand() (mathNode, error) {
+	p.pos++ // 跳过反斜杠
+	if p.eof() {
+		return nil, errors.New("公式以 \\ 结尾")
+	}
+	if !isLatexLetter(p.peek()) {
+		c := p.peek()
+		p.pos++
+		if c == '\\' {
+			return nil, errors.New(`公式中不支持 \\ 换行，请拆成多个公式`)
+		}
+		if t, ok := latexEscapes[c]; ok {
+			return t, nil
+		}
+		return nil, fmt.Errorf("不支持的命令 \\%c", c)
+	}
+	start := p.pos
+	for !p.eof() && isLatexLetter(p.peek()) {
+		p.pos++
+	}
+	name := string(p.src[start:p.pos])
+	switch name {
+	case "frac", "dfrac", "tfrac":
+		num, err := p.parseArg()
+		if err != nil {
+			return nil, fmt.Errorf(`\%s 的分子: %w`, name, err)
+		}
+		den, err := p.parseArg()
+		if err != nil {
+			return nil, fmt.Errorf(`\%s 的分母: %w`, name, err)
+		}
+		return mathFrac{num: num, den: den}, nil
+	case "sqrt":
+		var degree mathRow
+		p.skipSpace()
+		if !p.eof() && p.peek() == '[' {
+			p.pos++
+			var err error
+			if degree, err = p.parseRow("]"); err != nil {
+				return nil, err
+			}
+			p.pos++
+		}
+		body, err := p.parseArg()
+		if err != nil {
+			return nil, fmt.Errorf(`\sqrt: %w`, err)
+		}
+		return mathSqrt{degree: degree, body: body}, nil
+	case "text", "textrm", "mathrm", "mbox", "operatorname":
+		text, err := p.parseRawArg()
+		if err != nil {
+			return nil, fmt.Errorf(`\%s: %w`, name, err)
+		}
+		if name == "operatorname" {
+			return mathToken{mathFunction, text}, nil
+		}
+		return mathToken{mathText, text}, nil
+	case "left":
+		open, err := p.parseDelimiter()
+		if err != nil {
+			return nil, fmt.Errorf(`\left: %w`, err)
+		}
+		body, err := p.parseRow(`\right`)
+		if err != nil {
+			return nil, err
+		}
+		p.pos += len(`\right`)
+		closeDelim, err := p.parseDelimiter()
+		if err != nil {
+			return nil, fmt.Errorf(`\right: %w`, err)
+		}
+		return mathFenced{open: open, close: closeDelim, body: body}, nil
+	}
+	if accent, ok := latexAccents[name]; ok {
+		body, err := p.parseArg()
+		if err != nil {
+			return nil, fmt.Errorf(`\%s: %w`, name, err)
+		}
+		accent.body = body
+		return accent, nil
+	}
+	if t, ok := latexSymbols[name]; ok {
+		return t, nil
+	}
+	return nil, fmt.Errorf("不支持的命令 \\%s", name)
+}
+
+// parseRawArg 读取花括号中的原始文字，用于 \text 等命令
+func (p *latexParser) parseRawArg() (string, error) {
+	p.skipSpace()
+	if p.eof() || p.peek() != '{' {
+		return "", errors.New("缺少 {")
+	}
+	p.pos++
+	start, level := p.pos, 0
+	for ; !p.eof(); p.pos++ {
+		switch p.peek() {
+		case '{':
+			level++
+		case '}':
+			if level == 0 {
+				text := string(p.src[start:p.pos])
+				p.pos++
+				return text, nil
+			}
+			level--
+		}
+	}
+	return "", errors.New("缺少 }")
+}
+
+// parseDelimiter 读取 \left、\right 后面的括号
+func (p *latexParser) parseDelimiter() (string, error) {
+	p.skipSpace()
+	if p.eof() {
+		return "", errors.New("缺少括号")
+	}
+	c := p.peek()
+	if c == '\\' {
+		for _, d := range []struct{ cmd, text string }{
+			{`\{`, "{"}, {`\}`, "}"}, {`\|`, "‖"}, {`\langle`, "⟨"}, {`\rangle`, "⟩"},
+			{`\lfloor`, "⌊"}, {`\rfloor`, "⌋"}, {`\lceil`, "⌈"}, {`\rceil`, "⌉"},
+		} {
+			if p.hasPrefix(d.cmd) {
+				p.pos += len([]rune(d.cmd))
+				return d.text, nil
+			}
+		}
+		return "", errors.New("不支持的括号")
+	}
+	if strings.ContainsRune("()[]|.", c) {
+		p.pos++
+		if c == '.' {
+			return "", nil
+		}
+		return string(c), nil
+	}
+	return "", fmt.Errorf("不支持的括号 %c", c)
+}
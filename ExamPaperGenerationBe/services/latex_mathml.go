@@ -0,0 +1,155 @@
+package services
+
+import (
+	"html"
+	"strings"
+)
+
+// RenderMathML 把文字中的公式转换为 MathML，普通文字做 HTML 转义，供前端预览；
+// 第二个返回值表示文字中是否含有公式
+func RenderMathML(text string) (string, bool, error) {
+	segments, err := SplitMath(text)
+	if err != nil {
+		return "", false, err
+	}
+	var sb strings.Builder
+	hasFormula := false
+	for _, s := range segments {
+		if !s.Formula {
+			sb.WriteString(html.EscapeString(s.Text))
+			continue
+		}
+		row, err := ParseLatex(s.Text)
+		if err != nil {
+			return "", false, err
+		}
+		hasFormula = true
+		sb.WriteString(LatexToMathML(row, s.Display))
+	}
+	return sb.String(), hasFormula, nil
+}
+
+// LatexToMathML 把解析后的公式输出为 <math> 元素
+func LatexToMathML(row mathRow, display bool) string {
+	var sb strings.Builder
+	sb.WriteString(`<math xmlns="http://www.w3.org/1998/Math/MathML"`)
+	if display {
+		sb.WriteString(` display="block"`)
+	}
+	sb.WriteString(">")
+	writeMathMLRow(&sb, row)
+	sb.WriteString("</math>")
+	return sb.String()
+}
+
+// writeMathMLRow 输出一组节点，多个节点时用 mrow 包起来
+func writeMathMLRow(sb *strings.Builder, row mathRow) {
+	if len(row) == 1 {
+		writeMathMLNode(sb, row[0])
+		return
+	}
+	sb.WriteString("<mrow>")
+	for _, n := range row {
+		writeMathMLNode(sb, n)
+	}
+	sb.WriteString("</mrow>")
+}
+
+func writeMathMLNode(sb *strings.Builder, node mathNode) {
+	switch n := node.(type) {
+	case mathToken:
+		text := html.EscapeString(n.text)
+		switch n.kind {
+		case mathIdent:
+			sb.WriteString("<mi>" + text + "</mi>")
+		case mathNumber:
+			sb.WriteString("<mn>" + text + "</mn>")
+		case mathOperator:
+			sb.WriteString("<mo>" + text + "</mo>")
+		case mathFunction:
+			sb.WriteString(`<mi mathvariant="normal">` + text + "</mi>")
+		case mathText:
+			sb.WriteString("<mtext>" + text + "</mtext>")
+		case mathSpace:
+			sb.WriteString(`<mspace width="` + mathMLSpaceWidth(n.text) + `"/>`)
+		}
+	case mathGroup:
+		sb.WriteString("<mrow>")
+		for _, c := range n.body {
+			writeMathMLNode(sb, c)
+		}
+		sb.WriteString("</mrow>")
+	case mathFrac:
+		sb.WriteString("<mfrac>")
+		writeMathMLRow(sb, n.num)
+		writeMathMLRow(sb, n.den)
+		sb.WriteString("</mfrac>")
+	case mathSqrt:
+		if len(n.degree) == 0 {
+			sb.WriteString("<msqrt>")
+			writeMathMLRow(sb, n.body)
+			sb.WriteString("</msqrt>")
+			return
+		}
+		sb.WriteString("<mroot>")
+		writeMathMLRow(sb, n.body)
+		writeMathMLRow(sb, n.degree)
+		sb.WriteString("</mroot>")
+	case mathScript:
+		tag := "msubsup"
+		if n.sub == nil {
+			tag = "msup"
+		} else if n.sup == nil {
+			tag = "msub"
+		}
+		sb.WriteString("<" + tag + ">")
+		writeMathMLNode(sb, n.base)
+		if n.sub != nil {
+			writeMathMLRow(sb, n.sub)
+		}
+		if n.sup != nil {
+			writeMathMLRow(sb, n.sup)
+		}
+		sb.WriteString("</" + tag + ">")
+	case mathAccent:
+		if n.under {
+			sb.WriteString(`<munder accentunder="true">`)
+			writeMathMLRow(sb, n.body)
+			sb.WriteString("<mo>" + html.EscapeString(n.mark) + "</mo></munder>")
+			return
+		}
+		sb.WriteString(`<mover accent="true">`)
+		writeMathMLRow(sb, n.body)
+		sb.WriteString("<mo>" + html.EscapeString(n.mark) + "</mo></mover>")
+	case mathFenced:
+		sb.WriteString("<mrow>")
+		if n.open != "" {
+			sb.WriteString(`<mo fence="true">` + html.EscapeString(n.open) + "</mo>")
+		}
+		for _, c := range n.body {
+			writeMathMLNode(sb, c)
+		}
+		if n.close != "" {
+			sb.WriteString(`<mo fence="true">` + html.EscapeString(n.close) + "</mo>")
+		}
+		sb.WriteString("</mrow>")
+	}
+}
+
+// mathMLSpaceWidth 空白命令对应的宽度
+func mathMLSpaceWidth(space string) string {
+	switch space {
+	case "":
+		return "0"
+	case "\u2009":
+		return "0.17em"
+	case "\u2005":
+		return "0.25em"
+	case " ":
+		return "0.3em"
+	case "\u2003":
+		return "1em"
+	default:
+		return "2em"
+	}
+}
@@ -0,0 +1,135 @@
+package services
+
+import (
+	"archive/zip"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitMath(t *testing.T) {
+	segments, err := SplitMath(`价格为 \$5，若 $x^2$ 且 $$\frac{a}{b}$$ 或 \(y\)`)
+	require.NoError(t, err)
+	require.Equal(t, []MathSegment{
+		{Text: "价格为 $5，若 "},
+		{Text: "x^2", Formula: true},
+		{Text: " 且 "},
+		{Text: `\frac{a}{b}`, Formula: true, Display: true},
+		{Text: " 或 "},
+		{Text: "y", Formula: true},
+	}, segments)
+
+	_, err = SplitMath("MOV DX, OFFSET MSG ; MSG DB 'Hello$'")
+	require.ErrorContains(t, err, `\$`)
+	_, err = SplitMath("$ $")
+	require.Error(t, err)
+	require.False(t, HasMath("8086 CPU"))
+	require.True(t, HasMath("$2^{10}$"))
+}
+
+func TestValidateLatex(t *testing.T) {
+	valid := []string{
+		`$2^{10}=1024$`,
+		`$Y=\overline{A \cdot B}+\bar{C}$`,
+		`$$T=\frac{1}{f}=\frac{1}{5\text{MHz}}=0.2\mu s$$`,
+		`$\sqrt[3]{x_1^2+x_{2}^{n}}$`,
+		`$\left( \sum_{i=0}^{n-1} a_i 2^i \right)$`,
+		`$\log_2 N \leq 16$`,
+		`$x'\,\times\quad y$`,
+	}
+	for _, text := range valid {
+		require.NoError(t, ValidateLatex(text), text)
+	}
+
+	invalid := map[string]string{
+		`$\frac{1}$`:            "分母",
+		`$x^{2$`:                "缺少 }",
+		`$x}$`:                  "多余的 }",
+		`$\foo$`:                `不支持的命令 \foo`,
+		`$x^2^3$`:               "重复的上标",
+		`$\left( x$`:            `缺少 \right`,
+		`$x \right)$`:           `\right 缺少对应的 \left`,
+		`$a \\ b$`:              "换行",
+		`$a & b$`:               "不支持的字符 &",
+		`$a " b$`:               `不支持的字符 "`,
+		"$a ` b$":               "不支持的字符 `",
+		`$$ U = I \cdot R $ $$`: "不支持的字符 $",
+		`$\sqrt$`:               "缺少参数",
+		`$\text x$`:             "缺少 {",
+		`$\left< x \right>$`:    "不支持的括号",
+	}
+	for text, msg := range invalid {
+		err := ValidateLatex(text)
+		require.Error(t, err, text)
+		require.Contains(t, err.Error(), msg, text)
+	}
+}
+
+// 不支持的 ASCII 字符直接出现在公式源码中时报错，不能卡住解析
+func TestParseLatexUnsupportedASCII(t *testing.T) {
+	for _, src := range []string{"$", `"`, "`", `a \cdot "b"`} {
+		_, err := ParseLatex(src)
+		require.Error(t, err, src)
+		require.Contains(t, err.Error(), "不支持的字符", src)
+	}
+}
+
+func TestRenderMathML(t *testing.T) {
+	html, ok, err := RenderMathML(`若 <a> 为 $\frac{x^2}{2}$`)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, `若 &lt;a&gt; 为 <math xmlns="http://www.w3.org/1998/Math/MathML">`+
+		`<mfrac><msup><mi>x</mi><mn>2</mn></msup><mn>2</mn></mfrac></math>`, html)
+
+	html, _, err = RenderMathML(`$$\overline{A}\sqrt[3]{8}\left(x\right.$$`)
+	require.NoError(t, err)
+	require.Equal(t, `<math xmlns="http://www.w3.org/1998/Math/MathML" display="block"><mrow>`+
+		`<mover accent="true"><mi>A</mi><mo>‾</mo></mover>`+
+		`<mroot><mn>8</mn><mn>3</mn></mroot>`+
+		`<mrow><mo fence="true">(</mo><mi>x</mi></mrow></mrow></math>`, html)
+
+	html, ok, err = RenderMathML("8086 CPU")
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.Equal(t, "8086 CPU", html)
+}
+
+func TestExportTestPaperWithFormula(t *testing.T) {
+	contents := "[SECTION_TITLE]二、填空题（本大题共1小题，每小题2.0分，共2.0分）[/SECTION_TITLE]\r\r" +
+		`1、（本题2分）主频为 $f$ 时，时钟周期 $$T=\frac{1}{f}$$ 约为______` + "\r\r[QUESTION_END]\r\r"
+	file, err := NewWordExporterGooxml(map[string]string{"total_score": "2", "total_count": "1", "contents": contents}).ExportTestPaper(1)
+	require.NoError(t, err)
+	defer file.Close()
+
+	xml := readDocumentXML(t, file.Name())
+	require.Equal(t, 1, strings.Count(xml, "<m:oMathPara "))
+	require.Contains(t, xml, "<m:f>")
+	require.Contains(t, xml, "约为______")
+	require.NotContains(t, xml, `\frac`)
+	// 公式前后的文字按原顺序排列
+	require.Less(t, strings.Index(xml, "时钟周期"), strings.Index(xml, "<m:oMathPara "))
+	require.Less(t, strings.Index(xml, "<m:oMathPara "), strings.Index(xml, "约为______"))
+}
+
+// readDocumentXML 读取 docx 中的 word/document.xml
+func readDocumentXML(t *testing.T, path string) string {
+	t.Helper()
+	r, err := zip.OpenReader(path)
+	require.NoError(t, err)
+	defer r.Close()
+	for _, f := range r.File {
+		if f.Name != "word/document.xml" {
+			continue
+		}
+		rc, err := f.Open()
+		require.NoError(t, err)
+		defer rc.Close()
+		data, err := io.ReadAll(rc)
+		require.NoError(t, err)
+		return string(data)
+	}
+	t.Fatal("word/document.xml not found")
+	return ""
+}
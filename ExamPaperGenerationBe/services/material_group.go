@@ -0,0 +1,102 @@
+package services
+
+import (
+	"graduation/entity"
+	"sort"
+)
+
+// GroupByMaterial 调整题目顺序，使共用同一材料的题目相邻：同组题目按 ID 升序排在该组第一道题的位置，
+// 其余题目保持原有顺序
+func GroupByMaterial(questions []entity.QuestionBank) []entity.QuestionBank {
+	groups := make(map[int][]entity.QuestionBank)
+	for _, q := range questions {
+		if q.TopicMaterialID != 0 {
+			groups[q.TopicMaterialID] = append(groups[q.TopicMaterialID], q)
+		}
+	}
+	result := make([]entity.QuestionBank, 0, len(questions))
+	emitted := make(map[int]bool)
+	for _, q := range questions {
+		if q.TopicMaterialID == 0 {
+			result = append(result, q)
+			continue
+		}
+		if emitted[q.TopicMaterialID] {
+			continue
+		}
+		emitted[q.TopicMaterialID] = true
+		group := groups[q.TopicMaterialID]
+		sort.SliceStable(group, func(i, j int) bool { return group[i].ID < group[j].ID })
+		result = append(result, group...)
+	}
+	return result
+}
+
+// KeepMaterialGroups 让共用同一材料的题目整组进入组卷结果：选中组内任一题目时，从候选题目 pool 中补齐同组其余题目；
+// 补齐后总分超过 maxScore 的组整组移出结果，包含手动选择题目（pinnedIDs）的组总是保留。maxScore <= 0 时不限制总分。
+// 返回的结果中同组题目相邻，顺序与 GroupByMaterial 一致
+func KeepMaterialGroups(selected, pool []entity.QuestionBank, pinnedIDs []int, maxScore float64) []entity.QuestionBank {
+	pinned := make(map[int]bool, len(pinnedIDs))
+	for _, id := range pinnedIDs {
+		pinned[id] = true
+	}
+	chosen := make(map[int]bool, len(selected))
+	totalScore := 0.0 // 不属于材料题的题目和已保留的组的总分
+	groupScores := make(map[int]float64)
+	for _, q := range selected {
+		chosen[q.ID] = true
+		if q.TopicMaterialID == 0 {
+			totalScore += q.Score
+		} else {
+			groupScores[q.TopicMaterialID] += q.Score
+		}
+	}
+	// 候选题目中每组尚未选中的题目
+	missing := make(map[int][]entity.QuestionBank)
+	for _, q := range pool {
+		if q.TopicMaterialID != 0 && !chosen[q.ID] {
+			chosen[q.ID] = true
+			missing[q.TopicMaterialID] = append(missing[q.TopicMaterialID], q)
+			groupScores[q.TopicMaterialID] += q.Score
+		}
+	}
+
+	// 按组在结果中第一次出现的顺序决定补齐还是移除，先出现的组优先占用分数
+	keep := make(map[int]bool)
+	decided := make(map[int]bool)
+	for _, q := range selected {
+		materialID := q.TopicMaterialID
+		if materialID == 0 || decided[materialID] {
+			continue
+		}
+		decided[materialID] = true
+		if maxScore <= 0 || totalScore+groupScores[materialID] <= maxScore || groupPinned(selected, materialID, pinned) {
+			keep[materialID] = true
+			totalScore += groupScores[materialID]
+		}
+	}
+
+	result := make([]entity.QuestionBank, 0, len(selected))
+	for _, q := range selected {
+		if q.TopicMaterialID == 0 || keep[q.TopicMaterialID] {
+			result = append(result, q)
+		}
+	}
+	for _, q := range selected {
+		if keep[q.TopicMaterialID] {
+			result = append(result, missing[q.TopicMaterialID]...)
+			delete(missing, q.TopicMaterialID)
+		}
+	}
+	return GroupByMaterial(result)
+}
+
+// groupPinned 判断材料组中是否有手动选择的题目
+func groupPinned(selected []entity.QuestionBank, materialID int, pinned map[int]bool) bool {
+	for _, q := range selected {
+		if q.TopicMaterialID == materialID && pinned[q.ID] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,78 @@
+package services
+
+import (
+	"graduation/entity"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func questionIDs(questions []entity.QuestionBank) []int {
+	ids := make([]int, 0, len(questions))
+	for _, q := range questions {
+		ids = append(ids, q.ID)
+	}
+	return ids
+}
+
+func TestGroupByMaterial(t *testing.T) {
+	questions := []entity.QuestionBank{
+		{ID: 5, TopicMaterialID: 2},
+		{ID: 1},
+		{ID: 3, TopicMaterialID: 1},
+		{ID: 4, TopicMaterialID: 2},
+		{ID: 2, TopicMaterialID: 1},
+	}
+	require.Equal(t, []int{4, 5, 1, 2, 3}, questionIDs(GroupByMaterial(questions)))
+}
+
+func TestKeepMaterialGroups(t *testing.T) {
+	pool := []entity.QuestionBank{
+		{ID: 1, Score: 10},
+		{ID: 2, Score: 5, TopicMaterialID: 1},
+		{ID: 3, Score: 5, TopicMaterialID: 1},
+		{ID: 4, Score: 5, TopicMaterialID: 1},
+		{ID: 5, Score: 20, TopicMaterialID: 2},
+		{ID: 6, Score: 20, TopicMaterialID: 2},
+	}
+
+	// 选中组内一道题时补齐整组
+	result := KeepMaterialGroups([]entity.QuestionBank{pool[0], pool[2]}, pool, nil, 0)
+	require.Equal(t, []int{1, 2, 3, 4}, questionIDs(result))
+
+	// 补齐后超过总分的组整组移除
+	result = KeepMaterialGroups([]entity.QuestionBank{pool[0], pool[2], pool[4]}, pool, nil, 30)
+	require.Equal(t, []int{1, 2, 3, 4}, questionIDs(result))
+
+	// 包含手动选择题目的组总是保留
+	result = KeepMaterialGroups([]entity.QuestionBank{pool[0], pool[4]}, pool, []int{5}, 30)
+	require.Equal(t, []int{1, 5, 6}, questionIDs(result))
+
+	// 不在候选题目中的同组题目不会补入
+	result = KeepMaterialGroups([]entity.QuestionBank{pool[1]}, pool[:3], nil, 0)
+	require.Equal(t, []int{2, 3}, questionIDs(result))
+}
+
+func TestRandomSelectTopicKeepsMaterialGroups(t *testing.T) {
+	var questions []entity.QuestionBank
+	for i := 1; i <= 6; i++ {
+		questions = append(questions, entity.QuestionBank{ID: i, Difficulty: 3})
+	}
+	questions = append(questions,
+		entity.QuestionBank{ID: 7, Difficulty: 3, TopicMaterialID: 1},
+		entity.QuestionBank{ID: 8, Difficulty: 3, TopicMaterialID: 1},
+		entity.QuestionBank{ID: 9, Difficulty: 3, TopicMaterialID: 1},
+	)
+	for i := 0; i < 20; i++ {
+		selected, ids := RandomSelectTopic(questions, 3, 4)
+		require.Len(t, selected, 4)
+		require.Len(t, ids, 4)
+		grouped := 0
+		for _, q := range selected {
+			if q.TopicMaterialID == 1 {
+				grouped++
+			}
+		}
+		require.Contains(t, []int{0, 3}, grouped)
+	}
+}
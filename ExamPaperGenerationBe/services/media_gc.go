@@ -0,0 +1,149 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"graduation/entity"
+	"graduation/mapper"
+	"graduation/media"
+	"log"
+	"sync"
+	"time"
+)
+
+// MediaCollector 清理媒体存储中不再被任何题目、选项、材料、附件、题目历史版本或生成历史引用的文件
+type MediaCollector struct {
+	store media.Store
+	refs  mapper.MediaRepository
+	grace time.Duration
+	now   func() time.Time
+	mu    sync.Mutex // 同一时间只执行一次清理
+}
+
+// NewMediaCollector 创建媒体文件垃圾回收器，grace 内保存过的文件不清理
+func NewMediaCollector(store media.Store, refs mapper.MediaRepository, grace time.Duration) *MediaCollector {
+	return &MediaCollector{store: store, refs: refs, grace: grace, now: time.Now}
+}
+
+// referenced 返回数据库中引用的全部对象键
+func (c *MediaCollector) referenced() (map[string]bool, error) {
+	refs, err := c.refs.GetMediaRefs()
+	if err != nil {
+		return nil, err
+	}
+	keys := make(map[string]bool, len(refs))
+	for _, ref := range refs {
+		if key, ok := media.ParseRef(ref); ok {
+			keys[key] = true
+		}
+	}
+	return keys, nil
+}
+
+// Sweep 检查存储中的全部文件，删除没有被引用的文件，返回删除的键
+func (c *MediaCollector) Sweep(ctx context.Context) ([]string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	objects, err := c.store.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	referenced, err := c.referenced()
+	if err != nil {
+		return nil, err
+	}
+	return media.Collect(ctx, c.store, objects, referenced, c.grace, c.now())
+}
+
+// Release 在删除题目或图片之后调用，refs 为删除前引用的图片路径，其中不再被引用的文件立即删除。
+// 旧版本的文件路径不在存储中，直接忽略
+func (c *MediaCollector) Release(ctx context.Context, refs ...string) ([]string, error) {
+	seen := make(map[string]bool)
+	for _, ref := range refs {
+		if key, ok := media.ParseRef(ref); ok {
+			seen[key] = true
+		}
+	}
+	if len(seen) == 0 {
+		return nil, nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	referenced, err := c.referenced()
+	if err != nil {
+		return nil, err
+	}
+	var candidates []media.Object
+	var errs []error
+	for key := range seen {
+		if referenced[key] {
+			continue
+		}
+		obj, err := c.store.Stat(ctx, key)
+		if errors.Is(err, media.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		candidates = append(candidates, obj)
+	}
+	deleted, err := media.Collect(ctx, c.store, candidates, referenced, c.grace, c.now())
+	return deleted, errors.Join(append(errs, err)...)
+}
+
+var (
+	collectorMu    sync.RWMutex
+	mediaCollector *MediaCollector
+)
+
+// StartMediaGC 设置全局的媒体文件垃圾回收器，interval 大于 0 时按间隔定期清理
+func StartMediaGC(collector *MediaCollector, interval time.Duration) {
+	collectorMu.Lock()
+	mediaCollector = collector
+	collectorMu.Unlock()
+	if interval > 0 {
+		go autoSweepMedia(collector, interval)
+	}
+}
+
+func autoSweepMedia(collector *MediaCollector, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		deleted, err := collector.Sweep(context.Background())
+		if err != nil {
+			log.Printf("Error collecting unused media: %v", err)
+		}
+		if len(deleted) > 0 {
+			log.Printf("Deleted %d unused media files", len(deleted))
+		}
+	}
+}
+
+// ReleaseMedia 使用全局垃圾回收器释放 refs 中不再被引用的文件，未调用 StartMediaGC 时不做任何事
+func ReleaseMedia(ctx context.Context, refs ...string) {
+	collectorMu.RLock()
+	collector := mediaCollector
+	collectorMu.RUnlock()
+	if collector == nil {
+		return
+	}
+	if _, err := collector.Release(ctx, refs...); err != nil {
+		log.Printf("Error releasing media %v: %v", refs, err)
+	}
+}
+
+// QuestionMediaRefs 返回题目的题干图片和选项图片路径
+func QuestionMediaRefs(questions ...entity.QuestionBank) []string {
+	var refs []string
+	for _, q := range questions {
+		refs = append(refs, q.TopicImagePath)
+		for _, o := range q.Options {
+			refs = append(refs, o.ImagePath)
+		}
+	}
+	return refs
+}
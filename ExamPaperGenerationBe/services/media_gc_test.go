@@ -0,0 +1,70 @@
+package services
+
+import (
+	"context"
+	"graduation/entity"
+	"graduation/mapper/memory"
+	"graduation/media"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// putMedia 保存内容并把修改时间设为 age 之前，返回引用
+func putMedia(t *testing.T, root string, store media.Store, content string, age time.Duration) string {
+	t.Helper()
+	obj, err := store.Put(context.Background(), strings.NewReader(content))
+	require.NoError(t, err)
+	modTime := time.Now().Add(-age)
+	require.NoError(t, os.Chtimes(filepath.Join(root, obj.Key[:2], obj.Key[2:4], obj.Key), modTime, modTime))
+	return media.Ref(obj.Key)
+}
+
+func TestMediaCollector(t *testing.T) {
+	root := t.TempDir()
+	store := media.NewLocalStore(root)
+	topic := putMedia(t, root, store, "topic", 2*time.Hour)
+	option := putMedia(t, root, store, "option", 2*time.Hour)
+	shared := putMedia(t, root, store, "shared", 2*time.Hour)
+	orphan := putMedia(t, root, store, "orphan", 2*time.Hour)
+	fresh := putMedia(t, root, store, "fresh", time.Minute)
+
+	questions := memory.NewQuestionRepository(
+		entity.QuestionBank{Topic: "题目一", TopicImagePath: topic,
+			Options: []entity.QuestionOption{{Label: "A", Content: "甲", ImagePath: option}}},
+		entity.QuestionBank{Topic: "题目二", TopicImagePath: "resources/images/legacy.png"},
+	)
+	materials := memory.NewMaterialRepository(entity.QuestionMaterial{Title: "材料", ImagePaths: []string{shared}})
+	attachments := memory.NewAttachmentRepository(entity.QuestionAttachment{QuestionID: 1, Name: "1", Path: shared})
+	collector := NewMediaCollector(store, memory.NewMediaRepository(questions, materials, attachments, nil, nil), time.Hour)
+
+	// 全量清理只删除没有被引用且超过保留时间的文件
+	deleted, err := collector.Sweep(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []string{strings.TrimPrefix(orphan, media.RefPrefix)}, deleted)
+	_, err = store.Stat(context.Background(), strings.TrimPrefix(orphan, media.RefPrefix))
+	require.ErrorIs(t, err, media.ErrNotFound)
+
+	// 回收站中的题目仍然引用图片，彻底删除后才释放
+	q, _ := questions.GetQuestionBankById(1)
+	_, err = questions.SoftDeleteQuestionBanks([]int{1}, "admin")
+	require.NoError(t, err)
+	deleted, err = collector.Release(context.Background(), QuestionMediaRefs(q...)...)
+	require.NoError(t, err)
+	require.Empty(t, deleted)
+
+	// 彻底删除题目后释放它的图片，仍被材料和附件引用的图片保留
+	_, err = questions.PurgeQuestionBanks([]int{1})
+	require.NoError(t, err)
+	deleted, err = collector.Release(context.Background(), append(QuestionMediaRefs(q...), shared, fresh, "resources/images/legacy.png")...)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{strings.TrimPrefix(topic, media.RefPrefix), strings.TrimPrefix(option, media.RefPrefix)}, deleted)
+
+	list, err := store.List(context.Background())
+	require.NoError(t, err)
+	require.Len(t, list, 2) // shared 和刚保存的 fresh
+}
@@ -0,0 +1,164 @@
+package services
+
+import (
+	"encoding/json"
+	"graduation/entity"
+	"sort"
+	"time"
+)
+
+// 试卷中的题目相对出题时快照的状态
+const (
+	PaperQuestionChanged = "changed" // 题目在出题后被修改过
+	PaperQuestionMissing = "missing" // 题目已从题库中删除
+)
+
+// PaperQuestionChange 试卷中一道题目相对出题时快照的变化
+type PaperQuestionChange struct {
+	QuestionBankID int      `json:"question_bank_id"`
+	Topic          string   `json:"topic"` // 出题时的题干
+	Status         string   `json:"status"`
+	Fields         []string `json:"fields,omitempty"` // 发生变化的字段，按字段名排序
+}
+
+// ComparePaperSnapshot 按试卷顺序比较出题记录与题库中的当前内容，只返回有变化的题目。
+// current 为按 ID 查到的题目，attachments 和 materials 为当前的附件和材料。
+// 旧记录没有完整快照，只比较记录中保存的字段
+func ComparePaperSnapshot(
+	histories []entity.QuestionGenHistory,
+	current map[int]entity.QuestionBank,
+	attachments map[int][]entity.QuestionAttachment,
+	materials map[int]entity.QuestionMaterial,
+) []PaperQuestionChange {
+	changes := make([]PaperQuestionChange, 0)
+	for _, h := range histories {
+		q, ok := current[h.QuestionBankID]
+		if !ok {
+			changes = append(changes, PaperQuestionChange{
+				QuestionBankID: h.QuestionBankID,
+				Topic:          h.Topic,
+				Status:         PaperQuestionMissing,
+			})
+			continue
+		}
+		if fields := changedFields(h, q, attachments[q.ID], materials); len(fields) > 0 {
+			changes = append(changes, PaperQuestionChange{
+				QuestionBankID: h.QuestionBankID,
+				Topic:          h.Topic,
+				Status:         PaperQuestionChanged,
+				Fields:         fields,
+			})
+		}
+	}
+	return changes
+}
+
+// changedFields 返回题目相对出题记录发生变化的字段
+func changedFields(h entity.QuestionGenHistory, q entity.QuestionBank, attachments []entity.QuestionAttachment, materials map[int]entity.QuestionMaterial) []string {
+	before := entity.NewQuestionSnapshot(h.QuestionBank())
+	after := entity.NewQuestionSnapshot(q)
+	if h.Question == nil {
+		// 旧记录的题干已包含选项文字，也没有保存答案表格
+		after.Topic = q.DisplayTopic()
+		after.Options = nil
+		after.AnswerTable = nil
+	}
+	var fields []string
+	a, b := jsonFields(before), jsonFields(after)
+	for key, value := range a {
+		if value != b[key] {
+			fields = append(fields, key)
+		}
+	}
+	if h.Question != nil {
+		if !sameAttachments(h.Attachments, attachments) {
+			fields = append(fields, "attachments")
+		}
+		if h.Material != nil && h.Material.ID == q.TopicMaterialID && !sameMaterial(*h.Material, materials[q.TopicMaterialID]) {
+			fields = append(fields, "material")
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// jsonFields 把快照的每个字段转换为 JSON 文本，没有选项和选项为空列表视为相同
+func jsonFields(snapshot entity.QuestionSnapshot) map[string]string {
+	if len(snapshot.Options) == 0 {
+		snapshot.Options = nil
+	}
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return nil
+	}
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil
+	}
+	fields := make(map[string]string, len(raw))
+	for key, value := range raw {
+		fields[key] = string(value)
+	}
+	return fields
+}
+
+// sameAttachments 比较两组附件的内容，忽略 ID 和更新时间
+func sameAttachments(a, b []entity.QuestionAttachment) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		x, y := a[i], b[i]
+		if x.Target != y.Target || x.OptionLabel != y.OptionLabel || x.Name != y.Name || x.Path != y.Path ||
+			x.Caption != y.Caption || x.DisplayWidth != y.DisplayWidth || x.SortOrder != y.SortOrder {
+			return false
+		}
+	}
+	return true
+}
+
+// sameMaterial 比较两份材料的内容，忽略更新时间
+func sameMaterial(a, b entity.QuestionMaterial) bool {
+	x, err := materialContent(a)
+	if err != nil {
+		return false
+	}
+	y, err := materialContent(b)
+	return err == nil && x == y
+}
+
+// materialContent 返回材料内容的 JSON 文本，没有图片或表格和空列表视为相同
+func materialContent(m entity.QuestionMaterial) (string, error) {
+	if len(m.ImagePaths) == 0 {
+		m.ImagePaths = nil
+	}
+	if len(m.Tables) == 0 {
+		m.Tables = nil
+	}
+	data, err := json.Marshal([]interface{}{m.Title, m.Material, m.ImagePaths, m.Tables})
+	return string(data), err
+}
+
+// NewQuestionGenHistories 生成试卷中每道题目的出题记录，保存题目、附件和材料的完整快照，
+// 以后重新导出时按出题时的内容生成
+func NewQuestionGenHistories(
+	questions []entity.QuestionBank,
+	attachments map[int][]entity.QuestionAttachment,
+	materials map[int]entity.QuestionMaterial,
+	paperUID, paperName string,
+	date time.Time,
+) []entity.QuestionGenHistory {
+	histories := make([]entity.QuestionGenHistory, 0, len(questions))
+	for _, q := range questions {
+		var material *entity.QuestionMaterial
+		if m, ok := materials[q.TopicMaterialID]; ok && q.TopicMaterialID != 0 {
+			material = &m
+		}
+		h := entity.NewQuestionGenHistory(q, attachments[q.ID], material)
+		h.TestPaperUID = paperUID
+		h.TestPaperName = paperName
+		h.UpdateTime = date
+		histories = append(histories, h)
+	}
+	return histories
+}
@@ -0,0 +1,49 @@
+package services
+
+import (
+	"graduation/entity"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestComparePaperSnapshot(t *testing.T) {
+	choice := entity.QuestionBank{ID: 1, Topic: "8086 的地址总线宽度", Answer: "C", TopicType: entity.ChoiceTopicType, Score: 2, TopicMaterialID: 1,
+		Options: []entity.QuestionOption{{Label: "A", Content: "8"}, {Label: "B", Content: "16"}, {Label: "C", Content: "20"}}}
+	blank := entity.QuestionBank{ID: 2, Topic: "8086 有几个段寄存器？", Answer: "4", TopicType: "填空题", Score: 2}
+	removed := entity.QuestionBank{ID: 3, Topic: "8259A 的作用", Answer: "中断控制", TopicType: "简答题", Score: 10}
+	attachments := map[int][]entity.QuestionAttachment{1: {{ID: 7, QuestionID: 1, Target: entity.AttachmentTargetTopic, Name: "1", Path: "media:a"}}}
+	materials := map[int]entity.QuestionMaterial{1: {ID: 1, Material: "阅读下面的程序"}}
+
+	histories := NewQuestionGenHistories([]entity.QuestionBank{choice, blank, removed}, attachments, materials, "p1", "期中试卷", time.Now())
+	require.Len(t, histories, 3)
+	require.Equal(t, choice.DisplayTopic(), histories[0].Topic)
+	require.Equal(t, "阅读下面的程序", histories[0].Material.Material)
+	require.Nil(t, histories[1].Material)
+	require.Equal(t, choice, histories[0].QuestionBank())
+
+	// 内容没有变化时，附件 ID 和材料更新时间不影响比较
+	current := map[int]entity.QuestionBank{1: choice, 2: blank, 3: removed}
+	attachments[1][0].ID = 8
+	materials[1] = entity.QuestionMaterial{ID: 1, Material: "阅读下面的程序", UpdateTime: time.Now(), ImagePaths: []string{}}
+	require.Empty(t, ComparePaperSnapshot(histories, current, attachments, materials))
+
+	edited := choice
+	edited.Options = []entity.QuestionOption{{Label: "A", Content: "8"}, {Label: "B", Content: "16"}, {Label: "C", Content: "24"}}
+	edited.Score = 3
+	current = map[int]entity.QuestionBank{1: edited, 2: blank}
+	attachments[1][0].Caption = "图 1"
+	materials[1] = entity.QuestionMaterial{ID: 1, Material: "阅读下面的汇编程序"}
+	require.Equal(t, []PaperQuestionChange{
+		{QuestionBankID: 1, Topic: choice.DisplayTopic(), Status: PaperQuestionChanged, Fields: []string{"attachments", "material", "options", "score"}},
+		{QuestionBankID: 3, Topic: removed.Topic, Status: PaperQuestionMissing},
+	}, ComparePaperSnapshot(histories, current, attachments, materials))
+
+	// 旧记录的题干包含选项文字，只比较记录中保存的字段
+	legacy := histories[0]
+	legacy.Question, legacy.Attachments, legacy.Material = nil, nil, nil
+	require.Empty(t, ComparePaperSnapshot([]entity.QuestionGenHistory{legacy}, map[int]entity.QuestionBank{1: choice}, nil, nil))
+	require.Equal(t, []string{"score", "topic"},
+		ComparePaperSnapshot([]entity.QuestionGenHistory{legacy}, map[int]entity.QuestionBank{1: edited}, nil, nil)[0].Fields)
+}
@@ -0,0 +1,136 @@
+package services
+
+import (
+	"fmt"
+	"graduation/entity"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ImportColumns Excel 导入文件各列对应的字段，按列的顺序排列
+var ImportColumns = []string{
+	"topic", "topic_material_id", "answer", "topic_type", "score", "difficulty",
+	"chapter_1", "chapter_2", "label_1", "label_2", "update_time", "topic_table", "answer_table",
+}
+
+// ImportTopicTypes 导入时允许的题型，与组卷算法中的题型一致
+var ImportTopicTypes = []string{"选择题", "填空题", "判断题", "简答题"}
+
+// 导入题目的难度范围
+const (
+	MinImportDifficulty = 1
+	MaxImportDifficulty = 5
+)
+
+// ImportError 导入文件中一行数据的错误，Row 为 Excel 中的行号（表头为第 1 行），
+// Column 为出错的字段，见 ImportColumns
+type ImportError struct {
+	Row     int    `json:"row"`
+	Column  string `json:"column"`
+	Message string `json:"message"`
+}
+
+// ImportRow 校验通过的一行数据及转换后的题目
+type ImportRow struct {
+	Row      int                 `json:"row"`
+	Question entity.QuestionBank `json:"question"`
+}
+
+// importCell 读取一列的文字并去掉首尾空白，缺少该列时返回空字符串
+func importCell(record map[string]interface{}, column string) string {
+	s, _ := record[column].(string)
+	return strings.TrimSpace(s)
+}
+
+// ParseImportRows 校验 ExcelReader 读出的每一行并转换为题目，返回校验通过的行和全部错误。
+// 题干、答案、题型、分值、难度为必填，分值为正数，难度为 1-5 的整数，题型见 ImportTopicTypes，
+// 知识点必须是 labels 中已有的标签。公式由调用方检查
+func ParseImportRows(records []map[string]interface{}, labels []entity.QuestionLabels) ([]ImportRow, []ImportError) {
+	label1 := make(map[string]bool, len(labels))
+	label2 := make(map[string]bool, len(labels))
+	for _, l := range labels {
+		label1[l.Label1] = true
+		label2[l.Label2] = true
+	}
+
+	var rows []ImportRow
+	var errs []ImportError
+	for i, record := range records {
+		row, ok := record["row"].(int)
+		if !ok {
+			row = i + 2
+		}
+		var rowErrs []ImportError
+		fail := func(column, format string, args ...interface{}) {
+			rowErrs = append(rowErrs, ImportError{Row: row, Column: column, Message: fmt.Sprintf(format, args...)})
+		}
+
+		q := entity.QuestionBank{
+			Topic:     importCell(record, "topic"),
+			Answer:    importCell(record, "answer"),
+			TopicType: importCell(record, "topic_type"),
+			Chapter1:  importCell(record, "chapter_1"),
+			Chapter2:  importCell(record, "chapter_2"),
+			Label1:    importCell(record, "label_1"),
+			Label2:    importCell(record, "label_2"),
+		}
+		if q.Topic == "" {
+			fail("topic", "题干不能为空")
+		}
+		if q.Answer == "" {
+			fail("answer", "答案不能为空")
+		}
+		switch {
+		case q.TopicType == "":
+			fail("topic_type", "题型不能为空")
+		case !slices.Contains(ImportTopicTypes, q.TopicType):
+			fail("topic_type", "未知的题型 %s，应为 %s 之一", q.TopicType, strings.Join(ImportTopicTypes, "、"))
+		}
+
+		if cell := importCell(record, "score"); cell == "" {
+			fail("score", "分值不能为空")
+		} else if score, err := strconv.ParseFloat(cell, 64); err != nil || score <= 0 {
+			fail("score", "分值 %s 不是正数", cell)
+		} else {
+			q.Score = score
+		}
+		if cell := importCell(record, "difficulty"); cell == "" {
+			fail("difficulty", "难度不能为空")
+		} else if difficulty, err := strconv.Atoi(cell); err != nil || difficulty < MinImportDifficulty || difficulty > MaxImportDifficulty {
+			fail("difficulty", "难度 %s 不是 %d-%d 的整数", cell, MinImportDifficulty, MaxImportDifficulty)
+		} else {
+			q.Difficulty = difficulty
+		}
+		if cell := importCell(record, "topic_material_id"); cell != "" {
+			if id, err := strconv.Atoi(cell); err != nil || id < 0 {
+				fail("topic_material_id", "材料编号 %s 不是整数", cell)
+			} else {
+				q.TopicMaterialID = id
+			}
+		}
+
+		if q.Label1 != "" && !label1[q.Label1] {
+			fail("label_1", "知识点 %s 不存在", q.Label1)
+		}
+		if q.Label2 != "" && !label2[q.Label2] {
+			fail("label_2", "知识点 %s 不存在", q.Label2)
+		}
+
+		var err error
+		if q.TopicTable, err = ReadTableCell(importCell(record, "topic_table")); err != nil {
+			fail("topic_table", "题干表格: %v", err)
+		}
+		if q.AnswerTable, err = ReadTableCell(importCell(record, "answer_table")); err != nil {
+			fail("answer_table", "答案表格: %v", err)
+		}
+
+		if len(rowErrs) > 0 {
+			errs = append(errs, rowErrs...)
+			continue
+		}
+		q.NormalizeChoiceOptions()
+		rows = append(rows, ImportRow{Row: row, Question: q})
+	}
+	return rows, errs
+}
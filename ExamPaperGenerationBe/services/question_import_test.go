@@ -0,0 +1,39 @@
+package services
+
+import (
+	"graduation/entity"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseImportRows(t *testing.T) {
+	labels := []entity.QuestionLabels{{Chapter1: "第二章", Label1: "8086", Label2: "寄存器"}}
+	records := []map[string]interface{}{
+		{"row": 2, "topic": " 8086 有几个段寄存器？ ", "topic_material_id": "", "answer": "4", "topic_type": "填空题",
+			"score": "2", "difficulty": "1", "chapter_1": "第二章", "label_1": "8086", "label_2": "寄存器"},
+		// 缺少的列和格式错误都逐项报告
+		{"row": 4, "topic_material_id": "x", "topic_type": "论述题", "score": "abc", "difficulty": "9", "label_1": "未知"},
+		{"row": 6, "topic": "8086 的地址总线宽度", "answer": "A", "topic_type": "选择题", "score": "-1", "difficulty": "2.5",
+			"topic_table": "{"},
+		{"topic": "8259A 的作用", "answer": "中断控制", "topic_type": "简答题", "score": "10", "difficulty": "5"},
+	}
+	rows, errs := ParseImportRows(records, labels)
+
+	require.Len(t, rows, 2)
+	require.Equal(t, 2, rows[0].Row)
+	require.Equal(t, "8086 有几个段寄存器？", rows[0].Question.Topic)
+	require.Equal(t, 2.0, rows[0].Question.Score)
+	require.Equal(t, 1, rows[0].Question.Difficulty)
+	// 没有行号时按顺序推算
+	require.Equal(t, 5, rows[1].Row)
+	require.Equal(t, 5, rows[1].Question.Difficulty)
+
+	columns := make(map[int][]string)
+	for _, e := range errs {
+		require.NotEmpty(t, e.Message)
+		columns[e.Row] = append(columns[e.Row], e.Column)
+	}
+	require.Equal(t, []string{"topic", "answer", "topic_type", "score", "difficulty", "topic_material_id", "label_1"}, columns[4])
+	require.Equal(t, []string{"score", "difficulty", "topic_table"}, columns[6])
+}
@@ -0,0 +1,67 @@
+package services
+
+import (
+	"fmt"
+	"graduation/entity"
+)
+
+// 题目的审核操作
+const (
+	ReviewSubmit  = "submit"  // 草稿提交审核
+	ReviewApprove = "approve" // 审核通过
+	ReviewReject  = "reject"  // 退回修改，题目回到草稿
+	ReviewRetire  = "retire"  // 停用审核通过的题目
+	ReviewRedraft = "redraft" // 撤回审核中的题目或重新启用停用的题目，回到草稿
+	ReviewReopen  = "reopen"  // 已通过的题目修改或回滚后回到草稿，由系统执行
+)
+
+// reviewTransitions 每个审核操作允许的当前状态和操作后的状态
+var reviewTransitions = map[string]struct {
+	from []string
+	to   string
+}{
+	ReviewSubmit:  {from: []string{entity.QuestionStatusDraft}, to: entity.QuestionStatusInReview},
+	ReviewApprove: {from: []string{entity.QuestionStatusInReview}, to: entity.QuestionStatusApproved},
+	ReviewReject:  {from: []string{entity.QuestionStatusInReview}, to: entity.QuestionStatusDraft},
+	ReviewRetire:  {from: []string{entity.QuestionStatusApproved}, to: entity.QuestionStatusRetired},
+	ReviewRedraft: {from: []string{entity.QuestionStatusInReview, entity.QuestionStatusRetired}, to: entity.QuestionStatusDraft},
+	ReviewReopen:  {from: []string{entity.QuestionStatusApproved}, to: entity.QuestionStatusDraft},
+}
+
+// NextQuestionStatus 返回处于 status 状态的题目执行审核操作后的状态，当前状态不允许该操作时返回错误
+func NextQuestionStatus(status, action string) (string, error) {
+	t, ok := reviewTransitions[action]
+	if !ok {
+		return "", fmt.Errorf("unsupported review action: %s", action)
+	}
+	for _, from := range t.from {
+		if from == status {
+			return t.to, nil
+		}
+	}
+	return "", fmt.Errorf("题目状态为 %s，不能执行 %s", status, action)
+}
+
+// QuestionContentChanged 判断题目修改前后的内容和属性是否不同，比较的字段与历史版本相同
+func QuestionContentChanged(before, after entity.QuestionBank) bool {
+	return !sameSnapshot(entity.NewQuestionSnapshot(before), entity.NewQuestionSnapshot(after))
+}
+
+// SelectableQuestions 返回自动组卷可以抽取的题目。默认只包括审核通过的题目和手动选择的题目，
+// includeUnapproved 为 true 时也包括草稿和审核中的题目。已停用的题目始终排除
+func SelectableQuestions(questions []entity.QuestionBank, selectedIds []int, includeUnapproved bool) []entity.QuestionBank {
+	selected := make(map[int]bool, len(selectedIds))
+	for _, id := range selectedIds {
+		selected[id] = true
+	}
+	var out []entity.QuestionBank
+	for _, q := range questions {
+		if q.Status == entity.QuestionStatusRetired {
+			continue
+		}
+		if q.Status == entity.QuestionStatusApproved || includeUnapproved || selected[q.ID] {
+			out = append(out, q)
+		}
+	}
+	return out
+}
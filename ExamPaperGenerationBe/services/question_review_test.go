@@ -0,0 +1,67 @@
+package services
+
+import (
+	"graduation/entity"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNextQuestionStatus(t *testing.T) {
+	status := entity.QuestionStatusDraft
+	for _, step := range []struct{ action, want string }{
+		{ReviewSubmit, entity.QuestionStatusInReview},
+		{ReviewReject, entity.QuestionStatusDraft},
+		{ReviewSubmit, entity.QuestionStatusInReview},
+		{ReviewApprove, entity.QuestionStatusApproved},
+		{ReviewRetire, entity.QuestionStatusRetired},
+		{ReviewRedraft, entity.QuestionStatusDraft},
+	} {
+		next, err := NextQuestionStatus(status, step.action)
+		require.NoError(t, err, step.action)
+		require.Equal(t, step.want, next)
+		status = next
+	}
+
+	_, err := NextQuestionStatus(entity.QuestionStatusDraft, ReviewApprove)
+	require.Error(t, err)
+	_, err = NextQuestionStatus(entity.QuestionStatusApproved, ReviewSubmit)
+	require.Error(t, err)
+	_, err = NextQuestionStatus(entity.QuestionStatusDraft, "publish")
+	require.Error(t, err)
+	next, err := NextQuestionStatus(entity.QuestionStatusApproved, ReviewReopen)
+	require.NoError(t, err)
+	require.Equal(t, entity.QuestionStatusDraft, next)
+	_, err = NextQuestionStatus(entity.QuestionStatusInReview, ReviewReopen)
+	require.Error(t, err)
+}
+
+func TestQuestionContentChanged(t *testing.T) {
+	before := entity.QuestionBank{ID: 1, Topic: "8086 有几个段寄存器？", Answer: "4", Status: entity.QuestionStatusApproved}
+	after := before
+	after.Status, after.UpdateTime = entity.QuestionStatusDraft, before.UpdateTime.Add(1)
+	after.Options = []entity.QuestionOption{}
+	require.False(t, QuestionContentChanged(before, after))
+	after.Answer = "3"
+	require.True(t, QuestionContentChanged(before, after))
+}
+
+func TestSelectableQuestions(t *testing.T) {
+	questions := []entity.QuestionBank{
+		{ID: 1, Status: entity.QuestionStatusApproved},
+		{ID: 2, Status: entity.QuestionStatusDraft},
+		{ID: 3, Status: entity.QuestionStatusInReview},
+		{ID: 4, Status: entity.QuestionStatusRetired},
+	}
+	ids := func(qs []entity.QuestionBank) []int {
+		var out []int
+		for _, q := range qs {
+			out = append(out, q.ID)
+		}
+		return out
+	}
+	require.Equal(t, []int{1}, ids(SelectableQuestions(questions, nil, false)))
+	// 手动选择的题目保留，停用的题目始终排除
+	require.Equal(t, []int{1, 2}, ids(SelectableQuestions(questions, []int{2, 4}, false)))
+	require.Equal(t, []int{1, 2, 3}, ids(SelectableQuestions(questions, nil, true)))
+}
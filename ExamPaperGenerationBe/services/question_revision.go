@@ -0,0 +1,78 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"graduation/entity"
+	"graduation/mapper"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// QuestionRevisions 记录题目每次修改后的版本，版本写入后不再修改
+type QuestionRevisions struct {
+	revisions mapper.RevisionRepository
+	now       func() time.Time
+}
+
+// NewQuestionRevisions 创建题目版本记录器
+func NewQuestionRevisions(revisions mapper.RevisionRepository) *QuestionRevisions {
+	return &QuestionRevisions{revisions: revisions, now: time.Now}
+}
+
+// Baseline 在修改题目之前调用。开始记录版本之前已有的题目没有任何版本，把修改前的内容补记为 initial 版本，
+// 以便回滚到第一次修改之前
+func (r *QuestionRevisions) Baseline(before entity.QuestionBank) error {
+	_, err := r.revisions.GetLatestQuestionRevision(before.ID)
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	return r.revisions.InsertQuestionRevision(&entity.QuestionRevision{
+		QuestionID: before.ID,
+		Action:     entity.RevisionInitial,
+		Snapshot:   entity.NewQuestionSnapshot(before),
+		CreatedAt:  before.UpdateTime,
+	})
+}
+
+// Record 在新增或修改题目之后调用，q 为保存后的题目。内容与最新版本相同时不记录，返回 nil。
+// source 为回滚时恢复的版本号，其他操作为 0
+func (r *QuestionRevisions) Record(q entity.QuestionBank, action, editor string, source int) (*entity.QuestionRevision, error) {
+	snapshot := entity.NewQuestionSnapshot(q)
+	latest, err := r.revisions.GetLatestQuestionRevision(q.ID)
+	if err == nil && sameSnapshot(latest.Snapshot, snapshot) {
+		return nil, nil
+	}
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	revision := &entity.QuestionRevision{
+		QuestionID: q.ID,
+		Action:     action,
+		Source:     source,
+		Snapshot:   snapshot,
+		Editor:     editor,
+		CreatedAt:  r.now(),
+	}
+	if err := r.revisions.InsertQuestionRevision(revision); err != nil {
+		return nil, err
+	}
+	return revision, nil
+}
+
+// sameSnapshot 比较两个快照的内容，没有选项和选项为空列表视为相同
+func sameSnapshot(a, b entity.QuestionSnapshot) bool {
+	if len(a.Options) == 0 {
+		a.Options = nil
+	}
+	if len(b.Options) == 0 {
+		b.Options = nil
+	}
+	x, err := json.Marshal(a)
+	if err != nil {
+		return false
+	}
+	y, err := json.Marshal(b)
+	return err == nil && string(x) == string(y)
+}
@@ -35,14 +35,24 @@ func RandomSelectTopic(dataSource []entity.QuestionBank, targetDiff float64, sel
 	updateSelectedQuestions(nil)
 
 	// 选题过程
-	for remaining := selectCount; remaining > 0 && len(workingSet) > 0; remaining-- {
+	for remaining := selectCount; remaining > 0 && len(workingSet) > 0; {
 		selected, ok := selectQuestionV2(workingSet, targetDiff, historyWeights)
 		if !ok {
 			break
 		}
-		result = append(result, selected)
-		questionIds = append(questionIds, selected.ID)
-		workingSet = removeQuestion(workingSet, selected.ID)
+		// 材料题整组选入，剩余题数放不下时整组放弃
+		group := materialGroupOf(workingSet, selected)
+		for _, q := range group {
+			workingSet = removeQuestion(workingSet, q.ID)
+		}
+		if len(group) > remaining {
+			continue
+		}
+		for _, q := range group {
+			result = append(result, q)
+			questionIds = append(questionIds, q.ID)
+		}
+		remaining -= len(group)
 
 		// 更新当前试卷的已选题目
 		updateSelectedQuestions(result)
@@ -173,6 +183,20 @@ func selectQuestionV2(candidates []entity.QuestionBank, targetDiff float64,
 	return candidatesPool[len(candidatesPool)-1].q, true
 }
 
+// materialGroupOf 返回与题目共用同一材料的全部候选题目（包括题目本身），不属于材料题时只返回题目本身
+func materialGroupOf(candidates []entity.QuestionBank, q entity.QuestionBank) []entity.QuestionBank {
+	if q.TopicMaterialID == 0 {
+		return []entity.QuestionBank{q}
+	}
+	var group []entity.QuestionBank
+	for _, c := range candidates {
+		if c.TopicMaterialID == q.TopicMaterialID {
+			group = append(group, c)
+		}
+	}
+	return group
+}
+
 // removeQuestion 从切片中删除指定题目（保持顺序）
 func removeQuestion(slice []entity.QuestionBank, id int) []entity.QuestionBank {
 	for i, q := range slice {
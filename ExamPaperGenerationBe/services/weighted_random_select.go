@@ -261,7 +261,8 @@ func WeightedRandomSelect(
 		}
 	}
 
-	return selectedQuestions
+	// 材料题整组出现在试卷中
+	return KeepMaterialGroups(selectedQuestions, filteredQuestions, selectedQuestionIds, TARGET_TOTAL_SCORE)
 }
 
 // adjustScoresToIntegers 调整分数为整数，确保总和不变，并尽量减少奇数
@@ -317,17 +318,11 @@ func adjustScoresToIntegers(scores map[string]float64, totalScore float64) map[s
 }
 
 // GetExcludedQuestionIds 获取需要排除的题目ID列表
-func GetExcludedQuestionIds(similarityThreshold float64) []int {
+func GetExcludedQuestionIds(history mapper.HistoryRepository, similarityThreshold float64) []int {
 	// 获取最近两年内的所有题目ID
 	twoYearsAgo := time.Now().AddDate(-2, 0, 0)
-	var questionIds []int
-
-	// 一次性查询所有符合条件的题目ID
-	if err := mapper.DB.Model(&entity.QuestionGenHistory{}).
-		Select("DISTINCT questiongenhistory.question_bank_id").
-		Joins("JOIN testpapergenhistory ON testpapergenhistory.test_paper_uid = questiongenhistory.test_paper_uid").
-		Where("testpapergenhistory.update_time >= ?", twoYearsAgo).
-		Pluck("question_bank_id", &questionIds).Error; err != nil {
+	questionIds, err := history.GetQuestionIdsUsedSince(twoYearsAgo)
+	if err != nil {
 		return nil
 	}
 
@@ -0,0 +1,204 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"graduation/entity"
+	"graduation/media"
+	"image"
+	"log"
+	"regexp"
+	"strings"
+
+	"github.com/carmel/gooxml/common"
+	"github.com/carmel/gooxml/document"
+	measure "github.com/carmel/gooxml/measurement"
+	"github.com/carmel/gooxml/schema/soo/wml"
+)
+
+// 图片排版参数
+const (
+	maxImageWidth measure.Distance = 15 * measure.Centimeter // 版心宽度，A4 纸左右各留 3 厘米
+	captionSize   measure.Distance = 9 * measure.Point       // 图片说明使用小五号字
+)
+
+// attachmentMarkerRe 匹配试卷内容中的图片标记，以及旧版本只有路径的 [IMAGE:路径] 标记
+var attachmentMarkerRe = regexp.MustCompile(`\[ATTACH\](.*?)\[/ATTACH\]|\[IMAGE:(.*?)\]`)
+
+// AttachmentMarker 生成放在试卷内容中的图片标记，导出时图片单独成段，按原图比例缩放并在下方排出说明文字
+func AttachmentMarker(attachment entity.QuestionAttachment) string {
+	data, err := json.Marshal(attachment)
+	if err != nil {
+		log.Printf("Error marshaling question attachment: %v", err)
+		return ""
+	}
+	return "[ATTACH]" + string(data) + "[/ATTACH]"
+}
+
+// PlaceAttachments 把文字中的 {{img:名称}} 替换为对应图片的标记，没有位置标记的图片按顺序排在文字之后。
+// attachments 只包含同一位置（题干、某个选项或答案）的图片，找不到图片的位置标记直接去掉
+func PlaceAttachments(text string, attachments []entity.QuestionAttachment) string {
+	byName := make(map[string]entity.QuestionAttachment, len(attachments))
+	for _, a := range attachments {
+		byName[a.Name] = a
+	}
+	placed := make(map[string]bool)
+	text = entity.AttachmentPlaceholderRe.ReplaceAllStringFunc(text, func(placeholder string) string {
+		name := entity.AttachmentPlaceholderRe.FindStringSubmatch(placeholder)[1]
+		a, ok := byName[name]
+		if !ok || placed[name] {
+			return ""
+		}
+		placed[name] = true
+		return AttachmentMarker(a)
+	})
+	for _, a := range attachments {
+		if !placed[a.Name] {
+			text += AttachmentMarker(a)
+		}
+	}
+	return text
+}
+
+// PlaceOptionAttachments 返回选项的副本，各选项的图片标记写入选项文字。
+// 有附件的选项不再单独导出旧的 image_path，迁移时它已经复制为附件
+func PlaceOptionAttachments(options []entity.QuestionOption, attachments []entity.QuestionAttachment) []entity.QuestionOption {
+	if len(options) == 0 {
+		return options
+	}
+	placed := make([]entity.QuestionOption, len(options))
+	for i, o := range options {
+		images := entity.AttachmentsFor(attachments, entity.AttachmentTargetOption, o.Label)
+		if len(images) > 0 {
+			o.Content = PlaceAttachments(o.Content, images)
+			o.ImagePath = ""
+		}
+		placed[i] = o
+	}
+	return placed
+}
+
+// contentPart 一行内容中的一段文字或一张图片
+type contentPart struct {
+	text  string
+	image *entity.QuestionAttachment
+}
+
+// splitAttachments 按图片标记把一行内容拆成文字和图片
+func splitAttachments(line string) []contentPart {
+	var parts []contentPart
+	last := 0
+	for _, loc := range attachmentMarkerRe.FindAllStringSubmatchIndex(line, -1) {
+		if loc[0] > last {
+			parts = append(parts, contentPart{text: line[last:loc[0]]})
+		}
+		last = loc[1]
+		var attachment entity.QuestionAttachment
+		if loc[2] >= 0 {
+			if err := json.Unmarshal([]byte(line[loc[2]:loc[3]]), &attachment); err != nil {
+				log.Printf("Error parsing question attachment: %v", err)
+				continue
+			}
+		} else {
+			attachment.Path = line[loc[4]:loc[5]]
+		}
+		parts = append(parts, contentPart{image: &attachment})
+	}
+	if last < len(line) {
+		parts = append(parts, contentPart{text: line[last:]})
+	}
+	return parts
+}
+
+// stripAttachments 去掉内容中的图片标记，只保留文字
+func stripAttachments(text string) string {
+	return attachmentMarkerRe.ReplaceAllString(text, "")
+}
+
+// hasAttachments 判断内容中是否有图片
+func hasAttachments(text string) bool {
+	return attachmentMarkerRe.MatchString(text)
+}
+
+// addContentLine 排版一行题目内容：文字左对齐，行中的每张图片单独成段居中，
+// 图片前后的文字分别成段。style 设置文字 run 的格式
+func addContentLine(doc *document.Document, line string, style func(run document.Run)) {
+	for _, part := range splitAttachments(line) {
+		if part.image != nil {
+			addAttachment(doc, doc.AddParagraph, *part.image, maxImageWidth, wml.ST_JcCenter)
+			continue
+		}
+		text := strings.Trim(part.text, " ")
+		if text == "" {
+			continue
+		}
+		para := doc.AddParagraph()
+		para.Properties().SetAlignment(wml.ST_JcLeft)
+		run := para.AddRun()
+		style(run)
+		addMathText(para, run, text)
+	}
+}
+
+// addAttachment 用 newParagraph 创建的段落排版一张图片及其说明文字，图片宽度不超过 maxWidth
+func addAttachment(doc *document.Document, newParagraph func() document.Paragraph, attachment entity.QuestionAttachment,
+	maxWidth measure.Distance, align wml.ST_Jc) {
+	imgRef, err := addDocumentImage(doc, attachment.Path)
+	if err != nil {
+		log.Printf("Error adding question image: %v", err)
+		return
+	}
+	para := newParagraph()
+	para.Properties().SetAlignment(align)
+	inline, err := para.AddRun().AddDrawingInline(imgRef)
+	if err != nil {
+		log.Printf("Error adding inline image: %v", err)
+		return
+	}
+	inline.SetSize(scaledImageSize(imgRef.Size(), measure.Distance(attachment.DisplayWidth)*measure.Centimeter, maxWidth))
+	if attachment.Caption == "" {
+		return
+	}
+	captionPara := newParagraph()
+	captionPara.Properties().SetAlignment(align)
+	run := captionPara.AddRun()
+	run.Properties().SetSize(captionSize)
+	addMathText(captionPara, run, attachment.Caption)
+}
+
+// addDocumentImage 读取图片并加入文档，path 为 media: 引用或旧版本的文件路径
+func addDocumentImage(doc *document.Document, path string) (common.ImageRef, error) {
+	data, err := media.ReadFile(context.Background(), path)
+	if err != nil {
+		return common.ImageRef{}, fmt.Errorf("reading image file %s: %w", path, err)
+	}
+	img, err := common.ImageFromBytes(data)
+	if err != nil {
+		return common.ImageRef{}, fmt.Errorf("decoding image %s: %w", path, err)
+	}
+	imgRef, err := doc.AddImage(img)
+	if err != nil {
+		return common.ImageRef{}, fmt.Errorf("adding image to document: %w", err)
+	}
+	return imgRef, nil
+}
+
+// scaledImageSize 计算图片的导出尺寸：指定了宽度时按指定宽度，否则按 96 DPI 的原始大小，
+// 宽度超过 maxWidth 时缩小到 maxWidth，高度始终按原图的宽高比计算
+func scaledImageSize(size image.Point, width, maxWidth measure.Distance) (measure.Distance, measure.Distance) {
+	if size.X <= 0 || size.Y <= 0 {
+		// 读不出尺寸时按旧版本的 4:3 排版
+		size = image.Point{X: 4, Y: 3}
+		if width <= 0 {
+			width = 2 * measure.Inch
+		}
+	}
+	if width <= 0 {
+		width = measure.Distance(size.X) * measure.Pixel96
+	}
+	if width > maxWidth {
+		width = maxWidth
+	}
+	return width, width * measure.Distance(size.Y) / measure.Distance(size.X)
+}
@@ -0,0 +1,95 @@
+package services
+
+import (
+	"graduation/entity"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	measure "github.com/carmel/gooxml/measurement"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScaledImageSize(t *testing.T) {
+	// 按 96 DPI 的原始大小
+	w, h := scaledImageSize(image.Point{X: 96, Y: 48}, 0, maxImageWidth)
+	require.InDelta(t, float64(measure.Inch), float64(w), 0.001)
+	require.InDelta(t, float64(measure.Inch/2), float64(h), 0.001)
+
+	// 超过版心宽度时等比缩小
+	w, h = scaledImageSize(image.Point{X: 2000, Y: 1000}, 0, maxImageWidth)
+	require.Equal(t, maxImageWidth, w)
+	require.InDelta(t, float64(maxImageWidth/2), float64(h), 0.001)
+
+	// 指定宽度时按指定宽度，高度保持比例
+	w, h = scaledImageSize(image.Point{X: 300, Y: 400}, 3*measure.Centimeter, maxImageWidth)
+	require.InDelta(t, float64(3*measure.Centimeter), float64(w), 0.001)
+	require.InDelta(t, float64(4*measure.Centimeter), float64(h), 0.001)
+}
+
+func TestPlaceAttachments(t *testing.T) {
+	attachments := []entity.QuestionAttachment{{Name: "1", Path: "a.png"}, {Name: "2", Path: "b.png"}}
+	text := PlaceAttachments("见图{{img:2}}，再看{{img:2}}{{img:x}}", attachments)
+	parts := splitAttachments(text)
+	require.Len(t, parts, 4)
+	require.Equal(t, "见图", parts[0].text)
+	require.Equal(t, "b.png", parts[1].image.Path)
+	require.Equal(t, "，再看", parts[2].text)
+	require.Equal(t, "a.png", parts[3].image.Path)
+
+	// 旧版本的图片标记仍然可以识别
+	parts = splitAttachments("如图 [IMAGE:resources/images/q.jpg]")
+	require.Len(t, parts, 2)
+	require.Equal(t, "resources/images/q.jpg", parts[1].image.Path)
+}
+
+func TestExportTestPaperWithAttachments(t *testing.T) {
+	dir := t.TempDir()
+	wide := writeTestPNG(t, dir, "wide.png", 2000, 500)
+	small := writeTestPNG(t, dir, "small.png", 96, 96)
+	contents := "[SECTION_TITLE]四、简答题（本大题共1小题，每小题10.0分，共10.0分）[/SECTION_TITLE]\r\r" +
+		"1、（本题10分）" + PlaceAttachments("根据时序图{{img:t}}和电路图回答", []entity.QuestionAttachment{
+		{Name: "t", Path: wide, Caption: "图 1 时序图"},
+		{Name: "c", Path: small, Caption: "图 2 电路图", DisplayWidth: 5},
+	}) + "\r\r[QUESTION_END]\r\r"
+	file, err := NewWordExporterGooxml(map[string]string{"total_score": "10", "total_count": "1", "contents": contents}).ExportTestPaper(1)
+	require.NoError(t, err)
+	defer file.Close()
+
+	xml := readDocumentXML(t, file.Name())
+	require.Equal(t, 2, strings.Count(xml, "<wp:inline"))
+	require.NotContains(t, xml, "[ATTACH]")
+	// 文字、图片和说明按原顺序排列
+	order := []string{"根据时序图", "图 1 时序图", "和电路图回答", "图 2 电路图"}
+	for i := 1; i < len(order); i++ {
+		require.Less(t, strings.Index(xml, order[i-1]), strings.Index(xml, order[i]), order[i])
+	}
+
+	extents := regexp.MustCompile(`<wp:extent cx="(\d+)" cy="(\d+)"`).FindAllStringSubmatch(xml, -1)
+	require.Len(t, extents, 2)
+	cx, _ := strconv.ParseFloat(extents[0][1], 64)
+	cy, _ := strconv.ParseFloat(extents[0][2], 64)
+	// 宽图缩小到版心宽度并保持 4:1
+	require.InDelta(t, float64(maxImageWidth/measure.EMU), cx, 1)
+	require.InDelta(t, 4, cx/cy, 0.01)
+	cx, _ = strconv.ParseFloat(extents[1][1], 64)
+	cy, _ = strconv.ParseFloat(extents[1][2], 64)
+	require.InDelta(t, float64(5*measure.Centimeter/measure.EMU), cx, 1)
+	require.InDelta(t, cx, cy, 1)
+}
+
+// writeTestPNG 在 dir 中生成指定尺寸的 PNG 图片，返回文件路径
+func writeTestPNG(t *testing.T, dir, name string, width, height int) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	defer f.Close()
+	require.NoError(t, png.Encode(f, image.NewGray(image.Rect(0, 0, width, height))))
+	return path
+}
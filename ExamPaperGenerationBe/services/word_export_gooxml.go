@@ -2,13 +2,10 @@ package services
 
 import (
 	"fmt"
-	"io/ioutil"
-	"log"
 	"os"
 	"regexp"
 	"strings"
 
-	"github.com/carmel/gooxml/common"
 	"github.com/carmel/gooxml/document"
 	measure "github.com/carmel/gooxml/measurement"
 	"github.com/carmel/gooxml/schema/soo/wml"
@@ -95,7 +92,6 @@ func (we *WordExporterGooxml) ExportTestPaper(templateType int) (*os.File, error
 	// 处理题目内容
 	contents := we.data["contents"]
 	questions := strings.Split(contents, "[QUESTION_END]")
-	re := regexp.MustCompile(`\[IMAGE:(.*?)\]`)
 	sectionTitleRe := regexp.MustCompile(`\[SECTION_TITLE\](.*?)\[/SECTION_TITLE\]`)
 
 	// 用于跟踪当前是否在简答题部分
@@ -143,103 +139,66 @@ func (we *WordExporterGooxml) ExportTestPaper(templateType int) (*os.File, error
 			}
 		}
 
-		// 检查是否包含图片标记
-		if strings.Contains(question, "[IMAGE:") {
-			// 提取图片路径
-			var imgPath string
-			matches := re.FindStringSubmatch(question)
-			if len(matches) > 1 {
-				imgPath = matches[1]
-			}
-
-			// 创建一个段落来包含题目文本和图片
-			para := doc.AddParagraph()
-			para.Properties().SetAlignment(wml.ST_JcLeft)
-			run := para.AddRun()
-			run.Properties().SetSize(10.5 * measure.Point) // 5号字体
-			// 确保小题不加粗
-			run.Properties().SetBold(false)
-
-			// 添加题目文本（移除图片标记）
-			text := strings.TrimSpace(re.ReplaceAllString(question, ""))
-			run.AddText(text)
-
-			// 读取并添加图片
-			imgData, err := ioutil.ReadFile(imgPath)
-			if err != nil {
-				log.Printf("Error reading image file %s: %v", imgPath, err)
-				continue
-			}
-
-			img, err := common.ImageFromBytes(imgData)
-			if err != nil {
-				log.Printf("Error creating image from bytes: %v", err)
+		// 材料题组的第一道题之前先排材料
+		if text, material := extractMaterial(question); material != nil {
+			addMaterial(doc, *material)
+			question = strings.TrimSpace(text)
+			if question == "" {
 				continue
 			}
+		}
 
-			imgRef, err := doc.AddImage(img)
-			if err != nil {
-				log.Printf("Error adding image to document: %v", err)
+		// 取出选择题的选项，题干排完后再按列排版
+		text, options := extractChoiceOptions(question)
+		// 取出表格，排在题干之后、选项之前
+		text, tables := extractContentTables(text)
+		question = strings.TrimSpace(text)
+
+		// 按行分割文本，行中的图片单独成段
+		lines := strings.Split(question, "\n")
+		for i, line := range lines {
+			line = strings.TrimSpace(line)
+			if line == "" {
 				continue
 			}
 
-			// 在同一个段落中添加图片
-			inline, err := run.AddDrawingInline(imgRef)
-			if err != nil {
-				log.Printf("Error adding inline image: %v", err)
-				continue
+			// 处理选项的缩进
+			if strings.HasPrefix(line, "①") || strings.HasPrefix(line, "②") ||
+				strings.HasPrefix(line, "③") || strings.HasPrefix(line, "④") ||
+				strings.HasPrefix(line, "A.") || strings.HasPrefix(line, "B.") ||
+				strings.HasPrefix(line, "C.") || strings.HasPrefix(line, "D.") {
+				// 选项前添加制表符
+				line = "\t" + line
+			} else if strings.HasPrefix(line, "（") && strings.Contains(line, "）") {
+				// 小题编号前添加制表符
+				line = "\t" + line
 			}
-
-			inline.SetSize(2*measure.Inch, 1.5*measure.Inch)
-		} else {
-			// 按行分割文本
-			lines := strings.Split(question, "\n")
-			// 处理普通题目
-			for i, line := range lines {
-				line = strings.TrimSpace(line)
-				if line == "" {
-					continue
-				}
-
-				// 创建段落
-				para := doc.AddParagraph()
-				para.Properties().SetAlignment(wml.ST_JcLeft)
-				run := para.AddRun()
+			addContentLine(doc, line, func(run document.Run) {
 				run.Properties().SetSize(10.5 * measure.Point) // 5号字体
 				// 确保小题不加粗
 				run.Properties().SetBold(false)
-
-				// 处理选项的缩进
-				if strings.HasPrefix(line, "①") || strings.HasPrefix(line, "②") ||
-					strings.HasPrefix(line, "③") || strings.HasPrefix(line, "④") ||
-					strings.HasPrefix(line, "A.") || strings.HasPrefix(line, "B.") ||
-					strings.HasPrefix(line, "C.") || strings.HasPrefix(line, "D.") {
-					// 选项前添加制表符
-					run.AddText("\t" + line)
-				} else if strings.HasPrefix(line, "（") && strings.Contains(line, "）") {
-					// 小题编号前添加制表符
-					run.AddText("\t" + line)
-				} else {
-					run.AddText(line)
-				}
-
-				// 如果是简答题的最后一行，添加答题空间
-				if isShortAnswerSection && i == len(lines)-1 && !addedAnswerSpace {
-					// 添加多个空行作为答题空间
-					for j := 0; j < 8; j++ {
-						spacePara := doc.AddParagraph()
-						spacePara.Properties().SetAlignment(wml.ST_JcLeft)
-						spaceRun := spacePara.AddRun()
-						spaceRun.Properties().SetSize(10.5 * measure.Point) // 5号字体
-						spaceRun.AddText("")
-					}
-					addedAnswerSpace = true
-				} else if i == len(lines)-1 {
-					// 重置状态
-					addedAnswerSpace = false
+			})
+
+			// 如果是简答题的最后一行，添加答题空间
+			if isShortAnswerSection && i == len(lines)-1 && !addedAnswerSpace {
+				// 添加多个空行作为答题空间
+				for j := 0; j < 8; j++ {
+					spacePara := doc.AddParagraph()
+					spacePara.Properties().SetAlignment(wml.ST_JcLeft)
+					spaceRun := spacePara.AddRun()
+					spaceRun.Properties().SetSize(10.5 * measure.Point) // 5号字体
+					spaceRun.AddText("")
 				}
+				addedAnswerSpace = true
+			} else if i == len(lines)-1 {
+				// 重置状态
+				addedAnswerSpace = false
 			}
 		}
+		for _, table := range tables {
+			addContentTable(doc, table)
+		}
+		addChoiceOptions(doc, options)
 	}
 
 	// 创建临时文件
@@ -301,7 +260,6 @@ func (we *WordExporterGooxml) ExportAnswer(templateType int) (*os.File, error) {
 	// 处理题目内容
 	contents := we.data["contents"]
 	questions := strings.Split(contents, "[QUESTION_END]")
-	re := regexp.MustCompile(`\[IMAGE:(.*?)\]`)
 
 	for _, question := range questions {
 		question = strings.TrimSpace(question)
@@ -309,112 +267,66 @@ func (we *WordExporterGooxml) ExportAnswer(templateType int) (*os.File, error) {
 			continue
 		}
 
-		// 检查是否包含图片标记
-		if strings.Contains(question, "[IMAGE:") {
-			// 提取图片路径
-			var imgPath string
-			matches := re.FindStringSubmatch(question)
-			if len(matches) > 1 {
-				imgPath = matches[1]
-			}
-
-			// 创建一个段落来包含题目文本和图片
-			para := doc.AddParagraph()
-			para.Properties().SetAlignment(wml.ST_JcLeft)
-			run := para.AddRun()
-			run.Properties().SetSize(10.5 * measure.Point) // 5号字体
-
-			// 添加题目文本（移除图片标记）
-			text := strings.TrimSpace(re.ReplaceAllString(question, ""))
-			run.AddText(text)
+		// 取出答案中的表格，排在答案文字之后
+		text, tables := extractContentTables(question)
+		question = strings.TrimSpace(text)
 
-			// 读取并添加图片
-			imgData, err := ioutil.ReadFile(imgPath)
-			if err != nil {
-				log.Printf("Error reading image file %s: %v", imgPath, err)
-				continue
-			}
-
-			img, err := common.ImageFromBytes(imgData)
-			if err != nil {
-				log.Printf("Error creating image from bytes: %v", err)
-				continue
-			}
-
-			imgRef, err := doc.AddImage(img)
-			if err != nil {
-				log.Printf("Error adding image to document: %v", err)
-				continue
-			}
+		// 按行分割文本
+		lines := strings.Split(question, "\n")
 
-			// 在同一个段落中添加图片
-			inline, err := run.AddDrawingInline(imgRef)
-			if err != nil {
-				log.Printf("Error adding inline image: %v", err)
-				continue
+		// 检查是否是大题标题
+		isSectionTitle := false
+		for _, line := range lines {
+			if strings.HasPrefix(line, "一、") || strings.HasPrefix(line, "二、") ||
+				strings.HasPrefix(line, "三、") || strings.HasPrefix(line, "四、") {
+				isSectionTitle = true
+				break
 			}
+		}
 
-			inline.SetSize(2*measure.Inch, 1.5*measure.Inch)
-		} else {
-			// 按行分割文本
-			lines := strings.Split(question, "\n")
+		// 如果是大题标题，添加前后空行
+		if isSectionTitle {
+			// 添加前空行
+			doc.AddParagraph()
 
-			// 检查是否是大题标题
-			isSectionTitle := false
+			// 添加标题
 			for _, line := range lines {
-				if strings.HasPrefix(line, "一、") || strings.HasPrefix(line, "二、") ||
-					strings.HasPrefix(line, "三、") || strings.HasPrefix(line, "四、") {
-					isSectionTitle = true
-					break
-				}
+				para := doc.AddParagraph()
+				para.Properties().SetAlignment(wml.ST_JcLeft)
+				run := para.AddRun()
+				run.Properties().SetBold(true)                 // 大题标题加粗
+				run.Properties().SetSize(10.5 * measure.Point) // 5号字体
+				addMathText(para, run, line)
 			}
 
-			// 如果是大题标题，添加前后空行
-			if isSectionTitle {
-				// 添加前空行
-				doc.AddParagraph()
-
-				// 添加标题
-				for _, line := range lines {
-					para := doc.AddParagraph()
-					para.Properties().SetAlignment(wml.ST_JcLeft)
-					run := para.AddRun()
-					run.Properties().SetBold(true)                 // 大题标题加粗
-					run.Properties().SetSize(10.5 * measure.Point) // 5号字体
-					run.AddText(line)
-				}
+			// 添加后空行
+			doc.AddParagraph()
 
-				// 添加后空行
-				doc.AddParagraph()
+			continue
+		}
 
+		// 处理普通题目，行中的图片单独成段
+		for _, line := range lines {
+			line = strings.TrimSpace(line)
+			if line == "" {
 				continue
 			}
 
-			// 处理普通题目
-			for _, line := range lines {
-				line = strings.TrimSpace(line)
-				if line == "" {
-					continue
-				}
-
-				// 创建段落
-				para := doc.AddParagraph()
-				para.Properties().SetAlignment(wml.ST_JcLeft)
-				run := para.AddRun()
-				run.Properties().SetSize(10.5 * measure.Point) // 5号字体
-
-				// 处理选项的缩进
-				if strings.HasPrefix(line, "①") || strings.HasPrefix(line, "②") ||
-					strings.HasPrefix(line, "③") || strings.HasPrefix(line, "④") {
-					// 选项前添加制表符
-					run.AddText("\t" + line)
-				} else if strings.HasPrefix(line, "（") && strings.Contains(line, "）") {
-					// 小题编号前添加制表符
-					run.AddText("\t" + line)
-				} else {
-					run.AddText(line)
-				}
+			// 处理选项的缩进
+			if strings.HasPrefix(line, "①") || strings.HasPrefix(line, "②") ||
+				strings.HasPrefix(line, "③") || strings.HasPrefix(line, "④") {
+				// 选项前添加制表符
+				line = "\t" + line
+			} else if strings.HasPrefix(line, "（") && strings.Contains(line, "）") {
+				// 小题编号前添加制表符
+				line = "\t" + line
 			}
+			addContentLine(doc, line, func(run document.Run) {
+				run.Properties().SetSize(10.5 * measure.Point) // 5号字体
+			})
+		}
+		for _, table := range tables {
+			addContentTable(doc, table)
 		}
 	}
 
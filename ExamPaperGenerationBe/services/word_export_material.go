@@ -0,0 +1,73 @@
+package services
+
+import (
+	"encoding/json"
+	"graduation/entity"
+	"log"
+	"regexp"
+	"strings"
+
+	"github.com/carmel/gooxml/document"
+	measure "github.com/carmel/gooxml/measurement"
+	"github.com/carmel/gooxml/schema/soo/wml"
+)
+
+// materialMarkerRe 匹配试卷内容中的材料标记
+var materialMarkerRe = regexp.MustCompile(`(?s)\[MATERIAL\](.*?)\[/MATERIAL\]`)
+
+// MaterialMarker 生成放在材料题组第一道题之前的材料标记，导出时在该题之前排出材料的标题、正文、图片和表格
+func MaterialMarker(material entity.QuestionMaterial) string {
+	if material.IsEmpty() {
+		return ""
+	}
+	data, err := json.Marshal(material)
+	if err != nil {
+		log.Printf("Error marshaling question material: %v", err)
+		return ""
+	}
+	return "[MATERIAL]" + string(data) + "[/MATERIAL]"
+}
+
+// extractMaterial 从题目内容中取出材料，返回去掉材料标记后的内容，没有材料标记时返回 nil
+func extractMaterial(question string) (string, *entity.QuestionMaterial) {
+	matches := materialMarkerRe.FindStringSubmatch(question)
+	if len(matches) < 2 {
+		return question, nil
+	}
+	var material entity.QuestionMaterial
+	if err := json.Unmarshal([]byte(matches[1]), &material); err != nil {
+		log.Printf("Error parsing question material: %v", err)
+		return materialMarkerRe.ReplaceAllString(question, ""), nil
+	}
+	return materialMarkerRe.ReplaceAllString(question, ""), &material
+}
+
+// addMaterial 排版材料：标题加粗居中，正文按行分段并首行缩进，之后依次是图片和表格
+func addMaterial(doc *document.Document, material entity.QuestionMaterial) {
+	if material.Title != "" {
+		para := doc.AddParagraph()
+		para.Properties().SetAlignment(wml.ST_JcCenter)
+		run := para.AddRun()
+		run.Properties().SetBold(true)
+		run.Properties().SetSize(10.5 * measure.Point) // 5号字体
+		addMathText(para, run, material.Title)
+	}
+	for _, line := range strings.Split(material.Material, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		para := doc.AddParagraph()
+		para.Properties().SetAlignment(wml.ST_JcLeft)
+		para.Properties().SetFirstLineIndent(21 * measure.Point) // 首行缩进两个字
+		run := para.AddRun()
+		run.Properties().SetSize(10.5 * measure.Point) // 5号字体
+		addMathText(para, run, line)
+	}
+	for _, path := range material.ImagePaths {
+		addAttachment(doc, doc.AddParagraph, entity.QuestionAttachment{Path: path}, maxImageWidth, wml.ST_JcCenter)
+	}
+	for _, table := range material.Tables {
+		addContentTable(doc, table)
+	}
+}
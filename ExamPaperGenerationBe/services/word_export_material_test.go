@@ -0,0 +1,61 @@
+package services
+
+import (
+	"graduation/entity"
+	"strings"
+	"testing"
+
+	"github.com/carmel/gooxml/document"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaterialMarker(t *testing.T) {
+	material := entity.QuestionMaterial{ID: 1, Title: "阅读程序", Material: "MOV AX, [BX]"}
+	text, parsed := extractMaterial(MaterialMarker(material) + "1、执行后 AX 的值为______")
+	require.Equal(t, "1、执行后 AX 的值为______", text)
+	require.NotNil(t, parsed)
+	require.Equal(t, material.Material, parsed.Material)
+
+	text, parsed = extractMaterial("1、题干")
+	require.Equal(t, "1、题干", text)
+	require.Nil(t, parsed)
+	require.Empty(t, MaterialMarker(entity.QuestionMaterial{ID: 2}))
+}
+
+func TestExportTestPaperWithMaterial(t *testing.T) {
+	material := entity.QuestionMaterial{
+		Title:    "阅读下面的程序段",
+		Material: "MOV AX, 1234H\nMOV BX, AX",
+		Tables:   []entity.ContentTable{{HeaderRow: true, Rows: [][]string{{"寄存器", "值"}, {"AX", "1234H"}, {"BX"}}}},
+	}
+	contents := "[SECTION_TITLE]二、填空题（本大题共2小题，每小题2.0分，共4.0分）[/SECTION_TITLE]\r\r" +
+		MaterialMarker(material) + "1、（本题2分）执行后 AX 的值为______\r\r[QUESTION_END]\r\r" +
+		"2、（本题2分）执行后 BX 的值为______\r\r[QUESTION_END]\r\r"
+	file, err := NewWordExporterGooxml(map[string]string{"total_score": "4", "total_count": "2", "contents": contents}).ExportTestPaper(1)
+	require.NoError(t, err)
+	defer file.Close()
+
+	doc, err := document.Open(file.Name())
+	require.NoError(t, err)
+	var texts []string
+	for _, p := range doc.Paragraphs() {
+		var sb strings.Builder
+		for _, r := range p.Runs() {
+			sb.WriteString(r.Text())
+		}
+		if sb.Len() > 0 {
+			texts = append(texts, sb.String())
+		}
+	}
+	all := strings.Join(texts, "\n")
+	require.NotContains(t, all, "[MATERIAL]")
+	require.Equal(t, 1, strings.Count(all, "MOV AX, 1234H"))
+	require.Less(t, strings.Index(all, "阅读下面的程序段"), strings.Index(all, "1、（本题2分）"))
+
+	tables := doc.Tables()
+	require.Len(t, tables, 2) // 表头和材料中的表格
+	rows := tables[1].Rows()
+	require.Len(t, rows, 3)
+	require.Len(t, rows[2].Cells(), 2) // 缺少的单元格补齐
+	require.Equal(t, "寄存器", rows[0].Cells()[0].Paragraphs()[0].Runs()[0].Text())
+}
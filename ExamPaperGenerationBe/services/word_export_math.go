@@ -0,0 +1,191 @@
+package services
+
+import (
+	"log"
+
+	"github.com/carmel/gooxml/document"
+	"github.com/carmel/gooxml/schema/soo/ofc/math"
+	"github.com/carmel/gooxml/schema/soo/ofc/sharedTypes"
+	"github.com/carmel/gooxml/schema/soo/wml"
+)
+
+// addMathText 把文字写入 run，文字中的公式转换为 Word 公式（OMML）插入同一段落，
+// 公式之后的文字写入新的 run 并沿用原 run 的格式；返回最后一个 run，便于继续在其后添加图片
+func addMathText(para document.Paragraph, run document.Run, text string) document.Run {
+	if !HasMath(text) {
+		run.AddText(text)
+		return run
+	}
+	segments, _ := SplitMath(text)
+	current := run
+	wrote := false
+	for _, s := range segments {
+		if s.Formula {
+			row, err := ParseLatex(s.Text)
+			if err == nil {
+				addOMath(para, row, s.Display)
+				wrote = true
+				continue
+			}
+			// 保存时已经校验过公式，这里只会遇到历史数据，按原文输出
+			log.Printf("Error parsing formula %q: %v", s.Text, err)
+		}
+		if wrote {
+			current = para.AddRun()
+			current.X().RPr = run.X().RPr
+		}
+		current.AddText(s.Text)
+		wrote = false
+	}
+	if wrote {
+		current = para.AddRun()
+		current.X().RPr = run.X().RPr
+	}
+	return current
+}
+
+// addOMath 在段落末尾添加一个公式，行间公式居中
+func addOMath(para document.Paragraph, row mathRow, display bool) {
+	content := wml.NewEG_MathContent()
+	oMath := math.NewCT_OMath()
+	oMath.EG_OMathMathElements = ommlRow(row)
+	if display {
+		content.OMathPara = math.NewOMathPara()
+		content.OMathPara.OMathParaPr = math.NewCT_OMathParaPr()
+		content.OMathPara.OMathParaPr.Jc = math.NewCT_OMathJc()
+		content.OMathPara.OMathParaPr.Jc.ValAttr = math.ST_JcCenter
+		content.OMathPara.OMath = []*math.CT_OMath{oMath}
+	} else {
+		content.OMath = math.NewOMath()
+		content.OMath.CT_OMath = *oMath
+	}
+	runLevel := wml.NewEG_RunLevelElts()
+	runLevel.EG_MathContent = []*wml.EG_MathContent{content}
+	runContent := wml.NewEG_ContentRunContent()
+	runContent.EG_RunLevelElts = []*wml.EG_RunLevelElts{runLevel}
+	pContent := wml.NewEG_PContent()
+	pContent.EG_ContentRunContent = []*wml.EG_ContentRunContent{runContent}
+	para.X().EG_PContent = append(para.X().EG_PContent, pContent)
+}
+
+// ommlArg 把一组节点包装为公式参数（分子、上标、根号内容等）
+func ommlArg(row mathRow) *math.CT_OMathArg {
+	arg := math.NewCT_OMathArg()
+	arg.EG_OMathMathElements = ommlRow(row)
+	return arg
+}
+
+func ommlRow(row mathRow) []*math.EG_OMathMathElements {
+	var elements []*math.EG_OMathMathElements
+	for _, n := range row {
+		elements = append(elements, ommlNode(n)...)
+	}
+	return elements
+}
+
+// ommlNode 把一个节点转换为 OMML 元素，花括号分组展开为其中的元素
+func ommlNode(node mathNode) []*math.EG_OMathMathElements {
+	el := math.NewEG_OMathMathElements()
+	switch n := node.(type) {
+	case mathToken:
+		if n.text == "" {
+			return nil
+		}
+		el.R = ommlRun(n.text, n.kind == mathFunction || n.kind == mathText || n.kind == mathSpace)
+	case mathGroup:
+		return ommlRow(n.body)
+	case mathFrac:
+		el.F = math.NewCT_F()
+		el.F.Num = ommlArg(n.num)
+		el.F.Den = ommlArg(n.den)
+	case mathSqrt:
+		el.Rad = math.NewCT_Rad()
+		if len(n.degree) == 0 {
+			el.Rad.RadPr = math.NewCT_RadPr()
+			el.Rad.RadPr.DegHide = ommlOn()
+		}
+		el.Rad.Deg = ommlArg(n.degree)
+		el.Rad.E = ommlArg(n.body)
+	case mathScript:
+		base := ommlArg(mathRow{n.base})
+		switch {
+		case n.sub != nil && n.sup != nil:
+			el.SSubSup = math.NewCT_SSubSup()
+			el.SSubSup.E, el.SSubSup.Sub, el.SSubSup.Sup = base, ommlArg(n.sub), ommlArg(n.sup)
+		case n.sup != nil:
+			el.SSup = math.NewCT_SSup()
+			el.SSup.E, el.SSup.Sup = base, ommlArg(n.sup)
+		default:
+			el.SSub = math.NewCT_SSub()
+			el.SSub.E, el.SSub.Sub = base, ommlArg(n.sub)
+		}
+	case mathAccent:
+		if n.line {
+			el.Bar = math.NewCT_Bar()
+			el.Bar.BarPr = math.NewCT_BarPr()
+			el.Bar.BarPr.Pos = math.NewCT_TopBot()
+			el.Bar.BarPr.Pos.ValAttr = math.ST_TopBotTop
+			if n.under {
+				el.Bar.BarPr.Pos.ValAttr = math.ST_TopBotBot
+			}
+			el.Bar.E = ommlArg(n.body)
+			break
+		}
+		el.Acc = math.NewCT_Acc()
+		el.Acc.AccPr = math.NewCT_AccPr()
+		el.Acc.AccPr.Chr = &math.CT_Char{ValAttr: ommlAccentChar(n.mark)}
+		el.Acc.E = ommlArg(n.body)
+	case mathFenced:
+		el.D = math.NewCT_D()
+		el.D.DPr = math.NewCT_DPr()
+		el.D.DPr.BegChr = &math.CT_Char{ValAttr: n.open}
+		el.D.DPr.EndChr = &math.CT_Char{ValAttr: n.close}
+		el.D.E = []*math.CT_OMathArg{ommlArg(n.body)}
+	default:
+		return nil
+	}
+	return []*math.EG_OMathMathElements{el}
+}
+
+// ommlRun 公式中的文字，plain 为 true 时按正体排版
+func ommlRun(text string, plain bool) *math.CT_R {
+	r := math.NewCT_R()
+	if plain {
+		r.RPr = math.NewCT_RPR()
+		r.RPr.Choice = math.NewCT_RPRChoice()
+		r.RPr.Choice.Nor = ommlOn()
+	}
+	t := math.NewCT_Text()
+	t.Content = text
+	if text != "" && (text[0] == ' ' || text[len(text)-1] == ' ') {
+		preserve := "preserve"
+		t.SpaceAttr = &preserve
+	}
+	choice := math.NewCT_RChoice()
+	choice.T = []*math.CT_Text{t}
+	r.Choice = []*math.CT_RChoice{choice}
+	return r
+}
+
+func ommlOn() *math.CT_OnOff {
+	on := math.NewCT_OnOff()
+	on.ValAttr = &sharedTypes.ST_OnOff{ST_OnOff1: sharedTypes.ST_OnOff1On}
+	return on
+}
+
+// ommlAccentChar Word 公式中的重音符号使用组合字符
+func ommlAccentChar(mark string) string {
+	switch mark {
+	case "¯":
+		return "\u0305"
+	case "^":
+		return "\u0302"
+	case "→":
+		return "\u20d7"
+	case "˙":
+		return "\u0307"
+	case "~":
+		return "\u0303"
+	}
+	return mark
+}
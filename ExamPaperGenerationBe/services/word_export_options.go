@@ -0,0 +1,141 @@
+package services
+
+import (
+	"encoding/json"
+	"graduation/entity"
+	"log"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/carmel/gooxml/color"
+	"github.com/carmel/gooxml/document"
+	measure "github.com/carmel/gooxml/measurement"
+	"github.com/carmel/gooxml/schema/soo/wml"
+)
+
+// 选项排版参数，宽度以半角字符计，一个汉字占两个半角字符
+const (
+	optionLineWidth  = 84 // 五号字一行大约能放下的半角字符数
+	optionCellMargin = 4  // 每列预留的间距
+	optionImageWidth = 30 // 带图片的选项按该宽度计算，最多排成两列
+)
+
+// optionsMarkerRe 匹配试卷内容中的选项标记
+var optionsMarkerRe = regexp.MustCompile(`(?s)\[OPTIONS\](.*?)\[/OPTIONS\]`)
+
+// ChoiceOptionsMarker 生成放在试卷内容中的选项标记，导出时按选项长度排成 1、2 或 4 列，没有选项时返回空字符串
+func ChoiceOptionsMarker(options []entity.QuestionOption) string {
+	if len(options) == 0 {
+		return ""
+	}
+	data, err := json.Marshal(options)
+	if err != nil {
+		log.Printf("Error marshaling question options: %v", err)
+		return ""
+	}
+	return "[OPTIONS]" + string(data) + "[/OPTIONS]"
+}
+
+// extractChoiceOptions 从题目内容中取出选项，返回去掉选项标记后的内容
+func extractChoiceOptions(question string) (string, []entity.QuestionOption) {
+	matches := optionsMarkerRe.FindStringSubmatch(question)
+	if len(matches) < 2 {
+		return question, nil
+	}
+	var options []entity.QuestionOption
+	if err := json.Unmarshal([]byte(matches[1]), &options); err != nil {
+		log.Printf("Error parsing question options: %v", err)
+	}
+	return optionsMarkerRe.ReplaceAllString(question, ""), options
+}
+
+// displayWidth 计算文字的显示宽度，全角字符占两个半角字符
+func displayWidth(s string) int {
+	width := 0
+	for _, r := range s {
+		if unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul) || (r >= 0xFF00 && r <= 0xFFEF) || (r >= 0x3000 && r <= 0x303F) {
+			width += 2
+		} else {
+			width++
+		}
+	}
+	return width
+}
+
+// optionLabel 选项前显示的标号，例如 "A．"
+func optionLabel(o entity.QuestionOption) string {
+	return o.Label + "．"
+}
+
+// optionColumns 根据最长选项的宽度决定每行排几个选项：都能放进四分之一行时排 4 列，
+// 能放进半行时排 2 列，否则每个选项单独一行。列数不超过选项个数
+func optionColumns(options []entity.QuestionOption) int {
+	widest := 0
+	for _, o := range options {
+		w := displayWidth(optionLabel(o) + stripAttachments(o.Content))
+		if o.ImagePath != "" || hasAttachments(o.Content) {
+			w = max(w, optionImageWidth)
+		}
+		widest = max(widest, w)
+	}
+	columns := 1
+	switch {
+	case widest <= optionLineWidth/4-optionCellMargin:
+		columns = 4
+	case widest <= optionLineWidth/2-optionCellMargin:
+		columns = 2
+	}
+	return max(1, min(columns, len(options)))
+}
+
+// addChoiceOptions 用无边框表格排版选项，每行 optionColumns 个
+func addChoiceOptions(doc *document.Document, options []entity.QuestionOption) {
+	if len(options) == 0 {
+		return
+	}
+	columns := optionColumns(options)
+	table := doc.AddTable()
+	table.Properties().SetWidthPercent(100)
+	table.Properties().SetLayout(wml.ST_TblLayoutTypeFixed)
+	table.Properties().Borders().SetAll(wml.ST_BorderNone, color.Auto, 0)
+
+	var row document.Row
+	for i, o := range options {
+		if i%columns == 0 {
+			row = table.AddRow()
+		}
+		cell := row.AddCell()
+		cell.Properties().SetWidthPercent(100 / float64(columns))
+		parts := splitAttachments(optionLabel(o) + o.Content)
+		if o.ImagePath != "" {
+			parts = append(parts, contentPart{image: &entity.QuestionAttachment{Path: o.ImagePath}})
+		}
+		addOptionCell(doc, cell, parts, maxImageWidth/measure.Distance(columns)-optionCellMargin*measure.Character)
+	}
+	// 最后一行补齐空单元格，保持各列宽度一致
+	for i := len(options) % columns; i > 0 && i < columns; i++ {
+		cell := row.AddCell()
+		cell.Properties().SetWidthPercent(100 / float64(columns))
+		cell.AddParagraph()
+	}
+}
+
+// addOptionCell 在单元格中排版一个选项：文字左对齐，图片各自成段排在文字之后，宽度不超过 maxWidth
+func addOptionCell(doc *document.Document, cell document.Cell, parts []contentPart, maxWidth measure.Distance) {
+	for _, part := range parts {
+		if part.image != nil {
+			addAttachment(doc, cell.AddParagraph, *part.image, maxWidth, wml.ST_JcLeft)
+			continue
+		}
+		text := strings.Trim(part.text, " ")
+		if text == "" {
+			continue
+		}
+		para := cell.AddParagraph()
+		para.Properties().SetAlignment(wml.ST_JcLeft)
+		run := para.AddRun()
+		run.Properties().SetSize(10.5 * measure.Point) // 5号字体
+		addMathText(para, run, text)
+	}
+}
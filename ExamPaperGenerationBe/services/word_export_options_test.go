@@ -0,0 +1,58 @@
+package services
+
+import (
+	"graduation/entity"
+	"testing"
+
+	"github.com/carmel/gooxml/document"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOptionColumns(t *testing.T) {
+	short := []entity.QuestionOption{{Label: "A", Content: "原码"}, {Label: "B", Content: "补码"}, {Label: "C", Content: "反码"}, {Label: "D", Content: "移码"}}
+	require.Equal(t, 4, optionColumns(short))
+	require.Equal(t, 3, optionColumns(short[:3]))
+
+	medium := []entity.QuestionOption{{Label: "A", Content: "实现存储程序和程序控制"}, {Label: "B", Content: "可以直接访问外存"}}
+	require.Equal(t, 2, optionColumns(medium))
+
+	long := append(medium, entity.QuestionOption{Label: "C", Content: "缩短指令长度，扩大寻址空间，提高编程灵活性，并降低指令译码难度"})
+	require.Equal(t, 1, optionColumns(long))
+
+	withImage := []entity.QuestionOption{{Label: "A", Content: "甲", ImagePath: "a.png"}, {Label: "B", Content: "乙"}, {Label: "C", Content: "丙"}, {Label: "D", Content: "丁"}}
+	require.Equal(t, 2, optionColumns(withImage))
+}
+
+func TestChoiceOptionsMarker(t *testing.T) {
+	options := []entity.QuestionOption{{Label: "A", Content: "[1]"}, {Label: "B", Content: "a\nb", IsCorrect: true}}
+	marker := ChoiceOptionsMarker(options)
+	text, parsed := extractChoiceOptions("1、题干" + marker + "\r\r")
+	require.Equal(t, "1、题干\r\r", text)
+	require.Equal(t, options, parsed)
+	require.Empty(t, ChoiceOptionsMarker(nil))
+}
+
+func TestExportTestPaperWithOptions(t *testing.T) {
+	contents := "[SECTION_TITLE]一、选择题（本大题共1小题，每小题2.0分，共2.0分）[/SECTION_TITLE]\r\r" +
+		"1、（本题2分）零的表示形式是唯一的是（）" +
+		ChoiceOptionsMarker([]entity.QuestionOption{{Label: "A", Content: "原码"}, {Label: "B", Content: "补码"}, {Label: "C", Content: "反码"}}) +
+		"\r\r[QUESTION_END]\r\r"
+	file, err := NewWordExporterGooxml(map[string]string{"total_score": "2", "total_count": "1", "contents": contents}).ExportTestPaper(1)
+	require.NoError(t, err)
+	defer file.Close()
+
+	doc, err := document.Open(file.Name())
+	require.NoError(t, err)
+	tables := doc.Tables()
+	require.Len(t, tables, 2) // 表头和选项
+	rows := tables[1].Rows()
+	require.Len(t, rows, 1)
+	cells := rows[0].Cells()
+	require.Len(t, cells, 3)
+	require.Equal(t, "B．补码", cells[1].Paragraphs()[0].Runs()[0].Text())
+	for _, p := range doc.Paragraphs() {
+		for _, r := range p.Runs() {
+			require.NotContains(t, r.Text(), "[OPTIONS]")
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package services
+
+import (
+	"encoding/json"
+	"graduation/entity"
+	"log"
+	"regexp"
+
+	"github.com/carmel/gooxml/color"
+	"github.com/carmel/gooxml/document"
+	measure "github.com/carmel/gooxml/measurement"
+	"github.com/carmel/gooxml/schema/soo/wml"
+)
+
+// tableMarkerRe 匹配试卷内容中的表格标记
+var tableMarkerRe = regexp.MustCompile(`(?s)\[TABLE\](.*?)\[/TABLE\]`)
+
+// ContentTableMarker 生成放在题目或答案文字之后的表格标记，导出时排成 Word 表格，没有表格时返回空字符串
+func ContentTableMarker(table *entity.ContentTable) string {
+	if table.IsEmpty() {
+		return ""
+	}
+	data, err := json.Marshal(table)
+	if err != nil {
+		log.Printf("Error marshaling content table: %v", err)
+		return ""
+	}
+	return "[TABLE]" + string(data) + "[/TABLE]"
+}
+
+// extractContentTables 从题目内容中取出全部表格，返回去掉表格标记后的内容
+func extractContentTables(question string) (string, []entity.ContentTable) {
+	matches := tableMarkerRe.FindAllStringSubmatch(question, -1)
+	if len(matches) == 0 {
+		return question, nil
+	}
+	var tables []entity.ContentTable
+	for _, m := range matches {
+		var table entity.ContentTable
+		if err := json.Unmarshal([]byte(m[1]), &table); err != nil {
+			log.Printf("Error parsing content table: %v", err)
+			continue
+		}
+		tables = append(tables, table)
+	}
+	return tableMarkerRe.ReplaceAllString(question, ""), tables
+}
+
+// addContentTable 用带边框的表格排版 ContentTable：表头行加粗，单元格不足的行补齐空单元格，
+// 横向合并使用 gridSpan，纵向合并使用 vMerge
+func addContentTable(doc *document.Document, content entity.ContentTable) {
+	columns := content.Columns()
+	if columns == 0 {
+		return
+	}
+	table := doc.AddTable()
+	table.Properties().SetWidthPercent(100)
+	table.Properties().SetAlignment(wml.ST_JcTableCenter)
+	table.Properties().Borders().SetAll(wml.ST_BorderSingle, color.Auto, 0.5*measure.Point)
+	for i := range content.Rows {
+		row := table.AddRow()
+		for j := 0; j < columns; j++ {
+			merge, merged := content.MergeAt(i, j)
+			if merged && j != merge.Col {
+				continue // 已被同一行左侧的单元格横向合并
+			}
+			cell := row.AddCell()
+			colSpan := 1
+			if merged {
+				rowSpan, cols := merge.Spans()
+				colSpan = cols
+				if colSpan > 1 {
+					cell.Properties().SetColumnSpan(colSpan)
+				}
+				if rowSpan > 1 {
+					if i == merge.Row {
+						cell.Properties().SetVerticalMerge(wml.ST_MergeRestart)
+					} else {
+						cell.Properties().SetVerticalMerge(wml.ST_MergeContinue)
+					}
+				}
+			}
+			cell.Properties().SetWidthPercent(100 * float64(colSpan) / float64(columns))
+			para := cell.AddParagraph()
+			para.Properties().SetAlignment(wml.ST_JcCenter)
+			if merged && i != merge.Row {
+				continue // 纵向合并的后续单元格不写文字
+			}
+			text := content.Cell(i, j)
+			if text == "" {
+				continue
+			}
+			run := para.AddRun()
+			run.Properties().SetSize(10.5 * measure.Point) // 5号字体
+			run.Properties().SetBold(content.HeaderRow && i == 0)
+			addMathText(para, run, text)
+		}
+	}
+	// 表格后空一行，避免与下一道题连在一起
+	doc.AddParagraph()
+}
@@ -0,0 +1,67 @@
+package services
+
+import (
+	"graduation/entity"
+	"testing"
+
+	"github.com/carmel/gooxml/document"
+	"github.com/carmel/gooxml/schema/soo/wml"
+	"github.com/stretchr/testify/require"
+)
+
+// mergedTable 第一行表头横向合并两列，第一列第二、三行纵向合并
+var mergedTable = &entity.ContentTable{
+	HeaderRow: true,
+	Rows:      [][]string{{"寄存器", "", "说明"}, {"通用", "AX", "累加器"}, {"", "BX", "基址"}},
+	Merges:    []entity.TableMerge{{Row: 0, Col: 0, ColSpan: 2}, {Row: 1, Col: 0, RowSpan: 2}},
+}
+
+func TestContentTableMarker(t *testing.T) {
+	text, tables := extractContentTables("1、题干" + ContentTableMarker(mergedTable) + "\r\r")
+	require.Equal(t, "1、题干\r\r", text)
+	require.Equal(t, []entity.ContentTable{*mergedTable}, tables)
+	require.Empty(t, ContentTableMarker(nil))
+	require.Empty(t, ContentTableMarker(&entity.ContentTable{}))
+}
+
+// requireMergedTable 检查导出的 Word 表格保留了合并单元格
+func requireMergedTable(t *testing.T, table document.Table) {
+	rows := table.Rows()
+	require.Len(t, rows, 3)
+	header := rows[0].Cells()
+	require.Len(t, header, 2)
+	require.Equal(t, int64(2), header[0].X().TcPr.GridSpan.ValAttr)
+	require.Equal(t, "寄存器", header[0].Paragraphs()[0].Runs()[0].Text())
+	require.Equal(t, wml.ST_MergeRestart, rows[1].Cells()[0].X().TcPr.VMerge.ValAttr)
+	require.Equal(t, wml.ST_MergeContinue, rows[2].Cells()[0].X().TcPr.VMerge.ValAttr)
+	require.Equal(t, "基址", rows[2].Cells()[2].Paragraphs()[0].Runs()[0].Text())
+}
+
+func TestExportContentTables(t *testing.T) {
+	contents := "[SECTION_TITLE]一、简答题（本大题共1小题，每小题5.0分，共5.0分）[/SECTION_TITLE]\r\r" +
+		"1、（本题5分）说明下表中寄存器的用途" + ContentTableMarker(mergedTable) + "\r\r[QUESTION_END]\r\r"
+	exporter := NewWordExporterGooxml(map[string]string{"total_score": "5", "total_count": "1", "contents": contents})
+
+	file, err := exporter.ExportTestPaper(1)
+	require.NoError(t, err)
+	defer file.Close()
+	doc, err := document.Open(file.Name())
+	require.NoError(t, err)
+	tables := doc.Tables()
+	require.Len(t, tables, 2) // 表头和题干表格
+	requireMergedTable(t, tables[1])
+	for _, p := range doc.Paragraphs() {
+		for _, r := range p.Runs() {
+			require.NotContains(t, r.Text(), "[TABLE]")
+		}
+	}
+
+	answer, err := exporter.ExportAnswer(2)
+	require.NoError(t, err)
+	defer answer.Close()
+	doc, err = document.Open(answer.Name())
+	require.NoError(t, err)
+	tables = doc.Tables()
+	require.Len(t, tables, 2)
+	requireMergedTable(t, tables[1])
+}
@@ -0,0 +1,67 @@
+package utils
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"unicode"
+)
+
+// 密码长度限制，bcrypt 只使用前 72 字节
+const (
+	MinPasswordLength = 8
+	MaxPasswordLength = 72
+)
+
+// tempPasswordLength 临时密码长度
+const tempPasswordLength = 12
+
+// 临时密码字符集，去掉了容易混淆的 0/O、1/l/I
+const (
+	tempPasswordLetters = "abcdefghijkmnpqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ"
+	tempPasswordDigits  = "23456789"
+)
+
+// ValidatePassword 校验密码强度：长度 8~72 字节，至少包含字母和数字，不能包含空白字符
+func ValidatePassword(password string) error {
+	if len(password) < MinPasswordLength {
+		return fmt.Errorf("密码长度不能少于 %d 位", MinPasswordLength)
+	}
+	if len(password) > MaxPasswordLength {
+		return fmt.Errorf("密码长度不能超过 %d 个字节", MaxPasswordLength)
+	}
+	var hasLetter, hasDigit bool
+	for _, r := range password {
+		switch {
+		case unicode.IsSpace(r):
+			return errors.New("密码不能包含空白字符")
+		case unicode.IsLetter(r):
+			hasLetter = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		}
+	}
+	if !hasLetter || !hasDigit {
+		return errors.New("密码必须同时包含字母和数字")
+	}
+	return nil
+}
+
+// GenerateTempPassword 生成满足强度要求的随机临时密码
+func GenerateTempPassword() (string, error) {
+	alphabet := tempPasswordLetters + tempPasswordDigits
+	buf := make([]byte, tempPasswordLength)
+	for {
+		for i := range buf {
+			n, err := rand.Int(rand.Reader, big.NewInt(int64(len(alphabet))))
+			if err != nil {
+				return "", err
+			}
+			buf[i] = alphabet[n.Int64()]
+		}
+		if ValidatePassword(string(buf)) == nil {
+			return string(buf), nil
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidatePassword(t *testing.T) {
+	require.NoError(t, ValidatePassword("abc12345"))
+	require.NoError(t, ValidatePassword("题库Exam2025"))
+	require.Error(t, ValidatePassword("a1b2c3"), "too short")
+	require.Error(t, ValidatePassword("12345678"), "no letters")
+	require.Error(t, ValidatePassword("abcdefgh"), "no digits")
+	require.Error(t, ValidatePassword("abc 12345"), "whitespace")
+	require.Error(t, ValidatePassword("a1"+strings.Repeat("x", MaxPasswordLength)), "too long")
+}
+
+func TestGenerateTempPassword(t *testing.T) {
+	seen := make(map[string]bool)
+	for i := 0; i < 20; i++ {
+		p, err := GenerateTempPassword()
+		require.NoError(t, err)
+		require.NoError(t, ValidatePassword(p))
+		require.False(t, seen[p])
+		seen[p] = true
+	}
+}
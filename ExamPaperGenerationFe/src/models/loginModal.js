@@ -1,5 +1,5 @@
 import * as rqs from "../services/requestServices";
-import { message } from "antd";
+import { message, Modal } from "antd";
 import { checkCode, isArray } from "../utils/myUtils";
 
 export default {
@@ -9,8 +9,10 @@ export default {
     username: null,
     user_role: null,
     last_login: null,
+    must_change_password: false,
     allUser: [],
-    applyUser: []
+    applyUser: [],
+    auditLogs: { items: [], total: 0, page: 1, page_size: 20 }
   },
   reducers: {
     isLogin(state, { payload }) {
@@ -25,6 +27,12 @@ export default {
     last_login(state, { payload }) {
       return { ...state, last_login: payload };
     },
+    must_change_password(state, { payload }) {
+      return { ...state, must_change_password: payload };
+    },
+    auditLogs(state, { payload }) {
+      return { ...state, auditLogs: payload };
+    },
     allUser(state, { payload }) {
       return { ...state, allUser: payload };
     },
@@ -38,6 +46,7 @@ export default {
       yield put({ type: "username", payload: null });
       yield put({ type: "user_role", payload: null });
       yield put({ type: "last_login", payload: null });
+      yield put({ type: "must_change_password", payload: false });
     },
 
     *getLoginStatus({ payload }, { call, put }) {
@@ -65,8 +74,14 @@ export default {
           yield put({ type: "username", payload: res.data.username });
           yield put({ type: "user_role", payload: res.data.user_role });
           yield put({ type: "last_login", payload: res.data.last_login });
+          yield put({
+            type: "must_change_password",
+            payload: res.data.must_change_password === true
+          });
         } else if (res.code === 403) {
           message.error("用户名或密码错误", 1);
+        } else if (res.code === 429) {
+          message.error(`登录失败次数过多，请在 ${res.data.retry_after} 秒后重试`, 3);
         }
       } catch (e) {
         console.log(e);
@@ -115,6 +130,18 @@ export default {
       }
     },
 
+    // getAuditLogs
+    *getAuditLogs({ payload }, { call, put }) {
+      try {
+        const res = yield call(rqs.getAuditLogs, payload);
+        if (checkCode(res)) {
+          yield put({ type: "auditLogs", payload: res.data });
+        }
+      } catch (e) {
+        console.log(e);
+      }
+    },
+
     // deleteUser
     *deleteUser({ payload }, { call, put }) {
       try {
@@ -128,6 +155,50 @@ export default {
       }
     },
 
+    // unlockUser
+    *unlockUser({ payload }, { call, put }) {
+      try {
+        const res = yield call(rqs.unlockUser, payload);
+        if (checkCode(res)) {
+          message.success("解除锁定成功", 1);
+          yield put({ type: "getAllUser" });
+        }
+      } catch (e) {
+        console.log(e);
+      }
+    },
+
+    // changePassword
+    *changePassword({ payload }, { call, put }) {
+      try {
+        const res = yield call(rqs.changePassword, payload);
+        if (res.code === 200) {
+          message.success("密码修改成功", 1);
+          yield put({ type: "must_change_password", payload: false });
+          return true;
+        }
+        message.error(res.msg, 2);
+      } catch (e) {
+        console.log(e);
+      }
+      return false;
+    },
+
+    // resetPassword
+    *resetPassword({ payload }, { call, put }) {
+      try {
+        const res = yield call(rqs.resetPassword, payload);
+        if (checkCode(res)) {
+          Modal.info({
+            title: "密码已重置",
+            content: `用户 ${res.data.username} 的临时密码为：${res.data.temp_password}，该用户登录后需立即修改密码。`
+          });
+        }
+      } catch (e) {
+        console.log(e);
+      }
+    },
+
     *getApplyUser({ payload }, { call, put }) {
       try {
         const res = yield call(rqs.getApplyUser);
@@ -6,6 +6,7 @@ export default {
   namespace: "questionBank",
   state: {
     tableDataSource: [],
+    pageData: { items: [], total: 0, page: 1, page_size: 20 },
     eachChapterCount: null,
     eachScoreCount: null
   },
@@ -13,6 +14,9 @@ export default {
     tableDataSource(state, { payload }) {
       return { ...state, tableDataSource: payload };
     },
+    pageData(state, { payload }) {
+      return { ...state, pageData: payload };
+    },
     eachChapterCount(state, { payload }) {
       return { ...state, eachChapterCount: payload };
     },
@@ -37,6 +41,20 @@ export default {
         console.log(e);
       }
     },
+    *queryQuestionBank({ payload }, { call, put }) {
+      try {
+        const res = yield call(requestService.queryQuestionBank, payload);
+        if (checkCode(res) && isArray(res.data.items)) {
+          const offset = (res.data.page - 1) * res.data.page_size;
+          res.data.items.forEach((each, index) => {
+            each.key = offset + index;
+          });
+          yield put({ type: "pageData", payload: res.data });
+        }
+      } catch (e) {
+        console.log(e);
+      }
+    },
     *deleteSingleQuestionBank({ payload }, { call, put }) {
       try {
         const res = yield call(
@@ -44,7 +62,10 @@ export default {
           payload
         );
         if (checkCode(res)) {
-          message.success("删除成功", 1);
+          message.success("已移入回收站", 1);
+        } else if (res.code === 400) {
+          // 题目被已锁定的试卷引用
+          message.error(res.msg, 2);
         }
       } catch (e) {
         console.log(e);
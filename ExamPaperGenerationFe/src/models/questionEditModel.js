@@ -2,6 +2,15 @@ import * as requestService from "../services/requestServices";
 import { checkCode, isArray } from "../utils/myUtils";
 import { message } from "antd";
 
+// 保存题目后提示疑似重复的题目
+const warnDuplicates = duplicates => {
+  if (!isArray(duplicates) || duplicates.length === 0) return;
+  const detail = duplicates
+    .map(item => `#${item.id}（相似度 ${Math.round(item.similarity * 100)}%）`)
+    .join("、");
+  message.warning(`题库中存在疑似重复的题目：${detail}`, 5);
+};
+
 export default {
   namespace: "questionEdit",
   state: {
@@ -104,6 +113,7 @@ export default {
     insertSingleQuestionBank: function*({ payload }, { call, put }) {
       const res = yield call(requestService.insertSingleQuestionBank, payload);
       message.success("新增题目成功", 1);
+      if (checkCode(res)) warnDuplicates(res.data.duplicates);
     },
     *getQuestionBankById({ payload }, { call, put }) {
       try {
@@ -121,6 +131,7 @@ export default {
         const res = yield call(requestService.updateQuestionBankById, payload);
         if (checkCode(res)) {
           message.success("更新题目信息成功", 1);
+          warnDuplicates(res.data.duplicates);
         }
       } catch (e) {
         console.log(e);
@@ -135,7 +146,31 @@ export default {
             `成功导入${res.data.insertCount}条，删除了原有的${res.data.deleteCount}条.`,
             3
           );
+          if (isArray(res.data.duplicates) && res.data.duplicates.length > 0) {
+            message.warning(
+              `其中${res.data.duplicates.length}条与题库中已有题目疑似重复，可在重复题目报告中查看`,
+              5
+            );
+          }
+        } else if (res.code === 400 && res.data && isArray(res.data.errors)) {
+          // 有数据有误的行时整个文件不导入
+          message.error(res.msg, 3);
+        }
+        return res;
+      } catch (e) {
+        console.log(e);
+      }
+    },
+
+    // 只校验文件，返回将要导入的题目和每行的错误，不修改题库
+    *previewUploadFile({ payload }, { call }) {
+      try {
+        payload.append("dryRun", true);
+        const res = yield call(requestService.uploadFile, payload);
+        if (checkCode(res)) {
+          return res.data;
         }
+        message.error(res.msg || res.error, 2);
       } catch (e) {
         console.log(e);
       }
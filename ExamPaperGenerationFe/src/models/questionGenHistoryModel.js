@@ -3,6 +3,18 @@ import { checkCode, isArray } from "../utils/myUtils";
 import { message } from "antd";
 import { exportAnswer } from "../services/requestServices";
 
+// 按题库最新内容导出时，提示出题后修改过和已删除的题目
+const warnChangedQuestions = res => {
+  const changed = res.headers.get("X-Changed-Questions");
+  const missing = res.headers.get("X-Missing-Questions");
+  if (changed) {
+    message.warning(`以下题目在出题后被修改过：${changed}`, 5);
+  }
+  if (missing) {
+    message.warning(`以下题目已从题库删除，未导出：${missing}`, 5);
+  }
+};
+
 export default {
   namespace: "questionGenHistory",
   state: {
@@ -56,6 +68,9 @@ export default {
     *reExportTestPaper({ payload }, { call, put }) {
       try {
         const res = yield call(requestService.reExportTestPaper, payload);
+        if (payload.refresh) {
+          warnChangedQuestions(res);
+        }
         res.blob().then(b => {
           let a = document.createElement("a");
           a.href = URL.createObjectURL(b);
@@ -74,6 +89,9 @@ export default {
     *exportAnswer({ payload }, { call, put }) {
       try {
         const res = yield call(requestService.exportAnswer, payload);
+        if (payload.refresh) {
+          warnChangedQuestions(res);
+        }
         res.blob().then(b => {
           let a = document.createElement("a");
           a.href = URL.createObjectURL(b);
@@ -102,6 +120,18 @@ export default {
       } catch (e) {
         console.log(e);
       }
+    },
+
+    *lockTestPaper({ payload }, { call, put }) {
+      try {
+        const res = yield call(requestService.lockTestPaper, payload);
+        if (checkCode(res)) {
+          yield put({ type: "questionGenHistory/getAllTestPaperGenHistory" });
+          message.success(payload.locked ? "试卷已锁定" : "试卷已解锁", 1);
+        }
+      } catch (e) {
+        console.log(e);
+      }
     }
   },
   subscriptions: {}
@@ -42,6 +42,16 @@ export default class AllUserTable extends React.Component {
         width: 300,
         render: text => moment(text).format("YYYY-MM-DD HH:mm:ss")
       },
+      {
+        title: "锁定至",
+        dataIndex: "locked_until",
+        key: "locked_until",
+        width: 200,
+        render: text =>
+          text && moment(text).isAfter(moment())
+            ? moment(text).format("YYYY-MM-DD HH:mm:ss")
+            : "-"
+      },
       {
         title: "操作",
         key: "action",
@@ -59,6 +69,20 @@ export default class AllUserTable extends React.Component {
             >
               <Button type="link">删除账户</Button>
             </Popconfirm>
+            <Popconfirm
+              title={`确定要重置该账户的密码吗？`}
+              onConfirm={this.onResetPassword.bind(this, record)}
+              okText="确定"
+              cancelText="取消"
+              placement="rightBottom"
+            >
+              <Button type="link">重置密码</Button>
+            </Popconfirm>
+            {record.failed_login_count > 0 && (
+              <Button type="link" onClick={this.onUnlock.bind(this, record)}>
+                解除锁定
+              </Button>
+            )}
           </div>
         )
       }
@@ -77,6 +101,20 @@ export default class AllUserTable extends React.Component {
     });
   };
 
+  onResetPassword = record => {
+    this.props.dispatch({
+      type: "loginModel/resetPassword",
+      payload: { username: record.username }
+    });
+  };
+
+  onUnlock = record => {
+    this.props.dispatch({
+      type: "loginModel/unlockUser",
+      payload: { username: record.username }
+    });
+  };
+
   // table function
   getColumnSearchProps = dataIndex => ({
     filterDropdown: ({
@@ -0,0 +1,168 @@
+import React from "react";
+import { Button, DatePicker, Input, Select, Space, Table } from "antd";
+import moment from "moment";
+
+const entityTypeOptions = [
+  { value: "question", label: "题目" },
+  { value: "test_paper", label: "试卷" },
+  { value: "user", label: "用户" },
+  { value: "label", label: "知识点标签" },
+  { value: "api_key", label: "API 密钥" },
+  { value: "login_ip", label: "登录 IP" }
+];
+
+const actionNames = {
+  create: "新增",
+  update: "修改",
+  delete: "删除",
+  delete_all: "清空题库",
+  import: "导入",
+  approve: "通过申请",
+  reject: "拒绝申请",
+  unlock: "解除锁定",
+  reset_password: "重置密码",
+  change_password: "修改密码",
+  revoke: "注销"
+};
+
+export default class AuditLogTable extends React.Component {
+  constructor(props) {
+    super(props);
+    this.state = {
+      dataLoading: false,
+      username: "",
+      entity_type: undefined,
+      entity_id: "",
+      range: null
+    };
+    this.columns = [
+      {
+        title: "时间",
+        dataIndex: "created_at",
+        key: "created_at",
+        width: 180,
+        render: text => moment(text).format("YYYY-MM-DD HH:mm:ss")
+      },
+      {
+        title: "操作者",
+        dataIndex: "actor",
+        key: "actor",
+        width: 120,
+        render: text => text || "-"
+      },
+      {
+        title: "操作",
+        dataIndex: "action",
+        key: "action",
+        width: 100,
+        render: text => actionNames[text] || text
+      },
+      {
+        title: "对象",
+        key: "entity",
+        width: 180,
+        render: record =>
+          `${record.entity_type}${record.entity_id ? " #" + record.entity_id : ""}`
+      },
+      {
+        title: "IP",
+        dataIndex: "ip",
+        key: "ip",
+        width: 130
+      },
+      {
+        title: "变更",
+        dataIndex: "diff",
+        key: "diff",
+        render: diff =>
+          diff
+            ? Object.keys(diff)
+                .map(
+                  field =>
+                    `${field}: ${JSON.stringify(
+                      diff[field].before
+                    )} → ${JSON.stringify(diff[field].after)}`
+                )
+                .join("；")
+            : "-"
+      }
+    ];
+  }
+
+  // handle
+  fetchData = async (page, pageSize) => {
+    const { username, entity_type, entity_id, range } = this.state;
+    const payload = { page: page, page_size: pageSize };
+    if (username) payload.username = username;
+    if (entity_type) payload.entity_type = entity_type;
+    if (entity_id) payload.entity_id = entity_id;
+    if (range && range[0] && range[1]) {
+      payload.start = range[0].format("YYYY-MM-DD");
+      payload.end = range[1].format("YYYY-MM-DD");
+    }
+    await this.setState({ dataLoading: true });
+    await this.props.dispatch({
+      type: "loginModel/getAuditLogs",
+      payload: payload
+    });
+    await this.setState({ dataLoading: false });
+  };
+
+  onSearch = () => {
+    this.fetchData(1, this.props.auditLogs.page_size).then(() => null);
+  };
+
+  onTableChange = pagination => {
+    this.fetchData(pagination.current, pagination.pageSize).then(() => null);
+  };
+
+  // life cycle
+  componentWillMount() {
+    this.fetchData(1, 20).then(() => null);
+  }
+
+  // render
+  render() {
+    const { items, total, page, page_size } = this.props.auditLogs;
+    return (
+      <div>
+        <Space style={{ marginBottom: 16 }} wrap>
+          <Input
+            placeholder="操作者"
+            allowClear
+            style={{ width: 150 }}
+            onChange={e => this.setState({ username: e.target.value })}
+          />
+          <Select
+            placeholder="对象类型"
+            allowClear
+            style={{ width: 150 }}
+            options={entityTypeOptions}
+            onChange={value => this.setState({ entity_type: value })}
+          />
+          <Input
+            placeholder="对象 ID"
+            allowClear
+            style={{ width: 150 }}
+            onChange={e => this.setState({ entity_id: e.target.value })}
+          />
+          <DatePicker.RangePicker
+            onChange={range => this.setState({ range: range })}
+          />
+          <Button type="primary" onClick={this.onSearch}>
+            查询
+          </Button>
+        </Space>
+        <Table
+          rowKey="id"
+          columns={this.columns}
+          dataSource={items}
+          loading={this.state.dataLoading}
+          pagination={{ current: page, pageSize: page_size, total: total }}
+          onChange={this.onTableChange}
+          bordered
+        />
+      </div>
+    );
+  }
+}
@@ -3,6 +3,7 @@ import { connect } from "umi";
 import { Divider, PageHeader } from "antd";
 import AdminTable from "./adminTable";
 import AllUserTable from "./allUserTable";
+import AuditLogTable from "./auditLogTable";
 
 class Admin extends React.Component {
   constructor(props) {
@@ -40,14 +41,21 @@ class Admin extends React.Component {
           dataSource={this.props.allUser}
           dispatch={this.props.dispatch}
         />
+        <Divider orientation="left" style={{ fontWeight: "bold" }}>
+          操作审计日志
+        </Divider>
+        <AuditLogTable
+          auditLogs={this.props.auditLogs}
+          dispatch={this.props.dispatch}
+        />
       </div>
     );
   }
 }
 
 function mapStateToProps({ loginModel }) {
-  const { applyUser, allUser } = loginModel;
-  return { applyUser, allUser };
+  const { applyUser, allUser, auditLogs } = loginModel;
+  return { applyUser, allUser, auditLogs };
 }
 
 export default connect(mapStateToProps)(Admin);
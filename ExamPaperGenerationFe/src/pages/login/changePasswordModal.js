@@ -0,0 +1,97 @@
+import React from "react";
+import { Form, Input, Modal, message } from "antd";
+
+class ChangePasswordModal extends React.Component {
+  constructor(props) {
+    super(props);
+    this.state = {};
+  }
+  formRef = React.createRef();
+
+  // handle
+  onOk = async () => {
+    this.formRef.current
+      .validateFields()
+      .then(async values => {
+        if (values["new_password"] !== values["new_password2"]) {
+          message.warning("两次输入的密码不一致", 1);
+          return;
+        }
+        const payload = {
+          old_password: values["old_password"],
+          new_password: values["new_password"]
+        };
+        const ok = await this.props.dispatch({
+          type: "loginModel/changePassword",
+          payload: payload
+        });
+        if (ok) {
+          this.formRef.current.resetFields();
+          await this.props.hide(true);
+        }
+      })
+      .catch(errorInfo => {
+        console.log(errorInfo);
+      });
+  };
+
+  onCancel = async () => {
+    this.props.hide(false);
+  };
+
+  render() {
+    return (
+      <div>
+        <Modal
+          title={this.props.force ? "首次登录请修改密码" : "修改密码"}
+          centered={true}
+          visible={this.props.visible}
+          onOk={this.onOk}
+          onCancel={this.onCancel}
+          okText={"确定"}
+          cancelText={"取消"}
+        >
+          <Form
+            labelCol={{ span: 6 }}
+            wrapperCol={{ span: 16 }}
+            ref={this.formRef}
+            name="change-password"
+          >
+            <Form.Item
+              label="当前密码"
+              name="old_password"
+              rules={[{ required: true, message: "请输入当前密码" }]}
+            >
+              <Input type={"password"} />
+            </Form.Item>
+
+            <Form.Item
+              label="新密码"
+              name="new_password"
+              rules={[
+                { required: true, message: "请输入新密码" },
+                { min: 8, message: "密码长度不能少于 8 位" },
+                {
+                  pattern: /^(?=.*[A-Za-z])(?=.*\d)\S+$/,
+                  message: "密码必须同时包含字母和数字"
+                }
+              ]}
+            >
+              <Input type={"password"} />
+            </Form.Item>
+
+            <Form.Item
+              label="确认新密码"
+              name="new_password2"
+              rules={[{ required: true, message: "请确认新密码" }]}
+            >
+              <Input type={"password"} />
+            </Form.Item>
+          </Form>
+        </Modal>
+      </div>
+    );
+  }
+}
+
+export default ChangePasswordModal;
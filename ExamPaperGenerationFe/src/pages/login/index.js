@@ -4,12 +4,14 @@ import { Alert, Button, Form, Input, Radio, Modal, Divider } from "antd";
 import { LockOutlined, LoginOutlined, UserOutlined } from "@ant-design/icons";
 import styles from "./index.less";
 import RegisteredModal from "./registeredModal";
+import ChangePasswordModal from "./changePasswordModal";
 
 class Login extends React.Component {
   constructor(props) {
     super(props);
     this.state = {
-      modalVisible: false
+      modalVisible: false,
+      changePasswordVisible: false
     };
   }
 
@@ -20,7 +22,10 @@ class Login extends React.Component {
       password: value.password
     };
     await this.props.dispatch({ type: "loginModel/login", payload: payload });
-    if (this.props.isLogin === true) {
+    if (this.props.isLogin === true && this.props.must_change_password) {
+      // 管理员重置密码后需要先修改密码
+      await this.setState({ changePasswordVisible: true });
+    } else if (this.props.isLogin === true) {
       Modal.success({
         centered: true,
         title: `${this.props.username}，欢迎回来`,
@@ -49,6 +54,12 @@ class Login extends React.Component {
     await this.setState({ modalVisible: false });
   };
 
+  // 修改密码对话框
+  changePasswordHide = async changed => {
+    await this.setState({ changePasswordVisible: false });
+    if (changed) this.linkToPage();
+  };
+
   // life cycle
   initData = async () => {
     await this.props.dispatch({ type: "loginModel/getLoginStatus" });
@@ -166,14 +177,20 @@ class Login extends React.Component {
           hide={this.modalHide}
           dispatch={this.props.dispatch}
         />
+        <ChangePasswordModal
+          visible={this.state.changePasswordVisible}
+          force={this.props.must_change_password}
+          hide={this.changePasswordHide}
+          dispatch={this.props.dispatch}
+        />
       </div>
     );
   }
 }
 
 function mapStateToProps({ loginModel }) {
-  const { isLogin, username, user_role } = loginModel;
-  return { isLogin, username, user_role };
+  const { isLogin, username, user_role, must_change_password } = loginModel;
+  return { isLogin, username, user_role, must_change_password };
 }
 
 export default connect(mapStateToProps)(Login);
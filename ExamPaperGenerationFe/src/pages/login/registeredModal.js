@@ -85,7 +85,14 @@ class RegisteredModal extends React.Component {
             <Form.Item
               label="密码"
               name="password"
-              rules={[{ required: true, message: "请输入密码" }]}
+              rules={[
+                { required: true, message: "请输入密码" },
+                { min: 8, message: "密码长度不能少于 8 位" },
+                {
+                  pattern: /^(?=.*[A-Za-z])(?=.*\d)\S+$/,
+                  message: "密码必须同时包含字母和数字"
+                }
+              ]}
             >
               <Input type={"password"} />
             </Form.Item>
@@ -20,9 +20,77 @@ import {
 } from "@ant-design/icons";
 import style from "./index.less";
 import { delay } from "../../utils/myUtils";
+import { API } from "../../config/requestConfig";
 import RenderDrawer from "./renderDrawer";
 import OverViewModal from "./overViewModal";
 
+// 题目的审核状态，只有审核通过的题目会被自动组卷抽取
+const questionStatus = {
+  draft: { text: "草稿", color: "default" },
+  in_review: { text: "审核中", color: "processing" },
+  approved: { text: "已通过", color: "success" },
+  retired: { text: "已停用", color: "warning" }
+};
+
+// 选择题的选项，正确选项标为绿色
+const renderOptions = options => {
+  if (!Array.isArray(options) || options.length === 0) return null;
+  return options.map(option => (
+    <div
+      key={option.label}
+      style={option.is_correct ? { color: "#52c41a" } : undefined}
+    >
+      {`${option.label}．${option.content}`}
+    </div>
+  ));
+};
+
+// 题干表格，合并单元格用 rowSpan、colSpan 显示，被合并的单元格不渲染
+const renderContentTable = table => {
+  if (!table || !Array.isArray(table.rows) || table.rows.length === 0) {
+    return null;
+  }
+  const merges = table.merges || [];
+  const spanOf = merge => [merge.row_span || 1, merge.col_span || 1];
+  const mergeAt = (r, c) =>
+    merges.find(m => {
+      const [rowSpan, colSpan] = spanOf(m);
+      return (
+        r >= m.row && r < m.row + rowSpan && c >= m.col && c < m.col + colSpan
+      );
+    });
+  const columns = Math.max(...table.rows.map(row => row.length));
+  return (
+    <table style={{ borderCollapse: "collapse", marginTop: 4 }}>
+      <tbody>
+        {table.rows.map((row, r) => (
+          <tr key={r}>
+            {Array.from({ length: columns }).map((_, c) => {
+              const merge = mergeAt(r, c);
+              if (merge && (merge.row !== r || merge.col !== c)) return null;
+              const [rowSpan, colSpan] = merge ? spanOf(merge) : [1, 1];
+              return (
+                <td
+                  key={c}
+                  rowSpan={rowSpan}
+                  colSpan={colSpan}
+                  style={{
+                    border: "1px solid #d9d9d9",
+                    padding: "0 6px",
+                    fontWeight: table.header_row && r === 0 ? "bold" : undefined
+                  }}
+                >
+                  {row[c] || ""}
+                </td>
+              );
+            })}
+          </tr>
+        ))}
+      </tbody>
+    </table>
+  );
+};
+
 const renderTopicType = text => {
   switch (text) {
     case "填空题":
@@ -53,6 +121,7 @@ class QuestionBank extends React.Component {
         "操作",
         "难度",
         "更新时间",
+        "审核状态",
         "题目图片"
       ],
       visibleColumn: [
@@ -68,6 +137,7 @@ class QuestionBank extends React.Component {
         "操作",
         "难度",
         "更新时间",
+        "审核状态",
         "题目图片"
       ],
       defaultColumns: [
@@ -84,6 +154,17 @@ class QuestionBank extends React.Component {
           key: "topic",
           width: 400,
           ...this.getColumnSearchProps("topic"),
+          render: (text, record) => (
+            <div>
+              {record.topic_mathml ? (
+                <div dangerouslySetInnerHTML={{ __html: record.topic_mathml }} />
+              ) : (
+                this.getColumnSearchProps("topic").render(text)
+              )}
+              {renderOptions(record.options)}
+              {renderContentTable(record.topic_table)}
+            </div>
+          ),
           className: style.column_small_text
         },
         {
@@ -111,8 +192,7 @@ class QuestionBank extends React.Component {
               value: "简答题"
             }
           ],
-          filterMultiple: true,
-          onFilter: (value, record) => record?.topic_type?.indexOf(value) === 0
+          filterMultiple: true
         },
         {
           title: "分值",
@@ -121,7 +201,7 @@ class QuestionBank extends React.Component {
           className: style.column_small_text,
           width: 85,
           render: text => <Tag>{text}分</Tag>,
-          sorter: (a, b) => a.score - b.score,
+          sorter: { multiple: 3 },
           sortDirections: ["descend", "ascend"]
         },
         {
@@ -213,7 +293,7 @@ class QuestionBank extends React.Component {
           key: "difficulty",
           className: style.column_small_text,
           width: 80,
-          sorter: (a, b) => a.difficulty - b.difficulty,
+          sorter: { multiple: 2 },
           sortDirections: ["descend", "ascend"]
         },
         {
@@ -223,23 +303,40 @@ class QuestionBank extends React.Component {
           className: style.column_small_text,
           width: 180,
           render: text => moment(text).format("YYYY-MM-DD HH:mm:ss"),
-          sorter: (a, b) => a.update_time - b.update_time,
+          sorter: { multiple: 1 },
           sortDirections: ["descend", "ascend"]
         },
+        {
+          title: "审核状态",
+          dataIndex: "status",
+          key: "status",
+          className: style.column_small_text,
+          width: 100,
+          render: text => (
+            <Tag color={questionStatus[text]?.color}>
+              {questionStatus[text]?.text ?? text}
+            </Tag>
+          ),
+          filters: Object.keys(questionStatus).map(key => ({
+            text: questionStatus[key].text,
+            value: key
+          })),
+          filterMultiple: true
+        },
         {
           title: "题目图片",
-          dataIndex: "topic_image_path",
-          key: "topic_image_path",
+          dataIndex: "topic_thumbnail_url",
+          key: "topic_thumbnail_url",
           className: style.column_small_text,
           width: 120,
-          render: text => {
-            if (text && text.startsWith("data:image")) {
+          render: (text, record) => {
+            if (text) {
               return (
                 <Image
-                  src={text}
+                  src={API + text}
+                  preview={{ src: API + record.topic_image_url }}
                   alt="题目图片"
                   style={{ maxWidth: "100px", maxHeight: "100px" }}
-                  preview={true}
                 />
               );
             }
@@ -248,6 +345,7 @@ class QuestionBank extends React.Component {
         }
       ],
       columns: [],
+      query: { page: 1, page_size: 20 },
       isLoading: false,
       isDrawerVisible: false,
       modalVisible: false
@@ -266,7 +364,9 @@ class QuestionBank extends React.Component {
     });
   };
 
-  showModal = () => {
+  showModal = async () => {
+    // 概览需要统计全部题目
+    await this.props.dispatch({ type: "questionBank/getQuestionBank" });
     this.setState({ modalVisible: true });
   };
   hideModal = () => {
@@ -294,10 +394,38 @@ class QuestionBank extends React.Component {
       type: "questionBank/deleteSingleQuestionBank",
       payload: { id: record.id }
     });
-    await this.props.dispatch({ type: "questionBank/getQuestionBank" });
+    await this.fetchPage(this.state.query);
+  };
+
+  // 按当前的过滤、排序和分页条件查询
+  fetchPage = async query => {
+    await this.setState({ isLoading: true, query: query });
+    await this.props.dispatch({
+      type: "questionBank/queryQuestionBank",
+      payload: query
+    });
     await this.setState({ isLoading: false });
   };
 
+  // 表格翻页、过滤、排序变化时重新查询
+  handleTableChange = (pagination, filters, sorter) => {
+    const query = { page: pagination.current, page_size: pagination.pageSize };
+    if (filters.topic && filters.topic[0]) query.keyword = filters.topic[0];
+    if (filters.answer && filters.answer[0]) query.answer = filters.answer[0];
+    if (filters.topic_type && filters.topic_type.length > 0) {
+      query.topic_type = filters.topic_type.join(",");
+    }
+    if (filters.status && filters.status.length > 0) {
+      query.status = filters.status.join(",");
+    }
+    const sorters = (Array.isArray(sorter) ? sorter : [sorter])
+      .filter(s => s && s.order)
+      .sort((a, b) => b.column.sorter.multiple - a.column.sorter.multiple)
+      .map(s => (s.order === "descend" ? "-" : "") + s.field);
+    if (sorters.length > 0) query.sort = sorters.join(",");
+    this.fetchPage(query).then(() => null);
+  };
+
   // table 的列隐藏
   handleColumnVisible = value => {
     let newColumn = [];
@@ -365,13 +493,7 @@ class QuestionBank extends React.Component {
     filterIcon: filtered => (
       <SearchOutlined style={{ color: filtered ? "#1890ff" : undefined }} />
     ),
-    // 配置搜索规则，value传入
-    onFilter: (value, record) =>
-      record[dataIndex]
-        ?.toString()
-        .toLowerCase()
-        .includes(value.toLowerCase()),
-
+    // 搜索在服务端进行，见 handleTableChange
     onFilterDropdownVisibleChange: visible => {
       if (visible) {
         setTimeout(() => this.searchInput.select());
@@ -420,11 +542,9 @@ class QuestionBank extends React.Component {
   };
 
   initData = async () => {
-    await this.setState({ isLoading: true });
     await this.setState({ columns: this.state.defaultColumns });
-    await this.props.dispatch({ type: "questionBank/getQuestionBank" });
+    await this.fetchPage(this.state.query);
     await delay(200);
-    await this.setState({ isLoading: false });
   };
 
   componentDidMount() {
@@ -437,8 +557,14 @@ class QuestionBank extends React.Component {
         <div className={style.table_wrapper}>
           <Table
             columns={this.state.columns}
-            dataSource={this.props.tableDataSource}
+            dataSource={this.props.pageData.items}
             loading={this.state.isLoading}
+            pagination={{
+              current: this.props.pageData.page,
+              pageSize: this.props.pageData.page_size,
+              total: this.props.pageData.total
+            }}
+            onChange={this.handleTableChange}
             scroll={{ x: "max-content" }}
             rowClassName={this.rowClassName}
             // bordered
@@ -468,7 +594,7 @@ class QuestionBank extends React.Component {
         {/*标题栏*/}
         <PageHeader
           title={
-            "题库管理（当前共" + this.props.tableDataSource.length + "题）"
+            "题库管理（当前共" + this.props.pageData.total + "题）"
           }
           subTitle={"查看所有试题库，支持增删改查"}
           extra={[
@@ -526,9 +652,9 @@ class QuestionBank extends React.Component {
 }
 
 function mapStateToProps({ questionBank, questionGenerator }) {
-  const { tableDataSource, eachChapterCount } = questionBank;
+  const { tableDataSource, pageData, eachChapterCount } = questionBank;
   const { testPaperGenList } = questionGenerator;
-  return { tableDataSource, eachChapterCount, testPaperGenList };
+  return { tableDataSource, pageData, eachChapterCount, testPaperGenList };
 }
 
 export default connect(mapStateToProps)(QuestionBank);
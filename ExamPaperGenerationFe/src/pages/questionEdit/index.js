@@ -84,9 +84,17 @@ class QuestionEdit extends React.Component {
         console.log("添加图片到表单:", this.state.imageFile);
       }
 
-      // 添加JSON数据
+      // 添加JSON数据，选择题的选项按顺序标为 A、B、C...
       const questionData = {
         ...values,
+        options:
+          values.topic_type === "选择题" && Array.isArray(values.options)
+            ? values.options.map((option, index) => ({
+                label: String.fromCharCode(65 + index),
+                content: option.content,
+                is_correct: !!option.is_correct
+              }))
+            : [],
         id: this.state.isInsertMode
           ? undefined
           : this.props.location.query.questionBankId
@@ -157,7 +165,8 @@ class QuestionEdit extends React.Component {
             chapter_1: questionBank.chapter_1,
             chapter_2: questionBank.chapter_2,
             label_1: questionBank.label_1,
-            label_2: questionBank.label_2
+            label_2: questionBank.label_2,
+            options: questionBank.options || []
           }
         });
       }
@@ -220,6 +229,49 @@ class QuestionEdit extends React.Component {
   };
 
   render() {
+    // 选择题选项编辑，可勾选多个正确选项
+    const renderOptionsFormList = () => (
+      <Form.List name="options">
+        {(fields, { add, remove }) => (
+          <>
+            {fields.map((field, index) => (
+              <Form.Item
+                key={field.key}
+                label={`选项 ${String.fromCharCode(65 + index)}`}
+              >
+                <Space align="baseline">
+                  <Form.Item
+                    name={[field.name, "content"]}
+                    noStyle
+                    rules={[{ required: true, message: "请输入选项内容" }]}
+                  >
+                    <Input placeholder="选项内容" style={{ width: 320 }} />
+                  </Form.Item>
+                  <Form.Item
+                    name={[field.name, "is_correct"]}
+                    valuePropName="checked"
+                    noStyle
+                  >
+                    <Checkbox>正确</Checkbox>
+                  </Form.Item>
+                  <DeleteOutlined onClick={() => remove(field.name)} />
+                </Space>
+              </Form.Item>
+            ))}
+            <Form.Item wrapperCol={{ offset: 6, span: 18 }}>
+              <Button
+                type="dashed"
+                onClick={() => add({ content: "", is_correct: false })}
+                disabled={fields.length >= 8}
+              >
+                添加选项
+              </Button>
+            </Form.Item>
+          </>
+        )}
+      </Form.List>
+    );
+
     const renderForm = () => {
       if (this.state.isLoading) return renderLoading("正在加载", "50vh");
       return (
@@ -244,10 +296,26 @@ class QuestionEdit extends React.Component {
                 placeholder="请输入题目内容"
               />
             </Form.Item>
+            <Form.Item
+              noStyle
+              shouldUpdate={(prev, cur) => prev.topic_type !== cur.topic_type}
+            >
+              {({ getFieldValue }) =>
+                getFieldValue("topic_type") === "选择题"
+                  ? renderOptionsFormList()
+                  : null
+              }
+            </Form.Item>
             <Form.Item
               label="参考答案"
               name="answer"
-              rules={[{ required: true, message: "请输入答案" }]}
+              extra="选择题勾选正确选项后，答案会自动设置为正确选项的标号"
+              rules={[
+                ({ getFieldValue }) => ({
+                  required: getFieldValue("topic_type") !== "选择题",
+                  message: "请输入答案"
+                })
+              ]}
             >
               <Input.TextArea
                 autoSize={{ minRows: 3, maxRows: 15 }}
@@ -470,7 +538,9 @@ class ImportWizard extends React.Component {
       isDeleteAll: false,
       file: null,
       fileList: [],
-      uploadBtnLoading: false
+      uploadBtnLoading: false,
+      previewBtnLoading: false,
+      preview: null
     };
   }
 
@@ -484,27 +554,82 @@ class ImportWizard extends React.Component {
     });
     await this.setState({
       file: data.file,
-      fileList: [data.file]
+      fileList: [data.file],
+      preview: null
     });
   };
 
-  uploadFile = async () => {
-    await this.setState({ uploadBtnLoading: true });
+  formData = () => {
     let formData = new FormData();
     formData.append("file", this.state.file);
     formData.append("isDeleteAll", this.state.isDeleteAll);
-    await this.props.dispatch({
+    return formData;
+  };
+
+  previewFile = async () => {
+    await this.setState({ previewBtnLoading: true });
+    const preview = await this.props.dispatch({
+      type: "questionEdit/previewUploadFile",
+      payload: this.formData()
+    });
+    await this.setState({ previewBtnLoading: false, preview: preview || null });
+  };
+
+  uploadFile = async () => {
+    await this.setState({ uploadBtnLoading: true });
+    const res = await this.props.dispatch({
       type: "questionEdit/uploadFile",
-      payload: formData
+      payload: this.formData()
     });
     await this.setState({ uploadBtnLoading: false });
+    if (
+      res &&
+      res.code === 400 &&
+      res.data &&
+      myUtils.isArray(res.data.errors)
+    ) {
+      // 保留文件，显示每行的错误
+      await this.setState({ preview: res.data });
+      return;
+    }
     await this.setState({
       file: null,
-      fileList: []
+      fileList: [],
+      preview: null
     });
     await this.props.changeVisible();
   };
 
+  renderPreview = () => {
+    const preview = this.state.preview;
+    if (preview === null) return null;
+    const errors = preview.errors || [];
+    return (
+      <div style={{ margin: "0 20px" }}>
+        {preview.dryRun ? (
+          <div>
+            共{preview.total}行，可导入{preview.insertCount}条，将删除原有的
+            {preview.deleteCount}条，疑似重复
+            {(preview.duplicates || []).length}条。
+          </div>
+        ) : null}
+        {errors.length > 0 ? (
+          <Table
+            size="small"
+            rowKey={(e, i) => i}
+            dataSource={errors}
+            pagination={{ pageSize: 5 }}
+            columns={[
+              { title: "行号", dataIndex: "row", width: 70 },
+              { title: "列", dataIndex: "column", width: 140 },
+              { title: "错误", dataIndex: "message" }
+            ]}
+          />
+        ) : null}
+      </div>
+    );
+  };
+
   render() {
     return (
       <Modal
@@ -548,21 +673,36 @@ class ImportWizard extends React.Component {
           }}
           checked={this.state.isDeleteAll}
           onChange={e => {
-            this.setState({ isDeleteAll: e.target.checked });
+            this.setState({ isDeleteAll: e.target.checked, preview: null });
           }}
         >
           是否清空原来的题库？
         </Checkbox>
-        <Button
-          style={{ display: "block", margin: "20px auto" }}
-          icon={<CloudUploadOutlined />}
-          type="primary"
-          loading={this.state.uploadBtnLoading}
-          disabled={this.state.file === null}
-          onClick={this.uploadFile}
+        {this.renderPreview()}
+        <Space
+          style={{
+            display: "flex",
+            justifyContent: "center",
+            margin: "20px auto"
+          }}
         >
-          确定导入
-        </Button>
+          <Button
+            loading={this.state.previewBtnLoading}
+            disabled={this.state.file === null}
+            onClick={this.previewFile}
+          >
+            预览
+          </Button>
+          <Button
+            icon={<CloudUploadOutlined />}
+            type="primary"
+            loading={this.state.uploadBtnLoading}
+            disabled={this.state.file === null}
+            onClick={this.uploadFile}
+          >
+            确定导入
+          </Button>
+        </Space>
       </Modal>
     );
   }
@@ -71,6 +71,12 @@ export default class MainTable extends React.Component {
                   <Menu.Item key="2">
                     <Button type="link">答案(*.docx)</Button>
                   </Menu.Item>
+                  <Menu.Item key="3">
+                    <Button type="link">试卷(按题库最新内容)</Button>
+                  </Menu.Item>
+                  <Menu.Item key="4">
+                    <Button type="link">答案(按题库最新内容)</Button>
+                  </Menu.Item>
                 </Menu>
               }
               placement="bottom"
@@ -82,11 +88,15 @@ export default class MainTable extends React.Component {
             </Dropdown>
             <Button
               type="link"
+              disabled={record.locked}
               onClick={this.changeModifyTestPaperVisible.bind(this, record)}
             >
               修改组卷
             </Button>
-            {this.props.username === record.username ? (
+            <Button type="link" onClick={this.lockRecord.bind(this, record)}>
+              {record.locked ? "解锁" : "锁定"}
+            </Button>
+            {this.props.username === record.username && !record.locked ? (
               <Popconfirm
                 title={`你确定要删除该历史记录吗？`}
                 onConfirm={this.deleteRecord.bind(this, record)}
@@ -132,21 +142,35 @@ export default class MainTable extends React.Component {
     await this.setState({ dataLoading: false });
   };
 
+  // 锁定后试卷不能修改或删除，试卷中的题目也不能删除
+  lockRecord = async record => {
+    await this.props.dispatch({
+      type: "questionGenHistory/lockTestPaper",
+      payload: { test_paper_uid: record.test_paper_uid, locked: !record.locked }
+    });
+  };
+
   reExport = async (record, e) => {
     console.log(record, e);
-    // 导出试卷
-    if (e.key === "1") {
+    // 按出题时的内容导出试卷，3 为按题库最新内容导出
+    if (e.key === "1" || e.key === "3") {
       console.log(record.test_paper_uid);
       await this.props.dispatch({
         type: "questionGenHistory/reExportTestPaper",
-        payload: { test_paper_uid: record.test_paper_uid }
+        payload: {
+          test_paper_uid: record.test_paper_uid,
+          refresh: e.key === "3"
+        }
       });
     }
-    // 导出答案
-    if (e.key === "2") {
+    // 按出题时的内容导出答案，4 为按题库最新内容导出
+    if (e.key === "2" || e.key === "4") {
       await this.props.dispatch({
         type: "questionGenHistory/exportAnswer",
-        payload: { test_paper_uid: record.test_paper_uid }
+        payload: {
+          test_paper_uid: record.test_paper_uid,
+          refresh: e.key === "4"
+        }
       });
     }
   };
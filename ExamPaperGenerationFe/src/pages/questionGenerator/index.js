@@ -48,6 +48,7 @@ import {
   deleteLabel,
   setSimilarityThreshold
 } from "../../services/requestServices";
+import { API } from "../../config/requestConfig";
 
 class questionGenerator extends React.Component {
   constructor(props) {
@@ -64,6 +65,8 @@ class questionGenerator extends React.Component {
       geneticSelect: 0,
       // 迭代次数
       iterationsNum: 400,
+      // 同时从未审核通过的题目中抽题
+      includeUnapproved: false,
       TKTCount: 10,
       XZTCount: 10,
       PDTCount: 5,
@@ -122,7 +125,8 @@ class questionGenerator extends React.Component {
       generateRange: this.state.generateRange,
       averageDifficulty: this.state.averageDifficulty,
       knowledgeWeights: this.state.knowledgeWeights,
-      questionTypeRequirements: this.state.questionTypeRequirements
+      questionTypeRequirements: this.state.questionTypeRequirements,
+      includeUnapproved: this.state.includeUnapproved
     };
 
     if (this.state.geneticSelect === 0) {
@@ -447,17 +451,16 @@ class questionGenerator extends React.Component {
               <Descriptions.Item>
                 {index + 1}、(本题{item.score}分) {item.topic}
               </Descriptions.Item>
-              {item.topic_image_path &&
-                item.topic_image_path.startsWith("data:image") && (
-                  <Descriptions.Item>
-                    <Image
-                      src={item.topic_image_path}
-                      alt="题目图片"
-                      style={{ maxWidth: "300px", maxHeight: "200px" }}
-                      preview={true}
-                    />
-                  </Descriptions.Item>
-                )}
+              {item.topic_image_url && (
+                <Descriptions.Item>
+                  <Image
+                    src={API + item.topic_thumbnail_url}
+                    alt="题目图片"
+                    style={{ maxWidth: "300px", maxHeight: "200px" }}
+                    preview={{ src: API + item.topic_image_url }}
+                  />
+                </Descriptions.Item>
+              )}
             </Descriptions>
           );
         });
@@ -538,17 +541,16 @@ class questionGenerator extends React.Component {
                     <Descriptions.Item>
                       {index + 1}、(本题{item.score}分) {item.topic}
                     </Descriptions.Item>
-                    {item.topic_image_path &&
-                      item.topic_image_path.startsWith("data:image") && (
-                        <Descriptions.Item>
-                          <Image
-                            src={item.topic_image_path}
-                            alt="题目图片"
-                            style={{ maxWidth: "300px", maxHeight: "200px" }}
-                            preview={true}
-                          />
-                        </Descriptions.Item>
-                      )}
+                    {item.topic_image_url && (
+                      <Descriptions.Item>
+                        <Image
+                          src={API + item.topic_thumbnail_url}
+                          alt="题目图片"
+                          style={{ maxWidth: "300px", maxHeight: "200px" }}
+                          preview={{ src: API + item.topic_image_url }}
+                        />
+                      </Descriptions.Item>
+                    )}
                   </Descriptions>
                 );
               })
@@ -563,17 +565,16 @@ class questionGenerator extends React.Component {
                     <Descriptions.Item>
                       {index + 1}、(本题{item.score}分) {item.topic}
                     </Descriptions.Item>
-                    {item.topic_image_path &&
-                      item.topic_image_path.startsWith("data:image") && (
-                        <Descriptions.Item>
-                          <Image
-                            src={item.topic_image_path}
-                            alt="题目图片"
-                            style={{ maxWidth: "300px", maxHeight: "200px" }}
-                            preview={true}
-                          />
-                        </Descriptions.Item>
-                      )}
+                    {item.topic_image_url && (
+                      <Descriptions.Item>
+                        <Image
+                          src={API + item.topic_thumbnail_url}
+                          alt="题目图片"
+                          style={{ maxWidth: "300px", maxHeight: "200px" }}
+                          preview={{ src: API + item.topic_image_url }}
+                        />
+                      </Descriptions.Item>
+                    )}
                   </Descriptions>
                 );
               })
@@ -588,17 +589,16 @@ class questionGenerator extends React.Component {
                     <Descriptions.Item>
                       {index + 1}、(本题{item.score}分) {item.topic}
                     </Descriptions.Item>
-                    {item.topic_image_path &&
-                      item.topic_image_path.startsWith("data:image") && (
-                        <Descriptions.Item>
-                          <Image
-                            src={item.topic_image_path}
-                            alt="题目图片"
-                            style={{ maxWidth: "300px", maxHeight: "200px" }}
-                            preview={true}
-                          />
-                        </Descriptions.Item>
-                      )}
+                    {item.topic_image_url && (
+                      <Descriptions.Item>
+                        <Image
+                          src={API + item.topic_thumbnail_url}
+                          alt="题目图片"
+                          style={{ maxWidth: "300px", maxHeight: "200px" }}
+                          preview={{ src: API + item.topic_image_url }}
+                        />
+                      </Descriptions.Item>
+                    )}
                   </Descriptions>
                 );
               })
@@ -613,17 +613,16 @@ class questionGenerator extends React.Component {
                     <Descriptions.Item>
                       {index + 1}、(本题{item.score}分) {item.topic}
                     </Descriptions.Item>
-                    {item.topic_image_path &&
-                      item.topic_image_path.startsWith("data:image") && (
-                        <Descriptions.Item>
-                          <Image
-                            src={item.topic_image_path}
-                            alt="题目图片"
-                            style={{ maxWidth: "300px", maxHeight: "200px" }}
-                            preview={true}
-                          />
-                        </Descriptions.Item>
-                      )}
+                    {item.topic_image_url && (
+                      <Descriptions.Item>
+                        <Image
+                          src={API + item.topic_thumbnail_url}
+                          alt="题目图片"
+                          style={{ maxWidth: "300px", maxHeight: "200px" }}
+                          preview={{ src: API + item.topic_image_url }}
+                        />
+                      </Descriptions.Item>
+                    )}
                   </Descriptions>
                 );
               })
@@ -928,6 +927,16 @@ class questionGenerator extends React.Component {
               }
               className={style.wrapper_params_input}
             />
+            <div className={style.middle_line_space}>
+              包含未审核通过的题目：
+              <Switch
+                size="small"
+                checked={this.state.includeUnapproved}
+                onChange={checked =>
+                  this.setState({ includeUnapproved: checked })
+                }
+              />
+            </div>
             <Divider />
             <Tooltip
               placement="topLeft"
@@ -99,6 +99,50 @@ export function deleteUser(payload) {
   });
 }
 
+// unlockUser
+export function unlockUser(payload) {
+  const url = `${API}/unlockUser`;
+  return request(url, {
+    method: "get",
+    mode: "cors",
+    params: payload,
+    credentials: "include"
+  });
+}
+
+// getAuditLogs
+export function getAuditLogs(payload) {
+  const url = `${API}/audit`;
+  return request(url, {
+    method: "get",
+    mode: "cors",
+    params: payload,
+    credentials: "include"
+  });
+}
+
+// changePassword
+export function changePassword(payload) {
+  const url = `${API}/changePassword`;
+  return request(url, {
+    method: "post",
+    data: payload,
+    mode: "cors",
+    credentials: "include"
+  });
+}
+
+// resetPassword
+export function resetPassword(payload) {
+  const url = `${API}/resetPassword`;
+  return request(url, {
+    method: "post",
+    mode: "cors",
+    params: payload,
+    credentials: "include"
+  });
+}
+
 // getApplyUser
 export function getApplyUser() {
   const url = `${API}/getApplyUser`;
@@ -142,6 +186,157 @@ export function getQuestionBank() {
   });
 }
 
+// 分页查询题库，支持过滤和排序
+export function queryQuestionBank(payload) {
+  const url = `${API}/getQuestionBank`;
+  return request(url, {
+    method: "get",
+    params: payload,
+    mode: "cors",
+    credentials: "include"
+  });
+}
+
+// 全文检索题库，按相关度排序，返回高亮片段
+export function searchQuestionBank(payload) {
+  const url = `${API}/searchQuestionBank`;
+  return request(url, {
+    method: "get",
+    params: payload,
+    mode: "cors",
+    credentials: "include"
+  });
+}
+
+// 题库重复题目报告
+export function getDuplicateQuestions(payload) {
+  const url = `${API}/getDuplicateQuestions`;
+  return request(url, {
+    method: "get",
+    params: payload,
+    mode: "cors",
+    credentials: "include"
+  });
+}
+
+// 题目材料
+export function getQuestionMaterials() {
+  const url = `${API}/materials`;
+  return request(url, {
+    method: "get",
+    mode: "cors",
+    credentials: "include"
+  });
+}
+
+export function getQuestionMaterial(id) {
+  const url = `${API}/materials/${id}`;
+  return request(url, {
+    method: "get",
+    mode: "cors",
+    credentials: "include"
+  });
+}
+
+// payload 为材料对象，或包含 data 和 images 的 FormData
+export function createQuestionMaterial(payload) {
+  const url = `${API}/materials`;
+  return request(url, {
+    method: "post",
+    data: payload,
+    mode: "cors",
+    credentials: "include"
+  });
+}
+
+export function updateQuestionMaterial(id, payload) {
+  const url = `${API}/materials/${id}`;
+  return request(url, {
+    method: "put",
+    data: payload,
+    mode: "cors",
+    credentials: "include"
+  });
+}
+
+export function deleteQuestionMaterial(id, force = false) {
+  const url = `${API}/materials/${id}`;
+  return request(url, {
+    method: "delete",
+    params: force ? { force: true } : undefined,
+    mode: "cors",
+    credentials: "include"
+  });
+}
+
+export function linkMaterialQuestions(id, questionIds) {
+  const url = `${API}/materials/${id}/questions`;
+  return request(url, {
+    method: "post",
+    data: { question_ids: questionIds },
+    mode: "cors",
+    credentials: "include"
+  });
+}
+
+export function unlinkMaterialQuestion(id, questionId) {
+  const url = `${API}/materials/${id}/questions/${questionId}`;
+  return request(url, {
+    method: "delete",
+    mode: "cors",
+    credentials: "include"
+  });
+}
+
+// 题目图片，payload 为包含 images、target、option_label、caption、display_width 的 FormData
+export function getQuestionAttachments(questionId) {
+  const url = `${API}/questions/${questionId}/attachments`;
+  return request(url, {
+    method: "get",
+    mode: "cors",
+    credentials: "include"
+  });
+}
+
+export function uploadQuestionAttachments(questionId, payload) {
+  const url = `${API}/questions/${questionId}/attachments`;
+  return request(url, {
+    method: "post",
+    data: payload,
+    mode: "cors",
+    credentials: "include"
+  });
+}
+
+export function reorderQuestionAttachments(questionId, ids) {
+  const url = `${API}/questions/${questionId}/attachments/order`;
+  return request(url, {
+    method: "put",
+    data: { ids },
+    mode: "cors",
+    credentials: "include"
+  });
+}
+
+export function updateQuestionAttachment(id, payload) {
+  const url = `${API}/attachments/${id}`;
+  return request(url, {
+    method: "put",
+    data: payload,
+    mode: "cors",
+    credentials: "include"
+  });
+}
+
+export function deleteQuestionAttachment(id) {
+  const url = `${API}/attachments/${id}`;
+  return request(url, {
+    method: "delete",
+    mode: "cors",
+    credentials: "include"
+  });
+}
+
 export function deleteSingleQuestionBank(payload) {
   const url = `${API}/deleteSingleQuestionBank`;
   return request(url, {
@@ -170,6 +365,17 @@ export function getEachScoreCount() {
   });
 }
 
+// 预览文字中的 LaTeX 公式，返回 { html, has_formula }，公式有误时 code 为 400
+export function previewFormula(text) {
+  const url = `${API}/previewFormula`;
+  return request(url, {
+    method: "post",
+    data: { text },
+    mode: "cors",
+    credentials: "include"
+  });
+}
+
 // questionEdit
 
 export function getAllQuestionLabels() {
@@ -285,6 +491,17 @@ export function deleteQuestionGenHistoryByTestPaperUid(payload) {
   });
 }
 
+// 锁定或解锁试卷
+export function lockTestPaper(payload) {
+  const url = `${API}/lockTestPaper`;
+  return request(url, {
+    method: "post",
+    params: payload,
+    mode: "cors",
+    credentials: "include"
+  });
+}
+
 export function uploadFile(payload) {
   const url = `${API}/upload`;
   return request(url, {